@@ -20,32 +20,53 @@ import (
 // AFAIK need to be on a type for easy reflection (go/importer may help ??)
 var Ar *ar = &ar{}
 
+// Exec runs an action with its arguments in text form, returns the results
+// in text form. Trailing arguments that have a default may be omitted, an
+// action ending with a list (ie: []string) takes all the remaining arguments.
+// Invalid arguments are reported as ArgErrors.
 func Exec(action string, args []string) (res []string, err error) {
 	proto, ok := actions[action]
 	if !ok {
 		return res, fmt.Errorf("No such action %s", action)
 	}
-	if len(proto.ins) != len(args) {
-		return res, fmt.Errorf("Incorrect number of arguments for %s, got %d, want %d",
-			action, len(args), len(proto.ins))
-	}
 	in := []reflect.Value{reflect.ValueOf(Ar)}
-	for i := 0; i < len(proto.ins); {
-		argType := proto.ins[i]
-		end := i + 1
-		if strings.HasPrefix(argType.String(), "[]") {
-			end = len(proto.ins)
+	errs := ArgErrors{}
+	for i, p := range proto.params {
+		var val reflect.Value
+		var err error
+		switch {
+		case i >= len(args) && p.def != nil:
+			val, err = p.defVal()
+		case i >= len(args):
+			err = fmt.Errorf("missing")
+		case isList(p.typ) && i == len(proto.params)-1:
+			val, err = textsToVal(args[i:], p.typ)
+		default:
+			val, err = textToVal(args[i], p.typ)
 		}
-		val, err := argToVal(args[i:end], argType)
 		if err != nil {
-			return res, err
+			errs = append(errs, p.err(action, err))
+			continue
 		}
 		in = append(in, val)
-		i = end
+	}
+	if len(errs) > 0 {
+		return res, errs
+	}
+	last := len(proto.params) - 1
+	if len(args) > len(proto.params) && (last < 0 || !isList(proto.params[last].typ)) {
+		return res, fmt.Errorf("Too many arguments for %s, got %d, want %d",
+			action, len(args), len(proto.params))
 	}
 	out := proto.f.Call(in)
-	for i, argType := range proto.outs {
-		strs, err := valToStrs(out[i], argType)
+	for i, r := range proto.results {
+		if r.typ == errorType {
+			if !out[i].IsNil() {
+				return res, out[i].Interface().(error)
+			}
+			continue
+		}
+		strs, err := valToTexts(out[i], r.typ)
 		if err != nil {
 			return res, err
 		}
@@ -59,10 +80,122 @@ type ar struct{}
 var actions map[string]actionProto = map[string]actionProto{}
 
 type actionProto struct {
-	f    reflect.Value
-	ins  []reflect.Type
-	outs []reflect.Type
-	sig  string
+	f       reflect.Value
+	params  []param
+	results []param
+	sig     string // ie: "(int64, string) error"
+	usage   string // ie: "(viewId int64, text string) error"
+	scope   string // API permission scope required
+}
+
+// param is an action parameter or result.
+type param struct {
+	name string
+	typ  reflect.Type
+	def  *string // default value (text form), if any
+}
+
+// actionDoc is what reflection does not tell about an action, generated
+// from the source by gen_params.go.
+type actionDoc struct {
+	params   []string
+	results  []string
+	defaults map[int]string // param index -> default value (text form)
+	scope    string         // ScopeEdit if empty
+}
+
+// API permission scopes, each one includes the previous ones.
+const (
+	ScopeRead = "read" // read only access to the editor and views
+	ScopeEdit = "edit" // edit the views and files
+	ScopeExec = "exec" // run commands, full access
+)
+
+var scopeLevels = map[string]int{ScopeRead: 1, ScopeEdit: 2, ScopeExec: 3}
+
+// ValidScope returns whether scope is a known permission scope.
+func ValidScope(scope string) bool {
+	_, found := scopeLevels[scope]
+	return found
+}
+
+// ScopeAllows returns whether the granted scope includes the required one.
+func ScopeAllows(granted, required string) bool {
+	return scopeLevels[granted] >= scopeLevels[required] && ValidScope(required)
+}
+
+// ActionScope returns the permission scope an action requires, "" if there
+// is no such action.
+func ActionScope(action string) string {
+	return actions[action].scope
+}
+
+// CallScope returns the permission scope a call of the action requires, given
+// its first argument (text or JSON form), "" if there is no such action.
+// Editing a terminal view (shell or command) requires the exec scope, as the
+// text goes to the command input.
+func CallScope(action, firstArg string) string {
+	proto, found := actions[action]
+	if !found || proto.scope != ScopeEdit || len(proto.params) == 0 ||
+		proto.params[0].name != "viewId" {
+		return proto.scope
+	}
+	viewId, err := strconv.ParseInt(strings.TrimSpace(firstArg), 10, 64)
+	if err != nil {
+		return proto.scope // rejected by the call
+	}
+	switch core.ViewType(Ar.ViewType(viewId)) {
+	case core.ViewTypeShell, core.ViewTypeCmdOutput:
+		return ScopeExec
+	}
+	return proto.scope
+}
+
+//go:generate go run gen_params.go
+
+// ArgError is an invalid action argument.
+type ArgError struct {
+	Action string
+	Param  string // param name
+	Type   string // param type
+	Err    error
+}
+
+func (e ArgError) Error() string {
+	return fmt.Sprintf("%s : invalid %s (%s) : %s", e.Action, e.Param, e.Type, e.Err.Error())
+}
+
+// ArgErrors are the invalid arguments of an action call.
+type ArgErrors []ArgError
+
+func (e ArgErrors) Error() string {
+	msgs := []string{}
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, ", ")
+}
+
+func (p param) err(action string, err error) ArgError {
+	return ArgError{Action: action, Param: p.name, Type: p.typ.String(), Err: err}
+}
+
+func (p param) defVal() (reflect.Value, error) {
+	if isList(p.typ) {
+		return reflect.MakeSlice(p.typ, 0, 0), nil
+	}
+	return textToVal(*p.def, p.typ)
+}
+
+func (p param) String() string {
+	s := p.typ.String()
+	if len(p.name) > 0 {
+		s = p.name + " " + s
+	}
+	if p.def != nil {
+		s += "=" + *p.def
+	}
+	return s
 }
 
 func RegisterActions() {
@@ -74,21 +207,46 @@ func RegisterActions() {
 }
 
 func registerAction(m reflect.Method) {
+	name := toCamel(m.Name)
+	doc := actionDocs[name]
 	proto := actionProto{
-		f: m.Func,
+		f:     m.Func,
+		scope: doc.scope,
 	}
-	ins := []string{}
-	outs := []string{}
+	if len(proto.scope) == 0 {
+		proto.scope = ScopeEdit
+	}
+	ins, outs := []string{}, []string{}
 	for i := 1; i < m.Type.NumIn(); i++ {
-		proto.ins = append(proto.ins, m.Type.In(i))
-		ins = append(ins, m.Type.In(i).String())
+		p := param{name: fmt.Sprintf("arg%d", i-1), typ: m.Type.In(i)}
+		if i-1 < len(doc.params) {
+			p.name = doc.params[i-1]
+		}
+		if def, found := doc.defaults[i-1]; found {
+			p.def = &def
+		}
+		proto.params = append(proto.params, p)
+		ins = append(ins, p.typ.String())
 	}
 	for i := 0; i < m.Type.NumOut(); i++ {
-		proto.outs = append(proto.outs, m.Type.Out(i))
-		outs = append(outs, m.Type.Out(i).String())
+		r := param{typ: m.Type.Out(i)}
+		if i < len(doc.results) {
+			r.name = doc.results[i]
+		}
+		proto.results = append(proto.results, r)
+		outs = append(outs, r.typ.String())
 	}
 	proto.sig = fmt.Sprintf("(%s) %s", strings.Join(ins, ", "), strings.Join(outs, ", "))
-	actions[toCamel(m.Name)] = proto
+	proto.usage = fmt.Sprintf("(%s) %s", paramList(proto.params), paramList(proto.results))
+	actions[name] = proto
+}
+
+func paramList(params []param) string {
+	strs := []string{}
+	for _, p := range params {
+		strs = append(strs, p.String())
+	}
+	return strings.Join(strs, ", ")
 }
 
 // dispath an action to the evnt bus
@@ -113,7 +271,7 @@ func Usage() string {
 	sort.Strings(keys)
 	for _, k := range keys {
 		proto := actions[k]
-		u += fmt.Sprintf("%s%s\n", k, proto.sig)
+		u += fmt.Sprintf("%s%s\n", k, proto.usage)
 	}
 	return u
 }
@@ -131,78 +289,3 @@ func toCamel(s string) string {
 	}
 	return string(ns)
 }
-
-func argToVal(args []string, toType reflect.Type) (v reflect.Value, err error) {
-	t := toType.String()
-	switch t {
-	case "string":
-		return reflect.ValueOf(args[0]), nil
-	case "core.CursorMvmt":
-		i, err := strconv.Atoi(args[0])
-		if err != nil {
-			return v, err
-		}
-		return reflect.ValueOf(core.CursorMvmt(i)), nil
-	case "int":
-		i, err := strconv.Atoi(args[0])
-		if err != nil {
-			return v, err
-		}
-		return reflect.ValueOf(i), nil
-	case "int64":
-		i, err := strconv.ParseInt(args[0], 10, 64)
-		if err != nil {
-			return v, err
-		}
-		return reflect.ValueOf(i), nil
-	case "bool":
-		if strings.ToLower(args[0]) == "false" || args[0] == "0" {
-			return reflect.ValueOf(false), nil
-		}
-		return reflect.ValueOf(true), nil
-	case "[]string":
-		arr := []string{}
-		for _, arg := range args {
-			arr = append(arr, arg)
-		}
-		return reflect.ValueOf(arr), nil
-	default:
-		return v, fmt.Errorf("Unhandled type : %s !", t)
-	}
-}
-
-func valToStrs(v reflect.Value, fromType reflect.Type) (s []string, err error) {
-	t := fromType.String()
-	switch t {
-	case "string":
-		return []string{v.Interface().(string)}, nil
-	case "int64":
-		return []string{fmt.Sprintf("%d", v.Interface().(int64))}, nil
-	case "int":
-		return []string{fmt.Sprintf("%d", v.Interface().(int))}, nil
-	case "bool":
-		return []string{fmt.Sprintf("%t", v.Interface().(bool))}, nil
-	case "error":
-		return []string{fmt.Sprintf("%s", v.Interface().(error).Error())}, nil
-	case "[]int64":
-		array := []string{}
-		for _, i := range v.Interface().([]int64) {
-			array = append(array, fmt.Sprintf("%d", i))
-		}
-		return array, nil
-	case "[]core.Selection":
-		array := []string{}
-		for _, s := range v.Interface().([]core.Selection) {
-			array = append(array, s.String())
-		}
-		return array, nil
-	case "[]string":
-		array := []string{}
-		for _, s := range v.Interface().([]string) {
-			array = append(array, s)
-		}
-		return array, nil
-	default:
-		return s, fmt.Errorf("Unhandled type : %s !", t)
-	}
-}
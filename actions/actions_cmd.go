@@ -10,14 +10,26 @@ func (a *ar) CmdbarClear() {
 	d(cmdbarClear{})
 }
 
+// complete the command bar command name or argument at the cursor
+func (a *ar) CmdbarComplete() {
+	d(cmdbarComplete{})
+}
+
 func (a *ar) CmdbarDelete() {
 	d(cmdbarDelete{})
 }
 
+// fuzzy search the command bar history (next match if searching)
+func (a *ar) CmdbarHistorySearch() {
+	d(cmdbarHistorySearch{})
+}
+
 func (a *ar) CmdbarInsert(s string) {
 	d(cmdbarInsert{s: s})
 }
 
+// run the command bar content (might be a shell command)
+// scope: exec
 func (a *ar) CmdbarNewLine() {
 	d(cmdbarNewLine{})
 }
@@ -32,6 +44,7 @@ func (a *ar) CmdbarEnable(on bool) {
 }
 
 // check if cmdbar is enabled
+// scope: read
 func (a *ar) CmdbarEnabled() bool {
 	answer := make(chan bool, 1)
 	d(cmdbarEnabled{answer: answer})
@@ -56,12 +69,24 @@ func (a cmdbarClear) Run() {
 	core.Ed.Commandbar().Clear()
 }
 
+type cmdbarComplete struct{}
+
+func (a cmdbarComplete) Run() {
+	core.Ed.Commandbar().Complete()
+}
+
 type cmdbarDelete struct{}
 
 func (a cmdbarDelete) Run() {
 	core.Ed.Commandbar().Delete()
 }
 
+type cmdbarHistorySearch struct{}
+
+func (a cmdbarHistorySearch) Run() {
+	core.Ed.Commandbar().HistorySearch()
+}
+
 type cmdbarInsert struct {
 	s string
 }
@@ -9,6 +9,7 @@ import (
 )
 
 // blocks until any previously submitted action has completed
+// scope: read
 func (a *ar) EdActionBusFlush() {
 	core.Bus.Flush()
 }
@@ -18,7 +19,20 @@ func (a *ar) EdActivateView(viewId int64) {
 	d(edActivateView{viewId: viewId})
 }
 
+// collect the problems (compiler errors ...) reported in a view, typically a
+// command output, as the problem list (see EdNextError), using the named
+// problem matcher or if empty the ones applying to the view command.
+// returns how many were found.
+// defaults: matcher=
+func (a *ar) EdCollectProblems(viewId int64, matcher string) (int, error) {
+	count := make(chan int, 1)
+	err := make(chan error, 1)
+	d(edCollectProblems{viewId: viewId, matcher: matcher, count: count, err: err})
+	return <-count, <-err
+}
+
 // returns the currently active view
+// scope: read
 func (a *ar) EdCurView() int64 {
 	vid := make(chan (int64), 1)
 	d(edCurView{viewId: vid})
@@ -26,15 +40,17 @@ func (a *ar) EdCurView() int64 {
 }
 
 // delete the given column (by index). First column is index 1
-// if 'check' is true it will check if dirty first, in which case it will do nothing
-// unless called twice in a row.
+// if 'check' is true it will check if dirty first, in which case the user is
+// prompted to save or discard the changes.
+// defaults: check=true
 func (a *ar) EdDelCol(colIndex int, check bool) {
 	d(edDelCol{colIndex: colIndex, check: check})
 }
 
 // delete the given view (by id)
-// if 'check' is true it will check if dirty first, in which case it will do nothing
-// unless called twice in a row.
+// if 'check' is true it will check if dirty first, in which case the user is
+// prompted to save or discard the changes.
+// defaults: check=true
 func (a *ar) EdDelView(viewId int64, check bool) {
 	d(edDelView{viewId: viewId, check: check, terminate: true})
 }
@@ -45,10 +61,25 @@ func (a *ar) EdFileEvent(op core.FileOp, loc string) {
 	d(edFileEvent{op: op, loc: loc})
 }
 
+// Receives the new location of a watched file that was moved (renamed), so
+// the views showing it can follow.
+func (a *ar) EdFileMoved(from, to string) {
+	d(edFileMoved{from: from, to: to})
+}
+
+// open the location of the next problem of the problem list (see
+// EdCollectProblems, command problems are collected as they complete)
+func (a *ar) EdNextError() error {
+	err := make(chan error, 1)
+	d(edNextError{err: err})
+	return <-err
+}
+
 // Open a file/dir(loc) in the editor
 // rel is optionally the path to loc
 // viewId is the viewId where to open into (or a new one if viewId<0)
 // create indicates whether the file/dir needs to be created if it does not exist.
+// defaults: viewId=-1, rel=, create=false
 func (a *ar) EdOpen(loc string, viewId int64, rel string, create bool) int64 {
 	vid := make(chan (int64), 1)
 	d(edOpen{loc: loc, viewId: viewId, rel: rel, create: create, vid: vid})
@@ -56,18 +87,89 @@ func (a *ar) EdOpen(loc string, viewId int64, rel string, create bool) int64 {
 }
 
 // Open a new terminal view (~ vt100)
+// scope: exec
 func (a *ar) EdOpenTerm(args []string) int64 {
 	vid := make(chan (int64), 1)
 	d(edOpenTerm{args: args, vid: vid})
 	return <-vid
 }
 
+// open the location of the previous problem of the problem list
+func (a *ar) EdPrevError() error {
+	err := make(chan error, 1)
+	d(edNextError{backward: true, err: err})
+	return <-err
+}
+
+// return the problem list, one per line as loc:line:col: severity: message
+// scope: read
+func (a *ar) EdProblems() []string {
+	answer := make(chan []string, 1)
+	d(edProblems{answer: answer})
+	return <-answer
+}
+
+// Preview replacing pattern by repl in the files under dir (project wide)
+// in a new view, returns the view id. See EdProjectReplaceApply.
+// The files are searched in the background, the view title starts with
+// [RUNNING] until done.
+// defaults: regex=false, ignoreCase=false
+func (a *ar) EdProjectReplace(dir, pattern, repl string, regex, ignoreCase bool) (int64, error) {
+	vid := make(chan int64, 1)
+	err := make(chan error, 1)
+	d(edProjectReplace{dir: dir, pattern: pattern, repl: repl, regex: regex,
+		ignoreCase: ignoreCase, vid: vid, err: err})
+	return <-vid, <-err
+}
+
+// Apply the replacements previewed in the given view (see EdProjectReplace)
+// returns the number of lines replaced.
+func (a *ar) EdProjectReplaceApply(viewId int64) (int, error) {
+	count := make(chan int, 1)
+	err := make(chan error, 1)
+	d(edProjectReplaceApply{viewId: viewId, count: count, err: err})
+	return <-count, <-err
+}
+
+// Search pattern in the files under dir (project wide), ignoring files
+// excluded by .gitignore. The results are shown in a new view, one
+// path:line:col match per line, returns the view id.
+// The files are searched in the background, the view title starts with
+// [RUNNING] until done.
+// defaults: regex=false, ignoreCase=false
+func (a *ar) EdProjectSearch(dir, pattern string, regex, ignoreCase bool) (int64, error) {
+	vid := make(chan int64, 1)
+	err := make(chan error, 1)
+	d(edProjectSearch{dir: dir, pattern: pattern, regex: regex,
+		ignoreCase: ignoreCase, vid: vid, err: err})
+	return <-vid, <-err
+}
+
+// offer an event to the overlays (menus, tooltips ...), mouse locations being
+// terminal coordinates, returns whether an overlay consumed it
+func (a *ar) EdOverlayEvent(evtType, glyph string, mouse bool, y, x int) bool {
+	answer := make(chan bool, 1)
+	d(edOverlayEvent{evtType: evtType, glyph: glyph, mouse: mouse, y: y, x: x, answer: answer})
+	return <-answer
+}
+
+// ask the user a question, returns the picked choice ("" if cancelled),
+// choices default to yes / no, defaultChoice being the index of the
+// initially selected one
+func (a *ar) EdPrompt(question string, defaultChoice int, choices []string) string {
+	answer := make(chan string, 1)
+	d(edPrompt{question: question, defaultChoice: defaultChoice, choices: choices, answer: answer})
+	return <-answer
+}
+
 // Quit the editor
+// scope: exec
 func (a *ar) EdQuit() {
 	d(edQuit{})
 }
 
-// Retuns whether the editor can be quit (ie: are any views "dirty")
+// Retuns whether the editor can be quit (ie: are any views "dirty"), if not
+// the user is prompted to save or discard the changes, then quit.
 func (a *ar) EdQuitCheck() bool {
 	answer := make(chan (bool), 1)
 	d(edQuitCheck{answer: answer})
@@ -81,11 +183,38 @@ func (a *ar) EdRender() {
 	d(edRender{time: now})
 }
 
+// run the latest run project task again (see EdRunTask), the tasks file being
+// read again. returns the id of the view showing its output.
+// scope: exec
+func (a *ar) EdRerunTask() (int64, error) {
+	vid := make(chan int64, 1)
+	err := make(chan error, 1)
+	d(edRerunTask{vid: vid, err: err})
+	return <-vid, <-err
+}
+
 // resize the editor
 func (a *ar) EdResize(h, w int) {
 	d(edResize{h: h, w: w})
 }
 
+// run a task of the current view project, as defined in its .goed/tasks.toml
+// (found walking up from the view directory), its problems being collected
+// once done. returns the id of the view showing its output.
+// scope: exec
+func (a *ar) EdRunTask(name string) (int64, error) {
+	vid := make(chan int64, 1)
+	err := make(chan error, 1)
+	d(edRunTask{name: name, vid: vid, err: err})
+	return <-vid, <-err
+}
+
+// Show the errors of a script in an errors view, replacing the previous one,
+// none closes it.
+func (a *ar) EdScriptErrors(script string, errs []string) {
+	d(edScriptErrors{script: script, errs: errs})
+}
+
 // Show a status message in the satus bar
 func (a *ar) EdSetStatus(status string) {
 	d(edSetStatus{status: status, err: false})
@@ -97,6 +226,7 @@ func (a *ar) EdSetStatusErr(status string) {
 }
 
 // Retuns the editor overall size (in row, cols)
+// scope: read
 func (a *ar) EdSize() (rows, cols int) {
 	answer := make(chan (int))
 	d(edSize{answer: answer})
@@ -108,12 +238,24 @@ func (a *ar) EdSwapViews(view1Id, view2Id int64) {
 	d(edSwapViews{view1Id: view1Id, view2Id: view2Id})
 }
 
+// returns the task names of the project dir is in (.goed/tasks.toml), or if
+// dir is empty the one of the current view.
+// scope: read
+// defaults: dir=
+func (a *ar) EdTasks(dir string) ([]string, error) {
+	names := make(chan []string, 1)
+	err := make(chan error, 1)
+	d(edTasks{dir: dir, names: names, err: err})
+	return <-names, <-err
+}
+
 // call flush on the underlying terminal (force sync)
 func (a *ar) EdTermFlush() {
 	d(edTermFlush{})
 }
 
 // returns the view ids of the view(s) that holds a file/dir of the given path.
+// scope: read
 func (a *ar) EdViewsByLoc(loc string) []int64 {
 	vids := make(chan ([]int64), 1)
 	d(edViewsByLoc{loc: loc, vids: vids})
@@ -128,6 +270,7 @@ func (a *ar) EdViewMove(y1, x1, y2, x2 int) {
 // For a given Editor UI position (in characters, 1 indexed) returns
 // - The view at that position. -1 if not within any view bounds.
 // - y,x : 1 indexed coordinates within that view UI
+// scope: read
 func (a *ar) EdViewAt(ey, ex int) (vid int64, vy, vx int) {
 	answer := make(chan (int64), 3)
 	d(edViewAt{y: ey, x: ex, answer: answer})
@@ -136,6 +279,7 @@ func (a *ar) EdViewAt(ey, ex int) (vid int64, vy, vx int) {
 
 // Return the index of the view in the UI (row,col 1 indexed).
 // Returns -1, -1 if not found
+// scope: read
 func (a *ar) EdViewIndex(viewId int64) (row, col int) {
 	answer := make(chan (int), 2)
 	d(edViewIndex{viewId: viewId, answer: answer})
@@ -148,6 +292,7 @@ func (a *ar) EdViewNavigate(mvmt core.CursorMvmt) {
 }
 
 // return a list of currently opened views (viewids)
+// scope: read
 func (a *ar) EdViews() []int64 {
 	answer := make(chan int64)
 	d(edViews{answer: answer})
@@ -171,6 +316,19 @@ func (a edActivateView) Run() {
 	core.Ed.ViewActivate(a.viewId)
 }
 
+type edCollectProblems struct {
+	viewId  int64
+	matcher string
+	count   chan int
+	err     chan error
+}
+
+func (a edCollectProblems) Run() {
+	count, err := core.Ed.CollectProblems(a.viewId, a.matcher)
+	a.count <- count
+	a.err <- err
+}
+
 type edCurView struct {
 	viewId chan int64
 }
@@ -214,6 +372,23 @@ func (a edFileEvent) Run() {
 	core.Ed.FileEvent(a.op, a.loc)
 }
 
+type edFileMoved struct {
+	from, to string
+}
+
+func (a edFileMoved) Run() {
+	core.Ed.FileMoved(a.from, a.to)
+}
+
+type edNextError struct {
+	backward bool
+	err      chan error
+}
+
+func (a edNextError) Run() {
+	a.err <- core.Ed.NextError(a.backward)
+}
+
 type edOpen struct {
 	loc, rel string
 	viewId   int64
@@ -240,6 +415,77 @@ func (a edOpenTerm) Run() {
 	a.vid <- vid
 }
 
+type edProblems struct {
+	answer chan []string
+}
+
+func (a edProblems) Run() {
+	a.answer <- core.Ed.Problems()
+}
+
+type edProjectReplace struct {
+	dir, pattern, repl string
+	regex, ignoreCase  bool
+	vid                chan int64
+	err                chan error
+}
+
+func (a edProjectReplace) Run() {
+	vid, err := core.Ed.ProjectReplace(a.dir, a.pattern, a.repl, a.regex, a.ignoreCase)
+	a.vid <- vid
+	a.err <- err
+}
+
+type edProjectReplaceApply struct {
+	viewId int64
+	count  chan int
+	err    chan error
+}
+
+func (a edProjectReplaceApply) Run() {
+	count, err := core.Ed.ProjectReplaceApply(a.viewId)
+	a.count <- count
+	a.err <- err
+}
+
+type edProjectSearch struct {
+	dir, pattern      string
+	regex, ignoreCase bool
+	vid               chan int64
+	err               chan error
+}
+
+func (a edProjectSearch) Run() {
+	vid, err := core.Ed.ProjectSearch(a.dir, a.pattern, a.regex, a.ignoreCase)
+	a.vid <- vid
+	a.err <- err
+}
+
+type edOverlayEvent struct {
+	evtType, glyph string
+	mouse          bool
+	y, x           int
+	answer         chan bool
+}
+
+func (a edOverlayEvent) Run() {
+	a.answer <- core.Ed.OverlayEvent(a.evtType, a.glyph, a.mouse, a.y, a.x)
+}
+
+type edPrompt struct {
+	question      string
+	defaultChoice int
+	choices       []string
+	answer        chan string
+}
+
+func (a edPrompt) Run() {
+	core.Ed.Prompt(a.question, a.choices, a.defaultChoice, func(choice string) {
+		a.answer <- choice
+	})
+	core.Ed.Render()
+}
+
 type edQuit struct {
 }
 
@@ -263,6 +509,17 @@ func (a edRender) Run() {
 	// Not used, see actionBus.Start()
 }
 
+type edRerunTask struct {
+	vid chan int64
+	err chan error
+}
+
+func (a edRerunTask) Run() {
+	vid, err := core.Ed.RerunTask()
+	a.vid <- vid
+	a.err <- err
+}
+
 type edResize struct {
 	h, w int
 }
@@ -271,6 +528,27 @@ func (a edResize) Run() {
 	core.Ed.Resize(a.h, a.w)
 }
 
+type edRunTask struct {
+	name string
+	vid  chan int64
+	err  chan error
+}
+
+func (a edRunTask) Run() {
+	vid, err := core.Ed.RunTask(a.name)
+	a.vid <- vid
+	a.err <- err
+}
+
+type edScriptErrors struct {
+	script string
+	errs   []string
+}
+
+func (a edScriptErrors) Run() {
+	core.Ed.ScriptErrors(a.script, a.errs)
+}
+
 type edSetStatus struct {
 	status string
 	err    bool
@@ -312,6 +590,18 @@ func (a edSwapViews) Run() {
 	core.Ed.SwapViews(a.view1Id, a.view2Id)
 }
 
+type edTasks struct {
+	dir   string
+	names chan []string
+	err   chan error
+}
+
+func (a edTasks) Run() {
+	names, err := core.Ed.Tasks(a.dir)
+	a.names <- names
+	a.err <- err
+}
+
 type edTermFlush struct{}
 
 func (a edTermFlush) Run() {
@@ -0,0 +1,108 @@
+package actions
+
+import "github.com/tcolar/goed/core"
+
+// delete the last character of the fuzzy finder query
+func (a *ar) FinderBackspace() {
+	d(finderBackspace{})
+}
+
+// close the fuzzy finder
+func (a *ar) FinderClose() {
+	d(finderClose{})
+}
+
+// check if the fuzzy finder is open
+// scope: read
+func (a *ar) FinderEnabled() bool {
+	answer := make(chan bool, 1)
+	d(finderEnabled{answer: answer})
+	return <-answer
+}
+
+// add text to the fuzzy finder query
+func (a *ar) FinderInsert(s string) {
+	d(finderInsert{s: s})
+}
+
+// move the fuzzy finder selection by delta matches
+func (a *ar) FinderMove(delta int) {
+	d(finderMove{delta: delta})
+}
+
+// open the fuzzy finder, mode is "files" (files under the current view
+// directory) or "views" (switch to an open view)
+// defaults: mode=files
+func (a *ar) FinderOpen(mode string) error {
+	err := make(chan error, 1)
+	d(finderOpen{mode: mode, err: err})
+	return <-err
+}
+
+// open the fuzzy finder selection
+func (a *ar) FinderSelect() {
+	d(finderSelect{})
+}
+
+// ########  Impl ......
+
+type finderBackspace struct{}
+
+func (a finderBackspace) Run() {
+	if f := core.Ed.Finder(); f != nil {
+		f.Backspace()
+	}
+}
+
+type finderClose struct{}
+
+func (a finderClose) Run() {
+	if f := core.Ed.Finder(); f != nil {
+		f.Close()
+	}
+}
+
+type finderEnabled struct {
+	answer chan bool
+}
+
+func (a finderEnabled) Run() {
+	a.answer <- core.Ed.Finder() != nil
+}
+
+type finderInsert struct {
+	s string
+}
+
+func (a finderInsert) Run() {
+	if f := core.Ed.Finder(); f != nil {
+		f.Insert(a.s)
+	}
+}
+
+type finderMove struct {
+	delta int
+}
+
+func (a finderMove) Run() {
+	if f := core.Ed.Finder(); f != nil {
+		f.Move(a.delta)
+	}
+}
+
+type finderOpen struct {
+	mode string
+	err  chan error
+}
+
+func (a finderOpen) Run() {
+	a.err <- core.Ed.FinderOpen(a.mode)
+}
+
+type finderSelect struct{}
+
+func (a finderSelect) Run() {
+	if f := core.Ed.Finder(); f != nil {
+		f.Select()
+	}
+}
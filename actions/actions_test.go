@@ -1,8 +1,20 @@
 package actions
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattn/anko/ast"
+	"github.com/mattn/anko/parser"
+	"github.com/mattn/anko/vm"
 	"github.com/tcolar/goed/assert"
 	"github.com/tcolar/goed/core"
 	. "gopkg.in/check.v1"
@@ -106,20 +118,263 @@ func (s *ActionSuite) TestUndoLimit(t *C) {
 	add(v, &i, 11)
 	add(v, &i, 13)
 	core.Bus.Flush()
-	assert.Eq(t, len(undos[v]), 3)
+	assert.Eq(t, undoDepth(v), 3)
 	assert.Eq(t, i, 39)
 	Undo(v)
-	assert.Eq(t, len(undos[v]), 2)
+	assert.Eq(t, undoDepth(v), 2)
 	assert.Eq(t, i, 26)
 	Undo(v)
-	assert.Eq(t, len(undos[v]), 1)
+	assert.Eq(t, undoDepth(v), 1)
 	assert.Eq(t, i, 15)
 	Undo(v)
-	assert.Eq(t, len(undos[v]), 0)
+	assert.Eq(t, undoDepth(v), 0)
 	assert.Eq(t, i, 8)
 	Undo(v)
 }
 
+func (s *ActionSuite) TestUndoTree(t *C) {
+	v := int64(4)
+	i := 0
+	add(v, &i, 3)
+	add(v, &i, 5)
+	Undo(v)
+	core.Bus.Flush()
+	assert.Eq(t, i, 3)
+	// new branch, the "+5" state is kept
+	add(v, &i, 7)
+	assert.Eq(t, i, 10)
+	lines := UndoTree(v)
+	assert.Eq(t, len(lines), 4)
+	assert.True(t, strings.HasPrefix(lines[0], "0 -1 "))
+	assert.True(t, strings.HasPrefix(lines[2], "2 1 "))
+	assert.True(t, strings.HasPrefix(lines[3], "3 1 "))
+	assert.True(t, strings.Contains(lines[3], " * "))
+	// back to the abandoned branch
+	err := UndoTo(v, 2)
+	assert.Nil(t, err)
+	assert.Eq(t, i, 8)
+	assert.Eq(t, undoDepth(v), 2)
+	err = UndoTo(v, 0)
+	assert.Nil(t, err)
+	assert.Eq(t, i, 0)
+	// redo follows the most recently visited branch
+	Redo(v)
+	Redo(v)
+	assert.Eq(t, i, 8)
+	assert.NotNil(t, UndoTo(v, 42))
+	UndoClear(v)
+}
+
+func (s *ActionSuite) TestUndoCoalesce(t *C) {
+	v := int64(5)
+	typed := func(col int, text string) {
+		UndoAdd(v,
+			[]core.Action{NewViewInsertAction(v, 0, col, text, false)},
+			[]core.Action{NewViewDeleteAction(v, 0, col, 0, col, false), NewSetCursorAction(v, 0, col)})
+	}
+	typed(0, "a")
+	typed(1, "b")
+	typed(2, "c")
+	typed(3, " ")
+	typed(4, "d")
+	typed(6, "e") // not contiguous
+	assert.Eq(t, undoDepth(v), 4)
+	n := trees[v].root.children[0]
+	assert.Eq(t, n.do[0], NewViewInsertAction(v, 0, 0, "abc", false))
+	assert.Eq(t, n.undo[0], NewViewDeleteAction(v, 0, 0, 0, 2, false))
+	assert.Eq(t, n.undo[1], NewSetCursorAction(v, 0, 0))
+	UndoClear(v)
+}
+
+func (s *ActionSuite) TestUndoPersist(t *C) {
+	v, v2 := int64(6), int64(7)
+	home := core.Home
+	defer func() { core.Home = home }()
+	core.Home, _ = ioutil.TempDir("", "goed")
+	defer os.RemoveAll(core.Home)
+	os.MkdirAll(path.Join(core.Home, "buffers"), 0750)
+	loc := path.Join(core.Home, "foo.txt")
+	ioutil.WriteFile(loc, []byte("abc\n"), 0640)
+	UndoAdd(v,
+		[]core.Action{NewViewInsertAction(v, 0, 0, "abc", false), NewSetCursorAction(v, 0, 3)},
+		append([]core.Action{NewViewDeleteAction(v, 0, 0, 0, 2, false)},
+			NewSetSelectionsActions(v, &[]core.Selection{*core.NewSelection(0, 0, 0, 1)})...))
+	err := UndoSave(v, loc)
+	assert.Nil(t, err)
+	err = UndoLoad(v2, loc)
+	assert.Nil(t, err)
+	assert.Eq(t, undoDepth(v2), 1)
+	n := trees[v2].cur
+	assert.DeepEq(t, n.do, []core.Action{NewViewInsertAction(v2, 0, 0, "abc", false), NewSetCursorAction(v2, 0, 3)})
+	assert.Eq(t, len(n.undo), 3)
+	assert.Eq(t, n.undo[2], viewAddSelection{viewId: v2, l1: 1, c1: 1, l2: 1, c2: 2})
+	// truncated record : the history is dropped
+	UndoClear(v2)
+	data, _ := ioutil.ReadFile(UndoFile(loc))
+	ioutil.WriteFile(UndoFile(loc), bytes.Replace(data, []byte(`"Kind":"delete","Args":[1,1,1,3]`),
+		[]byte(`"Kind":"delete","Args":[1]`), 1), 0640)
+	assert.NotNil(t, UndoLoad(v2, loc))
+	_, found := trees[v2]
+	assert.False(t, found)
+	_, err = os.Stat(UndoFile(loc))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, UndoSave(v, loc))
+	// touched only, the content is hashed and still matches
+	UndoClear(v2)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(loc, later, later)
+	assert.Nil(t, UndoLoad(v2, loc))
+	assert.Eq(t, undoDepth(v2), 1)
+	// file changed since, history no longer applies
+	UndoClear(v2)
+	ioutil.WriteFile(loc, []byte("abcd\n"), 0640)
+	UndoLoad(v2, loc)
+	_, found = trees[v2]
+	assert.False(t, found)
+	UndoClear(v)
+}
+
+func (s *ActionSuite) TestUndoGroup(t *C) {
+	v := int64(8)
+	i := 0
+	add(v, &i, 3)
+	UndoBegin(v)
+	add(v, &i, 5)
+	UndoBegin(v) // nested
+	add(v, &i, 7)
+	UndoEnd(v)
+	add(v, &i, 11)
+	UndoEnd(v)
+	assert.Eq(t, i, 26)
+	assert.Eq(t, undoDepth(v), 2)
+	Undo(v)
+	core.Bus.Flush()
+	assert.Eq(t, i, 3)
+	Redo(v)
+	core.Bus.Flush()
+	assert.Eq(t, i, 26)
+	UndoClear(v)
+}
+
+func (s *ActionSuite) TestRegistry(t *C) {
+	RegisterActions()
+	// params_gen.go is up to date
+	for name, proto := range actions {
+		doc, found := actionDocs[name]
+		assert.True(t, found)
+		assert.Eq(t, len(doc.params), len(proto.params))
+	}
+	proto := actions["view_insert"]
+	assert.Eq(t, proto.usage, "(viewId int64, row int, col int, text string, undoable bool=true) ")
+	assert.True(t, strings.Contains(Usage(), "ed_view_at(ey int, ex int) vid int64, vy int, vx int\n"))
+
+	// argument validation, the action is not called
+	_, err := Exec("view_insert", []string{"x", "2"})
+	errs, ok := err.(ArgErrors)
+	assert.True(t, ok)
+	assert.Eq(t, len(errs), 3)
+	assert.Eq(t, errs[0].Param, "viewId")
+	assert.Eq(t, errs[1].Param, "col")
+	assert.Eq(t, errs[1].Err.Error(), "missing")
+	assert.Eq(t, errs[2].Param, "text")
+	_, err = Exec("ed_render", []string{"x"})
+	assert.NotNil(t, err)
+	_, err = Call("view_set_dirty", []json.RawMessage{[]byte("1"), []byte(`"x"`)})
+	errs, ok = err.(ArgErrors)
+	assert.True(t, ok)
+	assert.Eq(t, errs[0].Param, "on")
+
+	// scopes
+	assert.Eq(t, ActionScope("view_cursor_pos"), ScopeRead)
+	assert.Eq(t, ActionScope("view_insert"), ScopeEdit)
+	assert.Eq(t, ActionScope("ed_open_term"), ScopeExec)
+	assert.Eq(t, ActionScope("foobar"), "")
+	assert.True(t, ScopeAllows(ScopeExec, ScopeRead))
+	assert.True(t, ScopeAllows(ScopeEdit, ScopeEdit))
+	assert.False(t, ScopeAllows(ScopeRead, ScopeEdit))
+	assert.False(t, ScopeAllows("", ScopeRead))
+	assert.False(t, ScopeAllows(ScopeExec, ""))
+}
+
+func (s *ActionSuite) TestCodecs(t *C) {
+	var i int
+	var b []byte
+	var sel core.Selection
+	var sels []core.Selection
+	texts := []struct {
+		typ  reflect.Type
+		text string
+		val  interface{}
+	}{
+		{reflect.TypeOf(i), "12", 12},
+		{reflect.TypeOf(true), "false", false},
+		{reflect.TypeOf(core.CursorMvmt(0)), "3", core.CursorMvmt(3)},
+		{reflect.TypeOf(b), `a`, []byte{'a', 3}},
+		{reflect.TypeOf(sel), "1 2 3 4", *core.NewSelection(1, 2, 3, 4)},
+		{reflect.TypeOf(struct{ A int }{}), `{"A":5}`, struct{ A int }{5}}, // JSON fallback
+	}
+	for _, tt := range texts {
+		v, err := textToVal(tt.text, tt.typ)
+		assert.Nil(t, err)
+		assert.DeepEq(t, v.Interface(), tt.val)
+	}
+	_, err := textToVal("x", reflect.TypeOf(i))
+	assert.NotNil(t, err)
+
+	// lists
+	assert.True(t, isList(reflect.TypeOf(sels)))
+	assert.False(t, isList(reflect.TypeOf(b)))
+	v, err := textsToVal([]string{"1 1 1 2", "2 1 2 3"}, reflect.TypeOf(sels))
+	assert.Nil(t, err)
+	strs, err := valToTexts(v, reflect.TypeOf(sels))
+	assert.Nil(t, err)
+	assert.DeepEq(t, strs, []string{"1 1 1 2", "2 1 2 3"})
+
+	// JSON forms
+	v, err = jsonToVal([]byte(`"ab"`), reflect.TypeOf(b))
+	assert.Nil(t, err)
+	assert.DeepEq(t, v.Interface(), []byte("ab"))
+	v, err = jsonToVal([]byte(`[3, 13]`), reflect.TypeOf(b))
+	assert.Nil(t, err)
+	assert.DeepEq(t, v.Interface(), []byte{3, 13})
+	assert.DeepEq(t, valToJson(v, reflect.TypeOf(b)), []int{3, 13})
+	_, err = jsonToVal([]byte(`[300]`), reflect.TypeOf(b))
+	assert.NotNil(t, err)
+}
+
+func (s *ActionSuite) TestScripts(t *C) {
+	RegisterActions()
+	err := RunScript("/tmp/no/such/script.ank", nil)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "/tmp/no/such/script.ank: "))
+
+	// errors positions
+	err = scriptError("foo.ank", &parser.Error{Message: "syntax error", Pos: ast.Position{Line: 3, Column: 7}})
+	assert.Eq(t, err.Error(), "foo.ank:3:7: syntax error")
+	err = scriptError("foo.ank", &vm.Error{Message: "undefined symbol 'x'", Pos: ast.Position{Line: 1, Column: 2}})
+	assert.Eq(t, err.(*ScriptError).Line, 1)
+	assert.Eq(t, err.Error(), "foo.ank:1:2: undefined symbol 'x'")
+	assert.Nil(t, scriptError("foo.ank", nil))
+
+	// actions
+	assert.Nil(t, scriptAction("ed_action_bus_flush")())
+	defer func() {
+		r := recover()
+		assert.NotNil(t, r)
+		assert.True(t, strings.HasPrefix(fmt.Sprint(r), "view_insert : "))
+	}()
+	scriptAction("view_insert")("x")
+}
+
+func undoDepth(v int64) int {
+	depth := 0
+	t := trees[v]
+	for n := t.cur; n != t.root; n = n.parent {
+		depth++
+	}
+	return depth
+}
+
 func add(v int64, i *int, inc int) {
 	d(addAction{i, inc})
 	UndoAdd(v, []core.Action{addAction{i, inc}}, []core.Action{addAction{i, -inc}})
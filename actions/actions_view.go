@@ -1,6 +1,22 @@
 package actions
 
-import "github.com/tcolar/goed/core"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tcolar/goed/core"
+)
+
+// Add an extra cursor (multi cursor) to the view at ln, col (1 indexed)
+func (a *ar) ViewAddCursor(viewId int64, ln, col int) {
+	d(viewAddCursor{viewId: viewId, ln: ln, col: col})
+}
+
+// Select the next occurrence of the last selection text (multi cursor)
+// or the word at the cursor if there is no selection.
+func (a *ar) ViewAddNextOccurrence(viewId int64) {
+	d(viewAddNextOccurrence{viewId: viewId})
+}
 
 // Add a text selection to the view. from l1,c1 to l2,c2 (1 indexed)
 func (a *ar) ViewAddSelection(viewId int64, l1, c1, l2, c2 int) {
@@ -18,7 +34,16 @@ func (a *ar) ViewBackspace(viewId int64) {
 	d(viewBackspace{viewId: viewId})
 }
 
+// handle a click on the view banner, x being relative to the view.
+// returns whether the click was consumed.
+func (a *ar) ViewBannerClick(viewId int64, x int) bool {
+	answer := make(chan bool, 1)
+	d(viewBannerClick{viewId: viewId, x: x, answer: answer})
+	return <-answer
+}
+
 // return the current view location in the ui (1 indexed)
+// scope: read
 func (a *ar) ViewBounds(viewId int64) (ln, col, ln2, col2 int) {
 	answer := make(chan int, 4)
 	d(viewBounds{viewId: viewId, answer: answer})
@@ -31,17 +56,28 @@ func (a *ar) ViewClearSelections(viewId int64) {
 }
 
 // stop the command currenty running in the view (for exec views.)
+// scope: exec
 func (a *ar) ViewCmdStop(viewId int64) {
 	d(viewCmdStop{viewId: viewId})
 }
 
 // return the nuber of columns (width) of the view.
+// scope: read
 func (a *ar) ViewCols(viewId int64) (cols int) {
 	answer := make(chan int, 1)
 	d(viewCols{viewId: viewId, answer: answer})
 	return <-answer
 }
 
+// complete the word at the cursor using the language server.
+// the completion is inserted if unique, returns the candidates.
+func (a *ar) ViewComplete(viewId int64) ([]string, error) {
+	candidates := make(chan []string, 1)
+	err := make(chan error, 1)
+	d(viewComplete{viewId: viewId, candidates: candidates, err: err})
+	return <-candidates, <-err
+}
+
 // copy text from the view (current selection, if none, current line)
 func (a *ar) ViewCopy(viewId int64) {
 	d(viewCopy{viewId: viewId})
@@ -53,6 +89,7 @@ func (a *ar) ViewCut(viewId int64) {
 }
 
 // return the current cursor UI position in the view (1 indexed)
+// scope: read
 func (a *ar) ViewCursorCoords(viewId int64) (y, x int) {
 	answer := make(chan int, 2)
 	d(viewCursorCoords{viewId: viewId, answer: answer})
@@ -60,6 +97,7 @@ func (a *ar) ViewCursorCoords(viewId int64) (y, x int) {
 }
 
 // return the current cursor text position in the view (1 indexed)
+// scope: read
 func (a *ar) ViewCursorPos(viewId int64) (y, x int) {
 	answer := make(chan int, 2)
 	d(viewCursorPos{viewId: viewId, answer: answer})
@@ -72,6 +110,7 @@ func (a *ar) ViewCursorMvmt(viewId int64, mvmt core.CursorMvmt) {
 }
 
 // delete text from the view (from row1,col1 to row2,col2). 1 indexed
+// defaults: undoable=true
 func (a *ar) ViewDelete(viewId int64, row1, col1, row2, col2 int, undoable bool) {
 	d(viewDeleteAction{viewId: viewId, row1: row1, col1: col1, row2: row2, col2: col2, undoable: undoable})
 }
@@ -82,13 +121,50 @@ func (a *ar) ViewDeleteCur(viewId int64) {
 }
 
 // is the view dirty or not ?
+// scope: read
 func (a *ar) ViewDirty(viewId int64) bool {
 	answer := make(chan bool, 1)
 	d(viewDirty{answer: answer, viewId: viewId})
 	return <-answer
 }
 
+// search the view for query and select the first match after the cursor.
+// query is a literal string unless regex is true.
+// defaults: regex=false, ignoreCase=false
+func (a *ar) ViewFind(viewId int64, query string, regex, ignoreCase bool) error {
+	answer := make(chan error, 1)
+	d(viewFind{viewId: viewId, query: query, regex: regex, ignoreCase: ignoreCase, answer: answer})
+	return <-answer
+}
+
+// select the next (or previous if backward) match of the view search query.
+// returns false if there is no match.
+// defaults: backward=false
+func (a *ar) ViewFindNext(viewId int64, backward bool) bool {
+	answer := make(chan bool, 1)
+	d(viewFindNext{viewId: viewId, backward: backward, answer: answer})
+	return <-answer
+}
+
+// open the definition of the symbol at the cursor using the language server.
+func (a *ar) ViewGotoDefinition(viewId int64) error {
+	answer := make(chan error, 1)
+	d(viewGotoDefinition{viewId: viewId, answer: answer})
+	return <-answer
+}
+
+// show (and return) informations about the symbol at the cursor, using the
+// language server.
+// scope: read
+func (a *ar) ViewHover(viewId int64) (string, error) {
+	text := make(chan string, 1)
+	err := make(chan error, 1)
+	d(viewHover{viewId: viewId, text: text, err: err})
+	return <-text, <-err
+}
+
 // insert text into the view at the row,col location. 1 indexed
+// defaults: undoable=true
 func (a *ar) ViewInsert(viewId int64, row, col int, text string, undoable bool) {
 	d(viewInsertAction{viewId: viewId, row: row, col: col, text: text, undoable: undoable})
 }
@@ -105,6 +181,7 @@ func (a *ar) ViewInsertNewLine(viewId int64) {
 
 // move the cursor by ln, col runes (relative), scroll as needed
 // roll means "roll" to prev/next line on column overflow
+// defaults: roll=false
 func (a *ar) ViewMoveCursor(viewId int64, y, x int, roll bool) {
 	d(viewMoveCursor{viewId: viewId, x: x, y: y, roll: roll})
 }
@@ -116,18 +193,30 @@ func (a *ar) ViewPaste(viewId int64) {
 }
 
 // try to "open" the current selection into a view (ie: expect a file path)
+// defaults: newView=false
 func (a *ar) ViewOpenSelection(viewId int64, newView bool) {
 	d(viewOpenSelection{viewId: viewId, newView: newView})
 }
 
+// return whether the command output of the view is pinned : the view does not
+// follow the output (ie: while reading or searching it).
+// scope: read
+func (a *ar) ViewPinned(viewId int64) bool {
+	answer := make(chan bool, 1)
+	d(viewPinned{viewId: viewId, answer: answer})
+	return <-answer
+}
+
 // redo
 func (a *ar) ViewRedo(viewId int64) {
 	d(viewRedo{viewId: viewId})
 }
 
 // reload the view from it's source file, discard all unsaved buffer changes
-func (a *ar) ViewReload(viewId int64) {
-	d(viewReload{viewId: viewId})
+// if 'check' is true and the view is dirty, the user is asked to confirm first.
+// defaults: check=false
+func (a *ar) ViewReload(viewId int64, check bool) {
+	d(viewReload{viewId: viewId, check: check})
 }
 
 // render/repaint the view
@@ -135,7 +224,27 @@ func (a *ar) ViewRender(viewId int64) {
 	d(viewRender{viewId: viewId})
 }
 
+// replace the selected match of the view search query (see ViewFind)
+// and select the next one, or replace all the matches if all is true.
+// returns the number of replacements.
+// defaults: all=false
+func (a *ar) ViewReplace(viewId int64, with string, all bool) int {
+	answer := make(chan int, 1)
+	d(viewReplace{viewId: viewId, with: with, all: all, answer: answer})
+	return <-answer
+}
+
+// resolve a change of the view file on disk : "reload", "keep" (keep the
+// view content) or "diff" (show the differences in a new view).
+// fails if the file was not changed on disk while the view had changes.
+func (a *ar) ViewResolveConflict(viewId int64, choice string) error {
+	answer := make(chan error, 1)
+	d(viewResolveConflict{viewId: viewId, choice: choice, answer: answer})
+	return <-answer
+}
+
 // return the number of rows (lines) in the view
+// scope: read
 func (a *ar) ViewRows(viewId int64) (rows int) {
 	answer := make(chan int, 1)
 	d(viewRows{viewId: viewId, answer: answer})
@@ -147,6 +256,15 @@ func (a *ar) ViewSave(viewId int64) {
 	d(viewSave{viewId: viewId})
 }
 
+// save the full command output of the view to a file, including the oldest
+// rows no longer kept in memory (see CmdOutputLogSize).
+// scope: exec
+func (a *ar) ViewSaveOutput(viewId int64, loc string) error {
+	answer := make(chan error, 1)
+	d(viewSaveOutput{viewId: viewId, loc: loc, answer: answer})
+	return <-answer
+}
+
 // select all
 func (a *ar) ViewSelectAll(viewId int64) {
 	d(viewSelectAll{viewId: viewId})
@@ -155,6 +273,7 @@ func (a *ar) ViewSelectAll(viewId int64) {
 // Return a list of view selctions (one per line), 1 indexed, ie:
 // 2 1 2 6
 // 3 2 4 7
+// scope: read
 func (a *ar) ViewSelections(viewId int64) []core.Selection {
 	answer := make(chan []core.Selection, 1)
 	d(viewSelections{answer: answer, viewId: viewId})
@@ -176,6 +295,12 @@ func (a *ar) ViewSetDirty(viewId int64, on bool) {
 	d(viewSetDirty{viewId: viewId, on: on})
 }
 
+// pin (or unpin) the command output of the view : while pinned the view stays
+// on the same text rather than following the output.
+func (a *ar) ViewSetPinned(viewId int64, pinned bool) {
+	d(viewSetPinned{viewId: viewId, pinned: pinned})
+}
+
 // set scrolling offsets as percentage (of text)
 func (a *ar) ViewSetScrollPct(viewId int64, ypct int) {
 	d(viewSetScrollPct{viewId: viewId, ypct: ypct})
@@ -209,6 +334,7 @@ func (a *ar) ViewSetWorkDir(viewId int64, workDir string) {
 }
 
 // return the absolute path of the file backing the view (if any)
+// scope: read
 func (a *ar) ViewSrcLoc(viewId int64) string {
 	answer := make(chan string, 1)
 	d(viewSrcLoc{viewId: viewId, answer: answer})
@@ -216,6 +342,7 @@ func (a *ar) ViewSrcLoc(viewId int64) string {
 }
 
 // return the current scrolling position (1,1 is top, left)
+// scope: read
 func (a *ar) ViewScrollPos(viewId int64) (ln, col int) {
 	answer := make(chan int, 2)
 	d(viewScrollPos{viewId: viewId, answer: answer})
@@ -230,6 +357,7 @@ func (a *ar) ViewSyncSlice(viewId int64) {
 
 // returns a slice of the buffer text from ln1,col1 to ln2,col2 (inclusive). 1 indexed
 // note: col2==-1 means to end of line; ln2==-1 means to last line
+// scope: read
 func (a *ar) ViewText(viewId int64, ln1, col1, ln2, col2 int) []string {
 	answer := make(chan []string, 1)
 	d(viewText{viewId: viewId, ln1: ln1, col1: col1, ln2: ln2, col2: col2, answer: answer})
@@ -239,6 +367,7 @@ func (a *ar) ViewText(viewId int64, ln1, col1, ln2, col2 int) []string {
 // return the text position(1 indexed) for the given y,x cursor coordinates (0 indexed)
 // if the given coordinates are not on text, return the closest text position.
 // typically would be passed coordinates gotten from EdViewAt.
+// scope: read
 func (a *ar) ViewTextPos(viewId int64, y, x int) (ln, col int) {
 	answer := make(chan int, 2)
 	d(viewTextPos{viewId: viewId, answer: answer, y: y, x: x})
@@ -246,6 +375,7 @@ func (a *ar) ViewTextPos(viewId int64, y, x int) (ln, col int) {
 }
 
 // return the vew title
+// scope: read
 func (a *ar) ViewTitle(viewId int64) string {
 	answer := make(chan string, 1)
 	d(viewTitle{viewId: viewId, answer: answer})
@@ -253,6 +383,7 @@ func (a *ar) ViewTitle(viewId int64) string {
 }
 
 // return the vew type (core.ViewType)
+// scope: read
 func (a *ar) ViewType(viewId int64) int {
 	answer := make(chan int, 1)
 	d(viewType{viewId: viewId, answer: answer})
@@ -264,7 +395,24 @@ func (a *ar) ViewUndo(viewId int64) {
 	d(viewUndo{viewId: viewId})
 }
 
+// go to the given state of the undo tree (see ViewUndoTree)
+func (a *ar) ViewUndoTo(viewId int64, id int) error {
+	answer := make(chan error, 1)
+	d(viewUndoTo{viewId: viewId, id: id, answer: answer})
+	return <-answer
+}
+
+// list the undo tree states, one per line as :
+// "id parent_id unix_time current(*|-) description"
+// scope: read
+func (a *ar) ViewUndoTree(viewId int64) []string {
+	answer := make(chan []string, 1)
+	d(viewUndoTree{viewId: viewId, answer: answer})
+	return <-answer
+}
+
 // working directory
+// scope: read
 func (a *ar) ViewWorkDir(viewId int64) string {
 	answer := make(chan string, 1)
 	d(viewWorkDir{viewId: viewId, answer: answer})
@@ -272,12 +420,56 @@ func (a *ar) ViewWorkDir(viewId int64) string {
 }
 
 // send raw bytes to a terminal view
+// scope: exec
 func (a *ar) TermSendBytes(viewId int64, data []byte) {
 	d(termSendBytes{viewId: viewId, data: data})
 }
 
+// send a signal to the foreground program of a terminal view : INT, QUIT or TSTP
+// (as Ctrl+C, Ctrl+\ and Ctrl+Z would)
+// scope: exec
+func (a *ar) TermSignal(viewId int64, signal string) error {
+	answer := make(chan error, 1)
+	d(termSignal{viewId: viewId, signal: signal, answer: answer})
+	return <-answer
+}
+
+// report a mouse event at a text position to the program of a terminal view,
+// if it asked for mouse events (ie: vim, htop). Returns whether it was reported.
+// button is the xterm one : 0 left, 1 middle, 2 right, 64/65 wheel up/down
+// scope: exec
+func (a *ar) TermMouse(viewId int64, ln, col, button int, drag, release bool) bool {
+	answer := make(chan bool, 1)
+	d(termMouse{viewId: viewId, ln: ln - 1, col: col - 1, button: button,
+		drag: drag, release: release, answer: answer})
+	return <-answer
+}
+
 // ########  Impl ......
 
+type viewAddCursor struct {
+	viewId  int64
+	ln, col int
+}
+
+func (a viewAddCursor) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v != nil {
+		v.AddCursor(a.ln-1, a.col-1)
+	}
+}
+
+type viewAddNextOccurrence struct {
+	viewId int64
+}
+
+func (a viewAddNextOccurrence) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v != nil {
+		v.AddNextOccurrence()
+	}
+}
+
 type viewAddSelection struct {
 	viewId         int64
 	l1, c1, l2, c2 int
@@ -322,6 +514,17 @@ func (a viewBackspace) Run() {
 	}
 }
 
+type viewBannerClick struct {
+	viewId int64
+	x      int
+	answer chan bool
+}
+
+func (a viewBannerClick) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	a.answer <- v != nil && v.BannerClick(a.x)
+}
+
 type viewBounds struct {
 	answer chan int
 	viewId int64
@@ -384,6 +587,26 @@ func (a viewCols) Run() {
 	a.answer <- v.LastViewCol()
 }
 
+type viewComplete struct {
+	viewId     int64
+	candidates chan []string
+	err        chan error
+}
+
+func (a viewComplete) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.candidates <- nil
+		a.err <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	// answered once the language server replied, not to hold the bus meanwhile
+	v.Complete(func(candidates []string, err error) {
+		a.candidates <- candidates
+		a.err <- err
+	})
+}
+
 type viewCopy struct {
 	viewId int64
 }
@@ -493,6 +716,70 @@ func (a viewDirty) Run() {
 	a.answer <- false
 }
 
+type viewFind struct {
+	viewId            int64
+	query             string
+	regex, ignoreCase bool
+	answer            chan error
+}
+
+func (a viewFind) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	a.answer <- v.Find(a.query, a.regex, a.ignoreCase)
+}
+
+type viewFindNext struct {
+	viewId   int64
+	backward bool
+	answer   chan bool
+}
+
+func (a viewFindNext) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- false
+		return
+	}
+	a.answer <- v.FindNext(a.backward)
+}
+
+type viewGotoDefinition struct {
+	viewId int64
+	answer chan error
+}
+
+func (a viewGotoDefinition) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	v.GotoDefinition(func(err error) { a.answer <- err })
+}
+
+type viewHover struct {
+	viewId int64
+	text   chan string
+	err    chan error
+}
+
+func (a viewHover) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.text <- ""
+		a.err <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	v.Hover(func(text string, err error) {
+		a.text <- text
+		a.err <- err
+	})
+}
+
 type viewInsertAction struct {
 	viewId   int64
 	row, col int
@@ -572,6 +859,21 @@ func (a viewPaste) Run() {
 	}
 }
 
+type viewPinned struct {
+	viewId int64
+	answer chan bool
+}
+
+func (a viewPinned) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- false
+		return
+	}
+	out, ok := v.Backend().(core.CmdOutputBackend)
+	a.answer <- ok && out.Pinned()
+}
+
 type viewRedo struct {
 	viewId int64
 }
@@ -582,11 +884,19 @@ func (a viewRedo) Run() {
 	}
 }
 
-type viewReload struct{ viewId int64 }
+type viewReload struct {
+	viewId int64
+	check  bool
+}
 
 func (a viewReload) Run() {
 	v := core.Ed.ViewById(a.viewId)
-	if v != nil {
+	if v == nil {
+		return
+	}
+	if a.check {
+		core.Ed.ViewReloadCheck(a.viewId)
+	} else {
 		v.Reload()
 	}
 }
@@ -602,6 +912,37 @@ func (a viewRender) Run() {
 	}
 }
 
+type viewReplace struct {
+	viewId int64
+	with   string
+	all    bool
+	answer chan int
+}
+
+func (a viewReplace) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- 0
+		return
+	}
+	a.answer <- v.Replace(a.with, a.all)
+}
+
+type viewResolveConflict struct {
+	viewId int64
+	choice string
+	answer chan error
+}
+
+func (a viewResolveConflict) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	a.answer <- v.ResolveConflict(a.choice)
+}
+
 type viewRows struct {
 	answer chan int
 	viewId int64
@@ -627,6 +968,26 @@ func (a viewSave) Run() {
 	}
 }
 
+type viewSaveOutput struct {
+	viewId int64
+	loc    string
+	answer chan error
+}
+
+func (a viewSaveOutput) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		a.answer <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	out, ok := v.Backend().(core.CmdOutputBackend)
+	if !ok {
+		a.answer <- fmt.Errorf("Not a command output view")
+		return
+	}
+	a.answer <- out.SaveOutput(a.loc)
+}
+
 type viewScrollPos struct {
 	answer chan int
 	viewId int64
@@ -718,6 +1079,21 @@ func (a viewSetDirty) Run() {
 	}
 }
 
+type viewSetPinned struct {
+	viewId int64
+	pinned bool
+}
+
+func (a viewSetPinned) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil {
+		return
+	}
+	if out, ok := v.Backend().(core.CmdOutputBackend); ok {
+		out.SetPinned(a.pinned)
+	}
+}
+
 type viewSetScrollPct struct {
 	viewId int64
 	ypct   int
@@ -908,6 +1284,29 @@ func (a viewUndo) Run() {
 	}
 }
 
+type viewUndoTo struct {
+	viewId int64
+	id     int
+	answer chan error
+}
+
+func (a viewUndoTo) Run() {
+	if !viewExists(a.viewId) {
+		a.answer <- fmt.Errorf("No such view : %d", a.viewId)
+		return
+	}
+	a.answer <- UndoTo(a.viewId, a.id)
+}
+
+type viewUndoTree struct {
+	viewId int64
+	answer chan []string
+}
+
+func (a viewUndoTree) Run() {
+	a.answer <- UndoTree(a.viewId)
+}
+
 type viewWorkDir struct {
 	viewId int64
 	answer chan string
@@ -935,6 +1334,48 @@ func (a termSendBytes) Run() {
 	v.Backend().SendBytes(a.data)
 }
 
+type termSignal struct {
+	viewId int64
+	signal string
+	answer chan error
+}
+
+func (a termSignal) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil || v.Type() != core.ViewTypeShell {
+		a.answer <- fmt.Errorf("Not a terminal view")
+		return
+	}
+	term, ok := v.Backend().(core.TerminalBackend)
+	if !ok {
+		a.answer <- fmt.Errorf("Not a terminal view")
+		return
+	}
+	a.answer <- term.TermSignal(strings.ToUpper(a.signal))
+}
+
+type termMouse struct {
+	viewId        int64
+	ln, col       int
+	button        int
+	drag, release bool
+	answer        chan bool
+}
+
+func (a termMouse) Run() {
+	v := core.Ed.ViewById(a.viewId)
+	if v == nil || v.Type() != core.ViewTypeShell {
+		a.answer <- false
+		return
+	}
+	term, ok := v.Backend().(core.TerminalBackend)
+	if !ok {
+		a.answer <- false
+		return
+	}
+	a.answer <- term.TermMouse(a.ln, a.col, a.button, a.drag, a.release)
+}
+
 func NewViewInsertAction(viewId int64, row, col int, text string, undoable bool) core.Action {
 	return viewInsertAction{viewId: viewId, row: row + 1, col: col + 1,
 		text: text, undoable: undoable}
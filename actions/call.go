@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ActionInfo describes a registered action and its typed signature.
+type ActionInfo struct {
+	Name    string
+	Sig     string        // ie: "(int64, string) error"
+	Usage   string        // ie: "(viewId int64, text string) error"
+	Params  []ActionParam // parameters
+	Results []ActionParam // results, error excluded
+	Scope   string        // API permission scope required
+}
+
+// ActionParam describes an action parameter or result.
+type ActionParam struct {
+	Name    string
+	Type    reflect.Type
+	Default *string // default value in text form, nil if required
+}
+
+// Infos returns the description of all the registered actions, sorted by name.
+func Infos() []ActionInfo {
+	infos := []ActionInfo{}
+	for name, proto := range actions {
+		info := ActionInfo{
+			Name:  name,
+			Sig:   proto.sig,
+			Usage: proto.usage,
+			Scope: proto.scope,
+		}
+		for _, p := range proto.params {
+			info.Params = append(info.Params, ActionParam{p.name, p.typ, p.def})
+		}
+		for _, r := range proto.results {
+			if r.typ != errorType {
+				info.Results = append(info.Results, ActionParam{Name: r.name, Type: r.typ})
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// HasAction returns whether an action of that name is registered.
+func HasAction(action string) bool {
+	_, found := actions[action]
+	return found
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Call runs an action with its params in JSON form, one per action parameter,
+// trailing params that have a default may be omitted.
+// Unlike Exec the results are typed : they are the JSON form of the action
+// return values, minus the error which is returned as err.
+// Invalid params are reported as ArgErrors.
+func Call(action string, params []json.RawMessage) (res []interface{}, err error) {
+	proto, ok := actions[action]
+	if !ok {
+		return res, fmt.Errorf("No such action %s", action)
+	}
+	if len(params) > len(proto.params) {
+		return res, ArgErrors{{Action: action, Param: "params", Type: "array",
+			Err: fmt.Errorf("too many, got %d, want %d", len(params), len(proto.params))}}
+	}
+	in := []reflect.Value{reflect.ValueOf(Ar)}
+	errs := ArgErrors{}
+	for i, p := range proto.params {
+		var val reflect.Value
+		var err error
+		switch {
+		case i >= len(params) && p.def != nil:
+			val, err = p.defVal()
+		case i >= len(params):
+			err = fmt.Errorf("missing")
+		default:
+			val, err = jsonToVal(params[i], p.typ)
+		}
+		if err != nil {
+			errs = append(errs, p.err(action, err))
+			continue
+		}
+		in = append(in, val)
+	}
+	if len(errs) > 0 {
+		return res, errs
+	}
+	out := proto.f.Call(in)
+	for i, r := range proto.results {
+		if r.typ == errorType {
+			if !out[i].IsNil() {
+				err = out[i].Interface().(error)
+			}
+			continue
+		}
+		res = append(res, valToJson(out[i], r.typ))
+	}
+	return res, err
+}
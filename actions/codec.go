@@ -0,0 +1,240 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tcolar/goed/core"
+)
+
+// Codec converts the values of an action param or result type from and to
+// their text form (command line, Exec) and JSON form (JSON-RPC).
+// Types without a registered codec use their JSON encoding for both forms,
+// slices of a registered type use one text argument per element.
+type Codec struct {
+	// FromText decodes a value from its text form.
+	FromText func(s string) (interface{}, error)
+	// ToText encodes a value to its text form.
+	ToText func(v interface{}) string
+	// FromJson decodes a value from its JSON form, encoding/json if nil.
+	FromJson func(data []byte) (interface{}, error)
+	// ToJson returns the value to JSON encode, the value itself if nil.
+	ToJson func(v interface{}) interface{}
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[reflect.Type]Codec
+}{m: map[reflect.Type]Codec{}}
+
+// RegisterCodec registers the codec of a type, replacing any existing one.
+func RegisterCodec(t reflect.Type, c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[t] = c
+}
+
+func codecOf(t reflect.Type) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, found := codecs.m[t]
+	return c, found
+}
+
+// isList returns whether values of type t are given as several text
+// arguments, one per element.
+func isList(t reflect.Type) bool {
+	if _, found := codecOf(t); found {
+		return false
+	}
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	_, found := codecOf(t.Elem())
+	return found
+}
+
+// textToVal decodes a value of type t from its text form.
+func textToVal(s string, t reflect.Type) (reflect.Value, error) {
+	c, found := codecOf(t)
+	if !found {
+		v := reflect.New(t)
+		if err := json.Unmarshal([]byte(s), v.Interface()); err != nil {
+			return v, err
+		}
+		return v.Elem(), nil
+	}
+	i, err := c.FromText(s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(i).Convert(t), nil
+}
+
+// textsToVal decodes a list value of type t from its text arguments.
+func textsToVal(args []string, t reflect.Type) (reflect.Value, error) {
+	v := reflect.MakeSlice(t, 0, len(args))
+	for i, s := range args {
+		e, err := textToVal(s, t.Elem())
+		if err != nil {
+			return v, fmt.Errorf("element %d : %s", i, err.Error())
+		}
+		v = reflect.Append(v, e)
+	}
+	return v, nil
+}
+
+// valToTexts encodes a value to its text form, one string per element for
+// a list.
+func valToTexts(v reflect.Value, t reflect.Type) ([]string, error) {
+	if isList(t) {
+		strs := []string{}
+		for i := 0; i != v.Len(); i++ {
+			s, err := valToTexts(v.Index(i), t.Elem())
+			if err != nil {
+				return strs, err
+			}
+			strs = append(strs, s...)
+		}
+		return strs, nil
+	}
+	c, found := codecOf(t)
+	if !found {
+		data, err := json.Marshal(v.Interface())
+		return []string{string(data)}, err
+	}
+	return []string{c.ToText(v.Interface())}, nil
+}
+
+// jsonToVal decodes a value of type t from its JSON form.
+func jsonToVal(data []byte, t reflect.Type) (reflect.Value, error) {
+	c, found := codecOf(t)
+	if found && c.FromJson != nil {
+		i, err := c.FromJson(data)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(i).Convert(t), nil
+	}
+	if isList(t) {
+		elems := []json.RawMessage{}
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.MakeSlice(t, 0, len(elems))
+		for i, e := range elems {
+			ev, err := jsonToVal(e, t.Elem())
+			if err != nil {
+				return v, fmt.Errorf("element %d : %s", i, err.Error())
+			}
+			v = reflect.Append(v, ev)
+		}
+		return v, nil
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return v, err
+	}
+	return v.Elem(), nil
+}
+
+// valToJson returns the JSON form of a value.
+func valToJson(v reflect.Value, t reflect.Type) interface{} {
+	if c, found := codecOf(t); found && c.ToJson != nil {
+		return c.ToJson(v.Interface())
+	}
+	if t.Kind() == reflect.Slice {
+		if v.IsNil() {
+			return []interface{}{} // [] rather than null
+		}
+		if isList(t) {
+			l := []interface{}{}
+			for i := 0; i != v.Len(); i++ {
+				l = append(l, valToJson(v.Index(i), t.Elem()))
+			}
+			return l
+		}
+	}
+	return v.Interface()
+}
+
+func init() {
+	RegisterCodec(reflect.TypeOf(""), Codec{
+		FromText: func(s string) (interface{}, error) { return s, nil },
+		ToText:   func(v interface{}) string { return v.(string) },
+	})
+	RegisterCodec(reflect.TypeOf(0), Codec{
+		FromText: func(s string) (interface{}, error) { return strconv.Atoi(s) },
+		ToText:   func(v interface{}) string { return strconv.Itoa(v.(int)) },
+	})
+	RegisterCodec(reflect.TypeOf(int64(0)), Codec{
+		FromText: func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) },
+		ToText:   func(v interface{}) string { return strconv.FormatInt(v.(int64), 10) },
+	})
+	RegisterCodec(reflect.TypeOf(false), Codec{
+		FromText: func(s string) (interface{}, error) { return strconv.ParseBool(s) },
+		ToText:   func(v interface{}) string { return strconv.FormatBool(v.(bool)) },
+	})
+	RegisterCodec(reflect.TypeOf(core.CursorMvmt(0)), Codec{
+		FromText: func(s string) (interface{}, error) {
+			i, err := strconv.ParseUint(s, 10, 8)
+			return core.CursorMvmt(i), err
+		},
+		ToText: func(v interface{}) string { return strconv.Itoa(int(v.(core.CursorMvmt))) },
+	})
+	RegisterCodec(reflect.TypeOf(core.FileOp(0)), Codec{
+		FromText: func(s string) (interface{}, error) {
+			i, err := strconv.ParseUint(s, 10, 32)
+			return core.FileOp(i), err
+		},
+		ToText: func(v interface{}) string { return strconv.Itoa(int(v.(core.FileOp))) },
+	})
+	// selection : "l1 c1 l2 c2"
+	RegisterCodec(reflect.TypeOf(core.Selection{}), Codec{
+		FromText: func(s string) (interface{}, error) {
+			sel := core.Selection{}
+			_, err := fmt.Sscanf(s, "%d %d %d %d", &sel.LineFrom, &sel.ColFrom, &sel.LineTo, &sel.ColTo)
+			return sel, err
+		},
+		ToText: func(v interface{}) string { return v.(core.Selection).String() },
+	})
+	// bytes : text with Go escapes (ie: "\x03"), JSON string or array of bytes
+	RegisterCodec(reflect.TypeOf([]byte{}), Codec{
+		FromText: func(s string) (interface{}, error) {
+			if u, err := strconv.Unquote(`"` + strings.Replace(s, `"`, `\"`, -1) + `"`); err == nil {
+				return []byte(u), nil
+			}
+			return []byte(s), nil
+		},
+		ToText: func(v interface{}) string { return string(v.([]byte)) },
+		FromJson: func(data []byte) (interface{}, error) {
+			s := ""
+			if err := json.Unmarshal(data, &s); err == nil {
+				return []byte(s), nil
+			}
+			ints := []int{}
+			if err := json.Unmarshal(data, &ints); err != nil {
+				return nil, err
+			}
+			b := []byte{}
+			for _, i := range ints {
+				if i < 0 || i > 255 {
+					return nil, fmt.Errorf("Not a byte : %d", i)
+				}
+				b = append(b, byte(i))
+			}
+			return b, nil
+		},
+		ToJson: func(v interface{}) interface{} {
+			ints := []int{}
+			for _, b := range v.([]byte) {
+				ints = append(ints, int(b))
+			}
+			return ints
+		},
+	})
+}
@@ -0,0 +1,177 @@
+//go:build ignore
+// +build ignore
+
+// Generates params_gen.go : the actions parameter and result names, as well
+// as the parameter defaults, which reflection does not provide.
+// Defaults are given in the action doc as a "defaults: name=value, ..." line,
+// values in their text form. The API permission scope required by an action
+// is given by a "scope: read|edit|exec" line, edit if none.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	files, err := filepath.Glob("actions*.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	docs := map[string]string{}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !isAction(fn) {
+				continue
+			}
+			docs[toCamel(fn.Name.Name)] = actionDoc(fn)
+		}
+	}
+	names := []string{}
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_params.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package actions\n\n")
+	buf.WriteString("var actionDocs = map[string]actionDoc{\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%q: %s,\n", name, docs[name])
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile("params_gen.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isAction returns whether fn is an exported method of *ar
+func isAction(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 || !fn.Name.IsExported() {
+		return false
+	}
+	star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	id, ok := star.X.(*ast.Ident)
+	return ok && id.Name == "ar"
+}
+
+func actionDoc(fn *ast.FuncDecl) string {
+	params := fieldNames(fn.Type.Params)
+	results := fieldNames(fn.Type.Results)
+	defaults := map[string]string{}
+	scope := ""
+	if fn.Doc != nil {
+		for _, c := range fn.Doc.List {
+			line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if strings.HasPrefix(line, "scope:") {
+				scope = strings.TrimSpace(strings.TrimPrefix(line, "scope:"))
+				if scope != "read" && scope != "edit" && scope != "exec" {
+					log.Fatalf("%s : invalid scope %q", fn.Name.Name, scope)
+				}
+				continue
+			}
+			if !strings.HasPrefix(line, "defaults:") {
+				continue
+			}
+			for _, d := range strings.Split(strings.TrimPrefix(line, "defaults:"), ",") {
+				parts := strings.SplitN(strings.TrimSpace(d), "=", 2)
+				if len(parts) != 2 {
+					log.Fatalf("%s : invalid default %q", fn.Name.Name, d)
+				}
+				defaults[parts[0]] = parts[1]
+			}
+		}
+	}
+	// only trailing params may be defaulted
+	defs := []string{}
+	for i, p := range params {
+		def, found := defaults[p]
+		if !found {
+			if len(defs) > 0 {
+				log.Fatalf("%s : %s follows a defaulted param", fn.Name.Name, p)
+			}
+			continue
+		}
+		delete(defaults, p)
+		defs = append(defs, fmt.Sprintf("%d: %q", i, def))
+	}
+	for p := range defaults {
+		log.Fatalf("%s : no such param %s", fn.Name.Name, p)
+	}
+	s := fmt.Sprintf("{params: %s, results: %s", strList(params), strList(results))
+	if len(defs) > 0 {
+		s += fmt.Sprintf(", defaults: map[int]string{%s}", strings.Join(defs, ", "))
+	}
+	if len(scope) > 0 && scope != "edit" {
+		s += fmt.Sprintf(", scope: %q", scope)
+	}
+	return s + "}"
+}
+
+func fieldNames(fields *ast.FieldList) []string {
+	names := []string{}
+	if fields == nil {
+		return names
+	}
+	for _, f := range fields.List {
+		if len(f.Names) == 0 {
+			names = append(names, "")
+		}
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+func strList(l []string) string {
+	if len(l) == 0 {
+		return "nil"
+	}
+	quoted := []string{}
+	for _, s := range l {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// same as actions.toCamel
+func toCamel(s string) string {
+	ns := []rune{}
+	for i, c := range s {
+		if unicode.IsUpper(c) {
+			c = unicode.ToLower(c)
+			if i > 0 && i < len(s)-1 {
+				ns = append(ns, '_')
+			}
+		}
+		ns = append(ns, c)
+	}
+	return string(ns)
+}
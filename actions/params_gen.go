@@ -0,0 +1,125 @@
+// Code generated by gen_params.go; DO NOT EDIT.
+
+package actions
+
+var actionDocs = map[string]actionDoc{
+	"cmdbar_backspace":         {params: nil, results: nil},
+	"cmdbar_clear":             {params: nil, results: nil},
+	"cmdbar_complete":          {params: nil, results: nil},
+	"cmdbar_cursor_mvmt":       {params: []string{"m"}, results: nil},
+	"cmdbar_delete":            {params: nil, results: nil},
+	"cmdbar_enable":            {params: []string{"on"}, results: nil},
+	"cmdbar_enabled":           {params: nil, results: []string{""}, scope: "read"},
+	"cmdbar_history_search":    {params: nil, results: nil},
+	"cmdbar_insert":            {params: []string{"s"}, results: nil},
+	"cmdbar_new_line":          {params: nil, results: nil, scope: "exec"},
+	"cmdbar_toggle":            {params: nil, results: nil},
+	"ed_action_bus_flush":      {params: nil, results: nil, scope: "read"},
+	"ed_activate_view":         {params: []string{"viewId"}, results: nil},
+	"ed_collect_problems":      {params: []string{"viewId", "matcher"}, results: []string{"", ""}, defaults: map[int]string{1: ""}},
+	"ed_cur_view":              {params: nil, results: []string{""}, scope: "read"},
+	"ed_del_col":               {params: []string{"colIndex", "check"}, results: nil, defaults: map[int]string{1: "true"}},
+	"ed_del_view":              {params: []string{"viewId", "check"}, results: nil, defaults: map[int]string{1: "true"}},
+	"ed_file_event":            {params: []string{"op", "loc"}, results: nil},
+	"ed_file_moved":            {params: []string{"from", "to"}, results: nil},
+	"ed_next_error":            {params: nil, results: []string{""}},
+	"ed_open":                  {params: []string{"loc", "viewId", "rel", "create"}, results: []string{""}, defaults: map[int]string{1: "-1", 2: "", 3: "false"}},
+	"ed_open_term":             {params: []string{"args"}, results: []string{""}, scope: "exec"},
+	"ed_overlay_event":         {params: []string{"evtType", "glyph", "mouse", "y", "x"}, results: []string{""}},
+	"ed_prev_error":            {params: nil, results: []string{""}},
+	"ed_problems":              {params: nil, results: []string{""}, scope: "read"},
+	"ed_project_replace":       {params: []string{"dir", "pattern", "repl", "regex", "ignoreCase"}, results: []string{"", ""}, defaults: map[int]string{3: "false", 4: "false"}},
+	"ed_project_replace_apply": {params: []string{"viewId"}, results: []string{"", ""}},
+	"ed_project_search":        {params: []string{"dir", "pattern", "regex", "ignoreCase"}, results: []string{"", ""}, defaults: map[int]string{2: "false", 3: "false"}},
+	"ed_prompt":                {params: []string{"question", "defaultChoice", "choices"}, results: []string{""}},
+	"ed_quit":                  {params: nil, results: nil, scope: "exec"},
+	"ed_quit_check":            {params: nil, results: []string{""}},
+	"ed_render":                {params: nil, results: nil},
+	"ed_rerun_task":            {params: nil, results: []string{"", ""}, scope: "exec"},
+	"ed_resize":                {params: []string{"h", "w"}, results: nil},
+	"ed_run_task":              {params: []string{"name"}, results: []string{"", ""}, scope: "exec"},
+	"ed_script_errors":         {params: []string{"script", "errs"}, results: nil},
+	"ed_set_status":            {params: []string{"status"}, results: nil},
+	"ed_set_status_err":        {params: []string{"status"}, results: nil},
+	"ed_size":                  {params: nil, results: []string{"rows", "cols"}, scope: "read"},
+	"ed_swap_views":            {params: []string{"view1Id", "view2Id"}, results: nil},
+	"ed_tasks":                 {params: []string{"dir"}, results: []string{"", ""}, defaults: map[int]string{0: ""}, scope: "read"},
+	"ed_term_flush":            {params: nil, results: nil},
+	"ed_view_at":               {params: []string{"ey", "ex"}, results: []string{"vid", "vy", "vx"}, scope: "read"},
+	"ed_view_index":            {params: []string{"viewId"}, results: []string{"row", "col"}, scope: "read"},
+	"ed_view_move":             {params: []string{"y1", "x1", "y2", "x2"}, results: nil},
+	"ed_view_navigate":         {params: []string{"mvmt"}, results: nil},
+	"ed_views":                 {params: nil, results: []string{""}, scope: "read"},
+	"ed_views_by_loc":          {params: []string{"loc"}, results: []string{""}, scope: "read"},
+	"finder_backspace":         {params: nil, results: nil},
+	"finder_close":             {params: nil, results: nil},
+	"finder_enabled":           {params: nil, results: []string{""}, scope: "read"},
+	"finder_insert":            {params: []string{"s"}, results: nil},
+	"finder_move":              {params: []string{"delta"}, results: nil},
+	"finder_open":              {params: []string{"mode"}, results: []string{""}, defaults: map[int]string{0: "files"}},
+	"finder_select":            {params: nil, results: nil},
+	"term_mouse":               {params: []string{"viewId", "ln", "col", "button", "drag", "release"}, results: []string{""}, scope: "exec"},
+	"term_send_bytes":          {params: []string{"viewId", "data"}, results: nil, scope: "exec"},
+	"term_signal":              {params: []string{"viewId", "signal"}, results: []string{""}, scope: "exec"},
+	"view_add_cursor":          {params: []string{"viewId", "ln", "col"}, results: nil},
+	"view_add_next_occurrence": {params: []string{"viewId"}, results: nil},
+	"view_add_selection":       {params: []string{"viewId", "l1", "c1", "l2", "c2"}, results: nil},
+	"view_auto_scroll":         {params: []string{"viewId", "y", "x"}, results: nil},
+	"view_backspace":           {params: []string{"viewId"}, results: nil},
+	"view_banner_click":        {params: []string{"viewId", "x"}, results: []string{""}},
+	"view_bounds":              {params: []string{"viewId"}, results: []string{"ln", "col", "ln2", "col2"}, scope: "read"},
+	"view_clear_selections":    {params: []string{"viewId"}, results: nil},
+	"view_cmd_stop":            {params: []string{"viewId"}, results: nil, scope: "exec"},
+	"view_cols":                {params: []string{"viewId"}, results: []string{"cols"}, scope: "read"},
+	"view_complete":            {params: []string{"viewId"}, results: []string{"", ""}},
+	"view_copy":                {params: []string{"viewId"}, results: nil},
+	"view_cursor_coords":       {params: []string{"viewId"}, results: []string{"y", "x"}, scope: "read"},
+	"view_cursor_mvmt":         {params: []string{"viewId", "mvmt"}, results: nil},
+	"view_cursor_pos":          {params: []string{"viewId"}, results: []string{"y", "x"}, scope: "read"},
+	"view_cut":                 {params: []string{"viewId"}, results: nil},
+	"view_delete":              {params: []string{"viewId", "row1", "col1", "row2", "col2", "undoable"}, results: nil, defaults: map[int]string{5: "true"}},
+	"view_delete_cur":          {params: []string{"viewId"}, results: nil},
+	"view_dirty":               {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_find":                {params: []string{"viewId", "query", "regex", "ignoreCase"}, results: []string{""}, defaults: map[int]string{2: "false", 3: "false"}},
+	"view_find_next":           {params: []string{"viewId", "backward"}, results: []string{""}, defaults: map[int]string{1: "false"}},
+	"view_goto_definition":     {params: []string{"viewId"}, results: []string{""}},
+	"view_hover":               {params: []string{"viewId"}, results: []string{"", ""}, scope: "read"},
+	"view_insert":              {params: []string{"viewId", "row", "col", "text", "undoable"}, results: nil, defaults: map[int]string{4: "true"}},
+	"view_insert_cur":          {params: []string{"viewId", "text"}, results: nil},
+	"view_insert_new_line":     {params: []string{"viewId"}, results: nil},
+	"view_move_cursor":         {params: []string{"viewId", "y", "x", "roll"}, results: nil, defaults: map[int]string{3: "false"}},
+	"view_open_selection":      {params: []string{"viewId", "newView"}, results: nil, defaults: map[int]string{1: "false"}},
+	"view_paste":               {params: []string{"viewId"}, results: nil},
+	"view_pinned":              {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_redo":                {params: []string{"viewId"}, results: nil},
+	"view_reload":              {params: []string{"viewId", "check"}, results: nil, defaults: map[int]string{1: "false"}},
+	"view_render":              {params: []string{"viewId"}, results: nil},
+	"view_replace":             {params: []string{"viewId", "with", "all"}, results: []string{""}, defaults: map[int]string{2: "false"}},
+	"view_resolve_conflict":    {params: []string{"viewId", "choice"}, results: []string{""}},
+	"view_rows":                {params: []string{"viewId"}, results: []string{"rows"}, scope: "read"},
+	"view_save":                {params: []string{"viewId"}, results: nil},
+	"view_save_output":         {params: []string{"viewId", "loc"}, results: []string{""}, scope: "exec"},
+	"view_scroll_pos":          {params: []string{"viewId"}, results: []string{"ln", "col"}, scope: "read"},
+	"view_select_all":          {params: []string{"viewId"}, results: nil},
+	"view_select_word":         {params: []string{"viewId", "ln", "col"}, results: nil},
+	"view_selections":          {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_set_cursor_pos":      {params: []string{"viewId", "y", "x"}, results: nil},
+	"view_set_dirty":           {params: []string{"viewId", "on"}, results: nil},
+	"view_set_pinned":          {params: []string{"viewId", "pinned"}, results: nil},
+	"view_set_scroll_pct":      {params: []string{"viewId", "ypct"}, results: nil},
+	"view_set_scroll_pos":      {params: []string{"viewId", "ln", "col"}, results: nil},
+	"view_set_title":           {params: []string{"viewId", "title"}, results: nil},
+	"view_set_type":            {params: []string{"viewId", "viewType"}, results: nil},
+	"view_set_vt_cols":         {params: []string{"viewId", "cols"}, results: nil},
+	"view_set_work_dir":        {params: []string{"viewId", "workDir"}, results: nil},
+	"view_src_loc":             {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_sync_slice":          {params: []string{"viewId"}, results: nil},
+	"view_text":                {params: []string{"viewId", "ln1", "col1", "ln2", "col2"}, results: []string{""}, scope: "read"},
+	"view_text_pos":            {params: []string{"viewId", "y", "x"}, results: []string{"ln", "col"}, scope: "read"},
+	"view_title":               {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_type":                {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_undo":                {params: []string{"viewId"}, results: nil},
+	"view_undo_to":             {params: []string{"viewId", "id"}, results: []string{""}},
+	"view_undo_tree":           {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+	"view_work_dir":            {params: []string{"viewId"}, results: []string{""}, scope: "read"},
+}
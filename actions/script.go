@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/mattn/anko/parser"
+	"github.com/mattn/anko/vm"
+	"github.com/tcolar/goed/core"
+)
+
+// Anko scripts (.ank) run within the editor, in their own VM, with :
+//   args : the script arguments, as strings.
+//   Goed : a module with every action, by name, ie:
+//     Goed.view_insert(Goed.view(), 1, 1, "foo")
+//     Params are the action params, trailing params that have a default may
+//     be omitted. The result is nil, the action result, or an array when the
+//     action has several results. An action error stops the script.
+//     As well as Goed.view() : the view that was active when the script
+//     started and Goed.instance() : the goed instance id.
+
+// ScriptError is a script failure, at a position of the script.
+type ScriptError struct {
+	Script    string
+	Line, Col int // 0 if unknown
+	Msg       string
+}
+
+func (e *ScriptError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Script, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Script, e.Line, e.Col, e.Msg)
+}
+
+// RunScript runs an anko script, blocking until done, failures are returned as
+// a *ScriptError.
+// Actions wait on the action bus, so this must not be called from it.
+func RunScript(loc string, args []string) (err error) {
+	src, err := ioutil.ReadFile(loc)
+	if err != nil {
+		return &ScriptError{Script: loc, Msg: err.Error()}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ScriptError{Script: loc, Msg: fmt.Sprint(r)}
+		}
+	}()
+	_, err = scriptEnv(args).Execute(string(src))
+	return scriptError(loc, err)
+}
+
+// scriptEnv returns a new script VM environment.
+func scriptEnv(args []string) *vm.Env {
+	env := vm.NewEnv()
+	env.Define("args", args)
+	goed := env.NewModule("Goed")
+	names := []string{}
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		goed.Define(name, scriptAction(name))
+	}
+	vid := Ar.EdCurView()
+	goed.Define("view", func() int64 { return vid })
+	goed.Define("instance", func() int64 { return core.InstanceId })
+	return env
+}
+
+// scriptAction returns the script function of an action, the arguments are
+// passed to Call in their JSON form.
+func scriptAction(action string) func(args ...interface{}) interface{} {
+	return func(args ...interface{}) interface{} {
+		params := []json.RawMessage{}
+		for _, arg := range args {
+			data, err := json.Marshal(arg)
+			if err != nil {
+				panic(fmt.Errorf("%s : %s", action, err.Error()))
+			}
+			params = append(params, data)
+		}
+		res, err := Call(action, params)
+		if err != nil {
+			// the VM reports it, at the call position
+			panic(fmt.Errorf("%s : %s", action, err.Error()))
+		}
+		switch len(res) {
+		case 0:
+			return nil
+		case 1:
+			return res[0]
+		}
+		return res
+	}
+}
+
+// scriptError converts a VM error into a *ScriptError, with its position.
+func scriptError(loc string, err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *parser.Error:
+		return &ScriptError{Script: loc, Line: e.Pos.Line, Col: e.Pos.Column, Msg: e.Message}
+	case *vm.Error:
+		return &ScriptError{Script: loc, Line: e.Pos.Line, Col: e.Pos.Column, Msg: e.Message}
+	}
+	return &ScriptError{Script: loc, Msg: err.Error()}
+}
@@ -1,22 +1,35 @@
 package actions
 
 import (
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/tcolar/goed/core"
 )
 
 // TODO : This is kind of memory heavy ....
-// TODO : group together quick succesive undos (insert a, insert b, insert c) + Flushing
 
 var maxUndos = 500
 
-// viewId keyed map of undo actions
-var undos map[int64][]actionTuple = map[int64][]actionTuple{}
+// max pause between typed characters for them to be grouped in one undo step
+var coalesceDelay = 2 * time.Second
 
-// viewId keyed map of redo actions
-var redos map[int64][]actionTuple = map[int64][]actionTuple{}
+// viewId keyed map of undo trees
+var trees map[int64]*undoTree = map[int64]*undoTree{}
+
+// viewId keyed map of undo steps being grouped together (see UndoBegin)
+var groups map[int64]*undoGroup = map[int64]*undoGroup{}
 
 var lock sync.Mutex
 
@@ -26,19 +39,48 @@ type actionTuple struct {
 	undo []core.Action
 }
 
-// or group by alphanum sequence ??
+// undoNode is a state of the undo tree, reached by running "do" from its parent.
+type undoNode struct {
+	actionTuple
+	id       int
+	parent   *undoNode
+	children []*undoNode
+	redo     *undoNode // child we go to on redo (most recent branch)
+	ts       time.Time
+	typed    bool // single typed word character(s), might grow further
+}
+
+// undoTree keeps all the states of a view, including abandoned branches.
+type undoTree struct {
+	root   *undoNode
+	cur    *undoNode
+	nextId int
+	size   int // number of nodes, not counting root
+}
+
+func newUndoTree() *undoTree {
+	root := &undoNode{ts: time.Now()}
+	return &undoTree{root: root, cur: root, nextId: 1}
+}
+
+// undoGroup accumulates several undo steps into a single one.
+type undoGroup struct {
+	actionTuple
+	depth int
+}
+
 func Undo(viewId int64) {
 	action, err := func() ([]core.Action, error) {
 		lock.Lock()
 		defer lock.Unlock()
-		tuples, found := undos[viewId]
-		if !found || len(tuples) == 0 {
+		t, found := trees[viewId]
+		if !found || t.cur == t.root {
 			return nil, fmt.Errorf("Nothing to undo.")
 		}
-		tuple := tuples[len(tuples)-1]
-		undos[viewId] = undos[viewId][:len(tuples)-1]
-		redos[viewId] = append(redos[viewId], tuple)
-		return tuple.undo, nil
+		n := t.cur
+		n.parent.redo = n
+		t.cur = n.parent
+		return n.undo, nil
 	}()
 	if err != nil {
 		Ar.EdSetStatusErr(err.Error())
@@ -53,14 +95,12 @@ func Redo(viewId int64) {
 	action, err := func() ([]core.Action, error) {
 		lock.Lock()
 		defer lock.Unlock()
-		tuples, found := redos[viewId]
-		if !found || len(tuples) == 0 {
+		t, found := trees[viewId]
+		if !found || t.cur.redo == nil {
 			return nil, fmt.Errorf("Nothing to redo.")
 		}
-		tuple := tuples[len(tuples)-1]
-		redos[viewId] = redos[viewId][:len(tuples)-1]
-		undos[viewId] = append(undos[viewId], tuple)
-		return tuple.do, nil
+		t.cur = t.cur.redo
+		return t.cur.do, nil
 	}()
 	if err != nil {
 		Ar.EdSetStatusErr(err.Error())
@@ -71,33 +111,490 @@ func Redo(viewId int64) {
 	}
 }
 
+// UndoTo moves the view to the given state of its undo tree, going back up
+// and then down the tree as needed.
+func UndoTo(viewId int64, id int) error {
+	action, err := func() ([]core.Action, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		t, found := trees[viewId]
+		if !found {
+			return nil, fmt.Errorf("No undo history.")
+		}
+		target := t.root.find(id)
+		if target == nil {
+			return nil, fmt.Errorf("No such undo state : %d", id)
+		}
+		action := []core.Action{}
+		// undo up to the common ancestor
+		ancestors := map[*undoNode]bool{}
+		for n := target; n != nil; n = n.parent {
+			ancestors[n] = true
+		}
+		for !ancestors[t.cur] {
+			action = append(action, t.cur.undo...)
+			t.cur.parent.redo = t.cur
+			t.cur = t.cur.parent
+		}
+		// then redo down to the target
+		down := []*undoNode{}
+		for n := target; n != t.cur; n = n.parent {
+			down = append([]*undoNode{n}, down...)
+		}
+		for _, n := range down {
+			n.parent.redo = n
+			action = append(action, n.do...)
+		}
+		t.cur = target
+		return action, nil
+	}()
+	if err != nil {
+		return err
+	}
+	for _, a := range action {
+		a.Run()
+	}
+	return nil
+}
+
 func UndoAdd(viewId int64, do, undo []core.Action) {
 	lock.Lock()
 	defer lock.Unlock()
-	delete(redos, viewId)
-	if len(undos[viewId]) < maxUndos {
-		undos[viewId] = append(undos[viewId], actionTuple{do, undo})
-	} else {
-		copy(undos[viewId], undos[viewId][1:])
-		undos[viewId][len(undos[viewId])-1] = actionTuple{do, undo}
+	if g, found := groups[viewId]; found {
+		g.do = append(g.do, do...)
+		g.undo = append(append([]core.Action{}, undo...), g.undo...)
+		return
+	}
+	t := viewTree(viewId)
+	if t.coalesce(do, undo) {
+		return
+	}
+	n := t.add(actionTuple{do, undo})
+	n.typed = typedWord(do)
+}
+
+// UndoBegin starts grouping the undo steps of a view, they will be undone as
+// a single step once UndoEnd is called. Calls can be nested.
+func UndoBegin(viewId int64) {
+	lock.Lock()
+	defer lock.Unlock()
+	g, found := groups[viewId]
+	if !found {
+		g = &undoGroup{}
+		groups[viewId] = g
+	}
+	g.depth++
+}
+
+// UndoEnd ends grouping started by UndoBegin.
+func UndoEnd(viewId int64) {
+	lock.Lock()
+	defer lock.Unlock()
+	g, found := groups[viewId]
+	if !found {
+		return
+	}
+	g.depth--
+	if g.depth > 0 {
+		return
+	}
+	delete(groups, viewId)
+	if len(g.do) > 0 {
+		viewTree(viewId).add(g.actionTuple)
 	}
 }
 
 func UndoClear(viewId int64) {
 	lock.Lock()
 	defer lock.Unlock()
-	delete(undos, viewId)
-	delete(redos, viewId)
+	delete(trees, viewId)
+	delete(groups, viewId)
 }
 
-// Dump prints out the undo/redo stack of a view, for debugging
+// viewTree returns the undo tree of a view, creating it as needed.
+func viewTree(viewId int64) *undoTree {
+	t, found := trees[viewId]
+	if !found {
+		t = newUndoTree()
+		trees[viewId] = t
+	}
+	return t
+}
+
+// UndoTree lists the undo states of a view, one per line as :
+// "id parent_id unix_time current(*|-) description"
+func UndoTree(viewId int64) []string {
+	lock.Lock()
+	defer lock.Unlock()
+	lines := []string{}
+	t, found := trees[viewId]
+	if !found {
+		return lines
+	}
+	for _, n := range t.root.all() {
+		parent, cur := -1, "-"
+		if n.parent != nil {
+			parent = n.parent.id
+		}
+		if n == t.cur {
+			cur = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%d %d %d %s %s",
+			n.id, parent, n.ts.Unix(), cur, n.describe()))
+	}
+	return lines
+}
+
+func (t *undoTree) add(tuple actionTuple) *undoNode {
+	n := &undoNode{
+		actionTuple: tuple,
+		id:          t.nextId,
+		parent:      t.cur,
+		ts:          time.Now(),
+	}
+	t.nextId++
+	t.size++
+	t.cur.children = append(t.cur.children, n)
+	t.cur.redo = n
+	t.cur = n
+	t.prune()
+	return n
+}
+
+// prune drops the oldest states past maxUndos, keeping the current branch.
+func (t *undoTree) prune() {
+	for t.size > maxUndos && len(t.root.children) > 0 {
+		keep := t.cur
+		for keep != t.root && keep.parent != t.root {
+			keep = keep.parent
+		}
+		if keep == t.root {
+			// at the root, drop the oldest branch
+			old := t.root.children[0]
+			t.root.children = t.root.children[1:]
+			if t.root.redo == old {
+				t.root.redo = nil
+			}
+			t.size -= old.count()
+			continue
+		}
+		for _, c := range t.root.children {
+			if c != keep {
+				t.size -= c.count()
+			}
+		}
+		// keep becomes the new root
+		t.size--
+		keep.parent = nil
+		keep.actionTuple = actionTuple{}
+		keep.typed = false
+		t.root = keep
+	}
+}
+
+// coalesce merges a single typed character into the current state if it
+// continues the word typed there.
+func (t *undoTree) coalesce(do, undo []core.Action) bool {
+	n := t.cur
+	if n == t.root || !n.typed || len(n.children) > 0 ||
+		time.Since(n.ts) > coalesceDelay || !typedWord(do) {
+		return false
+	}
+	ins := do[0].(viewInsertAction)
+	prev := n.do[0].(viewInsertAction)
+	if ins.viewId != prev.viewId || ins.row != prev.row ||
+		ins.col != prev.col+utf8.RuneCountInString(prev.text) {
+		return false
+	}
+	text := prev.text + ins.text
+	prev.text = text
+	n.do = append([]core.Action{prev}, do[1:]...)
+	end := prev.col - 1 + utf8.RuneCountInString(text) - 1
+	n.undo = append([]core.Action{
+		NewViewDeleteAction(prev.viewId, prev.row-1, prev.col-1, prev.row-1, end, false)},
+		n.undo[1:]...)
+	n.ts = time.Now()
+	return true
+}
+
+// typedWord returns whether the action is the insertion of a single
+// word character (as typed).
+func typedWord(do []core.Action) bool {
+	if len(do) == 0 {
+		return false
+	}
+	ins, ok := do[0].(viewInsertAction)
+	if !ok || utf8.RuneCountInString(ins.text) != 1 {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(ins.text)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func (n *undoNode) find(id int) *undoNode {
+	if n.id == id {
+		return n
+	}
+	for _, c := range n.children {
+		if f := c.find(id); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// all returns the node and its descendants, parents first.
+func (n *undoNode) all() []*undoNode {
+	nodes := []*undoNode{n}
+	for i := 0; i < len(nodes); i++ {
+		nodes = append(nodes, nodes[i].children...)
+	}
+	return nodes
+}
+
+func (n *undoNode) count() int {
+	return len(n.all())
+}
+
+func (n *undoNode) describe() string {
+	if len(n.do) == 0 {
+		return "start"
+	}
+	r, ok := toUndoRecord(n.do[0])
+	if !ok {
+		return fmt.Sprintf("%T", n.do[0])
+	}
+	return fmt.Sprintf("%s %v %q", r.Kind, r.Args, r.Text)
+}
+
+// Dump prints out the undo tree of a view, for debugging
 func Dump(viewId int64) {
-	fmt.Printf("Undos:\n")
-	for _, u := range undos[viewId] {
-		fmt.Printf("\t %#v\n", u)
+	fmt.Printf("Undo tree:\n")
+	for _, l := range UndoTree(viewId) {
+		fmt.Printf("\t %s\n", l)
+	}
+}
+
+// ########  Persistence ......
+
+// undoRecord is the persisted form of an undo/redo action.
+type undoRecord struct {
+	Kind string
+	Args []int  `json:",omitempty"`
+	Text string `json:",omitempty"`
+}
+
+type undoNodeRecord struct {
+	Id       int
+	Parent   int
+	Ts       int64
+	Do, Undo []undoRecord
+}
+
+type undoTreeRecord struct {
+	Sum     string // sha1 of the file content the history leads to
+	ModTime time.Time
+	Size    int64
+	Cur     int
+	NextId  int
+	Nodes   []undoNodeRecord
+}
+
+// UndoFile returns the location of the persisted undo history of a file.
+func UndoFile(loc string) string {
+	abs, _ := filepath.Abs(loc)
+	return path.Join(core.Home, "buffers", fmt.Sprintf("%x.undo", sha1.Sum([]byte(abs))))
+}
+
+// UndoSave persists the undo tree of a view, to be called once the view
+// was saved to loc.
+func UndoSave(viewId int64, loc string) error {
+	info, err := os.Stat(loc)
+	if err != nil {
+		return err
+	}
+	sum, err := fileSum(loc)
+	if err != nil {
+		return err
+	}
+	data, err := func() ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		t, found := trees[viewId]
+		if !found {
+			return nil, nil
+		}
+		tr := undoTreeRecord{Sum: sum, ModTime: info.ModTime(), Size: info.Size(),
+			Cur: t.cur.id, NextId: t.nextId}
+		for _, n := range t.root.all() {
+			nr := undoNodeRecord{Id: n.id, Parent: -1, Ts: n.ts.Unix()}
+			if n.parent != nil {
+				nr.Parent = n.parent.id
+			}
+			var ok bool
+			if nr.Do, ok = toUndoRecords(n.do); !ok {
+				return nil, nil // not persistable
+			}
+			if nr.Undo, ok = toUndoRecords(n.undo); !ok {
+				return nil, nil
+			}
+			tr.Nodes = append(tr.Nodes, nr)
+		}
+		return json.Marshal(tr)
+	}()
+	if err != nil || data == nil {
+		return err
+	}
+	return ioutil.WriteFile(UndoFile(loc), data, 0640)
+}
+
+// UndoLoad restores the persisted undo tree of a file into a view, provided
+// the file was not changed since.
+func UndoLoad(viewId int64, loc string) error {
+	data, err := ioutil.ReadFile(UndoFile(loc))
+	if err != nil {
+		return nil // no history
+	}
+	tr := undoTreeRecord{}
+	if err = json.Unmarshal(data, &tr); err != nil {
+		return err
 	}
-	fmt.Printf("Redos:\n")
-	for _, r := range redos[viewId] {
-		fmt.Printf("\t %#v\n", r)
+	if len(tr.Nodes) == 0 {
+		return nil
+	}
+	if changed, err := undoFileChanged(loc, tr); err != nil || changed {
+		return err
+	}
+	t := &undoTree{nextId: tr.NextId}
+	nodes := map[int]*undoNode{}
+	for _, nr := range tr.Nodes {
+		do, err := fromUndoRecords(viewId, nr.Do)
+		if err != nil {
+			return undoDrop(loc, err)
+		}
+		undo, err := fromUndoRecords(viewId, nr.Undo)
+		if err != nil {
+			return undoDrop(loc, err)
+		}
+		n := &undoNode{
+			actionTuple: actionTuple{do: do, undo: undo},
+			id:          nr.Id,
+			ts:          time.Unix(nr.Ts, 0),
+		}
+		if p, found := nodes[nr.Parent]; found {
+			n.parent = p
+			p.children = append(p.children, n)
+			p.redo = n
+			t.size++
+		} else if t.root == nil {
+			t.root = n
+		}
+		nodes[n.id] = n
+	}
+	t.cur = nodes[tr.Cur]
+	if t.root == nil || t.cur == nil {
+		return undoDrop(loc, fmt.Errorf("Invalid undo file for %s", loc))
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	trees[viewId] = t
+	return nil
+}
+
+// undoDrop deletes the persisted undo history of a file that can't be
+// loaded (ie: truncated or hand edited), logging why.
+func undoDrop(loc string, err error) error {
+	log.Printf("Dropping the undo history of %s : %s", loc, err.Error())
+	os.Remove(UndoFile(loc))
+	return err
+}
+
+// undoFileChanged returns whether the file was changed since the history was
+// saved, only hashing it when the mtime or size differ.
+func undoFileChanged(loc string, tr undoTreeRecord) (bool, error) {
+	info, err := os.Stat(loc)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(tr.ModTime) && info.Size() == tr.Size {
+		return false, nil
+	}
+	if info.Size() != tr.Size {
+		return true, nil
+	}
+	sum, err := fileSum(loc)
+	return sum != tr.Sum, err
+}
+
+func fileSum(loc string) (string, error) {
+	f, err := os.Open(loc)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func toUndoRecords(actions []core.Action) ([]undoRecord, bool) {
+	records := []undoRecord{}
+	for _, a := range actions {
+		r, ok := toUndoRecord(a)
+		if !ok {
+			return nil, false
+		}
+		records = append(records, r)
+	}
+	return records, true
+}
+
+func toUndoRecord(a core.Action) (undoRecord, bool) {
+	switch a := a.(type) {
+	case viewInsertAction:
+		return undoRecord{Kind: "insert", Args: []int{a.row, a.col}, Text: a.text}, true
+	case viewDeleteAction:
+		return undoRecord{Kind: "delete", Args: []int{a.row1, a.col1, a.row2, a.col2}}, true
+	case viewSetCursorPos:
+		return undoRecord{Kind: "cursor", Args: []int{a.y, a.x}}, true
+	case viewClearSelections:
+		return undoRecord{Kind: "clear_selections"}, true
+	case viewAddSelection:
+		return undoRecord{Kind: "add_selection", Args: []int{a.l1, a.c1, a.l2, a.c2}}, true
+	}
+	return undoRecord{}, false
+}
+
+// undoRecordArgs is the number of arguments of the undo records, by kind.
+var undoRecordArgs = map[string]int{
+	"insert":           2,
+	"delete":           4,
+	"cursor":           2,
+	"clear_selections": 0,
+	"add_selection":    4,
+}
+
+func fromUndoRecords(viewId int64, records []undoRecord) ([]core.Action, error) {
+	actions := []core.Action{}
+	for _, r := range records {
+		a := r.Args
+		if n, found := undoRecordArgs[r.Kind]; !found || len(a) < n {
+			return nil, fmt.Errorf("Invalid undo record : %s %v", r.Kind, a)
+		}
+		switch r.Kind {
+		case "insert":
+			actions = append(actions, viewInsertAction{viewId: viewId, row: a[0], col: a[1], text: r.Text})
+		case "delete":
+			actions = append(actions, viewDeleteAction{viewId: viewId, row1: a[0], col1: a[1], row2: a[2], col2: a[3]})
+		case "cursor":
+			actions = append(actions, viewSetCursorPos{viewId: viewId, y: a[0], x: a[1]})
+		case "clear_selections":
+			actions = append(actions, viewClearSelections{viewId: viewId})
+		case "add_selection":
+			actions = append(actions, viewAddSelection{viewId: viewId, l1: a[0], c1: a[1], l2: a[2], c2: a[3]})
+		}
 	}
+	return actions, nil
 }
@@ -1,47 +1,83 @@
 // Package api provide the server side Goed API
-// via RPC over local socket.
+// via RPC over local socket, and JSON-RPC 2.0 over a sibling socket,
+// as well as optionally over TCP, with token authentication (see auth.go).
 // See client/ for the client implementation.
 package api
 
 import (
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/rpc"
-	"time"
+	"os"
 
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
 type Api struct {
+	TcpAddr string // address of the TCP listener, if any
 }
 
 func (a *Api) Start() {
-	r := new(GoedRpc)
-	rpc.Register(r)
-	rpc.HandleHTTP()
 	l, err := net.Listen("unix", core.Socket)
 	if err != nil {
 		log.Fatalf("Socket listen error %s : \n", err.Error())
 	}
+	os.Chmod(core.Socket, 0600)
+	if err := writeToken(); err != nil {
+		log.Fatalf("Failed to write the API token %s : \n", err.Error())
+	}
 
 	go func() {
-		err = http.Serve(l, nil)
+		err = http.Serve(l, http.HandlerFunc(serveLocal))
 		if err != nil {
 			panic(err)
 		}
 	}()
+
+	a.startJson()
+	if core.ApiPort > 0 {
+		a.startTcp()
+	}
+}
+
+// serveLocal serves the RPC API over the local socket, each connection
+// having its own GoedRpc (owning its subscriptions).
+func serveLocal(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "CONNECT" || req.URL.Path != rpc.DefaultRPCPath {
+		http.NotFound(w, req)
+		return
+	}
+	server := rpc.NewServer()
+	server.RegisterName("GoedRpc", &GoedRpc{scope: actions.ScopeExec})
+	server.ServeHTTP(w, req)
 }
 
 // Goed RPC functions holder
-type GoedRpc struct{}
+// There is one per client connection.
+type GoedRpc struct {
+	scope string // the client permission scope
+}
 
 type RpcStruct struct {
 	Data []string
 }
 
 func (r *GoedRpc) Action(args RpcStruct, res *RpcStruct) error {
+	if len(args.Data) == 0 {
+		return fmt.Errorf("No action given")
+	}
+	firstArg := ""
+	if len(args.Data) > 1 {
+		firstArg = args.Data[1]
+	}
+	if required := actions.CallScope(args.Data[0], firstArg); len(required) > 0 {
+		if err := r.allow(required); err != nil {
+			return err
+		}
+	}
 	results, err := actions.Exec(args.Data[0], args.Data[1:])
 	for _, r := range results {
 		res.Data = append(res.Data, r)
@@ -50,26 +86,36 @@ func (r *GoedRpc) Action(args RpcStruct, res *RpcStruct) error {
 }
 
 func (r *GoedRpc) Open(args []interface{}, _ *struct{}) error {
+	if err := r.allow(actions.ScopeEdit); err != nil {
+		return err
+	}
 	vid := actions.Ar.EdOpen(args[1].(string), -1, args[0].(string), true)
 	actions.Ar.EdActivateView(vid)
 	actions.Ar.EdRender()
 	return nil
 }
 
+// Edit opens a file and waits until its view is closed.
 func (r *GoedRpc) Edit(args []interface{}, _ *struct{}) error {
+	if err := r.allow(actions.ScopeEdit); err != nil {
+		return err
+	}
+	closed := core.Events.Subscribe(core.EvtViewClosed)
+	defer core.Events.Unsubscribe(closed.Id)
 	prevView := actions.Ar.EdCurView()
 	vid := actions.Ar.EdOpen(args[1].(string), -1, args[0].(string), true)
+	if vid < 0 {
+		return fmt.Errorf("Failed to open %s", args[1])
+	}
 	actions.Ar.EdActivateView(vid)
 	actions.Ar.EdRender()
-	// Wait til file closed
-	for {
-		idx, _ := actions.Ar.EdViewIndex(vid)
-		if idx == -1 { // view no longer present
-			// switch back to the original view
-			actions.Ar.EdActivateView(prevView)
-			actions.Ar.EdRender()
-			return nil
+	for e := range closed.C {
+		if e.ViewId == vid {
+			break
 		}
-		time.Sleep(250 * time.Millisecond)
 	}
+	// switch back to the original view
+	actions.Ar.EdActivateView(prevView)
+	actions.Ar.EdRender()
+	return nil
 }
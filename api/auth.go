@@ -0,0 +1,157 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/rpc"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// Optional TCP API (core.ApiPort), for editing inside containers or over SSH
+// port forwards. Each request must carry an access token :
+//   Authorization: Bearer <token>
+// The instance token, written to core.TokenLoc, has full access, tokens of a
+// narrower scope (actions.ScopeRead ...) can be created with GoedRpc.NewToken.
+// The TCP server serves :
+//   CONNECT /_goRPC_ : Go RPC (api/client)
+//   CONNECT /jsonrpc : JSON-RPC stream, same as core.JsonSocket
+//   POST /jsonrpc    : a JSON-RPC request (or batch) per HTTP request
+// The unix sockets are only accessible to the user and have full access.
+
+// JsonRpcPath is the path of the JSON-RPC endpoint of the TCP API.
+const JsonRpcPath = "/jsonrpc"
+
+// Access tokens, token -> scope
+var tokens = apiTokens{tokens: map[string]string{}}
+
+type apiTokens struct {
+	sync.Mutex
+	tokens map[string]string
+}
+
+// add creates a new token of the given scope.
+func (t *apiTokens) add(scope string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	t.Lock()
+	defer t.Unlock()
+	t.tokens[token] = scope
+	return token, nil
+}
+
+// scope returns the scope of a token, "" if it's not a valid token.
+func (t *apiTokens) scope(token string) string {
+	t.Lock()
+	defer t.Unlock()
+	scope := ""
+	for tk, s := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(tk), []byte(token)) == 1 {
+			scope = s
+		}
+	}
+	return scope
+}
+
+// writeToken creates the instance token, with full access, into core.TokenLoc.
+// The file is only readable by the user.
+func writeToken() error {
+	token, err := tokens.add(actions.ScopeExec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(core.TokenLoc, []byte(token), 0600)
+}
+
+// NewToken creates an access token of the given scope (read, edit or exec).
+func (r *GoedRpc) NewToken(scope string, token *string) error {
+	if err := r.allow(actions.ScopeExec); err != nil {
+		return err
+	}
+	if !actions.ValidScope(scope) {
+		return fmt.Errorf("Invalid scope %s", scope)
+	}
+	t, err := tokens.add(scope)
+	*token = t
+	return err
+}
+
+// allow returns an error if the client scope does not include required.
+func (r *GoedRpc) allow(required string) error {
+	if !actions.ScopeAllows(r.scope, required) {
+		return fmt.Errorf("Permission denied, requires the %s scope", required)
+	}
+	return nil
+}
+
+func (a *Api) startTcp() {
+	addr := net.JoinHostPort(core.ApiHost, strconv.Itoa(core.ApiPort))
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("API listen error %s : \n", err.Error())
+	}
+	a.TcpAddr = l.Addr().String()
+	go func() {
+		err = http.Serve(l, http.HandlerFunc(serveTcp))
+		if err != nil {
+			log.Printf("API server error : %s", err.Error())
+		}
+	}()
+}
+
+func serveTcp(w http.ResponseWriter, req *http.Request) {
+	auth := req.Header.Get("Authorization")
+	scope := ""
+	if strings.HasPrefix(auth, "Bearer ") {
+		scope = tokens.scope(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if len(scope) == 0 {
+		http.Error(w, "Invalid or missing access token", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case req.Method == "CONNECT" && req.URL.Path == rpc.DefaultRPCPath:
+		server := rpc.NewServer()
+		server.RegisterName("GoedRpc", &GoedRpc{scope: scope})
+		server.ServeHTTP(w, req)
+	case req.Method == "CONNECT" && req.URL.Path == JsonRpcPath:
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			log.Printf("JSON-RPC hijack error : %s", err.Error())
+			return
+		}
+		io.WriteString(conn, "HTTP/1.0 200 Connected to Goed JSON-RPC\n\n")
+		serveJson(conn, scope)
+	case req.Method == "POST" && req.URL.Path == JsonRpcPath:
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c := &jsonConn{scope: scope}
+		resp := c.handle(body)
+		w.Header().Set("Content-Type", "application/json")
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	default:
+		http.NotFound(w, req)
+	}
+}
@@ -1,12 +1,19 @@
 // Package client provides client functions to the Goed API server
 // via RPC over a socket.
+// If GOED_API_ADDR (host:port) is set, the API is called over TCP instead,
+// with the token from GOED_TOKEN, or else from the instance token file.
 package client
 
 import (
+	"bufio"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"net/rpc"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/tcolar/goed/api"
 	"github.com/tcolar/goed/core"
@@ -35,15 +42,92 @@ func Edit(instance int64, cwd, loc string) error {
 	return err
 }
 
+// Subscribe sends the editor events of the given types (all if none) to
+// events, until stop is closed.
+func Subscribe(instance int64, types []string, events chan<- core.Event, stop <-chan struct{}) error {
+	c := getClient(instance)
+	defer c.Close()
+	var id int64
+	if err := c.Call("GoedRpc.Subscribe", types, &id); err != nil {
+		return err
+	}
+	defer c.Call("GoedRpc.Unsubscribe", id, &struct{}{})
+	for {
+		evts := []core.Event{}
+		if err := c.Call("GoedRpc.Events", id, &evts); err != nil {
+			return err
+		}
+		for _, e := range evts {
+			select {
+			case events <- e:
+			case <-stop:
+				return nil
+			}
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
+
+// NewToken creates an API access token of the given scope (read, edit, exec).
+func NewToken(instance int64, scope string) (string, error) {
+	c := getClient(instance)
+	defer c.Close()
+	token := ""
+	err := c.Call("GoedRpc.NewToken", scope, &token)
+	return token, err
+}
+
 func getClient(id int64) *rpc.Client {
-	sock := core.GoedSocket(id)
-	c, err := rpc.DialHTTP("unix", sock)
+	var c *rpc.Client
+	var err error
+	if addr := os.Getenv("GOED_API_ADDR"); len(addr) > 0 {
+		c, err = dialTcp(addr, Token(id))
+	} else {
+		c, err = rpc.DialHTTP("unix", core.GoedSocket(id))
+	}
 	if err != nil {
 		panic(err)
 	}
 	return c
 }
 
+// dialTcp connects to the TCP API, same as rpc.DialHTTP but with the token.
+func dialTcp(addr, token string) (*rpc.Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	req, _ := http.NewRequest("CONNECT", rpc.DefaultRPCPath, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("API connection refused : %s", resp.Status)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return rpc.NewClient(conn), nil
+}
+
+// Token returns the API access token : GOED_TOKEN if set, otherwise the
+// token of the instance (from its token file).
+func Token(id int64) string {
+	if token := os.Getenv("GOED_TOKEN"); len(token) > 0 {
+		return token
+	}
+	b, _ := ioutil.ReadFile(core.GoedToken(id))
+	return strings.TrimSpace(string(b))
+}
+
 func GoedSocket(id int64) string {
 	p := path.Join(core.GoedHome(), "instances", fmt.Sprintf("%d.sock", id))
 	if _, err := os.Stat(p); os.IsNotExist(err) {
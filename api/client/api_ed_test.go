@@ -1,11 +1,19 @@
 package client
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/api"
 	"github.com/tcolar/goed/assert"
 	"github.com/tcolar/goed/core"
 	. "gopkg.in/check.v1"
@@ -55,15 +63,15 @@ func (s *ApiSuite) TestEdDelView(t *C) {
 	v1 := actions.Ar.EdCurView()
 	assert.NotEq(t, v0, v1)
 	actions.Ar.ViewSetDirty(v1, true)
-	// view is dirty so first try should do nothing
+	// view is dirty so the user is prompted first
 	res, err := Action(s.id, []string{"ed_del_view", fmt.Sprintf("%d", v1), "true"})
 	assert.Nil(t, err)
 	assert.Eq(t, len(res), 0)
 	assert.Eq(t, actions.Ar.EdCurView(), v1)
-	// but asking again, should force close v1 and send us back to v0
-	res, err = Action(s.id, []string{"ed_del_view", fmt.Sprintf("%d", v1), "true"})
+	// discarding the changes closes v1 and sends us back to v0
+	res, err = Action(s.id, []string{"ed_overlay_event", "", "d", "false", "-1", "-1"})
 	assert.Nil(t, err)
-	assert.Eq(t, len(res), 0)
+	assert.DeepEq(t, res, []string{"true"})
 	assert.Eq(t, actions.Ar.EdCurView(), v0)
 }
 
@@ -102,6 +110,10 @@ func (s *ApiSuite) TestEdQuitCheck(t *C) {
 	assert.Nil(t, err)
 	assert.Eq(t, len(res), 1)
 	assert.Eq(t, "false", res[0])
+	// cancel the save / discard prompt
+	res, err = Action(s.id, []string{"ed_overlay_event", "toggle_cmd_bar", "", "false", "-1", "-1"})
+	assert.Nil(t, err)
+	assert.DeepEq(t, res, []string{"true"})
 
 	actions.Ar.ViewSetDirty(vid, false)
 	res, err = Action(s.id, []string{"ed_quit_check"})
@@ -222,3 +234,326 @@ func (s *ApiSuite) TestEdViews(t *C) {
 	}
 	assert.DeepEq(t, vs, res)
 }
+
+type jsonResp struct {
+	Result json.RawMessage
+	Error  *struct {
+		Code    int
+		Message string
+	}
+	Id json.RawMessage
+}
+
+// jsonCall sends raw JSON-RPC requests and reads n response lines.
+func jsonCall(c *C, req string, n int) []string {
+	conn, err := net.Dial("unix", core.JsonSocket)
+	assert.Nil(c, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(req + "\n"))
+	assert.Nil(c, err)
+	conn.(*net.UnixConn).CloseWrite() // so a truncated request fails
+	r := bufio.NewReader(conn)
+	lines := []string{}
+	for i := 0; i != n; i++ {
+		line, err := r.ReadString('\n')
+		assert.Nil(c, err)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func jsonResult(c *C, req string) jsonResp {
+	resp := jsonResp{}
+	assert.Nil(c, json.Unmarshal([]byte(jsonCall(c, req, 1)[0]), &resp))
+	return resp
+}
+
+func (as *ApiSuite) TestJsonRpc(c *C) {
+	resp := jsonResult(c, `{"jsonrpc":"2.0","id":1,"method":"ed_views"}`)
+	assert.Nil(c, resp.Error)
+	assert.Eq(c, string(resp.Id), "1")
+	vids := []int64{}
+	assert.Nil(c, json.Unmarshal(resp.Result, &vids))
+	assert.DeepEq(c, vids, []int64{as.dirView})
+
+	// typed params and multiple results
+	vid := as.openFile1(c)
+	resp = jsonResult(c, `{"jsonrpc":"2.0","id":"a","method":"view_set_cursor_pos","params":[`+
+		vidStr(vid)+`,3,2]}`)
+	assert.Nil(c, resp.Error)
+	assert.Eq(c, string(resp.Result), "null")
+	resp = jsonResult(c, `{"jsonrpc":"2.0","id":"b","method":"view_cursor_pos","params":[`+
+		vidStr(vid)+`]}`)
+	assert.Nil(c, resp.Error)
+	assert.Eq(c, string(resp.Id), `"b"`)
+	assert.Eq(c, string(resp.Result), "[3,2]")
+
+	// errors
+	resp = jsonResult(c, `{"jsonrpc":"2.0","id":2,"method":"foobar"}`)
+	assert.Eq(c, resp.Error.Code, -32601)
+	resp = jsonResult(c, `{"jsonrpc":"2.0","id":3,"method":"view_cursor_pos","params":["x"]}`)
+	assert.Eq(c, resp.Error.Code, -32602)
+	resp = jsonResult(c, `{"jsonrpc":"2.0","id":4,"method":"view_cursor_pos"}`)
+	assert.Eq(c, resp.Error.Code, -32602)
+	resp = jsonResult(c, `{"id":5,"method":"ed_views"}`)
+	assert.Eq(c, resp.Error.Code, -32600)
+	resp = jsonResult(c, `{"jsonrpc":"2.0",`)
+	assert.Eq(c, resp.Error.Code, -32700)
+
+	// batch, the notification gets no response
+	lines := jsonCall(c, `[{"jsonrpc":"2.0","id":6,"method":"ed_views"},`+
+		`{"jsonrpc":"2.0","method":"ed_render"},`+
+		`{"jsonrpc":"2.0","id":7,"method":"foobar"}]`, 1)
+	resps := []jsonResp{}
+	assert.Nil(c, json.Unmarshal([]byte(lines[0]), &resps))
+	assert.Eq(c, len(resps), 2)
+	assert.Eq(c, string(resps[0].Id), "6")
+	assert.Nil(c, resps[0].Error)
+	assert.Eq(c, resps[1].Error.Code, -32601)
+}
+
+func (as *ApiSuite) TestJsonRpcDiscover(c *C) {
+	resp := jsonResult(c, `{"jsonrpc":"2.0","id":1,"method":"rpc.discover"}`)
+	assert.Nil(c, resp.Error)
+	d := struct {
+		Methods []struct {
+			Name    string
+			Summary string
+			Params  []struct {
+				Name   string
+				Schema struct{ Type string }
+			}
+			Result struct {
+				Schema struct{ Type string }
+			}
+		}
+	}{}
+	assert.Nil(c, json.Unmarshal(resp.Result, &d))
+	found := false
+	for _, m := range d.Methods {
+		if m.Name != "view_set_cursor_pos" {
+			continue
+		}
+		found = true
+		assert.Eq(c, m.Summary, "view_set_cursor_pos(int64, int, int) ")
+		assert.Eq(c, len(m.Params), 3)
+		assert.Eq(c, m.Params[0].Name, "viewId")
+		assert.Eq(c, m.Params[0].Schema.Type, "integer")
+		assert.Eq(c, m.Result.Schema.Type, "null")
+	}
+	assert.True(c, found)
+}
+
+// nextEvent returns the next event of the given type.
+func nextEvent(c *C, events chan core.Event, t core.EventType) core.Event {
+	for {
+		select {
+		case e := <-events:
+			if e.Type == t {
+				return e
+			}
+		case <-time.After(5 * time.Second):
+			c.Fatalf("timeout waiting for %s", t)
+		}
+	}
+}
+
+func (as *ApiSuite) TestSubscribe(c *C) {
+	api.EventsWait = 200 * time.Millisecond
+	defer func() { api.EventsWait = 10 * time.Second }()
+	events := make(chan core.Event, 100)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Subscribe(as.id, []string{"view_opened", "buffer_changed", "cursor_moved", "view_closed"},
+			events, stop)
+	}()
+	time.Sleep(100 * time.Millisecond) // subscribed
+	vid := as.openFile1(c)
+	e := nextEvent(c, events, core.EvtViewOpened)
+	assert.Eq(c, e.ViewId, vid)
+	loc, _ := filepath.Abs(refFile)
+	assert.Eq(c, e.Loc, loc)
+	actions.Ar.ViewInsert(vid, 2, 1, "abc\ndef", false)
+	e = nextEvent(c, events, core.EvtBufferChanged)
+	assert.Eq(c, e.FromLine, 2)
+	assert.Eq(c, e.ToLine, 3)
+	e = nextEvent(c, events, core.EvtCursorMoved)
+	assert.Eq(c, e.Line, 3)
+	assert.Eq(c, e.Col, 4)
+	actions.Ar.EdDelView(vid, false)
+	e = nextEvent(c, events, core.EvtViewClosed)
+	assert.Eq(c, e.ViewId, vid)
+	close(stop)
+	select {
+	case err := <-done:
+		assert.Nil(c, err)
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for the subscription to end")
+	}
+}
+
+func (as *ApiSuite) TestJsonRpcSubscribe(c *C) {
+	conn, err := net.Dial("unix", core.JsonSocket)
+	assert.Nil(c, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	read := func(v interface{}) {
+		line, err := r.ReadString('\n')
+		assert.Nil(c, err)
+		assert.Nil(c, json.Unmarshal([]byte(line), v))
+	}
+	conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"rpc.subscribe","params":["view_activated"]}` + "\n"))
+	resp := struct {
+		Result int64
+		Error  interface{}
+	}{}
+	read(&resp)
+	assert.Nil(c, resp.Error)
+	actions.Ar.EdActivateView(as.dirView)
+	evt := struct {
+		Method string
+		Params struct {
+			Subscription int64
+			Event        core.Event
+		}
+	}{}
+	read(&evt)
+	assert.Eq(c, evt.Method, "rpc.event")
+	assert.Eq(c, evt.Params.Subscription, resp.Result)
+	assert.Eq(c, evt.Params.Event.Type, core.EvtViewActivated)
+	assert.Eq(c, evt.Params.Event.ViewId, as.dirView)
+	conn.Write([]byte(`{"jsonrpc":"2.0","id":2,"method":"rpc.unsubscribe","params":[` +
+		fmt.Sprintf("%d", resp.Result) + `]}` + "\n"))
+	unsub := struct{ Result bool }{}
+	read(&unsub)
+	assert.True(c, unsub.Result)
+}
+
+func (as *ApiSuite) TestTcpApi(c *C) {
+	os.Setenv("GOED_API_ADDR", as.tcpAddr)
+	defer os.Unsetenv("GOED_API_ADDR")
+	// instance token, found automatically
+	res, err := Action(as.id, []string{"ed_views"})
+	assert.Nil(c, err)
+	assert.DeepEq(c, res, []string{vidStr(as.dirView)})
+
+	_, err = dialTcp(as.tcpAddr, "")
+	assert.NotNil(c, err)
+	_, err = dialTcp(as.tcpAddr, "foo")
+	assert.NotNil(c, err)
+
+	// read only token
+	token, err := NewToken(as.id, "read")
+	assert.Nil(c, err)
+	_, err = NewToken(as.id, "foo")
+	assert.NotNil(c, err)
+	client, err := dialTcp(as.tcpAddr, token)
+	assert.Nil(c, err)
+	defer client.Close()
+	results := api.RpcStruct{}
+	err = client.Call("GoedRpc.Action", api.RpcStruct{Data: []string{"ed_views"}}, &results)
+	assert.Nil(c, err)
+	assert.DeepEq(c, results.Data, []string{vidStr(as.dirView)})
+	err = client.Call("GoedRpc.Action", api.RpcStruct{Data: []string{"ed_activate_view",
+		vidStr(as.dirView)}}, &results)
+	assert.NotNil(c, err)
+	err = client.Call("GoedRpc.Open", []interface{}{"test_data", "empty.txt"}, &struct{}{})
+	assert.NotNil(c, err)
+	err = client.Call("GoedRpc.NewToken", "exec", &token)
+	assert.NotNil(c, err)
+	assert.Eq(c, len(actions.Ar.EdViews()), 1)
+}
+
+func (as *ApiSuite) TestTcpJsonRpc(c *C) {
+	token, err := NewToken(as.id, "read")
+	assert.Nil(c, err)
+	post := func(token, body string) (int, jsonResp) {
+		req, _ := http.NewRequest("POST", "http://"+as.tcpAddr+api.JsonRpcPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(c, err)
+		defer resp.Body.Close()
+		jr := jsonResp{}
+		json.NewDecoder(resp.Body).Decode(&jr)
+		return resp.StatusCode, jr
+	}
+	status, _ := post("foo", `{"jsonrpc":"2.0","id":1,"method":"ed_views"}`)
+	assert.Eq(c, status, http.StatusUnauthorized)
+	status, resp := post(token, `{"jsonrpc":"2.0","id":1,"method":"ed_views"}`)
+	assert.Eq(c, status, http.StatusOK)
+	assert.Nil(c, resp.Error)
+	assert.Eq(c, string(resp.Result), "["+vidStr(as.dirView)+"]")
+	_, resp = post(token, `{"jsonrpc":"2.0","id":2,"method":"ed_activate_view","params":[`+
+		vidStr(as.dirView)+`]}`)
+	assert.Eq(c, resp.Error.Code, api.JsonDenied)
+	_, resp = post(token, `{"jsonrpc":"2.0","id":3,"method":"rpc.subscribe"}`)
+	assert.Eq(c, resp.Error.Code, -32600)
+}
+
+func (as *ApiSuite) TestEditScopeTerm(c *C) {
+	token, err := NewToken(as.id, "edit")
+	assert.Nil(c, err)
+	client, err := dialTcp(as.tcpAddr, token)
+	assert.Nil(c, err)
+	defer client.Close()
+	tv := actions.Ar.EdOpenTerm([]string{"cat"})
+	defer actions.Ar.EdDelView(tv, false)
+	fv := as.openFile1(c)
+	results := api.RpcStruct{}
+	// editing a terminal view would run commands
+	for _, action := range []string{"view_insert_cur", "view_paste", "view_insert_new_line"} {
+		args := []string{action, vidStr(tv)}
+		if action == "view_insert_cur" {
+			args = append(args, "echo oops\n")
+		}
+		err = client.Call("GoedRpc.Action", api.RpcStruct{Data: args}, &results)
+		assert.NotNil(c, err)
+	}
+	err = client.Call("GoedRpc.Action", api.RpcStruct{Data: []string{"view_insert",
+		vidStr(tv), "1", "1", "echo oops\n"}}, &results)
+	assert.NotNil(c, err)
+	err = client.Call("GoedRpc.Action", api.RpcStruct{Data: []string{"view_insert_cur",
+		vidStr(fv), "ok"}}, &results)
+	assert.Nil(c, err)
+
+	post := func(body string) jsonResp {
+		req, _ := http.NewRequest("POST", "http://"+as.tcpAddr+api.JsonRpcPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(c, err)
+		defer resp.Body.Close()
+		jr := jsonResp{}
+		json.NewDecoder(resp.Body).Decode(&jr)
+		return jr
+	}
+	resp := post(`{"jsonrpc":"2.0","id":1,"method":"view_insert_cur","params":[` +
+		vidStr(tv) + `,"echo oops\n"]}`)
+	assert.NotNil(c, resp.Error)
+	assert.Eq(c, resp.Error.Code, api.JsonDenied)
+	resp = post(`{"jsonrpc":"2.0","id":2,"method":"view_insert_cur","params":[` +
+		vidStr(fv) + `,"ok"]}`)
+	assert.Nil(c, resp.Error)
+}
+
+func (as *ApiSuite) TestSubscriptionOwner(c *C) {
+	api.EventsWait = 100 * time.Millisecond
+	defer func() { api.EventsWait = 10 * time.Second }()
+	token, err := NewToken(as.id, "read")
+	assert.Nil(c, err)
+	owner, err := dialTcp(as.tcpAddr, token)
+	assert.Nil(c, err)
+	defer owner.Close()
+	other, err := dialTcp(as.tcpAddr, token)
+	assert.Nil(c, err)
+	defer other.Close()
+	var id int64
+	assert.Nil(c, owner.Call("GoedRpc.Subscribe", []string{}, &id))
+	evts := []core.Event{}
+	// another connection can't poll or cancel it
+	assert.NotNil(c, other.Call("GoedRpc.Events", id, &evts))
+	assert.NotNil(c, other.Call("GoedRpc.Unsubscribe", id, &struct{}{}))
+	assert.Nil(c, owner.Call("GoedRpc.Events", id, &evts))
+	assert.Nil(c, owner.Call("GoedRpc.Unsubscribe", id, &struct{}{}))
+	assert.NotNil(c, owner.Call("GoedRpc.Events", id, &evts))
+}
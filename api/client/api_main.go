@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -18,6 +19,8 @@ func HandleArgs(args []string) {
 		fmt.Println("instances : get all goed instances Ids")
 		fmt.Println("instance : get most recent goed instance Id")
 		fmt.Println("open <instid> <dir> <file>: Open a file.")
+		fmt.Println("subscribe <instid> [event types]: Print the editor events (JSON), all if no types given.")
+		fmt.Println("token <instid> <scope>: Create an API access token of the given scope (read, edit or exec).")
 		fmt.Println("version : get goed_api version")
 		fmt.Println()
 		fmt.Println("Goed Api methods: https://godoc.org/github.com/tcolar/goed/api")
@@ -35,6 +38,10 @@ func HandleArgs(args []string) {
 		handleEdit(args[1:])
 	case "open":
 		handleOpen(args[1:])
+	case "subscribe":
+		handleSubscribe(args[1:])
+	case "token":
+		handleToken(args[1:])
 	default:
 		// Everything else is passed to a goed instance
 		handleAction(args)
@@ -107,3 +114,45 @@ func handleEdit(args []string) {
 		os.Exit(1)
 	}
 }
+
+func handleSubscribe(args []string) {
+	if len(args) < 1 {
+		fmt.Printf("Action subscribe needs instance argument\n")
+		os.Exit(1)
+	}
+	instance, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Printf("InstanceId must be a number: %s\n", err.Error())
+		os.Exit(1)
+	}
+	events := make(chan core.Event)
+	go func() {
+		for e := range events {
+			data, _ := json.Marshal(e)
+			fmt.Println(string(data))
+		}
+	}()
+	err = Subscribe(instance, args[1:], events, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+func handleToken(args []string) {
+	if len(args) < 2 {
+		fmt.Printf("Action token needs instance, scope arguments\n")
+		os.Exit(1)
+	}
+	instance, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Printf("InstanceId must be a number: %s\n", err.Error())
+		os.Exit(1)
+	}
+	token, err := NewToken(instance, args[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Println(token)
+}
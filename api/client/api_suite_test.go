@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"path/filepath"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ func Test(t *testing.T) { TestingT(t) }
 
 type ApiSuite struct {
 	id      int64 // instance
+	tcpAddr string
 	ftext   []string
 	dirView int64
 }
@@ -48,8 +50,15 @@ func (s *ApiSuite) SetUpSuite(c *C) {
 	core.Ed = ui.NewMockEditor()
 	core.Bus = actions.NewActionBus()
 	actions.RegisterActions()
+	// TCP API on a free port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(c, err)
+	core.ApiHost = "127.0.0.1"
+	core.ApiPort = l.Addr().(*net.TCPAddr).Port
+	l.Close()
 	apiServer := api.Api{}
 	apiServer.Start()
+	s.tcpAddr = apiServer.TcpAddr
 	core.Ed.Start([]string{})
 	s.dirView = core.Ed.Views()[0]
 }
@@ -126,8 +126,16 @@ func (as *ApiSuite) TestViewClearSelection(t *C) {
 func (as *ApiSuite) TestViewCmdStop(t *C) {
 	marker := "4224"
 	vid := actions.Ar.EdOpenTerm([]string{"sleep", marker})
-	// "sleep" command should be running a while
-	out, err := exec.Command("ps", "-ax").CombinedOutput()
+	// "sleep" command should be running a while (once started)
+	var out []byte
+	var err error
+	for i := 0; i != 20; i++ {
+		out, err = exec.Command("ps", "-ax").CombinedOutput()
+		if strings.Contains(string(out), "sleep "+marker) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
 	assert.Nil(t, err)
 	assert.True(t, strings.Contains(string(out), "sleep "+marker))
 	// This should stop it
@@ -343,6 +351,24 @@ func (as *ApiSuite) TestViewDirty(t *C) {
 	assert.Eq(t, res[0], "false")
 }
 
+func (as *ApiSuite) TestViewFindReplace(t *C) {
+	vid := as.openFile1(t)
+	res, err := Action(as.id, []string{"view_find", vidStr(vid), "a{3}", "true", "false"})
+	assert.Nil(t, err)
+	assert.Eq(t, len(res), 0)
+	assert.Eq(t, actions.Ar.ViewSelections(vid)[0].String(), "11 1 11 3")
+	res, err = Action(as.id, []string{"view_find_next", vidStr(vid), "false"})
+	assert.Nil(t, err)
+	assert.DeepEq(t, res, []string{"true"})
+	assert.Eq(t, actions.Ar.ViewSelections(vid)[0].String(), "11 5 11 7")
+	res, err = Action(as.id, []string{"view_replace", vidStr(vid), "b", "true"})
+	assert.Nil(t, err)
+	assert.DeepEq(t, res, []string{"5"})
+	assert.Eq(t, actions.Ar.ViewText(vid, 11, 1, 11, -1)[0], "b b.go /tmp/b.go b.go:23 /tmp/b.go:23:7")
+	_, err = Action(as.id, []string{"view_find", vidStr(vid), "zzz", "false", "false"})
+	assert.NotNil(t, err)
+}
+
 func (as *ApiSuite) TestViewInsert(t *C) {
 	vid := as.openFile1(t)
 	res, err := Action(as.id, []string{"view_insert", vidStr(vid), "1", "1", "XYZ", "false"})
@@ -634,7 +660,7 @@ func (as *ApiSuite) TestViewSave(t *C) {
 	actions.Ar.EdActionBusFlush()
 	str, _ = ioutil.ReadFile(f.Name())
 	assert.Eq(t, string(str), "FOOBAR")
-	actions.Ar.ViewReload(vid)
+	actions.Ar.ViewReload(vid, false)
 	assert.Eq(t, actions.Ar.ViewText(vid, 1, 1, 1, -1)[0], "FOOBAR")
 }
 
@@ -836,6 +862,26 @@ func (as *ApiSuite) TestViewUndoRedo(t *C) {
 	assert.Eq(t, actions.Ar.ViewText(vid, 3, 1, 3, -1)[0], "abcdefghijklmnopqrstuvwDEFGHIJKLMNOPQRSTUVWXYZ")
 }
 
+func (as *ApiSuite) TestViewUndoTree(t *C) {
+	vid := as.openFile1(t)
+	actions.Ar.ViewInsert(vid, 1, 1, "A", true)
+	actions.Ar.ViewInsert(vid, 1, 1, "B ", true)
+	actions.Ar.ViewUndo(vid)
+	actions.Ar.ViewInsert(vid, 1, 1, "C ", true)
+	assert.Eq(t, actions.Ar.ViewText(vid, 1, 1, 1, -1)[0], "C A1234567890")
+	res, err := Action(as.id, []string{"view_undo_tree", vidStr(vid)})
+	assert.Nil(t, err)
+	assert.Eq(t, len(res), 4)
+	assert.True(t, strings.HasPrefix(res[3], "3 1 "))
+	assert.True(t, strings.Contains(res[3], " * insert"))
+	res, err = Action(as.id, []string{"view_undo_to", vidStr(vid), "2"})
+	assert.Nil(t, err)
+	assert.Eq(t, len(res), 0)
+	assert.Eq(t, actions.Ar.ViewText(vid, 1, 1, 1, -1)[0], "B A1234567890")
+	_, err = Action(as.id, []string{"view_undo_to", vidStr(vid), "99"})
+	assert.NotNil(t, err)
+}
+
 func (as *ApiSuite) TestWorkdir(t *C) {
 	vid := as.openFile1(t)
 	loc := actions.Ar.ViewWorkDir(vid)
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// EventsWait is how long GoedRpc.Events waits for events before returning
+// none.
+var EventsWait = 10 * time.Second
+
+// SubscriptionTimeout is how long a subscription is kept without being polled.
+var SubscriptionTimeout = time.Minute
+
+// RPC subscriptions, polled with GoedRpc.Events
+var subs = rpcSubs{subs: map[int64]*rpcSub{}}
+
+type rpcSubs struct {
+	sync.Mutex
+	subs map[int64]*rpcSub
+}
+
+type rpcSub struct {
+	sub      *core.Subscription
+	owner    *GoedRpc // the connection that subscribed
+	lastPoll time.Time
+}
+
+// Subscribe subscribes to editor events of the given types (all if none),
+// the subscription id is returned in id. The events are then read with Events,
+// on the same connection.
+func (r *GoedRpc) Subscribe(types []string, id *int64) error {
+	if err := r.allow(actions.ScopeRead); err != nil {
+		return err
+	}
+	evts := []core.EventType{}
+	for _, t := range types {
+		evts = append(evts, core.EventType(t))
+	}
+	subs.Lock()
+	defer subs.Unlock()
+	subs.expire()
+	s := core.Events.Subscribe(evts...)
+	subs.subs[s.Id] = &rpcSub{sub: s, owner: r, lastPoll: time.Now()}
+	*id = s.Id
+	return nil
+}
+
+// Events returns the pending events of a subscription, it waits for up to
+// EventsWait for some to be available.
+func (r *GoedRpc) Events(id int64, events *[]core.Event) error {
+	if err := r.allow(actions.ScopeRead); err != nil {
+		return err
+	}
+	subs.Lock()
+	s, found := subs.subs[id]
+	found = found && s.owner == r // other connections subscriptions are hidden
+	if found {
+		s.lastPoll = time.Now()
+	}
+	subs.Unlock()
+	if !found {
+		return fmt.Errorf("No such subscription %d", id)
+	}
+	select {
+	case e, ok := <-s.sub.C:
+		if !ok {
+			return fmt.Errorf("No such subscription %d", id)
+		}
+		*events = append(*events, e)
+	case <-time.After(EventsWait):
+		return nil
+	}
+	for {
+		select {
+		case e, ok := <-s.sub.C:
+			if !ok {
+				return nil
+			}
+			*events = append(*events, e)
+		default:
+			return nil
+		}
+	}
+}
+
+// Unsubscribe ends a subscription.
+func (r *GoedRpc) Unsubscribe(id int64, _ *struct{}) error {
+	if err := r.allow(actions.ScopeRead); err != nil {
+		return err
+	}
+	subs.Lock()
+	defer subs.Unlock()
+	if s, found := subs.subs[id]; !found || s.owner != r {
+		return fmt.Errorf("No such subscription %d", id)
+	}
+	delete(subs.subs, id)
+	core.Events.Unsubscribe(id)
+	return nil
+}
+
+// expire removes the subscriptions that are no longer polled.
+func (s *rpcSubs) expire() {
+	for id, sub := range s.subs {
+		if time.Now().Sub(sub.lastPoll) > SubscriptionTimeout {
+			delete(s.subs, id)
+			core.Events.Unsubscribe(id)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// JSON-RPC 2.0 API, served on core.JsonSocket.
+// Each request (or batch) is a JSON value, responses are written one per line,
+// which makes it usable from any language, or a shell :
+//   echo '{"jsonrpc":"2.0","id":1,"method":"ed_views"}' | socat - UNIX-CONNECT:~/.goed/instances/<id>.jsonrpc
+// Every action is a method, taking its params by position.
+// "rpc.discover" returns the schema of all the methods.
+// "rpc.subscribe" subscribes to editor events (core.EventType), they are then
+// streamed as "rpc.event" notifications on the connection.
+
+// JSON-RPC error codes
+const (
+	JsonParseError     = -32700
+	JsonInvalidRequest = -32600
+	JsonNoSuchMethod   = -32601
+	JsonInvalidParams  = -32602
+	JsonInternalError  = -32603
+	JsonActionError    = -32000 // the action returned an error
+	JsonDenied         = -32001 // the client scope does not allow the method
+)
+
+const jsonVersion = "2.0"
+
+type jsonRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Id      json.RawMessage `json:"id,omitempty"` // not set for notifications
+}
+
+type jsonResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JsonError      `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// JsonError is a JSON-RPC error.
+type JsonError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *JsonError) Error() string {
+	return fmt.Sprintf("%d : %s", e.Code, e.Message)
+}
+
+func (a *Api) startJson() {
+	l, err := net.Listen("unix", core.JsonSocket)
+	if err != nil {
+		log.Fatalf("Socket listen error %s : \n", err.Error())
+	}
+	os.Chmod(core.JsonSocket, 0600)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Printf("JSON-RPC accept error : %s", err.Error())
+				return
+			}
+			go serveJson(conn, actions.ScopeExec)
+		}
+	}()
+}
+
+// jsonConn is a JSON-RPC connection, with its event subscriptions.
+type jsonConn struct {
+	sync.Mutex               // guards enc
+	enc        *json.Encoder // nil if not a stream (no subscriptions)
+	subs       map[int64]*core.Subscription
+	scope      string // the client permission scope
+}
+
+// serveJson serves the requests of a connection, one at a time.
+func serveJson(conn net.Conn, scope string) {
+	defer conn.Close()
+	c := &jsonConn{enc: json.NewEncoder(conn), subs: map[int64]*core.Subscription{}, scope: scope}
+	defer c.unsubscribeAll()
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// can't tell where the next request starts, give up
+			c.send(jsonErrResponse(nil, JsonParseError, err.Error()))
+			return
+		}
+		if resp := c.handle(raw); resp != nil {
+			if err := c.send(resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *jsonConn) send(v interface{}) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.enc.Encode(v)
+}
+
+// handle handles a request or a batch of requests.
+// Returns the response(s), or nil if there is nothing to respond (notifications).
+func (c *jsonConn) handle(raw json.RawMessage) interface{} {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		if resp := c.handleRequest(raw); resp != nil {
+			return resp
+		}
+		return nil
+	}
+	batch := []json.RawMessage{}
+	if err := json.Unmarshal(raw, &batch); err != nil || len(batch) == 0 {
+		return jsonErrResponse(nil, JsonInvalidRequest, "Invalid batch")
+	}
+	resps := []*jsonResponse{}
+	for _, r := range batch {
+		if resp := c.handleRequest(r); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	return resps
+}
+
+func (c *jsonConn) handleRequest(raw json.RawMessage) *jsonResponse {
+	req := jsonRequest{}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return jsonErrResponse(nil, JsonInvalidRequest, err.Error())
+	}
+	if req.Version != jsonVersion || len(req.Method) == 0 {
+		return jsonErrResponse(req.Id, JsonInvalidRequest, "Not a JSON-RPC 2.0 request")
+	}
+	var result interface{}
+	var jerr *JsonError
+	switch req.Method {
+	case "rpc.subscribe":
+		result, jerr = c.subscribe(req.Params)
+	case "rpc.unsubscribe":
+		result, jerr = c.unsubscribe(req.Params)
+	case "rpc.discover":
+		result = Discover()
+	default:
+		required := actions.CallScope(req.Method, firstParam(req.Params)) // "" if no such action
+		if len(required) > 0 && !actions.ScopeAllows(c.scope, required) {
+			jerr = &JsonError{JsonDenied, "Permission denied, requires the " + required + " scope"}
+			break
+		}
+		result, jerr = callJson(req.Method, req.Params)
+	}
+	if len(req.Id) == 0 {
+		return nil // notification
+	}
+	if jerr != nil {
+		return jsonErrResponse(req.Id, jerr.Code, jerr.Message)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return jsonErrResponse(req.Id, JsonInternalError, err.Error())
+	}
+	return &jsonResponse{Version: jsonVersion, Result: data, Id: req.Id}
+}
+
+// firstParam returns the first of the request params (JSON form), "" if none.
+func firstParam(rawParams json.RawMessage) string {
+	params := []json.RawMessage{}
+	if err := json.Unmarshal(rawParams, &params); err != nil || len(params) == 0 {
+		return ""
+	}
+	return string(params[0])
+}
+
+// jsonEvent is the "rpc.event" notification params.
+type jsonEvent struct {
+	Subscription int64      `json:"subscription"`
+	Event        core.Event `json:"event"`
+}
+
+// subscribe subscribes to the event types given as params (all if none),
+// the events are then sent as "rpc.event" notifications until unsubscribed.
+// Returns the subscription id.
+func (c *jsonConn) subscribe(rawParams json.RawMessage) (interface{}, *JsonError) {
+	if c.enc == nil {
+		return nil, &JsonError{JsonInvalidRequest, "Subscriptions require a stream connection"}
+	}
+	if !actions.ScopeAllows(c.scope, actions.ScopeRead) {
+		return nil, &JsonError{JsonDenied, "Permission denied, requires the read scope"}
+	}
+	types := []core.EventType{}
+	if len(rawParams) > 0 && string(rawParams) != "null" {
+		if err := json.Unmarshal(rawParams, &types); err != nil {
+			return nil, &JsonError{JsonInvalidParams, "Params must be an array of event types"}
+		}
+	}
+	s := core.Events.Subscribe(types...)
+	c.Lock()
+	c.subs[s.Id] = s
+	c.Unlock()
+	go func() {
+		for e := range s.C {
+			c.send(map[string]interface{}{
+				"jsonrpc": jsonVersion,
+				"method":  "rpc.event",
+				"params":  jsonEvent{Subscription: s.Id, Event: e},
+			})
+		}
+	}()
+	return s.Id, nil
+}
+
+func (c *jsonConn) unsubscribe(rawParams json.RawMessage) (interface{}, *JsonError) {
+	ids := []int64{}
+	if err := json.Unmarshal(rawParams, &ids); err != nil || len(ids) != 1 {
+		return nil, &JsonError{JsonInvalidParams, "Params must be [subscription id]"}
+	}
+	c.Lock()
+	_, found := c.subs[ids[0]]
+	delete(c.subs, ids[0])
+	c.Unlock()
+	if found {
+		core.Events.Unsubscribe(ids[0])
+	}
+	return found, nil
+}
+
+func (c *jsonConn) unsubscribeAll() {
+	c.Lock()
+	defer c.Unlock()
+	for id := range c.subs {
+		core.Events.Unsubscribe(id)
+	}
+	c.subs = map[int64]*core.Subscription{}
+}
+
+func jsonErrResponse(id json.RawMessage, code int, msg string) *jsonResponse {
+	return &jsonResponse{
+		Version: jsonVersion,
+		Error:   &JsonError{Code: code, Message: msg},
+		Id:      id,
+	}
+}
+
+// callJson calls a method, the result is nil, a single value or an array
+// when the action returns several values.
+func callJson(method string, rawParams json.RawMessage) (result interface{}, jerr *JsonError) {
+	if !actions.HasAction(method) {
+		return nil, &JsonError{JsonNoSuchMethod, "No such method " + method}
+	}
+	params := []json.RawMessage{}
+	if len(rawParams) > 0 && string(rawParams) != "null" {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, &JsonError{JsonInvalidParams, "Params must be an array"}
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, jerr = nil, &JsonError{JsonInternalError, fmt.Sprint(r)}
+		}
+	}()
+	res, err := actions.Call(method, params)
+	if _, ok := err.(actions.ArgErrors); ok {
+		return nil, &JsonError{JsonInvalidParams, err.Error()}
+	}
+	if err != nil {
+		return nil, &JsonError{JsonActionError, err.Error()}
+	}
+	switch len(res) {
+	case 0:
+		return nil, nil
+	case 1:
+		return res[0], nil
+	}
+	return res, nil
+}
+
+// JsonSchema is a (subset of) JSON schema.
+type JsonSchema struct {
+	Title      string                 `json:"title,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	Items      interface{}            `json:"items,omitempty"` // schema, or array of schemas
+	Properties map[string]*JsonSchema `json:"properties,omitempty"`
+}
+
+// JsonMethod describes a JSON-RPC method.
+type JsonMethod struct {
+	Name    string      `json:"name"`
+	Summary string      `json:"summary"` // the Go signature
+	Scope   string      `json:"x-scope"` // the permission scope required
+	Params  []JsonParam `json:"params"`
+	Result  JsonParam   `json:"result"`
+}
+
+// JsonParam describes a method param or result.
+type JsonParam struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JsonSchema `json:"schema"`
+}
+
+// JsonDiscovery is the "rpc.discover" result, in the spirit of OpenRPC.
+type JsonDiscovery struct {
+	OpenRpc string       `json:"openrpc"`
+	Info    JsonInfo     `json:"info"`
+	Methods []JsonMethod `json:"methods"`
+}
+
+type JsonInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Discover returns the schema of all the JSON-RPC methods, generated from the
+// actions signatures.
+func Discover() JsonDiscovery {
+	d := JsonDiscovery{
+		OpenRpc: "1.2.6",
+		Info:    JsonInfo{Title: "goed", Version: core.Version},
+		Methods: []JsonMethod{},
+	}
+	for _, info := range actions.Infos() {
+		m := JsonMethod{
+			Name:    info.Name,
+			Summary: info.Name + info.Sig,
+			Scope:   info.Scope,
+			Params:  []JsonParam{},
+			Result:  JsonParam{Name: "result", Schema: &JsonSchema{Type: "null"}},
+		}
+		for _, p := range info.Params {
+			m.Params = append(m.Params, JsonParam{
+				Name:     p.Name,
+				Required: p.Default == nil,
+				Schema:   typeSchema(p.Type),
+			})
+		}
+		switch len(info.Results) {
+		case 0:
+		case 1:
+			m.Result.Schema = typeSchema(info.Results[0].Type)
+			if len(info.Results[0].Name) > 0 {
+				m.Result.Name = info.Results[0].Name
+			}
+		default:
+			items := []*JsonSchema{}
+			for _, r := range info.Results {
+				s := typeSchema(r.Type)
+				s.Title = r.Name
+				items = append(items, s)
+			}
+			m.Result.Schema = &JsonSchema{Type: "array", Items: items}
+		}
+		d.Methods = append(d.Methods, m)
+	}
+	return d
+}
+
+// typeSchema returns the JSON schema of a Go type.
+func typeSchema(t reflect.Type) *JsonSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &JsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &JsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JsonSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		s := &JsonSchema{Type: "object", Properties: map[string]*JsonSchema{}}
+		for i := 0; i != t.NumField(); i++ {
+			f := t.Field(i)
+			if len(f.PkgPath) == 0 { // exported
+				s.Properties[f.Name] = typeSchema(f.Type)
+			}
+		}
+		return s
+	}
+	return &JsonSchema{} // any
+}
@@ -3,6 +3,7 @@
 package backend
 
 import (
+	"io"
 	"os/exec"
 	"path"
 	"strconv"
@@ -16,9 +17,34 @@ func BufferFile(id int64) string {
 	return path.Join(core.Home, "buffers", strconv.FormatInt(id, 10))
 }
 
+// saveFile atomically writes loc, with a backup of the previous version as
+// configured.
+func saveFile(loc string, write func(w io.Writer) error) error {
+	backup := core.BackupNone
+	if core.Ed != nil {
+		backup = core.Ed.Config().Backups
+	}
+	return core.WriteFileAtomic(loc, backup, write)
+}
+
+// checkStamp returns an ErrChangedOnDisk if loc is the source file and it was
+// modified on disk since stamp was taken.
+func checkStamp(stamp core.FileStamp, srcLoc, loc string) error {
+	if loc == srcLoc && stamp.Changed(loc) {
+		return core.ErrChangedOnDisk{Loc: loc}
+	}
+	return nil
+}
+
 // NewMemBackendCmd creates a Command runner backed by an In-memory based backend
 // if title == nil then will show the command name
 func NewMemBackendCmd(args []string, dir string, viewId int64, title *string, scrollTop bool) (*BackendCmd, error) {
+	return NewMemBackendCmdEnv(args, nil, dir, viewId, title, scrollTop)
+}
+
+// NewMemBackendCmdEnv is NewMemBackendCmd with extra environment variables
+// (KEY=value) for the command.
+func NewMemBackendCmdEnv(args, env []string, dir string, viewId int64, title *string, scrollTop bool) (*BackendCmd, error) {
 
 	actions.Ar.ViewSetType(viewId, core.ViewTypeCmdOutput)
 
@@ -31,6 +57,7 @@ func NewMemBackendCmd(args []string, dir string, viewId int64, title *string, sc
 		return nil, err
 	}
 	c.scrollTop = scrollTop
+	c.env = env
 	c.MemBackend = b
 	c.MemBackend.Wipe()
 
@@ -1,13 +1,13 @@
 package backend
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -17,20 +17,28 @@ import (
 )
 
 var _ core.Backend = (*BackendCmd)(nil)
+var _ core.TerminalBackend = (*BackendCmd)(nil)
+var _ core.CmdOutputBackend = (*BackendCmd)(nil)
 
 // BackendCmd is used to run a command using a specific backend
-// whose content will be the the output of the command. (VT100 support)
+// whose content will be the the output of the command. (xterm emulation)
 type BackendCmd struct {
 	*MemBackend
 	dir           string
+	env           []string // extra environment variables
 	runner        *exec.Cmd
 	pty           *os.File
 	title         *string
 	Starter       CmdStarter
 	scrollTop     bool // whether to scroll back to top once command completed
-	MaxRows       int  // ring buffer size
-	head          int
+	MaxRows       int  // ring buffer size : max rows kept, the oldest are dropped
+	head          int  // ring buffer head : rows dropped so far, modulo MaxRows
+	dropped       int  // rows dropped so far
+	MaxLogSize    int64
+	outLog        *cmdLog // rows dropped, if MaxLogSize > 0
+	term          *vtTerm
 	refreshCursor int32
+	pinned        int32 // >0 if pinned : the view does not follow the output
 }
 
 func (c *BackendCmd) Reload() error {
@@ -48,13 +56,6 @@ func (c *BackendCmd) Reload() error {
 	return nil
 }
 
-func (b *BackendCmd) ColorAt(ln, col int) (fg, bg core.Style) {
-	if b.MaxRows > 0 {
-		ln = (ln + b.head) % b.MaxRows
-	}
-	return b.MemBackend.ColorAt(ln, col)
-}
-
 func (b *BackendCmd) Insert(row, col int, text string) error {
 	if b.pty != nil {
 		b.pty.Write([]byte(text))
@@ -74,6 +75,18 @@ func (b *BackendCmd) Save(loc string) error {
 	return fmt.Errorf("Not implemented, Save()")
 }
 
+// Args returns the command and its arguments.
+func (b *BackendCmd) Args() []string {
+	b.MemBackend.lock.Lock()
+	defer b.MemBackend.lock.Unlock()
+	return b.runner.Args
+}
+
+// Dir returns the command working directory.
+func (b *BackendCmd) Dir() string {
+	return b.dir
+}
+
 func (b *BackendCmd) SrcLoc() string {
 	return ""
 }
@@ -84,6 +97,12 @@ func (b *BackendCmd) BufferLoc() string {
 
 func (b *BackendCmd) Close() error {
 	b.stop()
+	b.MemBackend.lock.Lock()
+	if b.outLog != nil {
+		b.outLog.remove()
+		b.outLog = nil
+	}
+	b.MemBackend.lock.Unlock()
 	b.MemBackend.Close()
 	return nil
 }
@@ -98,6 +117,86 @@ func (b *BackendCmd) SendBytes(data []byte) {
 	b.pty.Write(data)
 }
 
+// TermMouse reports a mouse event to the program, if it asked for it.
+func (b *BackendCmd) TermMouse(ln, col, button int, drag, release bool) bool {
+	b.MemBackend.lock.Lock()
+	var data []byte
+	if b.term != nil {
+		data = b.term.mouseEvent(ln, col, button, drag, release)
+	}
+	b.MemBackend.lock.Unlock()
+	if data == nil {
+		return false
+	}
+	b.SendBytes(data)
+	return true
+}
+
+// TermPaste sends pasted text to the program, bracketed if it asked for it.
+func (b *BackendCmd) TermPaste(text string) {
+	b.MemBackend.lock.Lock()
+	data := []byte(text)
+	if b.term != nil {
+		data = b.term.paste(text)
+	}
+	b.MemBackend.lock.Unlock()
+	b.SendBytes(data)
+}
+
+// TermResize sets the terminal size, on the pty as well so that the program
+// gets notified (SIGWINCH).
+func (b *BackendCmd) TermResize(rows, cols int) {
+	if rows < 1 || cols < 1 {
+		return
+	}
+	b.MemBackend.lock.Lock()
+	b.MemBackend.vtCols = cols
+	f := b.pty
+	if b.term != nil {
+		b.term.resize(rows, cols)
+	}
+	b.MemBackend.lock.Unlock()
+	if f != nil {
+		pty.Setsize(f, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+	}
+}
+
+// SetVtCols sets the terminal width.
+func (b *BackendCmd) SetVtCols(cols int) {
+	b.MemBackend.lock.Lock()
+	rows := vtDefaultRows
+	if b.term != nil {
+		rows = b.term.rows
+	}
+	b.MemBackend.lock.Unlock()
+	b.TermResize(rows, cols)
+}
+
+// TermSignal sends a signal (INT, QUIT or TSTP) to the foreground process
+// group. Programs using the raw mode (ie: vim) get the control character
+// instead, as they would from a terminal.
+func (b *BackendCmd) TermSignal(sig string) error {
+	s, found := ptySignals[sig]
+	if !found {
+		return fmt.Errorf("Unsupported signal : %s", sig)
+	}
+	b.MemBackend.lock.Lock()
+	f := b.pty
+	b.MemBackend.lock.Unlock()
+	if f == nil {
+		return fmt.Errorf("Not running")
+	}
+	if isig, err := ptyIsig(f); err != nil || !isig {
+		b.SendBytes([]byte{s.char})
+		return nil
+	}
+	pgrp, err := ptyForeground(f)
+	if err != nil {
+		return err
+	}
+	return ptySignal(pgrp, s.sig)
+}
+
 func (b *BackendCmd) Head() int { // for unit testing
 	return b.head
 }
@@ -110,12 +209,18 @@ func (c *BackendCmd) Start(viewId int64) {
 	actions.Ar.ViewRender(viewId)
 	actions.Ar.EdTermFlush()
 
-	c.runner.Env = core.EnvWith([]string{"TERM=vt100",
+	c.runner.Env = core.EnvWith(append(append([]string{}, c.env...), "TERM=xterm-256color",
 		fmt.Sprintf("GOED_INSTANCE=%d", core.InstanceId),
-		fmt.Sprintf("GOED_VIEW=%d", viewId)})
+		fmt.Sprintf("GOED_VIEW=%d", viewId)))
 
 	err := c.Starter.Start(c)
 
+	exitCode := -1
+	if c.runner.ProcessState != nil {
+		exitCode = c.runner.ProcessState.ExitCode()
+	}
+	core.Events.Publish(core.Event{Type: core.EvtCmdDone, ViewId: viewId, Loc: workDir, ExitCode: exitCode})
+
 	if err != nil {
 		actions.Ar.EdSetStatusErr(err.Error())
 		actions.Ar.ViewSetTitle(viewId, fmt.Sprintf("[FAILED] %s", *c.title))
@@ -144,34 +249,149 @@ func (c *BackendCmd) stop() {
 
 func (b *BackendCmd) Wipe() {
 	b.MemBackend.lock.Lock()
-	b.head = 0
+	b.head, b.dropped = 0, 0
+	if b.outLog != nil {
+		b.outLog.remove()
+		b.outLog = nil
+	}
 	b.MemBackend.lock.Unlock()
 	b.MemBackend.Wipe()
 }
 
-func (b *BackendCmd) adjustHead(row int) {
-	h := core.Ed.Config().SyntaxHighlighting
-	head := (b.head + row - b.MaxRows + 1) % b.MaxRows
-	if head != b.head {
-		// if head changed, we are going to be reusing a line
-		// clear it on first re-use.
-		for i := b.head; i != head; i++ {
-			if i >= b.MaxRows {
-				i = -1
-				continue
-			}
-			if i >= len(b.text) {
-				continue
-			}
-			b.text[i] = b.text[i][:0]
-			if h {
-				b.colors[i] = b.colors[i][:0]
-			}
+// line returns the index of a buffer row, adding rows as needed.
+// The buffer being limited to MaxRows, the oldest rows are dropped if needed,
+// in which case the returned index is less than row.
+// The lock must be held.
+func (b *BackendCmd) line(row int) int {
+	for len(b.text) <= row {
+		b.text = append(b.text, []rune{})
+	}
+	for len(b.colors) < len(b.text) {
+		b.colors = append(b.colors, []*color{})
+	}
+	if b.MaxRows > 0 && len(b.text) > b.MaxRows {
+		drop := len(b.text) - b.MaxRows
+		b.logRows(b.text[:drop])
+		b.dropped += drop
+		b.text = b.text[drop:]
+		b.colors = b.colors[drop:]
+		b.head = (b.head + drop) % b.MaxRows
+		row -= drop
+	}
+	return row
+}
+
+// logRows keeps rows dropped from memory in the output log, if enabled.
+// The lock must be held.
+func (b *BackendCmd) logRows(rows [][]rune) {
+	if b.MaxLogSize <= 0 {
+		return
+	}
+	if b.outLog == nil {
+		b.outLog = newCmdLog(BufferFile(b.ViewId())+".log", b.MaxLogSize)
+	}
+	if err := b.outLog.write(rows); err != nil {
+		log.Printf("Command output log disabled : %s", err)
+		b.outLog.remove()
+		b.outLog, b.MaxLogSize = nil, 0
+	}
+}
+
+// Cmd returns the command and its arguments.
+func (b *BackendCmd) Cmd() []string {
+	return b.runner.Args
+}
+
+// Dropped returns how many rows were dropped from memory so far (MaxRows).
+func (b *BackendCmd) Dropped() int {
+	b.MemBackend.lock.Lock()
+	defer b.MemBackend.lock.Unlock()
+	return b.dropped
+}
+
+// SaveOutput saves the full output to a file : the rows dropped from memory
+// (as far as the output log goes) followed by the rows in memory.
+func (b *BackendCmd) SaveOutput(loc string) error {
+	f, err := os.Create(loc)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// snapshot under the lock, copy outside of it so the command output
+	// is not held up while writing
+	var logged io.Reader = io.MultiReader()
+	done := func() {}
+	b.MemBackend.lock.Lock()
+	if b.outLog != nil {
+		logged, done, err = b.outLog.snapshot()
+	}
+	text := b.text
+	for len(text) > 0 && len(text[len(text)-1]) == 0 {
+		text = text[:len(text)-1]
+	}
+	rows := make([]string, len(text))
+	for i, row := range text {
+		rows[i] = string(row)
+	}
+	b.MemBackend.lock.Unlock()
+	defer done()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err = io.Copy(w, logged); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err = w.WriteString(row + "\n"); err != nil {
+			return err
 		}
-		b.head = head
 	}
+	return w.Flush()
+}
+
+// Pinned returns whether the view is pinned : it does not follow the output.
+func (b *BackendCmd) Pinned() bool {
+	return atomic.LoadInt32(&b.pinned) > 0
+}
+
+// SetPinned pins the view (it does not follow the output) or unpins it.
+func (b *BackendCmd) SetPinned(pinned bool) {
+	if pinned {
+		atomic.StoreInt32(&b.pinned, 1)
+		return
+	}
+	atomic.StoreInt32(&b.pinned, 0)
+	atomic.AddInt32(&b.refreshCursor, 1) // back to the output
+}
+
+// put writes a rune at an existing buffer row, padding it with spaces as
+// needed. The colors are only kept if colored.
+// The lock must be held.
+func (b *BackendCmd) put(row, col int, r rune, fg, bg core.Style, colored bool) {
+	ln := b.text[row]
+	for len(ln) < col {
+		ln = append(ln, ' ')
+	}
+	if col == len(ln) {
+		ln = append(ln, r)
+	} else {
+		ln[col] = r
+	}
+	b.text[row] = ln
+	if !colored {
+		return
+	}
+	colors := b.colors[row]
+	for len(colors) <= col {
+		colors = append(colors, nil)
+	}
+	colors[col] = &color{fg, bg}
+	b.colors[row] = colors
 }
 
+// Overwrite writes text from row, col, wrapping lines wider than the terminal.
+// Returns where the text ended.
 func (b *BackendCmd) Overwrite(row, col int, text string, fg, bg core.Style) (atRow, atCol int) {
 	if len(text) == 0 {
 		return row, col
@@ -188,40 +408,13 @@ func (b *BackendCmd) Overwrite(row, col int, text string, fg, bg core.Style) (at
 			row++
 			col = 0
 		}
-		r := row
-		if b.MaxRows > 0 {
-			r = (b.head + row) % b.MaxRows
-			if row >= b.MaxRows {
-				b.adjustHead(row)
-			}
-		}
 		for _, ch := range ln {
 			if col >= b.MemBackend.vtCols { // wrap lines wider than terminal width
 				col = 0
 				row++
-				if b.MaxRows > 0 {
-					r = (b.head + row) % b.MaxRows
-					if row >= b.MaxRows {
-						b.adjustHead(row)
-					}
-				}
-			}
-			if len(b.text) <= r { // make sure we have enough rows
-				b.text = append(b.text, make([][]rune, r-len(b.text)+1)...)
-			}
-			if h && len(b.colors) <= r {
-				b.colors = append(b.colors, make([][]*color, r-len(b.colors)+1)...)
-			}
-			if len(b.text[r]) <= col { // make sure enough cols
-				b.text[r] = append(b.text[r], make([]rune, col-len(b.text[r])+1)...)
-			}
-			if h && len(b.colors[r]) <= col {
-				b.colors[r] = append(b.colors[r], make([]*color, col-len(b.colors[r])+1)...)
-			}
-			b.text[r][col] = ch // write the char
-			if h {
-				b.colors[r][col] = &color{fg, bg}
 			}
+			row = b.line(row)
+			b.put(row, col, ch, fg, bg, h)
 			col++
 		}
 	}
@@ -231,94 +424,6 @@ func (b *BackendCmd) Overwrite(row, col int, text string, fg, bg core.Style) (at
 	return row, col
 }
 
-func (b *BackendCmd) Slice(row, col, row2, col2 int) *core.Slice {
-	notRb := false
-	b.MemBackend.lock.Lock()
-	notRb = b.MaxRows <= 0 || b.head == 0
-	b.MemBackend.lock.Unlock()
-	if notRb {
-		return b.MemBackend.Slice(row, col, row2, col2)
-	}
-	// Otherwise ringbuffer
-	b.MemBackend.lock.Lock()
-	defer b.MemBackend.lock.Unlock()
-	slice := core.NewSlice(row, col, row2, col2, [][]rune{})
-	text := slice.Text()
-
-	r := (row + b.head) % b.MaxRows
-	r2 := (row2 + b.head) % b.MaxRows
-	if row2 == -1 || row2 >= len(b.text) {
-		// then read whole buffer (wrap all the way around)
-		r2 = (b.head - 1) % b.MaxRows
-	}
-
-	if r < 0 || r2 < 0 {
-		return slice
-	}
-
-	if r2 >= r {
-		// no wrap-around
-		*text = append(*text, *b.MemBackend.sliceNoLock(r, col, r2, col2).Text()...)
-		return slice
-	}
-	// otherwise read end + beginning
-	*text = append(*text, *b.MemBackend.sliceNoLock(r, col, -1, col2).Text()...)
-	*text = append(*text, *b.MemBackend.sliceNoLock(0, col, r2, col2).Text()...)
-	return slice
-}
-
-func (b *BackendCmd) clearLn(row, col int) {
-	h := core.Ed.Config().SyntaxHighlighting
-	if b.MaxRows > 0 {
-		row = (row + b.head) % b.MaxRows
-	}
-	b.MemBackend.lock.Lock()
-	defer b.MemBackend.lock.Unlock()
-	if row >= len(b.text) || col >= len(b.text[row]) {
-		return
-	}
-	b.text[row] = b.text[row][:col]
-	if h {
-		b.colors[row] = b.colors[row][:col]
-	}
-}
-
-func (b *BackendCmd) clearScreen(row, col int) {
-	h := core.Ed.Config().SyntaxHighlighting
-	b.lock.Lock()
-	defer b.lock.Unlock()
-	if row >= len(b.text) {
-		return
-	}
-	if b.MaxRows <= 0 {
-		b.text = b.text[0 : row+1]
-		if h {
-			b.colors = b.colors[0 : row+1]
-		}
-		if col < len(b.text[row]) {
-			b.text[row] = b.text[row][:col]
-			if h {
-				b.colors[row] = b.colors[row][:col]
-			}
-		}
-		return
-	}
-	// else ringbuffer
-	r := (b.head + row) % len(b.text)
-	b.text[r] = b.text[r][:col]
-	if h {
-		b.colors[r] = b.colors[r][:col]
-	}
-	r = (r + 1) % len(b.text)
-	for r != b.head {
-		b.text[r] = b.text[r][:0]
-		if h {
-			b.colors[r] = b.colors[r][:0]
-		}
-		r = (r + 1) % len(b.text)
-	}
-}
-
 // CmdStarter is an interface for a "startable" command
 type CmdStarter interface {
 	Start(c *BackendCmd) error
@@ -357,7 +462,8 @@ func (c *BackendCmd) WaitRunning(t time.Duration) bool {
 	return true
 }
 
-// check if the shell is currently running any subcommands
+// SubCmdRunning returns whether the shell is currently running a command,
+// that is whether the pty foreground process group is not the shell one.
 func (c *BackendCmd) SubCmdRunning() bool {
 	var pid int
 	c.MemBackend.lock.Lock()
@@ -365,26 +471,41 @@ func (c *BackendCmd) SubCmdRunning() bool {
 	if !stopped {
 		pid = c.runner.Process.Pid
 	}
+	f := c.pty
 	c.MemBackend.lock.Unlock()
-	if stopped {
+	if stopped || f == nil {
 		return false
 	}
-
-	out, err := exec.Command("pgrep", "-P", strconv.Itoa(pid)).CombinedOutput()
+	pgrp, err := ptyForeground(f)
 	if err != nil {
 		return false
 	}
-	return len(out) > 0
+	return pgrp != pid
 }
 
 func (c *BackendCmd) stream() error {
 	t := core.Ed.Theme()
-	w := backendAppender{backend: c, viewId: c.ViewId(), curFg: t.Fg, curBg: t.Bg}
+	viewId := c.ViewId()
+	term := newVtTerm(c, t.Fg, t.Bg, core.Ed.Config().SyntaxHighlighting)
+	term.onTitle = func(title string) {
+		actions.Ar.ViewSetTitle(viewId, title)
+	}
+	term.onBell = func() {
+		actions.Ar.EdSetStatusErr("Beep !!")
+	}
+	term.reply = c.SendBytes
+	c.MemBackend.lock.Lock()
+	c.term = term
+	c.MemBackend.lock.Unlock()
+	rows, cols := termSize(actions.Ar.ViewBounds(viewId))
+	c.TermResize(rows, cols)
+	w := backendAppender{backend: c, viewId: viewId, term: term}
 	endc := make(chan struct{}, 1)
 	go w.refresher(endc)
 	var err error
 	c.MemBackend.lock.Lock()
-	c.pty, err = pty.Start(c.runner)
+	size := &pty.Winsize{Rows: uint16(term.rows), Cols: uint16(term.cols)}
+	c.pty, err = pty.StartWithSize(c.runner, size)
 	c.MemBackend.lock.Unlock()
 	if err != nil {
 		return err
@@ -410,12 +531,17 @@ func (c *BackendCmd) stream() error {
 	return err
 }
 
+// termSize returns the terminal size fitting a view text area, given the
+// view bounds.
+func termSize(ln, col, ln2, col2 int) (rows, cols int) {
+	return ln2 - ln - 2, col2 - col - 2
+}
+
 type backendAppender struct {
-	backend      *BackendCmd
-	viewId       int64
-	line, col    int
-	dirty        int32      // >0 if dirty
-	curFg, curBg core.Style // current terminal color attributes
+	backend *BackendCmd
+	viewId  int64
+	term    *vtTerm
+	dirty   int32 // >0 if dirty
 }
 
 // refresh the view if needed(dirty) but no more than every so often
@@ -430,22 +556,18 @@ func (b *backendAppender) refresher(endc chan struct{}) {
 			return
 		default:
 			if atomic.SwapInt32(&b.dirty, 0) > 0 || atomic.SwapInt32(&b.backend.refreshCursor, 0) > 0 {
-				if actions.Ar.EdCurView() == b.viewId {
-					b.backend.MemBackend.lock.Lock()
-					ln, col := b.line+1, b.col+1
-					b.backend.MemBackend.lock.Unlock()
-					actions.Ar.ViewSetCursorPos(b.viewId, ln, col)
+				if actions.Ar.EdCurView() == b.viewId && !b.backend.Pinned() {
+					ln, col := b.term.Cursor()
+					actions.Ar.ViewSetCursorPos(b.viewId, ln+1, col+1)
 				}
 				actions.Ar.EdRender()
 			}
-			// If view was resized, do a stty resize
+			// If view was resized, resize the terminal
 			l2, c2, m2, d2 := actions.Ar.ViewBounds(b.viewId)
 			if m-l != m2-l2 || d-c != d2-c2 {
-				if !b.backend.SubCmdRunning() { // "heavy" call, do only as secondary check
-					l, c, m, d = l2, c2, m2, d2
-					b.backend.Insert(1, 1, "sz\n")
-					actions.Ar.EdRender()
-				}
+				l, c, m, d = l2, c2, m2, d2
+				b.backend.TermResize(termSize(l, c, m, d))
+				atomic.AddInt32(&b.dirty, 1)
 			}
 			time.Sleep(pause)
 		}
@@ -456,22 +578,7 @@ func (b *backendAppender) Write(data []byte) (int, error) {
 	if len(data) == 0 {
 		return 0, nil
 	}
-	size, err := b.vt100(data)
+	b.term.Write(data)
 	atomic.AddInt32(&b.dirty, 1)
-	if err != nil {
-		return 0, err
-	}
-	return size, nil
-}
-
-func (b *backendAppender) flush(data []byte) error {
-	if len(data) == 0 {
-		return nil
-	}
-	ln, col := b.backend.Overwrite(b.line, b.col, string(data), b.curFg, b.curBg)
-	b.backend.MemBackend.lock.Lock()
-	b.line, b.col = ln, col
-	b.backend.MemBackend.lock.Unlock()
-
-	return nil
+	return len(data), nil
 }
@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// cmdLog keeps the rows of a command output dropped from memory
+// (BackendCmd.MaxRows) in a file, rotated once it reaches maxSize : the
+// previous one is kept as loc.1, so the log is bounded to twice maxSize.
+type cmdLog struct {
+	loc     string
+	maxSize int64
+	size    int64
+	f       *os.File
+	w       *bufio.Writer
+}
+
+func newCmdLog(loc string, maxSize int64) *cmdLog {
+	return &cmdLog{loc: loc, maxSize: maxSize}
+}
+
+// write appends rows to the log.
+func (l *cmdLog) write(rows [][]rune) error {
+	if l.f == nil {
+		os.Remove(l.loc + ".1")
+		if err := l.open(); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if l.size >= l.maxSize {
+			if err := l.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := l.w.WriteString(string(row) + "\n")
+		if err != nil {
+			return err
+		}
+		l.size += int64(n)
+	}
+	return nil
+}
+
+func (l *cmdLog) open() error {
+	f, err := os.Create(l.loc)
+	if err != nil {
+		return err
+	}
+	l.f, l.w, l.size = f, bufio.NewWriter(f), 0
+	return nil
+}
+
+func (l *cmdLog) rotate() error {
+	l.close()
+	if err := os.Rename(l.loc, l.loc+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// snapshot returns a reader over the rows logged so far, oldest first,
+// which stays valid as more rows are logged or the log is rotated. done
+// must be called once the reader is no longer used.
+func (l *cmdLog) snapshot() (r io.Reader, done func(), err error) {
+	files := []*os.File{}
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	defer func() {
+		if err != nil {
+			closeAll()
+			done = func() {}
+		}
+	}()
+	if l.f == nil {
+		return io.MultiReader(), closeAll, nil
+	}
+	if err = l.w.Flush(); err != nil {
+		return nil, nil, err
+	}
+	readers := []io.Reader{}
+	for _, loc := range []string{l.loc + ".1", l.loc} {
+		f, err := os.Open(loc)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+		info, err := f.Stat()
+		if err != nil {
+			return nil, nil, err
+		}
+		// rows logged after this point are not part of the snapshot
+		readers = append(readers, io.LimitReader(f, info.Size()))
+	}
+	return io.MultiReader(readers...), closeAll, nil
+}
+
+func (l *cmdLog) close() {
+	if l.f != nil {
+		l.w.Flush()
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+// remove closes and deletes the log files.
+func (l *cmdLog) remove() {
+	l.close()
+	os.Remove(l.loc)
+	os.Remove(l.loc + ".1")
+}
@@ -1,409 +1,1023 @@
 package backend
 
 import (
-	"log"
+	"fmt"
+	"strings"
 
-	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
-// TODO : Handle VT100 codes
-// http://www.ccs.neu.edu/research/gpc/VonaUtils/vona/terminal/VT100_Escape_Codes.html
-// http://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-VT100-Mode
-func (b *backendAppender) vt100(data []byte) (int, error) {
-	// handle VT100 codes
-	from := 0
-	log.Printf("VT100 raw data: %v %q\n", data, string(data))
-	for i := 0; i < len(data); i++ {
-		if b.consumeVt100(data, from, &i) {
-			from = i
-			i--
-		}
-	}
-	// flush leftover
-	err := b.flush(data[from:len(data)])
-	return len(data), err
-}
-
-func (b *backendAppender) setCol(col int) {
-	if col < 0 {
-		col = 0
-	}
-	b.backend.MemBackend.lock.Lock()
-	defer b.backend.MemBackend.lock.Unlock()
-	b.col = col
-}
-
-func (b *backendAppender) setLine(line int) {
-	if line < 0 {
-		line = 0
-	}
-	b.backend.MemBackend.lock.Lock()
-	defer b.backend.MemBackend.lock.Unlock()
-	b.line = line
-}
-
-func (b *backendAppender) consumeVt100(data []byte, from int, i *int) bool {
-	t := core.Ed.Theme()
-
-	start := *i
-	// bell
-	if b.consume(data, i, 7) {
-		b.flush(data[from:start])
-		actions.Ar.EdSetStatusErr("Beep !!")
-		return true
-	}
-	*i = start
-	// "backspace" (move left)
-	if b.consume(data, i, 8) {
-		b.flush(data[from:start])
-		b.setCol(b.col - 1)
-		return true
-	}
-	// horizontal tab
-	if b.consume(data, i, 9) {
-		spaces := []byte{}
-		b.flush(data[from:start])
-		for i := 0; i < 8-(b.col%8); i++ {
-			spaces = append(spaces, ' ')
-		}
-		b.flush(spaces)
-		return true
-	}
-	// delete
-	*i = start
-	if b.consume(data, i, 127) {
-		b.flush(data[from:start])
-		actions.Ar.ViewDeleteCur(b.viewId)
-		return true
-	}
-	*i = start // \r\n
-	if b.consume(data, i, 13) && b.consume(data, i, 10) {
-		b.flush(data[from:start])
-		b.setLine(b.line + 1)
-		b.setCol(0)
-		return true
-	}
-	*i = start
-	if b.consume(data, i, 10) { // \n
-		b.flush(data[from:start])
-		b.setLine(b.line + 1)
-		b.setCol(0)
-		return true
-	}
-	*i = start
-	if b.consume(data, i, 13) { // \r
-		b.flush(data[from:start])
-		//b.line++
-		b.setCol(0)
-		return true
-	}
-	// Other unhandled control characters, skip them
-	*i = start
-	if data[*i] != 27 && data[*i] < 32 {
-		b.flush(data[from:start])
-		*i++
-		return true
-	}
-
-	// various ignored escape sequences
-	*i = start
-	if b.consume(data, i, 27) {
-		if b.consume(data, i, '=') || b.consume(data, i, '>') ||
-			(b.consume(data, i, '(') && b.consume(data, i, 'B')) ||
-			(b.consume(data, i, ')') && b.consume(data, i, '0')) {
-			return true
-		}
-	}
-
-	// set title (xterm-ish)
-	// http://www.xfree86.org/4.7.0/ctlseqs.html
-	*i = start
-	if b.consume(data, i, 27) && b.consume(data, i, 93) &&
-		// 0 is (icon&window title), 2 is window only
-		(b.consume(data, i, '0') || b.consume(data, i, '2')) &&
-		b.consume(data, i, ';') {
-		b.consumeUntil(data, i, 7)
-		actions.Ar.ViewSetTitle(b.viewId, string(data[start+4:*i]))
-		return true
-	}
-
-	// Other unhandled xterm codes -> ignore
-	*i = start
-	if b.consume(data, i, 27) && b.consume(data, i, 93) {
-		b.consumeUntil(data, i, 7)
-		return true
-	}
-
-	// ###### Start "real" VT100 codes
-	*i = start
-	if !(b.consume(data, i, 27) && b.consume(data, i, 91)) { // ^[
-		return false
-	}
-
-	*i = start + 2
-	// cursor up (A)
-	if b.consume(data, i, 'A') || (b.consumeNb(data, i) && b.consume(data, i, 'A')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setLine(b.line - nb)
-		if b.line-nb < 0 {
-			b.setCol(0)
-		}
-		return true
-	}
-	*i = start + 2
-	// cursor down (B)
-	if b.consume(data, i, 'B') || (b.consumeNb(data, i) && b.consume(data, i, 'B')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setLine(b.line + nb)
-		return true
-	}
-	*i = start + 2
-	// cursor right (C)
-	if b.consume(data, i, 'C') || (b.consumeNb(data, i) && b.consume(data, i, 'C')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setCol(b.col + nb)
-		return true
-	}
-	*i = start + 2
-	// cursor left (D)
-	if b.consume(data, i, 'D') || (b.consumeNb(data, i) && b.consume(data, i, 'D')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setCol(b.col - nb)
-		return true
-	}
-	*i = start + 2
-	// cursor next line (E)
-	if b.consume(data, i, 'E') || (b.consumeNb(data, i) && b.consume(data, i, 'E')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setLine(b.line + nb)
-		b.setCol(0)
-		return true
-	}
-	*i = start + 2
-	// cursor prev line (F)
-	if b.consume(data, i, 'F') || (b.consumeNb(data, i) && b.consume(data, i, 'F')) {
-		b.flush(data[from:start])
-		nb, _ := b.readNb(data, start+2, 1)
-		b.setLine(b.line - nb)
-		b.setCol(0)
-		return true
-	}
-	*i = start + 2
-	// set cursor (H)
-	if b.consume(data, i, 'H') || (b.consumeNbTuple(data, i) && b.consume(data, i, 'H')) {
-		b.flush(data[from:start])
-		row, col, _ := b.readNbTuple(data, start+2, 1, 1)
-		b.setLine(row - 1)
-		b.setCol(col - 1)
-		return true
-	}
-	*i = start + 2
-	// clear screen (J)
-	if b.consume(data, i, 'J') || (b.consumeNb(data, i) && b.consume(data, i, 'J')) {
-		b.flush(data[from:start])
-		ps, _ := b.readNb(data, start+2, 0)
-		switch ps {
-		case 0: // 0
-			b.backend.clearScreen(b.line, b.col)
+// Default terminal size, as assumed by programs (TERM) when the pty has none.
+const (
+	vtDefaultRows = 24
+	vtDefaultCols = 80
+)
+
+// Mouse reporting modes (DECSET), as requested by the program.
+const (
+	vtMouseOff    = 0
+	vtMouseX10    = 9    // presses only
+	vtMouseNormal = 1000 // presses and releases
+	vtMouseButton = 1002 // also motion while a button is down
+	vtMouseAny    = 1003 // all motion
+)
+
+// vtTerm is an xterm compatible terminal emulator : it interprets a command
+// output (escape sequences) onto the lines of its BackendCmd.
+// The screen is the last rows of the buffer, the rows above it being the
+// scrollback, except on the alternate screen (ie: vim, less) which replaces
+// the screen rows until the program leaves it.
+// See http://invisible-island.net/xterm/ctlseqs/ctlseqs.html
+type vtTerm struct {
+	b       *BackendCmd
+	parser  *vtParser
+	colored bool // whether to keep the colors
+
+	rows, cols   int
+	top          int  // buffer row of the first screen row
+	y, x         int  // cursor, screen relative
+	wrapNext     bool // the cursor is past the last column, next print wraps
+	marginTop    int  // scroll region, screen rows
+	marginBottom int
+	tabs         []bool
+
+	defFg, defBg core.Style
+	fg, bg       core.Style // current colors (SGR)
+	attrs        uint16     // current attributes (SGR)
+	reverse      bool
+	charsets     [2]bool // G0, G1 : whether DEC special graphics (line drawing)
+	charset      int     // G0 or G1 (SO / SI)
+	last         rune    // last printed rune (REP)
+	saved        vtCursor
+	alt          *vtScreen // the primary screen, while on the alternate one
+
+	// modes
+	autowrap       bool
+	origin         bool
+	insert         bool
+	newline        bool // LF also does CR
+	appCursor      bool // application cursor keys
+	cursorVisible  bool
+	bracketedPaste bool
+	mouse          int  // mouse reporting mode
+	mouseSgr       bool // SGR mouse encoding
+
+	// what to do once the lock is released
+	title   *string
+	bell    bool
+	replies []byte
+
+	onTitle func(title string)
+	onBell  func()
+	reply   func(data []byte) // answers to the program (ie: cursor position)
+}
+
+// vtCursor is the cursor state saved by DECSC.
+type vtCursor struct {
+	y, x     int
+	wrapNext bool
+	fg, bg   core.Style
+	attrs    uint16
+	reverse  bool
+	origin   bool
+	charsets [2]bool
+	charset  int
+}
+
+// vtScreen is the primary screen content and cursor, saved while on the
+// alternate screen.
+type vtScreen struct {
+	text   [][]rune
+	colors [][]*color
+	cursor vtCursor
+}
+
+func newVtTerm(b *BackendCmd, fg, bg core.Style, colored bool) *vtTerm {
+	t := &vtTerm{
+		b:       b,
+		colored: colored,
+		defFg:   fg,
+		defBg:   bg,
+	}
+	t.parser = newVtParser(t)
+	t.rows, t.cols = vtDefaultRows, vtDefaultCols
+	if b.vtCols > 0 {
+		t.cols = b.vtCols
+	}
+	t.reset()
+	t.top = len(b.text) // after what's there already
+	if t.top > 0 && len(b.text[t.top-1]) == 0 {
+		t.top--
+	}
+	return t
+}
+
+// reset resets the terminal modes and attributes (RIS, DECSTR).
+func (t *vtTerm) reset() {
+	t.fg, t.bg, t.attrs, t.reverse = t.defFg, t.defBg, 0, false
+	t.charsets, t.charset = [2]bool{}, 0
+	t.marginTop, t.marginBottom = 0, t.rows-1
+	t.autowrap, t.origin, t.insert, t.newline = true, false, false, false
+	t.appCursor, t.cursorVisible, t.bracketedPaste = false, true, false
+	t.mouse, t.mouseSgr = vtMouseOff, false
+	t.wrapNext = false
+	t.saved = vtCursor{fg: t.defFg, bg: t.defBg}
+	t.resetTabs()
+}
+
+func (t *vtTerm) resetTabs() {
+	t.tabs = make([]bool, t.cols)
+	for i := 8; i < t.cols; i += 8 {
+		t.tabs[i] = true
+	}
+}
+
+// Write interprets a chunk of the command output.
+func (t *vtTerm) Write(data []byte) {
+	t.b.MemBackend.lock.Lock()
+	t.parser.Parse(data)
+	title, bell, replies := t.title, t.bell, t.replies
+	t.title, t.bell, t.replies = nil, false, nil
+	t.b.MemBackend.lock.Unlock()
+
+	// callbacks might need the lock (ie: rendering)
+	if title != nil && t.onTitle != nil {
+		t.onTitle(*title)
+	}
+	if bell && t.onBell != nil {
+		t.onBell()
+	}
+	if len(replies) > 0 && t.reply != nil {
+		t.reply(replies)
+	}
+}
+
+// Cursor returns the cursor buffer position.
+func (t *vtTerm) Cursor() (row, col int) {
+	t.b.MemBackend.lock.Lock()
+	defer t.b.MemBackend.lock.Unlock()
+	x := t.x
+	if x >= t.cols {
+		x = t.cols - 1
+	}
+	return t.top + t.y, x
+}
+
+// resize changes the screen size, keeping the cursor on the same row.
+func (t *vtTerm) resize(rows, cols int) {
+	if rows < 1 || cols < 1 {
+		return
+	}
+	if t.alt == nil {
+		if t.y >= rows { // scroll the top rows out
+			t.top += t.y - rows + 1
+			t.y = rows - 1
+		} else if rows > t.rows && t.top > 0 { // bring scrollback rows in
+			d := rows - t.rows
+			if d > t.top {
+				d = t.top
+			}
+			t.top -= d
+			t.y += d
+		}
+	}
+	t.rows, t.cols = rows, cols
+	t.marginTop, t.marginBottom = 0, rows-1
+	t.y, t.x = t.clamp(t.y, 0, rows-1), t.clamp(t.x, 0, cols-1)
+	t.wrapNext = false
+	t.resetTabs()
+}
+
+func (t *vtTerm) clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// row returns the buffer row of screen row y, adding buffer rows as needed.
+func (t *vtTerm) row(y int) int {
+	r := t.b.line(t.top + y)
+	t.top = r - y // rows might have been dropped (MaxRows)
+	if t.top < 0 {
+		t.top = 0
+	}
+	return r
+}
+
+// exists returns whether screen row y is in the buffer.
+func (t *vtTerm) exists(y int) bool {
+	return t.top+y < len(t.b.text)
+}
+
+// style returns the colors of the printed runes.
+func (t *vtTerm) style() (fg, bg core.Style) {
+	fg, bg = t.fg, t.bg
+	if t.reverse {
+		fg, bg = bg, fg
+	}
+	return fg.WithAttr(t.attrs), bg
+}
+
+// ############## vtHandler
+
+func (t *vtTerm) vtPrint(runes []rune) {
+	fg, bg := t.style()
+	for _, r := range runes {
+		if t.charsets[t.charset] {
+			r = vtLineDrawing(r)
+		}
+		if t.wrapNext {
+			if t.autowrap {
+				t.x = 0
+				t.lineFeed()
+			}
+			t.wrapNext = false
+		}
+		if t.insert {
+			t.insertBlanks(1)
+		}
+		t.b.put(t.row(t.y), t.x, r, fg, bg, t.colored)
+		t.last = r
+		if t.x == t.cols-1 {
+			t.wrapNext = true
+		} else {
+			t.x++
+		}
+	}
+}
+
+func (t *vtTerm) vtExecute(c byte) {
+	switch c {
+	case 0x07: // BEL
+		t.bell = true
+	case 0x08: // BS
+		if t.x > 0 {
+			t.x--
+		}
+		t.wrapNext = false
+	case 0x09: // HT
+		t.tab(1)
+	case 0x0a, 0x0b, 0x0c: // LF, VT, FF
+		t.lineFeed()
+		if t.newline {
+			t.x = 0
+		}
+		t.wrapNext = false
+	case 0x0d: // CR
+		t.x = 0
+		t.wrapNext = false
+	case 0x0e: // SO
+		t.charset = 1
+	case 0x0f: // SI
+		t.charset = 0
+	}
+}
+
+func (t *vtTerm) vtEsc(intermediates []byte, final byte) {
+	if len(intermediates) > 0 {
+		switch intermediates[0] {
+		case '(', ')': // designate G0, G1
+			t.charsets[intermediates[0]-'('] = final == '0'
+		case '#':
+			if final == '8' { // DECALN
+				t.fill('E')
+			}
+		}
+		return
+	}
+	switch final {
+	case '7': // DECSC
+		t.saveCursor()
+	case '8': // DECRC
+		t.restoreCursor()
+	case 'D': // IND
+		t.lineFeed()
+	case 'E': // NEL
+		t.x = 0
+		t.lineFeed()
+	case 'H': // HTS
+		if t.x < t.cols {
+			t.tabs[t.x] = true
+		}
+	case 'M': // RI
+		t.reverseIndex()
+	case 'c': // RIS
+		if t.alt != nil {
+			t.leaveAlt()
+		}
+		t.reset()
+		t.eraseDisplay(2)
+		t.y, t.x = 0, 0
+	case '=', '>': // application / normal keypad
+	}
+	t.wrapNext = false
+}
+
+func (t *vtTerm) vtCsi(private byte, params [][]int, intermediates []byte, final byte) {
+	p := func(i, def int) int {
+		if i >= len(params) || params[i][0] == 0 {
+			return def
+		}
+		return params[i][0]
+	}
+	if private == '?' {
+		switch final {
+		case 'h', 'l':
+			for i := range params {
+				t.setPrivateMode(params[i][0], final == 'h')
+			}
+		}
+		return
+	}
+	if private == '>' {
+		if final == 'c' { // secondary DA : VT220
+			t.replies = append(t.replies, "\x1b[>1;10;0c"...)
+		}
+		return
+	}
+	if private != 0 {
+		return
+	}
+	if len(intermediates) > 0 {
+		if intermediates[0] == '!' && final == 'p' { // DECSTR
+			t.reset()
+		}
+		return // ie: DECSCUSR (cursor style)
+	}
+	if final != 'b' {
+		t.wrapNext = false
+	}
+	switch final {
+	case '@': // ICH
+		t.insertBlanks(p(0, 1))
+	case 'A': // CUU
+		t.y = t.clamp(t.y-p(0, 1), t.upperLimit(), t.rows-1)
+	case 'B', 'e': // CUD, VPR
+		t.y = t.clamp(t.y+p(0, 1), 0, t.lowerLimit())
+	case 'C', 'a': // CUF, HPR
+		t.x = t.clamp(t.x+p(0, 1), 0, t.cols-1)
+	case 'D': // CUB
+		t.x = t.clamp(t.x-p(0, 1), 0, t.cols-1)
+	case 'E': // CNL
+		t.y = t.clamp(t.y+p(0, 1), 0, t.lowerLimit())
+		t.x = 0
+	case 'F': // CPL
+		t.y = t.clamp(t.y-p(0, 1), t.upperLimit(), t.rows-1)
+		t.x = 0
+	case 'G', '`': // CHA, HPA
+		t.x = t.clamp(p(0, 1)-1, 0, t.cols-1)
+	case 'H', 'f': // CUP, HVP
+		t.moveTo(p(0, 1)-1, p(1, 1)-1)
+	case 'I': // CHT
+		t.tab(p(0, 1))
+	case 'J': // ED
+		t.eraseDisplay(p(0, 0))
+	case 'K': // EL
+		switch p(0, 0) {
+		case 0:
+			t.eraseLine(t.y, t.x, -1)
+		case 1:
+			t.eraseLine(t.y, 0, t.x+1)
 		case 2:
-			b.backend.clearScreen(0, 0)
-		default: // 1 is above, 3 is "saved lines"
-			log.Printf("TODO Unsupported clear mode (J) %d", ps)
-		}
-		return true
-	}
-	*i = start + 2
-	// clear line (K)
-	if b.consume(data, i, 'K') {
-		b.flush(data[from:start])
-		ps, _ := b.readNb(data, start+2, 0)
-		switch ps {
-		case 0: // 0
-			b.backend.clearLn(b.line, b.col)
-		default: // 1 is left, 2 is whole line
-			log.Printf("TODO Unsupported clear line mode (K) %d", ps)
-		}
-		return true
-	}
-
-	*i = start + 2
-	// Color attribute + fg color  + bg color
-	if b.consumeNbTriple(data, i) && b.consume(data, i, 'm') {
-		b.flush(data[from:start]) // flush what was before escape seq first.
-		x, y, z := b.readNbTriple(data, start+2, 0, 0, 0)
-		b.applyColors(x, y, z)
-		return true
-	}
-	*i = start + 2
-	// Color attribute + fg color
-	if b.consumeNbTuple(data, i) && b.consume(data, i, 'm') {
-		b.flush(data[from:start])
-		x, y, _ := b.readNbTuple(data, start+2, 0, 0)
-		b.applyColors(x, y)
-		return true
-	}
-	*i = start + 2
-	// color attr alone
-	if b.consumeNb(data, i) && b.consume(data, i, 'm') {
-		b.flush(data[from:start])
-		x, _ := b.readNb(data, start+2, 0)
-		b.applyColors(x)
-		return true
-	}
-	*i = start + 2
-	// reset char/color attributes
-	if b.consume(data, i, 'm') {
-		b.flush(data[from:start])
-		b.curFg, b.curBg = t.Fg, t.Bg
-		return true
-	}
-
-	// ###### Start unhandled ! ######################################
-
-	*i = start + 2
-	// set scrolling region (r)
-	if b.consumeNbTuple(data, i) && b.consume(data, i, 'r') {
-		b.flush(data[from:start])
-		// TODO VT100 scrolling region
-		log.Printf("TODO Vt100 scrolling region: %v", data[start+2:*i])
-		return true
-	}
-	*i = start + 2
-	// Various Set comand, ignore for now
-	if b.consume(data, i, '?') && b.consumeNb(data, i) && b.consume(data, i, 'h') {
-		b.flush(data[from:start])
-		log.Printf("TODO Vt100 set command: %v", data[start+2:*i])
-		return true
-	}
-	*i = start + 2
-	// Various Set comand, ignore for now
-	if b.consume(data, i, '?') && b.consumeNb(data, i) && b.consume(data, i, 'l') {
-		b.flush(data[from:start])
-		log.Printf("TODO Vt100 set command: %v", data[start+2:*i])
-		return true
-	}
-	*i = start + 2
-	// Set alternate keypad mode
-	if b.consume(data, i, '=') {
-		b.flush(data[from:start])
-		log.Printf("TODO Vt100 alternate keypad")
-		// ignore for now
-		return true
-	}
-	*i = start + 2
-	// Set numeric keypad mode
-	if b.consume(data, i, '>') {
-		b.flush(data[from:start])
-		log.Printf("TODO Vt100 alternate keypad")
-		// ignore for now
-		return true
-	}
-
-	// Debug : write other potential sequences to log for now
-	to := start + 10
-	if to > len(data) {
-		to = len(data)
-	}
-	log.Printf("Unhandled escape sequence ? %v | %+q |\n", data[start:to], string(data[start+2:to]))
-	// end debug
-
-	// no match
-	*i = start
-	return false
-}
-
-// apply VT100 color attributes
-// http://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-Functions-using-CSI-_-ordered-by-the-final-character_s_
-func (b *backendAppender) applyColors(colors ...int) {
-	t := core.Ed.Theme()
-	for _, color := range colors {
-		switch {
-		case color == 0: // normal
-			b.curFg = t.Fg
-			b.curBg = t.Bg
-		case color == 1: // bold
-			b.curFg = b.curFg.WithAttr(core.Bold)
-		case color == 39: // reset fg
-			b.curFg = t.Fg
-		case color == 49: // reset bg
-			b.curBg = t.Bg
-		case color >= 30 && color <= 37: // set fg
-			b.curFg = core.NewStyle(uint16(color - 30 + 8))
-		case color >= 40 && color <= 47: // set bg
-			b.curBg = core.NewStyle(uint16(color - 40 + 8))
-		case color >= 90 && color <= 97:
-			b.curFg = core.NewStyle(uint16(color - 90 + 8))
-		case color >= 100 && color <= 107:
-			b.curBg = core.NewStyle(uint16(color - 100 + 8))
-		}
-	}
-}
-
-func (b *backendAppender) consumeNb(data []byte, i *int) bool {
-	found := false
-	for *i < len(data) && data[*i] >= '0' && data[*i] <= '9' {
-		*i++
-		found = true
-	}
-	return found
+			t.eraseLine(t.y, 0, -1)
+		}
+	case 'L': // IL
+		if t.y >= t.marginTop && t.y <= t.marginBottom {
+			t.scrollDown(t.y, t.marginBottom, p(0, 1))
+			t.x = 0
+		}
+	case 'M': // DL
+		if t.y >= t.marginTop && t.y <= t.marginBottom {
+			t.scrollUp(t.y, t.marginBottom, p(0, 1))
+			t.x = 0
+		}
+	case 'P': // DCH
+		t.deleteChars(p(0, 1))
+	case 'S': // SU
+		t.scrollUp(t.marginTop, t.marginBottom, p(0, 1))
+	case 'T': // SD
+		t.scrollDown(t.marginTop, t.marginBottom, p(0, 1))
+	case 'X': // ECH
+		t.eraseLine(t.y, t.x, t.x+p(0, 1))
+	case 'Z': // CBT
+		for n := p(0, 1); n > 0 && t.x > 0; n-- {
+			t.x--
+			for t.x > 0 && !t.tabs[t.x] {
+				t.x--
+			}
+		}
+	case 'b': // REP
+		if t.last != 0 {
+			runes := make([]rune, t.clamp(p(0, 1), 0, t.cols*t.rows))
+			for i := range runes {
+				runes[i] = t.last
+			}
+			t.vtPrint(runes)
+		}
+	case 'c': // DA : VT220 with ANSI colors
+		if p(0, 0) == 0 {
+			t.replies = append(t.replies, "\x1b[?62;22c"...)
+		}
+	case 'd': // VPA
+		t.moveTo(p(0, 1)-1, t.x)
+	case 'g': // TBC
+		switch p(0, 0) {
+		case 0:
+			if t.x < t.cols {
+				t.tabs[t.x] = false
+			}
+		case 3:
+			t.tabs = make([]bool, t.cols)
+		}
+	case 'h', 'l': // SM, RM
+		for i := range params {
+			switch params[i][0] {
+			case 4:
+				t.insert = final == 'h'
+			case 20:
+				t.newline = final == 'h'
+			}
+		}
+	case 'm': // SGR
+		t.sgr(params)
+	case 'n': // DSR
+		switch p(0, 0) {
+		case 5: // status
+			t.replies = append(t.replies, "\x1b[0n"...)
+		case 6: // cursor position
+			y := t.y
+			if t.origin {
+				y -= t.marginTop
+			}
+			t.replies = append(t.replies, fmt.Sprintf("\x1b[%d;%dR", y+1, t.x+1)...)
+		}
+	case 'r': // DECSTBM
+		top, bottom := p(0, 1)-1, p(1, t.rows)-1
+		if bottom >= t.rows {
+			bottom = t.rows - 1
+		}
+		if top < bottom {
+			t.marginTop, t.marginBottom = top, bottom
+			t.moveTo(0, 0)
+		}
+	case 's': // SCOSC
+		t.saveCursor()
+	case 'u': // SCORC
+		t.restoreCursor()
+	case 't': // window operations
+		if p(0, 0) == 18 { // text area size
+			t.replies = append(t.replies, fmt.Sprintf("\x1b[8;%d;%dt", t.rows, t.cols)...)
+		}
+	}
+}
+
+func (t *vtTerm) vtOsc(data string) {
+	parts := strings.SplitN(data, ";", 2)
+	if len(parts) != 2 {
+		return
+	}
+	switch parts[0] {
+	case "0", "2": // icon name and window title, window title
+		title := parts[1]
+		t.title = &title
+	}
+}
+
+// ############## Operations
+
+// upperLimit is how far up the cursor may go : the top margin, unless
+// already above it.
+func (t *vtTerm) upperLimit() int {
+	if t.y >= t.marginTop {
+		return t.marginTop
+	}
+	return 0
 }
 
-func (b *backendAppender) consumeNbTuple(data []byte, i *int) bool {
-	return b.consumeNb(data, i) && b.consume(data, i, ';') &&
-		b.consumeNb(data, i)
+// lowerLimit is how far down the cursor may go : the bottom margin, unless
+// already below it.
+func (t *vtTerm) lowerLimit() int {
+	if t.y <= t.marginBottom {
+		return t.marginBottom
+	}
+	return t.rows - 1
 }
 
-func (b *backendAppender) consumeNbTriple(data []byte, i *int) bool {
-	return b.consumeNbTuple(data, i) && b.consume(data, i, ';') &&
-		b.consumeNb(data, i)
+// moveTo moves the cursor, relatively to the scroll region in origin mode.
+func (t *vtTerm) moveTo(y, x int) {
+	if t.origin {
+		t.y = t.clamp(y+t.marginTop, t.marginTop, t.marginBottom)
+	} else {
+		t.y = t.clamp(y, 0, t.rows-1)
+	}
+	t.x = t.clamp(x, 0, t.cols-1)
+	t.wrapNext = false
 }
 
-func (b *backendAppender) consume(data []byte, i *int, c byte) bool {
-	if *i >= len(data) {
-		return false
+func (t *vtTerm) tab(n int) {
+	for ; n > 0 && t.x < t.cols-1; n-- {
+		t.x++
+		for t.x < t.cols-1 && !t.tabs[t.x] {
+			t.x++
+		}
 	}
-	if data[*i] == c {
-		*i++
-		return true
+}
+
+func (t *vtTerm) lineFeed() {
+	switch {
+	case t.y == t.marginBottom:
+		t.scrollUp(t.marginTop, t.marginBottom, 1)
+	case t.y < t.rows-1:
+		t.y++
 	}
-	return false
 }
 
-func (b *backendAppender) consumeUntil(data []byte, i *int, c byte) {
-	for *i < len(data) && data[*i] != c {
-		*i++
+func (t *vtTerm) reverseIndex() {
+	switch {
+	case t.y == t.marginTop:
+		t.scrollDown(t.marginTop, t.marginBottom, 1)
+	case t.y > 0:
+		t.y--
 	}
-	return
 }
 
-func (b *backendAppender) readNb(data []byte, i int, defVal int) (nb, readTo int) {
-	if i >= len(data) || data[i] < '0' || data[i] > '9' {
-		return defVal, i
+// scrollUp scrolls the screen rows from..to up by n rows, blank rows coming
+// in at the bottom. Scrolling the whole primary screen moves the top rows
+// to the scrollback.
+func (t *vtTerm) scrollUp(from, to, n int) {
+	n = t.clamp(n, 0, to-from+1)
+	if n == 0 {
+		return
+	}
+	if t.alt == nil && from == 0 && to == t.rows-1 {
+		for y := t.rows; y < t.rows+n; y++ { // coming in, should be blank
+			if t.exists(y) {
+				t.eraseLine(y, 0, -1)
+			}
+		}
+		t.top += n
+		return
+	}
+	if !t.exists(from) {
+		return
+	}
+	b := t.b
+	r1, r2 := t.row(from), t.row(to)
+	for r := r1; r <= r2; r++ {
+		if r+n <= r2 {
+			b.text[r], b.colors[r] = b.text[r+n], b.colors[r+n]
+		} else {
+			b.text[r], b.colors[r] = []rune{}, []*color{}
+		}
 	}
-	n := 0
-	for data[i] >= '0' && data[i] <= '9' {
-		n = 10*n + int(data[i]-'0')
-		i++
+	t.blank(r2-n+1, r2)
+}
+
+// scrollDown scrolls the screen rows from..to down by n rows, blank rows
+// coming in at the top.
+func (t *vtTerm) scrollDown(from, to, n int) {
+	n = t.clamp(n, 0, to-from+1)
+	if n == 0 || !t.exists(from) {
+		return
+	}
+	b := t.b
+	r1, r2 := t.row(from), t.row(to)
+	for r := r2; r >= r1; r-- {
+		if r-n >= r1 {
+			b.text[r], b.colors[r] = b.text[r-n], b.colors[r-n]
+		} else {
+			b.text[r], b.colors[r] = []rune{}, []*color{}
+		}
+	}
+	t.blank(r1, r1+n-1)
+}
+
+// blank fills the buffer rows r1..r2 (new rows) with the current background
+// color if not the default one.
+func (t *vtTerm) blank(r1, r2 int) {
+	if t.bg == t.defBg {
+		return
+	}
+	for r := r1; r <= r2; r++ {
+		t.eraseLine(r-t.top, 0, -1)
+	}
+}
+
+// eraseLine erases the cells from..to (excluded, -1 for the end of the line)
+// of screen row y.
+func (t *vtTerm) eraseLine(y, from, to int) {
+	if to < 0 || to > t.cols {
+		to = t.cols
+	}
+	if from >= to {
+		return
+	}
+	if t.bg == t.defBg { // no need to add cells
+		if !t.exists(y) {
+			return
+		}
+		r := t.top + y
+		ln := t.b.text[r]
+		if to >= len(ln) {
+			if from < len(ln) {
+				t.b.text[r] = ln[:from]
+				if from < len(t.b.colors[r]) {
+					t.b.colors[r] = t.b.colors[r][:from]
+				}
+			}
+			return
+		}
+	}
+	r := t.row(y)
+	for x := from; x < to; x++ {
+		if t.bg == t.defBg && x >= len(t.b.text[r]) {
+			break
+		}
+		t.b.put(r, x, ' ', t.defFg, t.bg, t.colored)
 	}
-	return n, i
 }
 
-func (b *backendAppender) readNbTuple(data []byte, i int, defVal1, defVal2 int) (nb1, nb2, readTo int) {
-	n1, readTo := b.readNb(data, i, defVal1)
-	readTo++ //;
-	n2, readTo := b.readNb(data, readTo, defVal2)
-	return n1, n2, readTo
+// eraseDisplay erases below the cursor (0), above it (1), the screen (2) or
+// the scrollback (3).
+func (t *vtTerm) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(t.y, t.x, -1)
+		for y := t.y + 1; y < t.rows; y++ {
+			t.eraseLine(y, 0, -1)
+		}
+	case 1:
+		for y := 0; y < t.y; y++ {
+			t.eraseLine(y, 0, -1)
+		}
+		t.eraseLine(t.y, 0, t.x+1)
+	case 2:
+		for y := 0; y < t.rows; y++ {
+			t.eraseLine(y, 0, -1)
+		}
+	case 3:
+		if t.alt == nil && t.top > 0 {
+			b := t.b
+			b.text = append(b.text[:0:0], b.text[t.top:]...)
+			b.colors = append(b.colors[:0:0], b.colors[t.top:]...)
+			t.top = 0
+		}
+		return
+	}
+	t.trim()
+}
+
+// trim drops the blank rows at the end of the buffer, below the cursor.
+func (t *vtTerm) trim() {
+	b := t.b
+	last := len(b.text) - 1
+	for last > t.top+t.y && len(b.text[last]) == 0 {
+		last--
+	}
+	b.text, b.colors = b.text[:last+1], b.colors[:last+1]
+}
+
+// fill fills the screen with r (DECALN).
+func (t *vtTerm) fill(r rune) {
+	for y := 0; y < t.rows; y++ {
+		row := t.row(y)
+		for x := 0; x < t.cols; x++ {
+			t.b.put(row, x, r, t.defFg, t.defBg, t.colored)
+		}
+	}
+}
+
+// insertBlanks inserts n blank cells at the cursor, shifting the rest of the
+// line right.
+func (t *vtTerm) insertBlanks(n int) {
+	if !t.exists(t.y) {
+		return
+	}
+	r := t.top + t.y
+	ln := t.b.text[r]
+	if t.x >= len(ln) {
+		return
+	}
+	n = t.clamp(n, 0, t.cols-t.x)
+	blanks := make([]rune, n)
+	for i := range blanks {
+		blanks[i] = ' '
+	}
+	ln = append(ln[:t.x], append(blanks, ln[t.x:]...)...)
+	if len(ln) > t.cols {
+		ln = ln[:t.cols]
+	}
+	t.b.text[r] = ln
+	if colors := t.b.colors[r]; t.x < len(colors) {
+		colors = append(colors[:t.x], append(make([]*color, n), colors[t.x:]...)...)
+		if len(colors) > t.cols {
+			colors = colors[:t.cols]
+		}
+		t.b.colors[r] = colors
+	}
+}
+
+// deleteChars deletes n cells at the cursor, shifting the rest of the line
+// left.
+func (t *vtTerm) deleteChars(n int) {
+	if !t.exists(t.y) {
+		return
+	}
+	r := t.top + t.y
+	ln := t.b.text[r]
+	if t.x >= len(ln) {
+		return
+	}
+	to := t.clamp(t.x+n, t.x, len(ln))
+	t.b.text[r] = append(ln[:t.x], ln[to:]...)
+	if colors := t.b.colors[r]; t.x < len(colors) {
+		to = t.clamp(t.x+n, t.x, len(colors))
+		t.b.colors[r] = append(colors[:t.x], colors[to:]...)
+	}
+}
+
+func (t *vtTerm) saveCursor() {
+	t.saved = vtCursor{
+		y: t.y, x: t.x, wrapNext: t.wrapNext,
+		fg: t.fg, bg: t.bg, attrs: t.attrs, reverse: t.reverse,
+		origin: t.origin, charsets: t.charsets, charset: t.charset,
+	}
+}
+
+func (t *vtTerm) restoreCursor() {
+	s := t.saved
+	t.y, t.x = t.clamp(s.y, 0, t.rows-1), t.clamp(s.x, 0, t.cols-1)
+	t.wrapNext = s.wrapNext
+	t.fg, t.bg, t.attrs, t.reverse = s.fg, s.bg, s.attrs, s.reverse
+	t.origin, t.charsets, t.charset = s.origin, s.charsets, s.charset
+}
+
+func (t *vtTerm) setPrivateMode(mode int, on bool) {
+	switch mode {
+	case 1: // DECCKM
+		t.appCursor = on
+	case 6: // DECOM
+		t.origin = on
+		t.moveTo(0, 0)
+	case 7: // DECAWM
+		t.autowrap = on
+	case 25: // DECTCEM
+		t.cursorVisible = on
+	case 47, 1047: // alternate screen
+		if on {
+			t.enterAlt(mode == 1047)
+		} else {
+			t.leaveAlt()
+		}
+	case 1048:
+		if on {
+			t.saveCursor()
+		} else {
+			t.restoreCursor()
+		}
+	case 1049: // alternate screen, saving the cursor
+		if on {
+			t.saveCursor()
+			t.enterAlt(true)
+		} else {
+			t.leaveAlt()
+			t.restoreCursor()
+		}
+	case vtMouseX10, vtMouseNormal, vtMouseButton, vtMouseAny:
+		t.mouse = vtMouseOff
+		if on {
+			t.mouse = mode
+		}
+	case 1006:
+		t.mouseSgr = on
+	case 2004:
+		t.bracketedPaste = on
+	}
+}
+
+// enterAlt switches to the alternate screen, the primary screen rows are
+// put aside until leaveAlt.
+func (t *vtTerm) enterAlt(clear bool) {
+	if t.alt != nil {
+		if clear {
+			t.eraseDisplay(2)
+		}
+		return
+	}
+	b := t.b
+	from := t.clamp(t.top, 0, len(b.text))
+	t.alt = &vtScreen{
+		text:   append([][]rune{}, b.text[from:]...),
+		colors: append([][]*color{}, b.colors[from:]...),
+		cursor: vtCursor{y: t.y, x: t.x, wrapNext: t.wrapNext},
+	}
+	b.text, b.colors = b.text[:from], b.colors[:from]
+	t.row(0) // the screen first row
+}
+
+// leaveAlt switches back to the primary screen.
+func (t *vtTerm) leaveAlt() {
+	if t.alt == nil {
+		return
+	}
+	b := t.b
+	from := t.clamp(t.top, 0, len(b.text))
+	b.text = append(b.text[:from], t.alt.text...)
+	b.colors = append(b.colors[:from], t.alt.colors...)
+	if len(b.text) == 0 {
+		t.row(0)
+	}
+	c := t.alt.cursor
+	t.y, t.x, t.wrapNext = c.y, c.x, c.wrapNext
+	t.alt = nil
+	t.marginTop, t.marginBottom = 0, t.rows-1
+}
+
+// sgr applies graphic rendition parameters : attributes and colors.
+func (t *vtTerm) sgr(params [][]int) {
+	if len(params) == 0 {
+		params = [][]int{{0}}
+	}
+	for i := 0; i < len(params); i++ {
+		group := params[i]
+		switch n := group[0]; {
+		case n == 0:
+			t.fg, t.bg, t.attrs, t.reverse = t.defFg, t.defBg, 0, false
+		case n == 1:
+			t.attrs |= core.Bold
+		case n == 4:
+			t.attrs |= core.Underlined
+		case n == 7:
+			t.reverse = true
+		case n == 22:
+			t.attrs &^= core.Bold
+		case n == 24:
+			t.attrs &^= core.Underlined
+		case n == 27:
+			t.reverse = false
+		case n >= 30 && n <= 37:
+			t.fg = core.NewStyle(uint16(n - 30 + 8))
+		case n == 38, n == 48:
+			var c core.Style
+			var ok bool
+			if len(group) > 1 { // colon form : 38:5:n, 38:2:[cs:]r:g:b
+				c, ok = vtExtendedColor(group[1:])
+			} else {
+				rest := []int{}
+				for _, g := range params[i+1:] {
+					rest = append(rest, g[0])
+				}
+				var used int
+				c, ok, used = vtExtendedColorSemicolon(rest)
+				i += used
+			}
+			if ok && n == 38 {
+				t.fg = c
+			} else if ok {
+				t.bg = c
+			}
+		case n == 39:
+			t.fg = t.defFg
+		case n >= 40 && n <= 47:
+			t.bg = core.NewStyle(uint16(n - 40 + 8))
+		case n == 49:
+			t.bg = t.defBg
+		case n >= 90 && n <= 97:
+			t.fg = core.NewStyle(uint16(n - 90 + 8))
+		case n >= 100 && n <= 107:
+			t.bg = core.NewStyle(uint16(n - 100 + 8))
+		}
+	}
+}
+
+// vtExtendedColor decodes a colon separated extended color : 5:n or
+// 2:[colorspace:]r:g:b.
+func vtExtendedColor(p []int) (core.Style, bool) {
+	switch {
+	case p[0] == 5 && len(p) >= 2:
+		return core.NewStyle(uint16(p[1] & 0xFF)), true
+	case p[0] == 2 && len(p) >= 5:
+		return vtRgb(p[2], p[3], p[4]), true
+	case p[0] == 2 && len(p) == 4:
+		return vtRgb(p[1], p[2], p[3]), true
+	}
+	return core.Style{}, false
+}
+
+// vtExtendedColorSemicolon decodes a semicolon separated extended color :
+// 5;n or 2;r;g;b, returns how many params were used.
+func vtExtendedColorSemicolon(p []int) (c core.Style, ok bool, used int) {
+	switch {
+	case len(p) >= 2 && p[0] == 5:
+		return core.NewStyle(uint16(p[1] & 0xFF)), true, 2
+	case len(p) >= 4 && p[0] == 2:
+		return vtRgb(p[1], p[2], p[3]), true, 4
+	}
+	return c, false, len(p)
+}
+
+// vtRgb returns the xterm 256 colors palette color closest to a true color.
+func vtRgb(r, g, b int) core.Style {
+	// 6x6x6 color cube (16-231) levels
+	levels := []int{0, 95, 135, 175, 215, 255}
+	level := func(v int) int {
+		best := 0
+		for i, l := range levels {
+			if abs(v-l) < abs(v-levels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cr, cg, cb := level(r), level(g), level(b)
+	cube := 16 + 36*cr + 6*cg + cb
+	dist := func(r2, g2, b2 int) int {
+		return (r-r2)*(r-r2) + (g-g2)*(g-g2) + (b-b2)*(b-b2)
+	}
+	best, bestDist := cube, dist(levels[cr], levels[cg], levels[cb])
+	// grayscale ramp (232-255)
+	for i := 0; i < 24; i++ {
+		v := 8 + 10*i
+		if d := dist(v, v, v); d < bestDist {
+			best, bestDist = 232+i, d
+		}
+	}
+	return core.NewStyle(uint16(best))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vtLineDrawing maps the DEC special graphics characters.
+func vtLineDrawing(r rune) rune {
+	if r < '`' || r > '~' {
+		return r
+	}
+	return []rune("◆▒␉␌␍␊°±␤␋┘┐┌└┼⎺⎻─⎼⎽├┤┴┬│≤≥π≠£·")[r-'`']
+}
+
+// ############## Input
+
+// Mouse buttons, as encoded by xterm.
+const (
+	VtMouseLeft      = 0
+	VtMouseMiddle    = 1
+	VtMouseRight     = 2
+	VtMouseWheelUp   = 64
+	VtMouseWheelDown = 65
+)
+
+// mouseEvent encodes a mouse event at a buffer position, if the program asked
+// for it. Returns nil otherwise.
+func (t *vtTerm) mouseEvent(row, col, button int, drag, release bool) []byte {
+	y := row - t.top
+	if t.mouse == vtMouseOff || y < 0 || y >= t.rows || col < 0 || col >= t.cols {
+		return nil
+	}
+	wheel := button >= VtMouseWheelUp
+	switch {
+	case drag && t.mouse < vtMouseButton,
+		release && (wheel || t.mouse == vtMouseX10):
+		return nil
+	}
+	if drag {
+		button += 32
+	}
+	if t.mouseSgr {
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", button, col+1, y+1, final))
+	}
+	if release {
+		button = 3
+	}
+	enc := func(v int) byte {
+		if v > 223 {
+			v = 223
+		}
+		return byte(32 + v)
+	}
+	return []byte{0x1b, '[', 'M', enc(button), enc(col + 1), enc(y + 1)}
 }
 
-func (b *backendAppender) readNbTriple(data []byte, i int, defVal1, defVal2, defVal3 int) (int, int, int) {
-	n1, n2, readTo := b.readNbTuple(data, i, defVal1, defVal2)
-	readTo++ // ;
-	n3, readTo := b.readNb(data, readTo, defVal3)
-	return n1, n2, n3
+// paste encodes pasted text, bracketed if the program asked for it.
+func (t *vtTerm) paste(text string) []byte {
+	text = strings.Replace(text, "\r\n", "\r", -1)
+	text = strings.Replace(text, "\n", "\r", -1)
+	if t.bracketedPaste {
+		// no escape sequences : a pasted "\x1b[201~" would end the bracket
+		text = strings.Replace(text, "\x1b", "", -1)
+		text = "\x1b[200~" + text + "\x1b[201~"
+	}
+	return []byte(text)
 }
@@ -0,0 +1,413 @@
+package backend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kr/pty"
+	"github.com/tcolar/goed/assert"
+	"github.com/tcolar/goed/core"
+	. "gopkg.in/check.v1"
+)
+
+var vtFg, vtBg = core.NewStyle(1), core.NewStyle(2)
+
+// vtRecorder records what the parser decodes.
+type vtRecorder struct {
+	events []string
+}
+
+func (r *vtRecorder) vtPrint(runes []rune) {
+	r.events = append(r.events, "print:"+string(runes))
+}
+
+func (r *vtRecorder) vtExecute(c byte) {
+	r.events = append(r.events, fmt.Sprintf("exec:%d", c))
+}
+
+func (r *vtRecorder) vtEsc(intermediates []byte, final byte) {
+	r.events = append(r.events, fmt.Sprintf("esc:%s%c", intermediates, final))
+}
+
+func (r *vtRecorder) vtCsi(private byte, params [][]int, intermediates []byte, final byte) {
+	p := ""
+	if private != 0 {
+		p = string(private)
+	}
+	r.events = append(r.events, fmt.Sprintf("csi:%s%v%s%c", p, params, intermediates, final))
+}
+
+func (r *vtRecorder) vtOsc(data string) {
+	r.events = append(r.events, "osc:"+data)
+}
+
+func newTestVtTerm() (*vtTerm, *BackendCmd) {
+	m, _ := NewMemBackend("", id)
+	b := &BackendCmd{MemBackend: m}
+	return newVtTerm(b, vtFg, vtBg, true), b
+}
+
+// replay feeds a recorded program output, up to cut if not empty.
+func replay(t *C, term *vtTerm, file, cut string) {
+	data, err := ioutil.ReadFile("../test_data/vt/" + file)
+	assert.Nil(t, err)
+	if cut != "" {
+		i := strings.Index(string(data), cut)
+		assert.True(t, i > 0)
+		data = data[:i]
+	}
+	term.Write(data)
+}
+
+func screenLines(term *vtTerm) []string {
+	lines := []string{}
+	for i := term.top; i < len(term.b.text); i++ {
+		lines = append(lines, string(term.b.text[i]))
+	}
+	return lines
+}
+
+func vtColorAt(b *BackendCmd, ln, col int) (fg, bg core.Style) {
+	if ln >= len(b.colors) || col >= len(b.colors[ln]) || b.colors[ln][col] == nil {
+		return vtFg, vtBg
+	}
+	return b.colors[ln][col].fg, b.colors[ln][col].bg
+}
+
+func (bs *BackendSuite) TestVtParser(t *C) {
+	r := &vtRecorder{}
+	p := newVtParser(r)
+	p.Parse([]byte("ab\x1b[1;38:2::1:2:3mc\x1b[?25l\x1b(0\x1b]0;title\x07"))
+	assert.DeepEq(t, r.events, []string{
+		"print:ab", "csi:[[1] [38 2 0 1 2 3]]m", "print:c", "csi:?[[25]]l",
+		"esc:(0", "osc:0;title"})
+	// sequences and runes split across writes
+	r.events = nil
+	for _, s := range []string{"\x1b", "[3", "1mé"[:3], "1mé"[3:], "\x1b]2;a", "b\x1b", "\\x\r"} {
+		p.Parse([]byte(s))
+	}
+	assert.DeepEq(t, r.events, []string{
+		"csi:[[31]]m", "print:é", "osc:2;ab", "print:x", "exec:13"})
+	// CAN aborts a sequence, ESC restarts one, DEL is ignored
+	r.events = nil
+	p.Parse([]byte("\x1b[12\x18a\x1b[1\x1b[2J\x7f"))
+	assert.DeepEq(t, r.events, []string{"print:a", "csi:[[2]]J"})
+	// the ignored strings (DCS)
+	r.events = nil
+	p.Parse([]byte("\x1bPzz\x1b\\ok"))
+	assert.DeepEq(t, r.events, []string{"print:ok"})
+}
+
+func (bs *BackendSuite) TestVtRecordings(t *C) {
+	// ls --color
+	term, b := newTestVtTerm()
+	replay(t, term, "ls.out", "")
+	assert.DeepEq(t, screenLines(term), []string{"a.txt  b.go  dir1/  link@  nums.txt  run.sh*"})
+	fg, bg := vtColorAt(b, 0, 13) // dir1 : bold blue
+	assert.Eq(t, fg, core.NewStyle(12).WithAttr(core.Bold))
+	assert.Eq(t, bg, vtBg)
+	fg, _ = vtColorAt(b, 0, 17) // "/"
+	assert.Eq(t, fg, vtFg)
+
+	// less : alternate screen, restored on exit
+	term, b = newTestVtTerm()
+	replay(t, term, "less.out", "\x1b[?1049l")
+	assert.NotNil(t, term.alt)
+	lines := screenLines(term)
+	assert.Eq(t, len(lines), 24)
+	assert.Eq(t, lines[0], "24")
+	assert.Eq(t, lines[22], "46")
+	assert.Eq(t, lines[23], "") // prompt erased
+	term, b = newTestVtTerm()
+	replay(t, term, "less.out", "")
+	assert.Nil(t, term.alt)
+	assert.DeepEq(t, screenLines(term), []string{"before", "after"})
+
+	// git log pager (less -FRX) : scrolling the primary screen
+	term, b = newTestVtTerm()
+	replay(t, term, "gitlog.out", "")
+	assert.Eq(t, len(b.text), 47)
+	assert.Eq(t, term.top, 23)
+	assert.Eq(t, string(b.text[0]), "commit 2c048268d1a267ab4c6ecf9a72b2ab2f4e7c0a78 (HEAD -> master)")
+	assert.Eq(t, string(b.text[23]), "") // ':' prompt erased
+	assert.Eq(t, string(b.text[24]), "commit f6b6e1fbed076744dbd7afa141084218fe80285d")
+	fg, _ = vtColorAt(b, 0, 0)
+	assert.Eq(t, fg, core.NewStyle(11))
+	fg, _ = vtColorAt(b, 0, 57) // master
+	assert.Eq(t, fg, core.NewStyle(10).WithAttr(core.Bold))
+
+	// vim : scroll regions, insert mode, mouse mode
+	term, b = newTestVtTerm()
+	replay(t, term, "vim.out", ":q!\r")
+	lines = screenLines(term)
+	assert.Eq(t, len(lines), 24)
+	assert.Eq(t, lines[0], "hello2")
+	assert.Eq(t, lines[1], "3")
+	assert.Eq(t, lines[22], "24")
+	assert.Eq(t, lines[23], "")
+	assert.Eq(t, term.mouse, vtMouseButton)
+	assert.True(t, term.mouseSgr)
+	assert.True(t, term.bracketedPaste)
+	assert.True(t, term.appCursor)
+	term, b = newTestVtTerm()
+	replay(t, term, "vim.out", "")
+	assert.DeepEq(t, screenLines(term), []string{"before", "after"})
+	assert.Eq(t, term.mouse, vtMouseOff)
+	assert.False(t, term.bracketedPaste)
+	assert.False(t, term.appCursor)
+
+	// tmux : status line, scroll region
+	term, b = newTestVtTerm()
+	replay(t, term, "tmux.out", "\x1b[?1l\x1b>\x1b[H\x1b[2J")
+	lines = screenLines(term)
+	assert.Eq(t, len(lines), 24)
+	assert.Eq(t, lines[0], "9")
+	assert.Eq(t, lines[21], "30")
+	assert.True(t, strings.HasPrefix(lines[23], "[0] 0:sh*"))
+	fg, bg = vtColorAt(b, term.top+23, 0)
+	assert.Eq(t, fg, core.NewStyle(8))
+	assert.Eq(t, bg, core.NewStyle(10))
+	term, b = newTestVtTerm()
+	replay(t, term, "tmux.out", "")
+	assert.DeepEq(t, screenLines(term), []string{"before", "[exited]", "after"})
+
+	// top : full screen redraws on the primary screen
+	term, b = newTestVtTerm()
+	replay(t, term, "top.out", "")
+	assert.Eq(t, len(b.text), 25)
+	assert.True(t, strings.HasPrefix(string(b.text[0]), "top - "))
+	assert.True(t, strings.HasPrefix(string(b.text[6]), "  PID USER"))
+	assert.Eq(t, string(b.text[24]), "after")
+	fg, _ = vtColorAt(b, 1, 8) // total count : bold
+	assert.True(t, fg.IsBold())
+	assert.True(t, term.cursorVisible)
+}
+
+func (bs *BackendSuite) TestVtColors(t *C) {
+	term, b := newTestVtTerm()
+	term.Write([]byte("\x1b[38;5;196ma\x1b[48;2;128;128;128mb\x1b[38:2::255:0:0;49mc" +
+		"\x1b[0;7md\x1b[27;1;4;31;42me\x1b[22;24;27;39mf\x1b[m\x1b[44m\x1b[K"))
+	expected := []struct{ fg, bg core.Style }{
+		{core.NewStyle(196), vtBg},
+		{core.NewStyle(196), core.NewStyle(244)},
+		{core.NewStyle(196), vtBg},
+		{vtBg, vtFg}, // reverse
+		{core.NewStyle(9).WithAttr(core.Bold | core.Underlined), core.NewStyle(10)},
+		{vtFg, core.NewStyle(10)},
+		{vtFg, core.NewStyle(12)}, // erased with the current background
+	}
+	for i, e := range expected {
+		fg, bg := vtColorAt(b, 0, i)
+		assert.Eq(t, fg, e.fg)
+		assert.Eq(t, bg, e.bg)
+	}
+	assert.Eq(t, len(b.text[0]), 80)
+	assert.Eq(t, vtRgb(0, 0, 0), core.NewStyle(16))
+	assert.Eq(t, vtRgb(255, 255, 255), core.NewStyle(231))
+	assert.Eq(t, vtRgb(95, 135, 175), core.NewStyle(67))
+}
+
+func (bs *BackendSuite) TestVtEditing(t *C) {
+	term, b := newTestVtTerm()
+	// insert / delete lines and chars within a scroll region
+	term.Write([]byte("1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[2;1H\x1b[L\x1b[4;1H\x1b[M"))
+	assert.DeepEq(t, screenLines(term), []string{"1", "", "2", "", "5"})
+	term.Write([]byte("\x1b[r\x1b[1;1Habcdef\x1b[1;2H\x1b[2P\x1b[2@\x1b[4hX\x1b[4l"))
+	assert.Eq(t, string(b.text[0]), "aX  def")
+	// wrapping, line drawing, tabs, erase display
+	term.Write([]byte("\x1b[5;79Hxyz\x1b(0qx\x1b(B\tT"))
+	lines := screenLines(term)
+	assert.Eq(t, len(lines), 6)
+	assert.Eq(t, lines[4], "5"+strings.Repeat(" ", 77)+"xy")
+	assert.Eq(t, lines[5], "z─│     T")
+	term.Write([]byte("\x1b[6;3H\x1b[1J"))
+	lines = screenLines(term)
+	assert.Eq(t, lines[4], "")
+	assert.Eq(t, lines[5], "        T")
+	term.Write([]byte("\x1b[2J\x1b[3J"))
+	assert.Eq(t, term.top, 0)
+	assert.DeepEq(t, screenLines(term), []string{"", "", "", "", "", ""})
+	// scrolling the primary screen keeps the scrollback
+	term, b = newTestVtTerm()
+	for i := 1; i <= 30; i++ {
+		term.Write([]byte(fmt.Sprintf("%d\r\n", i)))
+	}
+	assert.Eq(t, term.top, 7)
+	assert.Eq(t, len(b.text), 30)
+	row, col := term.Cursor()
+	assert.Eq(t, row, 30)
+	assert.Eq(t, col, 0)
+	// unless the program scrolls a region
+	term.Write([]byte("\x1b[1;3r\x1b[3;1H\n\x1b[r"))
+	assert.Eq(t, term.top, 7)
+	assert.Eq(t, string(b.text[7]), "9")
+	assert.Eq(t, string(b.text[9]), "")
+	// MaxRows
+	term, b = newTestVtTerm()
+	b.MaxRows = 30
+	for i := 1; i <= 50; i++ {
+		term.Write([]byte(fmt.Sprintf("%d\r\n", i)))
+	}
+	assert.Eq(t, len(b.text), 30)
+	assert.Eq(t, string(b.text[0]), "21")
+	assert.Eq(t, term.top, 7)
+	term.Write([]byte("\x1b[Hx"))
+	assert.Eq(t, string(b.text[7]), "x8")
+}
+
+func (bs *BackendSuite) TestCmdOutput(t *C) {
+	term, b := newTestVtTerm()
+	b.MaxRows, b.MaxLogSize = 30, 20
+	for i := 1; i <= 50; i++ {
+		term.Write([]byte(fmt.Sprintf("%d\r\n", i)))
+	}
+	assert.Eq(t, b.Dropped(), 20)
+	assert.Eq(t, string(b.text[0]), "21")
+	// dropped rows logged, rotated once : 1 to 10 are gone
+	logLoc := BufferFile(id) + ".log"
+	data, err := ioutil.ReadFile(logLoc + ".1")
+	assert.Nil(t, err)
+	assert.Eq(t, string(data), "11\n12\n13\n14\n15\n16\n17\n")
+	loc := path.Join(t.MkDir(), "out.txt")
+	assert.Nil(t, b.SaveOutput(loc))
+	data, err = ioutil.ReadFile(loc)
+	assert.Nil(t, err)
+	expected := ""
+	for i := 11; i <= 50; i++ {
+		expected += fmt.Sprintf("%d\n", i)
+	}
+	assert.Eq(t, string(data), expected)
+	// the log snapshot is not affected by later rows, nor rotation
+	r, done, err := b.outLog.snapshot()
+	assert.Nil(t, err)
+	for i := 51; i <= 60; i++ {
+		b.outLog.write([][]rune{[]rune(fmt.Sprintf("%d", i))})
+	}
+	data, err = ioutil.ReadAll(r)
+	done()
+	assert.Nil(t, err)
+	assert.Eq(t, string(data), expected[:len(expected)-len("21\n")*30])
+	// pinning
+	assert.False(t, b.Pinned())
+	b.SetPinned(true)
+	assert.True(t, b.Pinned())
+	b.SetPinned(false)
+	assert.False(t, b.Pinned())
+	// wipe
+	b.Wipe()
+	assert.Eq(t, b.Dropped(), 0)
+	_, err = os.Stat(logLoc)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(logLoc + ".1")
+	assert.True(t, os.IsNotExist(err))
+	// no log
+	term, b = newTestVtTerm()
+	b.MaxRows = 30
+	for i := 1; i <= 50; i++ {
+		term.Write([]byte(fmt.Sprintf("%d\r\n", i)))
+	}
+	assert.Nil(t, b.SaveOutput(loc))
+	data, _ = ioutil.ReadFile(loc)
+	assert.True(t, strings.HasPrefix(string(data), "21\n22\n"))
+	_, err = os.Stat(logLoc)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func (bs *BackendSuite) TestVtInput(t *C) {
+	term, _ := newTestVtTerm()
+	var title string
+	var replies []string
+	bell := false
+	term.onTitle = func(s string) { title = s }
+	term.onBell = func() { bell = true }
+	term.reply = func(data []byte) { replies = append(replies, string(data)) }
+	term.Write([]byte("ab\x1b]2;my title\x1b\\\x07\x1b[6n\x1b[c"))
+	assert.Eq(t, title, "my title")
+	assert.True(t, bell)
+	assert.DeepEq(t, replies, []string{"\x1b[1;3R\x1b[?62;22c"})
+
+	// mouse
+	assert.Nil(t, term.mouseEvent(0, 0, VtMouseLeft, false, false))
+	term.Write([]byte("\x1b[?1000h"))
+	assert.Eq(t, string(term.mouseEvent(2, 4, VtMouseLeft, false, false)), "\x1b[M %#")
+	assert.Eq(t, string(term.mouseEvent(2, 4, VtMouseLeft, false, true)), "\x1b[M#%#")
+	assert.Nil(t, term.mouseEvent(2, 4, VtMouseLeft, true, false))
+	term.Write([]byte("\x1b[?1002h\x1b[?1006h"))
+	assert.Eq(t, string(term.mouseEvent(2, 4, VtMouseLeft, true, false)), "\x1b[<32;5;3M")
+	assert.Eq(t, string(term.mouseEvent(2, 4, VtMouseRight, false, true)), "\x1b[<2;5;3m")
+	assert.Eq(t, string(term.mouseEvent(0, 0, VtMouseWheelUp, false, false)), "\x1b[<64;1;1M")
+	assert.Nil(t, term.mouseEvent(30, 0, VtMouseLeft, false, false)) // off screen
+
+	// paste
+	assert.Eq(t, string(term.paste("a\nb")), "a\rb")
+	term.Write([]byte("\x1b[?2004h"))
+	assert.Eq(t, string(term.paste("a\r\nb")), "\x1b[200~a\rb\x1b[201~")
+	assert.Eq(t, string(term.paste("a\x1b[201~rm -rf ~\n")), "\x1b[200~a[201~rm -rf ~\r\x1b[201~")
+}
+
+func waitFor(cond func() bool) bool {
+	end := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(end) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return true
+}
+
+func (bs *BackendSuite) TestTermPty(t *C) {
+	term, b := newTestVtTerm()
+	b.term = term
+	b.runner = exec.Command("sh", "-i")
+	b.runner.Env = []string{"PS1=$ ", "TERM=xterm-256color", "PATH=/bin:/usr/bin"}
+	var err error
+	b.pty, err = pty.StartWithSize(b.runner, &pty.Winsize{Rows: 24, Cols: 80})
+	assert.Nil(t, err)
+	defer b.runner.Process.Kill()
+	go b.runner.Wait()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := b.pty.Read(buf)
+			if err != nil {
+				return
+			}
+			term.Write(buf[:n])
+		}
+	}()
+
+	b.TermResize(30, 100)
+	rows, cols, err := pty.Getsize(b.pty)
+	assert.Nil(t, err)
+	assert.Eq(t, rows, 30)
+	assert.Eq(t, cols, 100)
+	assert.Eq(t, term.rows, 30)
+	assert.Eq(t, b.MemBackend.vtCols, 100)
+	b.SetVtCols(90)
+	rows, cols, _ = pty.Getsize(b.pty)
+	assert.Eq(t, rows, 30)
+	assert.Eq(t, cols, 90)
+
+	assert.False(t, b.SubCmdRunning())
+	b.SendBytes([]byte("stty size; sleep 30\n"))
+	assert.True(t, waitFor(b.SubCmdRunning))
+	assert.True(t, waitFor(func() bool {
+		b.MemBackend.lock.Lock()
+		defer b.MemBackend.lock.Unlock()
+		for _, l := range b.text {
+			if strings.HasSuffix(string(l), "30 90") { // maybe after the prompt
+				return true
+			}
+		}
+		return false
+	}))
+	assert.Nil(t, b.TermSignal("INT"))
+	assert.True(t, waitFor(func() bool { return !b.SubCmdRunning() }))
+	assert.NotNil(t, b.TermSignal("FOO"))
+}
@@ -5,14 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
-	"strings"
 	"sync"
 	"unicode/utf8"
 
 	"golang.org/x/text/encoding/unicode"
 
-	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
@@ -26,6 +23,7 @@ type FileBackend struct {
 	file      core.Rwsc //ReaderWriterSeekerCloser
 	viewId    int64
 	textInfo  *core.TextInfo
+	stamp     core.FileStamp
 
 	bufferSize int64 // Internal buffer size for file ops
 
@@ -76,7 +74,6 @@ func (f *FileBackend) ColorAt(ln, col int) (fg, bg core.Style) {
 func (b *FileBackend) Reload() error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
-	// TODO : check dirty
 	b.Close()
 	fb := BufferFile(b.viewId)
 	b.bufferLoc = fb
@@ -85,6 +82,7 @@ func (b *FileBackend) Reload() error {
 		// make sure there is no existing buffer content
 		os.Remove(fb)
 	}
+	b.stamp = core.StampFile(b.srcLoc)
 	newFile := false
 	if _, err := os.Stat(b.srcLoc); os.IsNotExist(err) {
 		newFile = true
@@ -271,26 +269,58 @@ func (f *FileBackend) Save(loc string) error {
 	if loc == f.bufferLoc {
 		return nil // editing in place
 	}
-	_, err := os.Stat(loc)
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(path.Dir(loc), 0750); err != nil {
+	if err := checkStamp(f.stamp, f.srcLoc, loc); err != nil {
+		return err
+	}
+	err := saveFile(loc, func(w io.Writer) error {
+		in, err := os.Open(f.bufferLoc)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if f.textInfo.Enc == nil {
+			_, err = io.Copy(w, in)
+			return err
+		}
+		ew := f.textInfo.Enc.NewEncoder().Writer(w)
+		if _, err = io.Copy(ew, in); err != nil {
 			return err
 		}
+		if c, ok := ew.(io.Closer); ok {
+			return c.Close() // flush
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	f.srcLoc = loc
+	f.stamp = core.StampFile(loc)
+	return nil
+}
 
-	err = core.CopyFromUTF8(f.bufferLoc, loc, f.textInfo.Enc)
-	// some sort of rsync would be nice ?
-	if err != nil {
-		return err
+func (f *FileBackend) IsStale() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.bufferLoc == f.srcLoc {
+		return false // editing in place
 	}
-	// temporary hack for go format
-	// should hook-up through action/eventing
-	if strings.HasSuffix(loc, ".go") {
-		go actions.ExecScript("goimports.sh")
+	return len(f.srcLoc) > 0 && f.stamp.Changed(f.srcLoc)
+}
+
+func (f *FileBackend) SetSrcLoc(loc string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.bufferLoc == f.srcLoc {
+		f.bufferLoc = loc
 	}
+	f.srcLoc = loc
+}
 
-	return nil
+func (f *FileBackend) Stamp() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stamp = core.StampFile(f.srcLoc)
 }
 
 func (f *FileBackend) ViewId() int64 {
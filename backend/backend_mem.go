@@ -1,12 +1,12 @@
 package backend
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
-	"path"
 	"sync"
-	"unicode/utf8"
 
 	"github.com/tcolar/goed/core"
 )
@@ -18,6 +18,7 @@ type MemBackend struct {
 	text   [][]rune
 	colors [][]*color
 	file   string
+	stamp  core.FileStamp
 	viewId int64
 	lock   sync.Mutex
 	vtCols int
@@ -63,6 +64,7 @@ func (m *MemBackend) Reload() error {
 	if len(m.file) == 0 {
 		return nil
 	}
+	m.stamp = core.StampFile(m.file)
 	if _, err := os.Stat(m.file); os.IsNotExist(err) {
 		return nil
 	}
@@ -93,35 +95,52 @@ func (b *MemBackend) Save(loc string) error {
 	if len(loc) == 0 {
 		return fmt.Errorf("Save where ? Use save [path]")
 	}
-	_, err := os.Stat(loc)
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(path.Dir(loc), 0750); err != nil {
-			return err
-		}
+	if err := checkStamp(b.stamp, b.file, loc); err != nil {
+		return err
 	}
-	f, err := os.Create(loc)
-	if err != nil {
-		return fmt.Errorf("Saving Failed ! %v", loc)
-	}
-	defer f.Close()
-	buf := make([]byte, 4)
-	for i, l := range b.text {
-		for _, c := range l {
-			n := utf8.EncodeRune(buf, c)
-			_, err := f.Write(buf[0:n])
-			if err != nil {
-				return fmt.Errorf("Saved Failed failed %v", err.Error())
+	err := saveFile(loc, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		for i, l := range b.text {
+			for _, c := range l {
+				if _, err := w.WriteRune(c); err != nil {
+					return err
+				}
+			}
+			if i != b.lineCount() || len(l) != 0 {
+				w.WriteString("\n")
 			}
 		}
-		if i != b.LineCount() || len(l) != 0 {
-			f.WriteString("\n")
-		}
+		return w.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("Saving failed ! %v", err.Error())
 	}
 	b.file = loc
-	core.Ed.SetStatus("Saved " + b.file)
+	b.stamp = core.StampFile(loc)
+	if core.Ed != nil {
+		core.Ed.SetStatus("Saved " + b.file)
+	}
 	return nil
 }
 
+func (b *MemBackend) Stamp() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.stamp = core.StampFile(b.file)
+}
+
+func (b *MemBackend) IsStale() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.file) > 0 && b.stamp.Changed(b.file)
+}
+
+func (b *MemBackend) SetSrcLoc(loc string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.file = loc
+}
+
 func (b *MemBackend) SrcLoc() string {
 	return b.file
 }
@@ -272,6 +291,11 @@ func (b *MemBackend) Slice(row, col, row2, col2 int) *core.Slice {
 func (b *MemBackend) LineCount() int {
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	return b.lineCount()
+}
+
+// lineCount is LineCount, b.lock being held.
+func (b *MemBackend) lineCount() int {
 	count := len(b.text)
 	if count > 0 {
 		last := len(b.text) - 1
@@ -0,0 +1,526 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/tcolar/goed/core"
+)
+
+var _ core.Backend = (*PieceBackend)(nil)
+
+// PieceBackend is a backend implementation using a piece table.
+// The original file is never modified, edits are recorded as spans over
+// the original file and an append-only "add" buffer and are only written
+// out on Save. Well suited to very large files.
+// Note that the original is read as needed, so it must not be rewritten in
+// place by other programs while edited (replacing it is fine).
+type PieceBackend struct {
+	srcLoc   string
+	origLoc  string   // UTF8 version of the original, read only
+	orig     *os.File // original content
+	origNl   []int64  // offsets of '\n' in orig
+	add      []byte   // added content
+	addNl    []int64  // offsets of '\n' in add
+	pieces   []piece
+	viewId   int64
+	textInfo *core.TextInfo
+	stamp    core.FileStamp
+	length   int64
+	lnCount  int
+	lock     sync.Mutex
+}
+
+// piece is a span of either the original or the add buffer
+type piece struct {
+	add    bool // whether in the add buffer (otherwise orig)
+	start  int64
+	length int64
+	lines  int // number of '\n' in the span
+}
+
+// NewPieceBackend creates a piece table backend over the given file.
+func NewPieceBackend(loc string, viewId int64) (*PieceBackend, error) {
+	b := &PieceBackend{
+		srcLoc:   loc,
+		viewId:   viewId,
+		textInfo: core.CrLfTextInfo(unicode.UTF8, false),
+	}
+	err := b.Reload()
+	return b, err
+}
+
+func (b *PieceBackend) SrcLoc() string {
+	return b.srcLoc
+}
+
+func (b *PieceBackend) BufferLoc() string {
+	return b.origLoc
+}
+
+func (b *PieceBackend) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.closeOrig()
+}
+
+func (b *PieceBackend) closeOrig() error {
+	if b.orig == nil {
+		return nil
+	}
+	err := b.orig.Close()
+	b.orig = nil
+	return err
+}
+
+func (b *PieceBackend) ColorAt(ln, col int) (fg, bg core.Style) {
+	return core.Ed.Theme().Fg, core.Ed.Theme().Bg
+}
+
+// Reload drops all the edits and goes back to the file content.
+func (b *PieceBackend) Reload() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if err := b.load(); err != nil {
+		return err
+	}
+	if core.Ed != nil {
+		v := core.Ed.ViewById(b.viewId)
+		if v != nil {
+			v.SetDirty(false)
+		}
+	}
+	return nil
+}
+
+func (b *PieceBackend) load() error {
+	b.closeOrig()
+	b.origLoc, b.origNl = "", nil
+	b.add, b.addNl = []byte{}, []int64{}
+	b.pieces = []piece{}
+	b.length = 0
+	b.stamp = core.StampFile(b.srcLoc)
+	if _, err := os.Stat(b.srcLoc); len(b.srcLoc) > 0 && err == nil {
+		usesCrLf := core.UsesCrLf(b.srcLoc)
+		b.textInfo = core.ReadTextInfo(b.srcLoc, usesCrLf)
+		if b.textInfo == nil {
+			return fmt.Errorf("Unsupported encoding ? Binary file ? %s", b.srcLoc)
+		}
+		b.origLoc = b.srcLoc
+		if b.textInfo.Enc != nil && b.textInfo.Enc != unicode.UTF8 {
+			// keep a UTF8 copy of the original in the buffer dir
+			b.origLoc = BufferFile(b.viewId)
+			if err := core.CopyToUTF8(b.srcLoc, b.origLoc, b.textInfo.Enc); err != nil {
+				return err
+			}
+		}
+		f, err := os.Open(b.origLoc)
+		if err != nil {
+			return err
+		}
+		b.orig = f
+		if err = b.indexOrig(); err != nil {
+			return err
+		}
+	}
+	// Ensure content ends with '\n', POSIX rule and simplifies usage.
+	if b.length == 0 || b.byteAt(b.length-1) != '\n' {
+		b.insert(b.length, core.LineSep)
+	}
+	return nil
+}
+
+// indexOrig records the newline locations of the original file
+func (b *PieceBackend) indexOrig() error {
+	buf := make([]byte, 65536)
+	var offset int64
+	for {
+		n, err := b.orig.Read(buf)
+		for i, c := range buf[:n] {
+			if c == '\n' {
+				b.origNl = append(b.origNl, offset+int64(i))
+			}
+		}
+		offset += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if offset > 0 {
+		b.pieces = append(b.pieces, piece{
+			start:  0,
+			length: offset,
+			lines:  len(b.origNl),
+		})
+	}
+	b.length = offset
+	b.lnCount = len(b.origNl)
+	return nil
+}
+
+func (b *PieceBackend) Append(text string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.insert(b.length, []byte(text))
+	return nil
+}
+
+func (b *PieceBackend) Insert(row, col int, text string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	offset, err := b.offset(row, col)
+	if err != nil {
+		return err
+	}
+	b.insert(offset, []byte(text))
+	return nil
+}
+
+func (b *PieceBackend) Remove(row1, col1, row2, col2 int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if row1 < 0 {
+		row1 = 0
+	}
+	if col1 < 0 {
+		col1 = 0
+	}
+	start, err := b.offset(row1, col1)
+	if err != nil {
+		return err
+	}
+	var end int64
+	if col2 < 0 { // up to the end of the previous line
+		end, err = b.lineStart(row2)
+	} else {
+		end, err = b.offset(row2, col2)
+		if err == nil && end < b.length {
+			_, size, _ := b.reader(end).ReadRune()
+			end += int64(size)
+		}
+	}
+	if err != nil {
+		end = b.length
+	}
+	b.remove(start, end)
+	return nil
+}
+
+func (b *PieceBackend) LineCount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.lnCount == 0 {
+		return 1
+	}
+	return b.lnCount
+}
+
+// Slice returns the runes that are in the given rectangle.
+// line2 / col2 maybe -1, meaning all lines / whole lines
+func (b *PieceBackend) Slice(line1, col, line2, col2 int) *core.Slice {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	slice := core.NewSlice(line1, col, line2, col2, [][]rune{})
+	text := slice.Text()
+	if line1 < 0 || col < 0 {
+		return slice
+	}
+	for l := slice.R1; slice.R2 == -1 || l <= slice.R2; l++ {
+		offset, err := b.lineStart(l)
+		if err != nil || offset >= b.length {
+			return slice
+		}
+		r := b.reader(offset)
+		ln := []rune{}
+		for c := 0; slice.C2 == -1 || c <= slice.C2; c++ {
+			rn, _, err := r.ReadRune()
+			if err != nil || rn == '\n' {
+				break
+			}
+			if c >= slice.C1 {
+				ln = append(ln, rn)
+			}
+		}
+		*text = append(*text, ln)
+	}
+	return slice
+}
+
+// Save writes the content to loc and then uses it as the new original.
+func (b *PieceBackend) Save(loc string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if len(loc) == 0 {
+		loc = b.srcLoc
+	}
+	if len(loc) == 0 {
+		return fmt.Errorf("Save where ? Use save [path]")
+	}
+	if err := checkStamp(b.stamp, b.srcLoc, loc); err != nil {
+		return err
+	}
+	// the original stays readable while writing as the new content is
+	// renamed into place
+	err := saveFile(loc, func(out io.Writer) error {
+		w := bufio.NewWriter(out)
+		var dst io.Writer = w
+		if b.textInfo.Enc != nil && b.textInfo.Enc != unicode.UTF8 {
+			dst = b.textInfo.Enc.NewEncoder().Writer(w)
+		}
+		if _, err := io.Copy(dst, io.NewSectionReader(b, 0, b.length)); err != nil {
+			return err
+		}
+		if c, ok := dst.(io.Closer); ok {
+			if err := c.Close(); err != nil { // flush
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	b.srcLoc = loc
+	return b.load()
+}
+
+func (b *PieceBackend) IsStale() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.srcLoc) > 0 && b.stamp.Changed(b.srcLoc)
+}
+
+func (b *PieceBackend) SetSrcLoc(loc string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.origLoc == b.srcLoc {
+		b.origLoc = loc // still the same (opened) file
+	}
+	b.srcLoc = loc
+}
+
+func (b *PieceBackend) Stamp() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.stamp = core.StampFile(b.srcLoc)
+}
+
+func (b *PieceBackend) ViewId() int64 {
+	return b.viewId
+}
+
+func (b *PieceBackend) Wipe() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pieces = []piece{}
+	b.length = 0
+	b.lnCount = 0
+}
+
+func (b *PieceBackend) SetVtCols(cols int) { // N/A
+}
+
+func (b *PieceBackend) SendBytes(data []byte) {}
+
+func (b *PieceBackend) OnActivate() {}
+
+// ReadAt reads the (UTF8) content at the given offset.
+func (b *PieceBackend) ReadAt(p []byte, offset int64) (n int, err error) {
+	var pos int64
+	for _, pc := range b.pieces {
+		if n >= len(p) {
+			break
+		}
+		if offset+int64(n) >= pos+pc.length {
+			pos += pc.length
+			continue
+		}
+		from := offset + int64(n) - pos
+		want := pc.length - from
+		if want > int64(len(p)-n) {
+			want = int64(len(p) - n)
+		}
+		c, err := b.readPiece(pc, p[n:n+int(want)], from)
+		n += c
+		if err != nil {
+			return n, err
+		}
+		pos += pc.length
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (b *PieceBackend) readPiece(pc piece, p []byte, from int64) (int, error) {
+	if pc.add {
+		return copy(p, b.add[pc.start+from:pc.start+pc.length]), nil
+	}
+	return b.orig.ReadAt(p, pc.start+from)
+}
+
+// reader returns a rune reader starting at the given offset
+func (b *PieceBackend) reader(offset int64) *bufio.Reader {
+	return bufio.NewReader(io.NewSectionReader(b, offset, b.length-offset))
+}
+
+func (b *PieceBackend) byteAt(offset int64) byte {
+	buf := []byte{0}
+	b.ReadAt(buf, offset)
+	return buf[0]
+}
+
+// newlines returns the newline index of the piece buffer
+func (b *PieceBackend) newlines(pc piece) []int64 {
+	if pc.add {
+		return b.addNl
+	}
+	return b.origNl
+}
+
+// countLines counts the newlines in the [start, end[ span of a piece buffer.
+func (b *PieceBackend) countLines(pc piece, start, end int64) int {
+	nl := b.newlines(pc)
+	from := sort.Search(len(nl), func(i int) bool { return nl[i] >= start })
+	to := sort.Search(len(nl), func(i int) bool { return nl[i] >= end })
+	return to - from
+}
+
+// lineStart returns the offset of the start of a line
+func (b *PieceBackend) lineStart(line int) (int64, error) {
+	if line <= 0 {
+		return 0, nil
+	}
+	if line > b.lnCount {
+		return b.length, io.EOF
+	}
+	var pos int64
+	lines := 0
+	for _, pc := range b.pieces {
+		if lines+pc.lines < line {
+			lines += pc.lines
+			pos += pc.length
+			continue
+		}
+		nl := b.newlines(pc)
+		from := sort.Search(len(nl), func(i int) bool { return nl[i] >= pc.start })
+		at := nl[from+line-lines-1]
+		return pos + at - pc.start + 1, nil
+	}
+	return b.length, io.EOF
+}
+
+// offset returns the offset of the given line / col
+// If col is passed the end of the line, then stops at EOL.
+func (b *PieceBackend) offset(line, col int) (int64, error) {
+	offset, err := b.lineStart(line)
+	if err != nil {
+		return offset, err
+	}
+	r := b.reader(offset)
+	for i := 0; i < col; i++ {
+		rn, size, err := r.ReadRune()
+		if err != nil || rn == '\n' {
+			break
+		}
+		offset += int64(size)
+	}
+	return offset, nil
+}
+
+// find returns the index of the piece containing the offset and the
+// offset of that piece start.
+func (b *PieceBackend) find(offset int64) (int, int64) {
+	var pos int64
+	for i, pc := range b.pieces {
+		if offset < pos+pc.length {
+			return i, pos
+		}
+		pos += pc.length
+	}
+	return len(b.pieces), pos
+}
+
+// split splits the piece at the given offset so that the offset is at a piece
+// boundary, returns the index of the piece starting at offset.
+func (b *PieceBackend) split(offset int64) int {
+	i, pos := b.find(offset)
+	if i >= len(b.pieces) || pos == offset {
+		return i
+	}
+	pc := b.pieces[i]
+	at := offset - pos
+	left := piece{add: pc.add, start: pc.start, length: at,
+		lines: b.countLines(pc, pc.start, pc.start+at)}
+	right := piece{add: pc.add, start: pc.start + at, length: pc.length - at,
+		lines: pc.lines - left.lines}
+	b.pieces = append(b.pieces, piece{})
+	copy(b.pieces[i+2:], b.pieces[i+1:])
+	b.pieces[i], b.pieces[i+1] = left, right
+	return i + 1
+}
+
+func (b *PieceBackend) insert(offset int64, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	start := int64(len(b.add))
+	lines := 0
+	for i, c := range data {
+		if c == '\n' {
+			b.addNl = append(b.addNl, start+int64(i))
+			lines++
+		}
+	}
+	b.add = append(b.add, data...)
+	b.length += int64(len(data))
+	b.lnCount += lines
+	i := b.split(offset)
+	// typing is sequential, so extend the previous piece when possible
+	if i > 0 {
+		prev := &b.pieces[i-1]
+		if prev.add && prev.start+prev.length == start {
+			prev.length += int64(len(data))
+			prev.lines += lines
+			return
+		}
+	}
+	pc := piece{add: true, start: start, length: int64(len(data)), lines: lines}
+	b.pieces = append(b.pieces, piece{})
+	copy(b.pieces[i+1:], b.pieces[i:])
+	b.pieces[i] = pc
+}
+
+func (b *PieceBackend) remove(start, end int64) {
+	if end > b.length {
+		end = b.length
+	}
+	if start >= end {
+		return
+	}
+	from := b.split(start)
+	to := b.split(end)
+	for _, pc := range b.pieces[from:to] {
+		b.lnCount -= pc.lines
+		b.length -= pc.length
+	}
+	b.pieces = append(b.pieces[:from], b.pieces[to:]...)
+}
+
+// text returns the whole content, mostly useful for testing.
+func (b *PieceBackend) text() string {
+	var buf bytes.Buffer
+	io.Copy(&buf, io.NewSectionReader(b, 0, b.length))
+	return buf.String()
+}
@@ -1,6 +1,11 @@
 package backend
 
 import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,6 +70,90 @@ func (bs *BackendSuite) TestMemBackend(t *C) {
 	bs.testBackend(t, b2, id2)
 	err = b2.Close()
 	assert.Nil(t, err)
+
+	b2, err = NewMemBackend("", id2)
+	assert.Nil(t, err)
+	b2.Insert(0, 0, "abc\ndef")
+	loc := path.Join(core.Home, "mem_test.txt")
+	err = b2.Save(loc)
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "abc\ndef\n")
+	assert.Eq(t, b2.SrcLoc(), loc)
+}
+
+func (bs *BackendSuite) TestPieceBackend(t *C) {
+	b, err := NewPieceBackend("../test_data/file1.txt", id)
+	assert.Nil(t, err)
+	assert.Eq(t, b.BufferLoc(), "../test_data/file1.txt")
+	bs.testBackend(t, b, id)
+	assert.Eq(t, len(b.add) > 0, true)
+	err = b.Close()
+	assert.Nil(t, err)
+
+	// edits only make it to the file on save
+	loc := path.Join(core.Home, "piece_test.txt")
+	err = core.CopyFile("../test_data/file1.txt", loc)
+	assert.Nil(t, err)
+	b, err = NewPieceBackend(loc, id)
+	assert.Nil(t, err)
+	orig := b.text()
+	b.Insert(0, 0, "abc\n")
+	b.Remove(2, 0, 2, 4)
+	data, _ := ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), orig)
+	err = b.Save(loc)
+	assert.Nil(t, err)
+	data, _ = ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "abc\n"+strings.Replace(orig, "\n\n", "\n", 1))
+	assert.Eq(t, b.text(), string(data))
+	assert.Eq(t, len(b.pieces), 1)
+	assert.Eq(t, b.LineCount(), 12)
+	b.Close()
+}
+
+func (bs *BackendSuite) TestChangedOnDisk(t *C) {
+	loc := path.Join(core.Home, "changed_test.txt")
+	news := []func() (core.Backend, error){
+		func() (core.Backend, error) { return NewFileBackend(loc, id) },
+		func() (core.Backend, error) { return NewMemBackend(loc, id) },
+		func() (core.Backend, error) { return NewPieceBackend(loc, id) },
+	}
+	// modified by somebody else, replacing the file (as most programs do)
+	modify := func(content string, ts time.Time) {
+		core.WriteFileAtomic(loc, core.BackupNone, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		})
+		os.Chtimes(loc, ts, ts)
+	}
+	for _, newBackend := range news {
+		ioutil.WriteFile(loc, []byte("abc\n"), 0644)
+		b, err := newBackend()
+		assert.Nil(t, err)
+		b.Insert(0, 0, "1")
+		assert.Nil(t, b.Save(loc))
+		later := time.Now().Add(time.Minute)
+		modify("xyz\n", later)
+		b.Insert(0, 0, "2")
+		err = b.Save(loc)
+		assert.True(t, core.IsChangedOnDisk(err))
+		data, _ := ioutil.ReadFile(loc)
+		assert.Eq(t, string(data), "xyz\n")
+		// saving elsewhere is fine
+		assert.Nil(t, b.Save(loc+".other"))
+		b.Close()
+		// stamping accepts the disk state
+		b, _ = newBackend()
+		b.Insert(0, 0, "3")
+		modify("xyz2\n", later.Add(time.Minute))
+		assert.NotNil(t, b.Save(loc))
+		b.Stamp()
+		assert.Nil(t, b.Save(loc))
+		data, _ = ioutil.ReadFile(loc)
+		assert.Eq(t, string(data), "3xyz\n")
+		b.Close()
+	}
 }
 
 // test Backend API methods
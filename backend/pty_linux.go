@@ -0,0 +1,5 @@
+package backend
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TCGETS
@@ -0,0 +1,49 @@
+//go:build !windows
+// +build !windows
+
+package backend
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ptySignals maps the signal names to the signals and the control characters
+// generating them.
+var ptySignals = map[string]struct {
+	sig  syscall.Signal
+	char byte
+}{
+	"INT":  {syscall.SIGINT, 0x03},  // Ctrl+C
+	"QUIT": {syscall.SIGQUIT, 0x1c}, // Ctrl+\
+	"TSTP": {syscall.SIGTSTP, 0x1a}, // Ctrl+Z
+}
+
+// ptyForeground returns the foreground process group of a pty.
+func ptyForeground(f *os.File) (int, error) {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// ptyIsig returns whether the pty line discipline turns the control
+// characters into signals, that is unless the program uses the raw mode.
+func ptyIsig(f *os.File) (bool, error) {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(ioctlGetTermios), uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return false, errno
+	}
+	return t.Lflag&syscall.ISIG != 0, nil
+}
+
+// ptySignal sends a signal to a process group.
+func ptySignal(pgrp int, sig syscall.Signal) error {
+	return syscall.Kill(-pgrp, sig)
+}
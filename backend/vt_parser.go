@@ -0,0 +1,256 @@
+package backend
+
+import "unicode/utf8"
+
+// vtHandler receives what the vtParser decodes from a terminal output stream.
+type vtHandler interface {
+	// vtPrint prints runes at the cursor.
+	vtPrint(runes []rune)
+	// vtExecute executes a C0 control character (BEL, BS, LF ...).
+	vtExecute(c byte)
+	// vtEsc executes an escape sequence : ESC intermediates final.
+	vtEsc(intermediates []byte, final byte)
+	// vtCsi executes a control sequence : CSI private params intermediates final.
+	// Each param is a group of sub params (colon separated, ie: 38:2:r:g:b),
+	// missing values are 0.
+	vtCsi(private byte, params [][]int, intermediates []byte, final byte)
+	// vtOsc executes an operating system command (ie: "0;title").
+	vtOsc(data string)
+}
+
+type vtState int
+
+const (
+	vtGround vtState = iota
+	vtEscape
+	vtEscapeIntermediate
+	vtCsiParam
+	vtCsiIntermediate
+	vtCsiIgnore
+	vtOscString
+	vtIgnoreString // DCS, SOS, PM and APC strings, ignored
+)
+
+// maxVtString limits the length of the OSC strings.
+const maxVtString = 4096
+
+// vtParser is a terminal output parser, a state machine along the lines of
+// https://vt100.net/emu/dec_ansi_parser, with UTF-8 support.
+// Partial sequences (or runes) at the end of a write are completed by the
+// next one.
+// See http://invisible-island.net/xterm/ctlseqs/ctlseqs.html
+type vtParser struct {
+	h             vtHandler
+	state         vtState
+	private       byte // CSI private marker ('?', '>' ...)
+	params        [][]int
+	intermediates []byte
+	str           []byte // OSC string
+	strEsc        bool   // ESC seen within a string (maybe ST)
+	utf8          []byte // incomplete rune
+	runes         []rune // printable runes not handed yet
+}
+
+func newVtParser(h vtHandler) *vtParser {
+	return &vtParser{h: h}
+}
+
+// Parse parses a chunk of the terminal output.
+func (p *vtParser) Parse(data []byte) {
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if p.state == vtGround && (c >= 0x20 && c != 0x7f || len(p.utf8) > 0) {
+			i += p.print(data[i:]) - 1
+			continue
+		}
+		p.flush()
+		p.parse(c)
+	}
+	p.flush()
+}
+
+// print consumes the printable runes at the start of data, returns how many
+// bytes were consumed.
+func (p *vtParser) print(data []byte) int {
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		if len(p.utf8) == 0 && (c < 0x20 || c == 0x7f) {
+			break
+		}
+		if c < utf8.RuneSelf && len(p.utf8) == 0 {
+			p.runes = append(p.runes, rune(c))
+			i++
+			continue
+		}
+		if len(p.utf8) > 0 && (c < 0x80 || c >= 0xc0) {
+			// not a continuation byte : invalid sequence
+			p.runes = append(p.runes, utf8.RuneError)
+			p.utf8 = p.utf8[:0]
+			continue
+		}
+		p.utf8 = append(p.utf8, c)
+		i++
+		if utf8.FullRune(p.utf8) {
+			r, _ := utf8.DecodeRune(p.utf8)
+			p.runes = append(p.runes, r)
+			p.utf8 = p.utf8[:0]
+		}
+	}
+	return i
+}
+
+// flush hands the pending printable runes to the handler.
+func (p *vtParser) flush() {
+	if len(p.runes) > 0 {
+		p.h.vtPrint(p.runes)
+		p.runes = p.runes[:0]
+	}
+}
+
+func (p *vtParser) parse(c byte) {
+	// "anywhere" transitions
+	switch c {
+	case 0x18, 0x1a: // CAN, SUB : abort the sequence
+		p.state = vtGround
+		return
+	case 0x1b:
+		if p.state == vtOscString || p.state == vtIgnoreString {
+			p.strEsc = true
+			return
+		}
+		p.clear()
+		p.state = vtEscape
+		return
+	}
+
+	switch p.state {
+	case vtGround:
+		p.control(c)
+	case vtEscape, vtEscapeIntermediate:
+		p.escape(c)
+	case vtCsiParam, vtCsiIntermediate, vtCsiIgnore:
+		p.csi(c)
+	case vtOscString, vtIgnoreString:
+		p.string(c)
+	}
+}
+
+// control executes C0 control characters, DEL is ignored.
+func (p *vtParser) control(c byte) {
+	if c < 0x20 {
+		p.h.vtExecute(c)
+	}
+}
+
+func (p *vtParser) clear() {
+	p.private = 0
+	p.params = p.params[:0]
+	p.intermediates = p.intermediates[:0]
+	p.str = p.str[:0]
+	p.strEsc = false
+}
+
+func (p *vtParser) escape(c byte) {
+	switch {
+	case c < 0x20:
+		p.control(c)
+	case c <= 0x2f: // intermediate
+		p.intermediates = append(p.intermediates, c)
+		p.state = vtEscapeIntermediate
+	case p.state == vtEscape && c == '[':
+		p.state = vtCsiParam
+	case p.state == vtEscape && c == ']':
+		p.state = vtOscString
+	case p.state == vtEscape && (c == 'P' || c == 'X' || c == '^' || c == '_'):
+		p.state = vtIgnoreString
+	case c < 0x7f:
+		p.h.vtEsc(p.intermediates, c)
+		p.state = vtGround
+	}
+}
+
+func (p *vtParser) csi(c byte) {
+	switch {
+	case c < 0x20:
+		p.control(c)
+	case p.state == vtCsiIgnore:
+		if c >= 0x40 && c < 0x7f {
+			p.state = vtGround
+		}
+	case c >= '0' && c <= '9':
+		if p.state != vtCsiParam {
+			p.state = vtCsiIgnore
+			return
+		}
+		if len(p.params) == 0 {
+			p.params = append(p.params, []int{0})
+		}
+		group := p.params[len(p.params)-1]
+		n := &group[len(group)-1]
+		if *n < 1e6 {
+			*n = *n*10 + int(c-'0')
+		}
+	case c == ';':
+		if p.state != vtCsiParam {
+			p.state = vtCsiIgnore
+			return
+		}
+		if len(p.params) == 0 {
+			p.params = append(p.params, []int{0})
+		}
+		p.params = append(p.params, []int{0})
+	case c == ':':
+		if p.state != vtCsiParam {
+			p.state = vtCsiIgnore
+			return
+		}
+		if len(p.params) == 0 {
+			p.params = append(p.params, []int{0})
+		}
+		last := len(p.params) - 1
+		p.params[last] = append(p.params[last], 0)
+	case c >= 0x3c && c <= 0x3f: // private marker : < = > ?
+		if p.state != vtCsiParam || len(p.params) > 0 || p.private != 0 {
+			p.state = vtCsiIgnore
+			return
+		}
+		p.private = c
+	case c <= 0x2f: // intermediate
+		p.intermediates = append(p.intermediates, c)
+		p.state = vtCsiIntermediate
+	case c < 0x7f:
+		p.h.vtCsi(p.private, p.params, p.intermediates, c)
+		p.state = vtGround
+	}
+}
+
+// string collects an OSC string (others are ignored), terminated by ST
+// (ESC \) or BEL.
+func (p *vtParser) string(c byte) {
+	if p.strEsc {
+		// ESC \ (ST) terminates the string, any other ESC sequence as well
+		p.strEsc = false
+		p.endString()
+		if c != '\\' {
+			p.clear()
+			p.state = vtEscape
+			p.parse(c)
+		}
+		return
+	}
+	if c == 0x07 {
+		p.endString()
+		return
+	}
+	if p.state == vtOscString && c >= 0x20 && len(p.str) < maxVtString {
+		p.str = append(p.str, c)
+	}
+}
+
+func (p *vtParser) endString() {
+	if p.state == vtOscString {
+		p.h.vtOsc(string(p.str))
+	}
+	p.state = vtGround
+}
@@ -36,11 +36,21 @@ type Backend interface {
 	// Reloads the text (from SrcLoc to BufferLoc)
 	Reload() error
 
+	// Stamp records the current state of SrcLoc on disk. Save refuses to
+	// overwrite SrcLoc if it was changed since (ErrChangedOnDisk).
+	Stamp()
+
 	// return the color style at a specific location (mem backends)
 	ColorAt(ln, col int) (fg, bg Style)
 
+	// IsStale returns whether SrcLoc was changed on disk since it was loaded,
+	// saved or stamped.
+	IsStale() bool
+	// SetSrcLoc changes the location of the original data, ie: after it was
+	// moved by another program.
+	SetSrcLoc(loc string)
+
 	//Sync() error         // sync from source ?
-	//IsStale() bool       // whether the source as changed under us (fsnotify)
 	//IsBufferStale() bool // whether the buffer has changed under us
 
 	//SourceMd5 or ts?
@@ -49,6 +59,35 @@ type Backend interface {
 	OnActivate()
 }
 
+// TerminalBackend is implemented by the backends running a terminal program
+// (shell views), forwarding the user input the way the program expects it.
+type TerminalBackend interface {
+	// TermMouse reports a mouse event at a text position (0 indexed) if the
+	// program asked for mouse events, returns whether it was reported.
+	// Buttons are the xterm ones : 0 left, 1 middle, 2 right, 64/65 wheel.
+	TermMouse(ln, col, button int, drag, release bool) bool
+	// TermPaste sends pasted text to the program.
+	TermPaste(text string)
+	// TermResize sets the terminal size (the program gets a SIGWINCH).
+	TermResize(rows, cols int)
+	// TermSignal sends a signal (INT, QUIT or TSTP) to the foreground program.
+	TermSignal(sig string) error
+}
+
+// CmdOutputBackend is implemented by the backends showing a command output,
+// the oldest rows being dropped from memory past a limit.
+type CmdOutputBackend interface {
+	// Cmd returns the command and its arguments.
+	Cmd() []string
+	// Dropped returns how many rows were dropped from memory so far.
+	Dropped() int
+	// SaveOutput saves the full output, including the dropped rows, to a file.
+	SaveOutput(loc string) error
+	// Pinned returns whether the view is pinned : it does not follow the output.
+	Pinned() bool
+	SetPinned(pinned bool)
+}
+
 type Rwsc interface {
 	io.Reader
 	io.Writer
@@ -4,8 +4,13 @@ package core
 type Commander interface {
 	Backspace()
 	Clear()
+	// Complete completes the command name or argument at the cursor.
+	Complete()
 	CursorMvmt(mvmt CursorMvmt)
 	Delete()
+	// HistorySearch starts a fuzzy search of the history, or selects the next
+	// match.
+	HistorySearch()
 	Insert(text string)
 	NewLine()
 }
@@ -7,11 +7,58 @@ type Config struct {
 	SyntaxHighlighting bool
 	Theme              string // ie: theme1.toml
 	MaxCmdBufferLines  int    // Max # of lines to keep in buffer when running a command
+	// max size (bytes) of the on disk log of the command output lines dropped
+	// from the buffer, rotated once (kept as .1), -1: none
+	CmdOutputLogSize   int64
 	GuiFont            string // full path to a monospace TTF font
 	GuiFontSize        int
 	GuiFontDpi         int
 	MinViewWidth       int // preferred minimum view width (in characters)
 	LineWidthIndicator int // line width indicator (ie: 80 cols)
+	// files of at least that size (bytes) use the piece table backend, -1: never
+	PieceTableMinSize int64
+	// language servers, by name
+	LspServers map[string]LspServer
+	// commands run when saving files, in order
+	SaveHooks []SaveHook
+	// max duration (seconds) of a save hook command, killed past it
+	SaveHookTimeout int
+	// backup of the previous version on save: "" (none), "simple" (file~)
+	// or "numbered" (file.~1~, file.~2~ ...)
+	Backups string
+	// command bar aliases, name -> command, ie: gs = "git status"
+	CmdAliases map[string]string
+	// problem matchers (compiler errors ...) by name, added to or overriding
+	// the builtin ones (go, gcc, eslint)
+	ProblemMatchers map[string]ProblemMatcher
+}
+
+// Save hook stages
+const (
+	SaveHookPre  = "pre"  // formatter: gets the buffer on stdin, stdout replaces it
+	SaveHookPost = "post" // run after the file was written, ie: a linter
+)
+
+// SaveHook is a command run when saving files matching Match.
+type SaveHook struct {
+	Match string   // glob matched against the file name (ie: "*.go") or extension (ie: ".go")
+	Stage string   // SaveHookPre or SaveHookPost
+	Cmd   []string // command and arguments, $FILE is replaced by the file path
+}
+
+// LspServer is a language server configuration.
+type LspServer struct {
+	Cmd        []string // command starting the server (stdio)
+	Extensions []string // file extensions handled by the server, ie: ".go"
+	LanguageId string   // ie: "go"
+}
+
+// ProblemMatcher finds the problems (errors ...) reported in a command output,
+// see the problems package.
+type ProblemMatcher struct {
+	Cmds        []string // commands it applies to, ie: "go"
+	Pattern     string   // regexp, named groups : file, line, col, severity, message
+	FilePattern string   // optional regexp of a line giving the file of the next problems
 }
 
 func LoadConfig(file string) *Config {
@@ -23,6 +70,9 @@ func LoadConfig(file string) *Config {
 	if conf.MaxCmdBufferLines == 0 {
 		conf.MaxCmdBufferLines = 10000
 	}
+	if conf.CmdOutputLogSize == 0 {
+		conf.CmdOutputLogSize = 10000000
+	}
 	if conf.GuiFontSize == 0 {
 		conf.GuiFontSize = 10
 	}
@@ -35,5 +85,11 @@ func LoadConfig(file string) *Config {
 	if conf.LineWidthIndicator == 0 {
 		conf.LineWidthIndicator = 80
 	}
+	if conf.PieceTableMinSize == 0 {
+		conf.PieceTableMinSize = 5000000
+	}
+	if conf.SaveHookTimeout == 0 {
+		conf.SaveHookTimeout = 10
+	}
 	return conf
 }
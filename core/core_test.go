@@ -1,7 +1,11 @@
 package core
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -87,3 +91,125 @@ func (cs *CoreSuite) TestIsText(t *C) {
 	assert.NotNil(t, ReadTextInfo("../test_data/empty.txt", false))
 	assert.NotNil(t, ReadTextInfo("../test_data/test.txt", false))
 }
+
+func (cs *CoreSuite) TestEnvWith(t *C) {
+	os.Setenv("GOED_ENV_TEST", "a")
+	defer os.Unsetenv("GOED_ENV_TEST")
+	env := EnvWith([]string{"GOED_ENV_TEST=b", "GOED_ENV_TEST_NEW=c"})
+	assert.Eq(t, len(env), len(os.Environ())+1)
+	found := map[string]bool{}
+	for _, e := range env {
+		found[e] = true
+	}
+	assert.False(t, found["GOED_ENV_TEST=a"])
+	assert.True(t, found["GOED_ENV_TEST=b"])
+	assert.True(t, found["GOED_ENV_TEST_NEW=c"])
+}
+
+func (cs *CoreSuite) TestWriteFileAtomic(t *C) {
+	dir := path.Join(Home, "atomic_test")
+	os.RemoveAll(dir)
+	loc := path.Join(dir, "a.txt")
+	write := func(s string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, s)
+			return err
+		}
+	}
+	assert.Nil(t, WriteFileAtomic(loc, BackupSimple, write("one")))
+	info, err := os.Stat(loc)
+	assert.Nil(t, err)
+	assert.Eq(t, info.Mode().Perm(), os.FileMode(0644))
+	_, err = os.Stat(loc + "~")
+	assert.True(t, os.IsNotExist(err))
+
+	// mode is kept, previous version backed up
+	os.Chmod(loc, 0600)
+	assert.Nil(t, WriteFileAtomic(loc, BackupSimple, write("two")))
+	info, _ = os.Stat(loc)
+	assert.Eq(t, info.Mode().Perm(), os.FileMode(0600))
+	data, _ := ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "two")
+	data, _ = ioutil.ReadFile(loc + "~")
+	assert.Eq(t, string(data), "one")
+
+	assert.Nil(t, WriteFileAtomic(loc, BackupNumbered, write("three")))
+	assert.Nil(t, WriteFileAtomic(loc, BackupNumbered, write("four")))
+	data, _ = ioutil.ReadFile(loc + ".~1~")
+	assert.Eq(t, string(data), "two")
+	data, _ = ioutil.ReadFile(loc + ".~2~")
+	assert.Eq(t, string(data), "three")
+
+	// symlinks are followed
+	link := path.Join(dir, "link.txt")
+	assert.Nil(t, os.Symlink(loc, link))
+	assert.Nil(t, WriteFileAtomic(link, BackupNone, write("five")))
+	data, _ = ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "five")
+	info, _ = os.Lstat(link)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0)
+
+	// failed write leaves the file (and no temp file) behind
+	err = WriteFileAtomic(loc, BackupNone, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return fmt.Errorf("Failed")
+	})
+	assert.NotNil(t, err)
+	data, _ = ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "five")
+	files, _ := ioutil.ReadDir(dir)
+	assert.Eq(t, len(files), 5) // a.txt, a.txt~, a.txt.~1~, a.txt.~2~, link.txt
+}
+
+func (cs *CoreSuite) TestFileStamp(t *C) {
+	loc := path.Join(Home, "stamp_test.txt")
+	os.Remove(loc)
+	stamp := StampFile(loc)
+	assert.False(t, stamp.Changed(loc))
+	ioutil.WriteFile(loc, []byte("abc"), 0644)
+	assert.True(t, stamp.Changed(loc)) // created
+	stamp = StampFile(loc)
+	assert.False(t, stamp.Changed(loc))
+	<-stamp.hash.done // hashed in the background
+	assert.DeepEq(t, stamp.hash.sum, hashFile(loc))
+	// touched only
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(loc, later, later)
+	assert.False(t, stamp.Changed(loc))
+	ioutil.WriteFile(loc, []byte("abd"), 0644)
+	os.Chtimes(loc, stamp.ModTime, stamp.ModTime)
+	assert.False(t, stamp.Changed(loc)) // same mtime & size : trusted
+	os.Chtimes(loc, later, later)
+	assert.True(t, stamp.Changed(loc))
+	// changed while hashing : the hash can't be trusted
+	stamp = StampFile(loc)
+	stamp.hash = &stampHash{done: make(chan struct{})}
+	close(stamp.hash.done)
+	os.Chtimes(loc, stamp.ModTime.Add(time.Minute), stamp.ModTime.Add(time.Minute))
+	assert.True(t, stamp.Changed(loc))
+	os.Remove(loc)
+	assert.False(t, stamp.Changed(loc)) // deleted
+}
+
+func (cs *CoreSuite) TestEvents(t *C) {
+	h := NewEventHub()
+	all := h.Subscribe()
+	saved := h.Subscribe(EvtSaved)
+	h.Publish(Event{Type: EvtCursorMoved, ViewId: 1, Line: 2, Col: 3})
+	h.Publish(Event{Type: EvtSaved, ViewId: 1, Loc: "foo"})
+	assert.Eq(t, len(all.C), 2)
+	assert.Eq(t, len(saved.C), 1)
+	assert.DeepEq(t, <-all.C, Event{Type: EvtCursorMoved, ViewId: 1, Line: 2, Col: 3})
+	assert.Eq(t, (<-saved.C).Loc, "foo")
+	h.Unsubscribe(saved.Id)
+	_, ok := <-saved.C
+	assert.False(t, ok)
+	h.Publish(Event{Type: EvtSaved})
+	// a slow subscriber does not block
+	for i := 0; i <= EventBufferSize; i++ {
+		h.Publish(Event{Type: EvtViewClosed})
+	}
+	assert.Eq(t, len(all.C), EventBufferSize)
+	assert.Eq(t, OpWrite.String(), "write")
+	assert.Eq(t, (OpCreate | OpChmod).String(), "create|chmod")
+}
@@ -11,16 +11,50 @@ type Editable interface {
 	DelViewCheck(viewId int64, terminate bool)
 	Dispatch(action Action)
 	FileEvent(op FileOp, loc string)
+	// FileMoved is called when a watched file was moved (renamed) on disk.
+	FileMoved(from, to string)
+	// Finder returns the fuzzy finder, nil if not open.
+	Finder() Finder
+	// FinderOpen opens the fuzzy finder in the given mode (FinderFiles, FinderViews).
+	FinderOpen(mode string) error
 	// CmdOn indicates whether the CommandBar is currently active
 	CmdOn() bool
+	// CollectProblems collects the problems (compiler errors ...) reported in
+	// a view as the problem list, using the named problem matcher or if empty
+	// the ones applying to the view command. Returns how many were found.
+	CollectProblems(viewId int64, matcher string) (int, error)
+	// NextError opens the location of the next (or previous if backward)
+	// problem of the problem list.
+	NextError(backward bool) error
+	// OverlayEvent offers an event to the overlays (menus, tooltips ...),
+	// mouse locations being in terminal coordinates, returns whether consumed.
+	OverlayEvent(evtType, glyph string, mouse bool, y, x int) bool
+	// Prompt asks the user a question in a modal dialog, answer is called
+	// with the picked choice ("" if cancelled), choices default to yes / no.
+	Prompt(question string, choices []string, defaultChoice int, answer func(choice string))
 	// Open opens a file in the given view (new view if viewid<0)
 	// create -> create file at loc if does not exist yet
 	Open(loc string, viewId int64, rel string, create bool) (int64, error)
+	// Problems returns the problem list, as loc:line:col: severity: message
+	Problems() []string
+	// ProjectReplace previews a project wide replacement in a new view.
+	ProjectReplace(dir, pattern, repl string, regex, ignoreCase bool) (int64, error)
+	// ProjectReplaceApply applies the replacement previewed in a view.
+	ProjectReplaceApply(viewId int64) (int, error)
+	// ProjectSearch searches files under dir, results shown in a new view.
+	ProjectSearch(dir, pattern string, regex, ignoreCase bool) (int64, error)
 	Quit()
 	QuitCheck() bool
 	// Render updates the whole editor UI
 	Render()
+	// RerunTask runs the latest run project task again.
+	RerunTask() (int64, error)
 	Resize(h, w int)
+	// RunTask runs a task of the current view project (.goed/tasks.toml),
+	// returns the id of the view showing its output.
+	RunTask(name string) (int64, error)
+	// ScriptErrors shows the errors of a script in an errors view.
+	ScriptErrors(script string, errs []string)
 	// SetStatusErr displays an error message in the status bar
 	SetStatusErr(err string)
 	// SetStatusErr displays a message in the status bar
@@ -32,6 +66,9 @@ type Editable interface {
 	StartTermView(args []string) int64
 	SwapViews(v1, v2 int64)
 	Start(locs []string)
+	// Tasks returns the task names of the project dir is in (.goed/tasks.toml),
+	// or if empty the one of the current view.
+	Tasks(dir string) ([]string, error)
 	TermChar(y, x int, c rune)
 	TermFB(fg, bg Style)
 	TermFill(c rune, y1, x1, y2, x2 int)
@@ -47,6 +84,8 @@ type Editable interface {
 	ViewIndex(id int64) (row, col int) // column, row **index** in the editor UI
 	// Move a view
 	ViewMove(y1, x1, y2, x2 int)
+	// Reload a view, with dirty check
+	ViewReloadCheck(viewId int64)
 	// Navigate from a view to another
 	ViewNavigate(mvmt CursorMvmt)
 	Views() []int64 // list of all opened views
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"log"
+	"sync"
+)
+
+// EventType is the type of an editor event.
+type EventType string
+
+// Editor events, published to the subscribers.
+const (
+	EvtViewOpened    EventType = "view_opened"
+	EvtViewClosed    EventType = "view_closed"
+	EvtViewActivated EventType = "view_activated"
+	EvtBufferChanged EventType = "buffer_changed" // lines FromLine to ToLine inserted or removed
+	EvtSaved         EventType = "saved"
+	EvtCursorMoved   EventType = "cursor_moved"
+	EvtFileEvent     EventType = "file_event" // file watcher event
+	EvtCmdDone       EventType = "cmd_done"   // a command view completed
+)
+
+// Event is an editor event. Lines and columns are 1 indexed.
+type Event struct {
+	Type     EventType
+	ViewId   int64
+	Loc      string `json:",omitempty"`
+	FromLine int    `json:",omitempty"`
+	ToLine   int    `json:",omitempty"`
+	Line     int    `json:",omitempty"`
+	Col      int    `json:",omitempty"`
+	Op       string `json:",omitempty"` // file watcher operation
+	ExitCode int    // command exit code (cmd_done)
+}
+
+// EventBufferSize is how many events a subscriber may lag behind before
+// events are dropped.
+var EventBufferSize = 1000
+
+// Events is the editor event hub.
+var Events = NewEventHub()
+
+// EventHub publishes events to its subscribers.
+type EventHub struct {
+	sync.Mutex
+	nextId int64
+	subs   map[int64]*Subscription
+}
+
+// Subscription receives the subscribed events on C.
+type Subscription struct {
+	Id      int64
+	C       chan Event
+	types   map[EventType]bool // all if empty
+	dropped bool
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{subs: map[int64]*Subscription{}}
+}
+
+// Subscribe subscribes to the given event types, or all events if none.
+func (h *EventHub) Subscribe(types ...EventType) *Subscription {
+	h.Lock()
+	defer h.Unlock()
+	h.nextId++
+	s := &Subscription{
+		Id:    h.nextId,
+		C:     make(chan Event, EventBufferSize),
+		types: map[EventType]bool{},
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+	h.subs[s.Id] = s
+	return s
+}
+
+// Unsubscribe ends a subscription, its channel is closed.
+func (h *EventHub) Unsubscribe(id int64) {
+	h.Lock()
+	defer h.Unlock()
+	if s, found := h.subs[id]; found {
+		delete(h.subs, id)
+		close(s.C)
+	}
+}
+
+// Publish sends an event to the subscribers, it never blocks : events are
+// dropped for subscribers that are too far behind.
+func (h *EventHub) Publish(e Event) {
+	h.Lock()
+	defer h.Unlock()
+	for _, s := range h.subs {
+		if len(s.types) > 0 && !s.types[e.Type] {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			if !s.dropped {
+				log.Printf("Event subscriber %d too slow, dropping events.", s.Id)
+				s.dropped = true
+			}
+		}
+	}
+}
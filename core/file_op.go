@@ -1,5 +1,7 @@
 package core
 
+import "strings"
+
 type FileOp uint32
 
 const (
@@ -9,3 +11,16 @@ const (
 	OpRename
 	OpChmod
 )
+
+var fileOpNames = []string{"create", "write", "remove", "rename", "chmod"}
+
+// String returns the operation(s) names, ie: "write|chmod"
+func (op FileOp) String() string {
+	names := []string{}
+	for i, name := range fileOpNames {
+		if op&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
@@ -0,0 +1,18 @@
+package core
+
+// Fuzzy finder modes.
+const (
+	FinderFiles = "files" // files under the current view directory
+	FinderViews = "views" // the open views
+)
+
+// Finder is the fuzzy finder overlay.
+type Finder interface {
+	Backspace()
+	Close()
+	Insert(text string)
+	// Move moves the selection by delta matches.
+	Move(delta int)
+	// Select opens the selected match and closes the finder.
+	Select()
+}
@@ -73,10 +73,13 @@ func InitHome(id int64) {
 	os.MkdirAll(path.Join(Home, "buffers"), 0750)
 	os.MkdirAll(path.Join(Home, "logs"), 0750)
 	os.MkdirAll(path.Join(Home, "instances"), 0750)
+	os.MkdirAll(path.Join(Home, "sessions"), 0750)
 	ioutil.WriteFile(path.Join(Home, "Version.txt"), []byte(Version), 644)
 
 	// RCP instance socket
 	Socket = GoedSocket(id)
+	JsonSocket = GoedJsonSocket(id)
+	TokenLoc = GoedToken(id)
 
 	// Terminal app
 	Terminal = os.Getenv("SHELL")
@@ -124,6 +127,16 @@ func GoedSocket(id int64) string {
 	return path.Join(GoedHome(), "instances", fmt.Sprintf("%d.sock", id))
 }
 
+// GoedJsonSocket returns the JSON-RPC socket of an instance.
+func GoedJsonSocket(id int64) string {
+	return path.Join(GoedHome(), "instances", fmt.Sprintf("%d.jsonrpc", id))
+}
+
+// GoedToken returns the location of the API access token of an instance.
+func GoedToken(id int64) string {
+	return path.Join(GoedHome(), "instances", fmt.Sprintf("%d.token", id))
+}
+
 func GoedHome() string {
 	usr, err := user.Current()
 	t := ""
@@ -164,17 +177,25 @@ func Cleanup() {
 		os.Remove(LogFile.Name())
 	}
 	os.Remove(Socket)
+	os.Remove(JsonSocket)
+	os.Remove(TokenLoc)
 }
 
+// EnvWith returns the environment with the custom variables (KEY=value)
+// replacing or added to the current ones.
 func EnvWith(custom []string) []string {
 	env := os.Environ()
-	for i, e := range env {
-		key := strings.Split(e, "=")[0] + "="
-		for _, c := range custom {
-			if strings.HasPrefix(c, key) {
-				env[i] = c
+	for _, c := range custom {
+		key := strings.Split(c, "=")[0] + "="
+		found := false
+		for i, e := range env {
+			if strings.HasPrefix(e, key) {
+				env[i], found = c, true
 			}
 		}
+		if !found {
+			env = append(env, c)
+		}
 	}
 	return env
 }
@@ -7,12 +7,9 @@
 // res/default/actions/goed.fish
 // res/default/actions/goed.rc
 // res/default/actions/goed.sh
-// res/default/actions/goed_helper.ank
-// res/default/actions/goimports.sh
 // res/default/actions/search.ank
 // res/default/actions/search_text.sh
 // res/default/actions/stats.sh
-// res/default/actions/vt100_size.sh
 // res/default/bindings.toml
 // res/default/config.toml
 // res/default/themes/acme.toml
@@ -106,7 +103,7 @@ func resDs_store() (*asset, error) {
 	return a, nil
 }
 
-var _resReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x52\xb1\x6e\x1b\x31\x0c\xdd\xef\x2b\x5e\xb7\x04\x70\xed\x3d\x5b\x51\x67\x08\x50\xc4\x05\x9c\xec\xa6\x25\xea\x2c\x54\x77\x3a\x48\x54\x93\x76\xe8\xb7\x57\x92\x75\x71\xce\x68\x33\x8a\x7c\xef\xf1\xf1\x51\x5f\xfd\x28\x3c\x4a\x84\x37\xf8\xb3\x59\xf7\x9e\x35\xee\x3a\xe0\x33\x94\x1f\x8d\xed\xd7\xe2\x07\x87\x3b\x3c\x47\x0e\xb8\x51\x29\xe6\xb7\xfd\xcd\xfa\xb6\xf5\x53\x20\xb1\x7e\x84\xb1\x8e\x2b\x4d\x4e\x3c\x70\xdc\xfc\x93\x72\xee\xad\xa0\x02\x93\x30\x4c\xf0\x03\xa2\xca\x0a\xea\x04\x1f\xb2\xe2\x64\xf3\xf8\x73\x59\x68\xd4\x14\xf4\xa6\xe9\x55\x6d\x52\x65\xd6\x7f\xc4\x5b\xb3\xab\x48\xcd\x86\x92\x93\x82\xdc\x05\xdb\xdb\x91\x1c\xea\x6e\xc5\x67\x76\xa0\x3d\x46\x2f\x60\x6d\x05\xda\x06\x56\xe2\x7e\x81\x22\x5e\xac\x73\x38\x32\x02\x4f\x8e\x54\xc6\xa7\x29\x2f\x97\xa6\x3e\x90\xe6\xb8\x5e\x68\x2f\x03\xda\x37\xc3\x73\x6e\xd8\x5e\x46\x2c\x79\x97\x84\xe6\x25\x5b\x30\x1f\x70\xde\x6d\xfe\x46\x6a\xb5\x2b\x56\xa5\x1d\x93\x31\x1c\x32\x5c\x9d\xcf\x8b\x40\x2f\x73\x11\x9a\x84\x56\xd8\xee\xf0\xb8\x7b\xc2\xfd\xf6\xe1\x09\x9f\x2a\xc9\x8e\x45\x5a\x71\xa3\x51\x7e\x17\x63\x50\x29\x84\xa2\x51\xf3\x9b\x41\x37\xf1\x16\x5f\xbe\x3f\x20\x7a\xf5\x83\xa5\x24\xd3\xbd\x25\x50\x33\x06\x05\x46\x8a\x99\xf2\xfc\xf8\xed\x7e\xbf\x07\xe1\x72\x2d\xfc\xcc\x46\xca\xb7\xe1\x57\x1b\x25\xae\xba\xe8\x61\xf2\x0f\xe0\x57\x1a\x26\xc7\xb0\x06\x47\x2f\x27\x1c\xe6\xbd\x7b\x6f\x06\xa9\x51\x1f\x90\xa7\xe0\x70\x1d\xcc\x7b\x40\x13\x9d\xd9\x8b\xde\x7c\xe0\xe2\x6c\xdd\xfd\x0d\x00\x00\xff\xff\x4d\x01\x5a\xb0\xfe\x02\x00\x00")
+var _resReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x92\xc1\x6e\xdb\x30\x0c\x86\xef\x7e\x8a\x7f\xb7\x06\xc8\x9c\x7b\x6f\xc3\xd2\x43\x81\xa1\x19\x90\xf6\x1e\x46\xa2\x1c\x21\xb6\x68\x88\xf4\xda\xed\xb0\x67\x1f\xa4\xda\x4d\x53\x6c\xbb\x92\xfa\x7e\x89\x1f\xf5\x55\x92\x71\x32\x85\x04\xfc\xde\xb4\x9d\xb0\xc7\x6d\x03\x7c\x86\x93\x14\x62\xd7\x9a\x0c\x3d\x6e\xf1\xa4\x9c\x71\xe3\x26\x35\x19\xe2\x2f\xf6\xab\xb9\x3f\x65\xb2\x28\x09\x21\xf6\x5c\x31\x3b\xf1\xc0\xba\xf9\x2b\xf2\xda\x5b\xc3\x65\x26\x63\x84\x2c\x03\xd4\x65\x32\x77\x82\x64\x38\x19\x23\xfb\xb9\x6c\x94\x3c\x65\xbf\x99\xf3\x6a\x36\xb9\x72\xd7\x3f\xc2\xe7\x26\x28\x79\x50\x3a\x4b\x49\x8e\xa3\x29\x6e\x5a\x4a\xe7\x55\x53\x13\x3c\x07\x9a\x7a\x2b\x09\xbb\x1c\xbb\x98\xa8\x47\x9d\xb9\xbc\x5f\xd7\xf0\x82\x24\x06\xf6\xd1\xe0\x63\x66\x67\xfd\x4f\x90\xe2\x39\xf6\x3d\x8e\x8c\xcc\x63\x4f\x8e\x3d\xa6\x51\x12\xa6\xb1\xcb\xe4\x59\xdb\xab\xec\x6b\x71\xfb\x79\x90\xc5\x27\xb6\x97\x2b\xae\xb9\x8b\x39\x5d\x98\xd7\xd2\x7f\x98\x77\x46\xde\xa0\xb9\xf6\x81\xaa\xd8\x71\x0a\x81\xb3\x6e\xca\x63\xca\xda\x91\xe9\x79\x29\xc2\x93\xd1\x1a\xdb\x1d\x1e\x76\x8f\xb8\xdb\xde\x3f\xe2\x53\x85\x62\x2a\xd1\x8e\x67\x8c\x62\x52\xd8\x89\xe1\xa6\x9c\x4b\x46\xf5\xb7\x1c\xba\xd1\x15\xbe\x7c\xbf\x87\x8a\x3b\xb3\x15\x33\xcd\x9b\x81\xea\x18\x94\x19\x93\xb2\xc7\xd3\xc3\xb7\xbb\xfd\x1e\x84\xcb\x16\xf1\x83\xb3\x96\xef\xc4\x2f\x51\x4d\xd7\x8d\x0a\x82\x64\xf0\x0b\x0d\x63\xcf\x88\x01\x47\xb1\x13\x0e\xcb\xdc\x9d\x84\xc1\xaa\xea\x43\x5d\xfc\xe1\xa3\x98\xf7\x07\xe6\xd0\x85\xbe\xea\x2d\x0b\x9e\x94\x7d\xdb\xfc\x19\x00\x81\xae\x55\x7d\x16\x03\x00\x00")
 
 func resReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -121,7 +118,7 @@ func resReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/Readme.md", size: 766, mode: os.FileMode(420), modTime: time.Unix(1464737891, 0)}
+	info := bindataFileInfo{name: "res/Readme.md", size: 790, mode: os.FileMode(436), modTime: time.Unix(1792321971, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -166,7 +163,7 @@ func resDefaultActionsFind_filesSh() (*asset, error) {
 	return a, nil
 }
 
-var _resDefaultActionsGoedFish = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x91\xcd\xae\xda\x30\x10\x85\xd7\xf8\x29\x46\x0e\x0b\x90\x0a\x81\x07\xc8\x02\x95\x08\x58\x14\xaa\x82\xda\x45\x85\x2c\x37\xb6\xc9\xa8\xb9\x36\x8a\x27\x04\x78\xfa\x9b\x3f\x74\x13\xb6\xe7\x78\xbe\x39\x73\x1c\xc0\xc6\x69\x05\x13\x83\x53\x9f\x4e\x1e\x53\x40\x8b\xc4\x98\xd7\x04\xb3\x3b\x6c\x0e\xf1\x5a\xec\xf6\xc7\xd3\x6a\xff\x3d\x86\xb1\xcc\x2f\xfe\xef\xe2\x3c\x70\x7f\xef\xe2\x3f\x9d\xb3\x3c\x33\x16\xc0\x51\xcb\x3c\x49\xe1\x52\x63\x93\xc2\x93\xfb\x08\xff\x15\x98\x11\x5a\x20\xe7\x32\x0f\x06\x73\x4f\xf0\x82\xfc\x5c\x9d\xb6\x30\xde\x1e\x7e\xc4\xe1\xbc\x9e\x09\x65\x42\xe8\xac\x0f\x07\xa2\xd2\x46\x16\x19\xf5\xcc\x7a\x8e\x31\x53\xd8\x46\x69\xd6\x89\x44\xb1\x51\x00\x89\xaa\x8e\x20\x07\x12\x14\xe6\x3a\x21\x97\x3f\xbe\x81\xb4\x0a\xac\x23\x34\x8f\x36\x99\x33\x40\xa9\x06\xab\xcb\xfa\x15\x1b\xbd\x22\x56\xc3\xf5\x31\x37\x36\x6a\x9e\xcd\x66\xf2\x8a\x70\x43\x5d\x8a\x2a\xaf\x28\x5d\xfe\x5f\x54\xef\x61\xfc\xd6\xcc\x57\x15\x7c\x72\x2d\xd5\x94\x33\x5d\x2d\xec\xe5\x73\x03\xa0\xbb\x6a\xfb\xce\xe8\x06\xdb\xf5\x75\xcb\x2d\xa1\xab\x29\x5e\xef\x4e\x87\x5f\xc0\x7b\x10\xad\x90\xde\x21\x1d\x23\x68\x4d\x59\x75\x9d\x69\xc6\x64\x86\xd2\x57\xa7\x45\xbc\xab\x89\xbf\x34\x1f\x71\xdf\xfc\x97\x20\x7d\xa7\xb9\x4f\x79\x67\x98\x88\x1b\xb4\x4a\xd4\x00\xdf\xd3\xfd\x33\xe2\x37\x5a\x2e\x16\xc2\xe3\x53\x37\x06\x9b\x87\x03\x85\x7d\x06\x00\x00\xff\xff\xf1\x60\x7e\x7d\x54\x02\x00\x00")
+var _resDefaultActionsGoedFish = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x91\xc1\x6e\xf2\x30\x10\x84\xcf\xf8\x29\x56\x0e\x07\x90\x7e\xc8\xdf\x07\xe0\x80\x4a\x04\x1c\x0a\x55\x41\xed\xa1\x42\x91\x6b\xaf\xc9\xaa\xa9\x8d\xec\x0d\x81\xb7\xaf\x12\x82\x0a\x5c\x77\x66\xbe\x5d\x8f\x13\x98\x7b\x34\x30\xb0\x34\x8c\xc5\xe0\x3c\x04\x72\xc4\x42\x44\x64\x18\x9d\x60\xbe\xce\x66\xf9\x72\xb5\xd9\x4e\x57\xcf\x19\xf4\x55\xd8\xc7\xcf\xff\xbb\x3b\xf5\x7d\x99\x7d\x74\xca\xd3\x4e\x88\x04\x36\xa8\x82\x2e\x60\xdf\x60\x75\x15\xd9\xff\xa4\x5f\x15\x95\x4c\x0e\xd8\xfb\x32\x82\xa5\x10\x19\xae\x90\xd7\xe9\x76\x01\xfd\xc5\xfa\x25\x4b\xc7\x4d\x26\x55\x9a\xc9\xbb\x98\xde\x0d\x0d\x5a\x55\x95\x7c\x23\x36\x39\x21\x6c\xe5\xda\x49\xbb\x2e\xd7\x46\xf4\x12\xd0\x06\xc8\xb1\x07\x05\x86\x02\x6a\xf6\xe1\xfc\x0f\x94\x33\xe0\x3c\x93\x3d\xb7\x56\xf0\x16\xb8\x40\x70\x58\x37\x2e\xd1\xbb\x9e\xa8\x4d\xfb\x98\xa3\xe8\xb5\xb6\xd1\x48\x1d\x08\x8e\x84\x75\x1e\x91\xf3\xda\x87\xef\xdc\x50\x80\xfe\x43\x33\x7f\x55\xc8\xc1\xa1\x36\x43\x29\xd0\x19\xb8\xb9\xcf\xdf\x01\xfd\x01\xdd\x23\xa3\x0b\x5e\xd6\x37\x2d\x5f\x08\x5d\x4d\xd9\x6c\xb9\x5d\xbf\x81\xbc\x81\xa0\x21\x7e\x84\x74\x8c\xe4\x22\x2a\xb0\x54\xa2\x10\xaa\x24\x15\x41\x9b\x89\xec\x6a\x92\xd7\x59\x9c\xc8\xd8\xfe\x57\xce\x78\xe2\x71\x2c\x64\x27\xd8\x89\xb4\xe4\x4c\x6e\xa9\xc4\x38\x8e\x85\x14\xbf\x03\x00\x81\x4f\x0c\x5c\x2a\x02\x00\x00")
 
 func resDefaultActionsGoedFishBytes() ([]byte, error) {
 	return bindataRead(
@@ -181,12 +178,12 @@ func resDefaultActionsGoedFish() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/actions/goed.fish", size: 596, mode: os.FileMode(488), modTime: time.Unix(1477326112, 0)}
+	info := bindataFileInfo{name: "res/default/actions/goed.fish", size: 554, mode: os.FileMode(509), modTime: time.Unix(1792324612, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultActionsGoedRc = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x84\x52\xb1\x6e\xdb\x30\x10\x9d\xcd\xaf\x38\x40\x9c\x82\x24\x4a\x3a\x16\xd0\x10\x34\x42\xe2\xa1\x76\x51\x1b\xf5\xc8\xd2\x22\x59\x1d\xaa\x92\x86\x78\xb6\x6a\x0b\xfa\xf7\x92\x94\x5b\x5b\x5e\x3a\x91\xb8\xf7\xee\xbd\xc7\x3b\x66\xf0\xe6\xb4\x82\xb6\x02\xb4\x48\x8c\xbd\x2d\xcb\x57\x31\x5f\xac\xd6\x2f\x8b\x4f\x65\xc1\x9f\xc7\xc2\xb7\x79\xb9\x29\xf8\x07\xc6\x32\x58\x69\xd9\x56\x35\xfc\x88\x5d\xd5\xde\x93\xfb\x95\x6f\xf7\xd8\x10\x5a\x20\xe7\x1a\x0f\x06\x5b\x4f\xc0\xbe\xbc\xac\xdf\x0b\xfe\xbe\xfc\x5c\xe6\x8f\x91\x9c\xcb\x8a\xd0\x59\x9f\x7f\xbc\x2e\x2a\x6d\xe4\xbe\xa1\x2b\x30\xf6\x31\x66\x6c\x72\x10\x6e\xa7\x2d\xf4\x6c\x96\x41\xba\xc9\xa0\xde\xe8\x10\x35\xa1\x6c\x96\x52\x3c\x3c\xc8\x1d\x8e\x38\x9f\xc4\x87\xef\xfd\xae\x53\x03\x84\x57\x0c\x17\x49\xad\x90\x46\xc9\x74\x9b\x4a\x82\xb4\x0a\x3a\x19\xea\xc6\xb5\x10\x0e\x72\xb0\xd5\xe0\xe5\x21\x60\x39\x54\x8d\xf3\x37\xbe\x49\xe4\x3f\xbe\x95\x1a\x0d\xc3\x89\x36\x28\x4a\x50\xd8\xea\x8a\x5c\x7b\xbc\x4f\x8e\xd6\x11\x9a\xe3\x98\xc0\x19\xa0\x5a\x83\xd5\x5d\x64\xb1\xd9\xdf\xe9\x86\x66\x7e\x37\xb1\x3e\xa0\xee\x84\xd7\x24\x3a\xd7\x8a\x9f\x81\x7c\x9b\x83\xff\x5b\xde\x39\x12\x1b\x80\xb1\xf2\x75\xbe\x5e\x7e\x2d\x2e\xc3\xc8\x60\x53\x87\xe1\x75\x48\xf5\x79\x0c\xf7\xc0\x47\x16\xa0\x1f\x47\x1d\x9f\xe1\xa0\xbf\x2c\x85\xdf\x0d\x30\xd9\x4a\x1e\xd3\x06\x96\x87\xde\xa7\x3f\x22\x48\xff\xa6\x47\x5f\x47\x6a\x04\x0c\xf4\x06\xad\x12\x91\xec\xcf\x75\x48\x1d\x27\xe8\x0f\xf4\xfc\xf4\x24\x3c\x9e\x74\x40\x62\xca\x49\x81\xb1\x3f\x01\x00\x00\xff\xff\x8a\xdb\xb8\xe1\xa8\x02\x00\x00")
+var _resDefaultActionsGoedRc = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x84\x52\xcb\x6e\xdb\x30\x10\x3c\x7b\xbf\x62\x00\xf3\x64\x24\x16\xd2\x63\x01\x1f\x82\x46\x48\x7c\x68\x5c\x34\x46\x73\x54\x19\x3e\xaa\x45\x55\xd2\x10\xd7\x51\x03\x41\xff\x5e\x90\x4a\xeb\x38\x97\x5e\x88\xc5\x3e\x66\x66\x67\xb9\xc4\x6d\x74\x16\xbd\x01\x07\x16\xa2\xdb\x5d\x7d\xd3\x6c\xef\x1f\xf6\xd7\xf7\x9f\xea\x8d\xba\x9a\x13\xdf\xb6\xf5\xe3\x46\x7d\x20\x5a\xe2\xc1\xe9\xde\xb4\xf8\x91\xa7\xcc\x31\x49\xfc\x55\x3d\x1d\xb9\x13\x0e\x90\x18\xbb\x04\xcf\x7d\x12\xd0\x97\xeb\xfd\xdd\x46\xdd\xed\x3e\xd7\xd5\x3a\x37\x57\xda\x08\xc7\x90\xaa\x8f\x6f\x93\xd6\x79\x7d\xec\xe4\x4d\x31\xcf\x11\xf9\x50\x18\x9a\x78\x70\x01\x23\x2d\x96\x28\x91\x86\xe7\xce\x81\xe7\x2a\x2d\xf2\x8b\xcb\x4b\x7d\xe0\xb9\xae\xce\xe4\xe3\xfb\x78\x18\xec\x04\x75\x45\xd3\x09\xd2\x59\x96\x19\xb2\x44\xe7\x90\xd0\xc1\x62\xd0\x2c\xf0\xb1\x07\x0b\x24\xe2\xc9\x21\xe9\x67\x67\x51\xc1\x74\x31\xbd\xe3\x2d\x20\xff\xe1\x35\x76\x26\x34\x16\x1c\x24\x42\xc3\x72\xef\x8c\xc4\xfe\xe5\xa2\x30\x86\x28\xec\x5f\x8a\x3e\x44\x0f\x69\x1d\x82\x1b\x72\x17\x2d\xfe\xba\x6b\x2c\xd4\xea\x8c\xfa\x99\xdd\xd0\x24\x27\xcd\x10\xfb\xe6\xa7\xe5\xfe\xfd\xfe\xea\xdf\xf1\x5e\x25\xd1\x04\xa2\xfa\x66\xbb\xdf\x7d\xdd\x9c\xcc\x58\xe2\xb1\x75\x01\x03\x4b\xfb\x6a\xc3\x05\xd4\xdc\x05\x4e\x25\x51\xd6\x88\x18\x4f\x47\x51\xab\x09\x67\x57\xa9\xb2\x5a\x1f\x90\x30\xa6\xf2\x47\x1a\x71\xbf\x65\x9d\x5a\xa8\xd5\x94\xc7\x3d\x46\xcf\xc1\x36\xd9\xef\xb4\x4e\x2d\xd4\x6a\x02\xd1\x9f\x01\x00\x7f\xb8\x57\x35\x82\x02\x00\x00")
 
 func resDefaultActionsGoedRcBytes() ([]byte, error) {
 	return bindataRead(
@@ -201,12 +198,12 @@ func resDefaultActionsGoedRc() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/actions/goed.rc", size: 680, mode: os.FileMode(420), modTime: time.Unix(1475089231, 0)}
+	info := bindataFileInfo{name: "res/default/actions/goed.rc", size: 642, mode: os.FileMode(436), modTime: time.Unix(1792324612, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultActionsGoedSh = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x91\x51\x6f\x82\x30\x14\x85\x9f\xed\xaf\xb8\x29\x3c\x6c\xc9\x14\xdd\xe3\x12\x92\x99\x49\xd4\x87\xe9\x32\xcd\xf6\x58\x2b\x2d\xa3\x19\x6b\x09\xbd\x88\xba\xec\xbf\x8f\x02\x26\xe8\x63\xcf\xbd\xdf\xb9\x87\x83\x07\x73\x23\x05\xec\xb9\x4d\x41\x69\x85\x84\xc8\x63\x6e\x0a\x84\xf9\x3a\x9a\xb1\xe5\x6a\xb3\x9d\xae\x5e\xa2\xd0\x9f\x5c\xe9\x1f\xcb\xe8\x33\xf4\x1f\x09\xf1\x60\x23\x79\x11\xa7\xf0\xe5\x5c\xe2\xd2\xa2\xf9\x09\xf6\xa5\xca\x50\x69\x40\x63\x32\x0b\x89\x2a\x2c\xc2\x05\x7f\x9b\x6e\x17\xa1\xbf\x58\xbf\x46\xc1\xc8\x31\x01\x8f\x51\x19\x6d\x83\xa7\xbe\x28\x64\xc2\xcb\x0c\x7b\x43\xc7\x11\x92\x94\xba\x51\x9a\x73\x2c\x16\x77\xf7\xf0\x4b\x06\x1e\xc4\xa2\x0e\x8f\x06\x38\x08\x55\xc8\x18\x4d\x71\x7a\x00\xae\x05\x68\x83\x2a\x39\xb5\xe9\x4c\x02\x98\x4a\xd0\xb2\x72\x5b\x64\x70\x89\x59\xc3\xfe\x33\x19\x34\x3b\xc3\x21\xcf\x15\x1c\x94\xac\x98\x95\xc8\x2a\x53\x7c\xb3\x7a\x19\xfc\xab\x3a\xba\xa7\x6b\x01\xe8\x2e\xaf\xc4\x8e\x92\x3f\xe8\xc5\x33\x6d\xb0\x9e\xa5\xc9\xa5\xbe\x75\xe9\x50\x77\xdd\xd1\x5d\x43\xd1\x6c\xb9\x5d\xbf\x87\xb4\x07\x4b\xa1\xf0\x16\xee\x58\xaf\x1d\xf2\xba\xe6\x4c\x12\xc2\x33\xc5\x6d\xfd\x45\x2d\x5e\x37\x44\x2f\x9a\x0d\xa9\x6d\x7e\x15\x43\x79\xc4\x91\x4d\x69\x37\x48\x42\x9a\x28\x2d\x98\x33\xb0\x3d\xdd\x9e\x43\x7a\xc0\xc9\x78\xcc\xac\x3a\xcb\x66\x40\xae\xde\xe4\x3f\x00\x00\xff\xff\xdf\x60\x48\xea\x3c\x02\x00\x00")
+var _resDefaultActionsGoedSh = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x91\x4f\x4f\xc2\x40\x10\xc5\xcf\xec\xa7\x98\x6c\x7b\xd0\x44\x68\xf4\x68\xd2\x44\x22\x0d\x70\x10\x8c\x10\x3d\x2e\xcb\xfe\xb1\x13\xeb\x6e\xd3\x9d\x5a\x88\xf1\xbb\x9b\x96\x92\x14\x8e\xfb\x66\x7e\x6f\x5e\xde\x46\x30\xf7\x46\xc3\x5e\x86\x1c\xd0\x21\x31\x66\x0e\xa5\xaf\x08\xe6\xeb\x6c\x26\x96\xab\xcd\x76\xba\x7a\xce\xd2\xf8\xfe\x42\x7f\x5f\x66\x1f\x69\xfc\xc0\x58\x04\x1b\x23\x2b\x95\xc3\x67\xeb\xa2\xea\x40\xfe\x3b\xd9\xd7\x58\x10\x3a\x20\xef\x8b\x00\x16\xab\x40\x70\xc6\x5f\xa7\xdb\x45\x1a\x2f\xd6\x2f\x59\x32\x69\x99\x44\x2a\x42\xef\x42\xf2\x38\x14\xb5\xb1\xb2\x2e\x68\x30\x6c\x39\xc6\x6c\xed\x3a\xa5\x3b\x27\x94\xbe\xb9\x85\x5f\x36\x8a\x40\x69\x40\x47\x1e\x24\x68\xac\x8c\x22\x5f\x1d\xef\x40\x3a\x0d\xce\x13\xda\x63\xb7\x0e\xde\x02\xe5\x06\x9c\x69\xda\x2d\x36\x3a\xc7\x54\x1a\xe2\x27\x36\xea\x76\xc6\x63\x59\x22\xfc\xa0\x69\x44\x30\x24\x1a\x5f\x7d\x09\x8d\x15\xc4\x17\x75\xf4\xcf\xb6\x05\xe0\xbb\xb2\xd1\x3b\xce\xfe\x60\x10\xcf\x9f\x82\x0d\x2c\x7d\x69\xdc\xb5\x4b\x8f\xb6\xd7\x5b\xba\x6f\x28\x9b\x2d\xb7\xeb\xb7\x94\x0f\x60\xa3\x91\xae\xe1\x9e\x8d\x4e\x43\x09\x16\x0b\xc3\x98\x2c\x50\x06\x50\xfa\x84\x0b\xa5\xf9\x59\x0b\x29\x0f\xdd\x57\x09\x32\x07\x9a\x84\x9c\xf7\x03\x9b\x72\x8b\x4e\x0b\x8b\x85\x09\x93\x90\x73\xf6\x3f\x00\x67\x2e\xba\xb2\x14\x02\x00\x00")
 
 func resDefaultActionsGoedShBytes() ([]byte, error) {
 	return bindataRead(
@@ -221,52 +218,12 @@ func resDefaultActionsGoedSh() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/actions/goed.sh", size: 572, mode: os.FileMode(420), modTime: time.Unix(1475089176, 0)}
+	info := bindataFileInfo{name: "res/default/actions/goed.sh", size: 532, mode: os.FileMode(436), modTime: time.Unix(1792324612, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultActionsGoed_helperAnk = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x90\xc1\x4e\xfb\x30\x0c\xc6\xcf\xcd\x53\xf8\xdf\xff\x25\x95\x50\x81\x07\xd8\x61\xda\xaa\xa9\x97\x21\x31\x04\x07\x84\x50\xd4\xba\x10\xd1\x3a\x95\x93\x0e\x24\xb4\x77\xc7\x69\xc3\xe0\xc8\x2d\xfe\x3e\xfb\x67\x7f\xf9\x0f\x3b\x87\x2d\xbc\x62\x3f\x22\xc3\xe0\xda\xa9\x47\xe8\x1c\x83\xa1\x37\x07\xbe\x61\x3b\x06\x5f\xaa\xa3\x61\xc0\x0f\x6c\x60\x05\x76\x18\x1d\x07\x9d\x3b\x7f\x19\x95\xbc\x50\x2a\x8d\xcd\xa4\x4f\x95\x25\xe6\xd1\xe2\x3b\xd4\x5b\x50\x59\x37\x51\x33\x97\xba\x88\x7e\xc6\x18\x26\x26\x08\xae\xa6\xa0\x9d\x2f\x77\x18\x90\x8e\x3a\xdf\xdd\x54\xdb\xe7\xfb\xba\x7a\xc8\x8b\x42\x65\x27\x95\x9d\x59\x96\x7c\x30\xd4\xa0\xf0\x12\xee\x5b\xf9\x0b\xb2\xde\x1f\xee\xd6\xfb\x4d\xf5\x1b\x7b\x3b\x91\x07\x03\x8d\x1b\x06\x43\xed\x85\xa4\xb3\x41\x5f\x17\x60\x3b\xe8\x8c\xed\x7d\x5a\xc3\x13\x69\xc3\x2f\x7e\xd9\xd2\x0c\xad\xfc\x40\x8c\x5d\x6e\x96\xc1\xd9\x7c\xbc\x7a\x2a\x16\xb7\x5c\x4b\x29\x2d\x51\x15\x65\x64\x4b\xa1\x4f\x84\xd4\x71\x08\xad\x9b\x82\xf4\xc8\x95\xcb\xfb\xc7\x40\xe6\xb3\x21\x6f\x31\x16\x25\xda\x72\xb0\x8e\x0c\x39\x30\x8a\xff\x56\x40\xb6\x9f\xaf\x3a\xaf\x11\x3d\x76\x64\x02\xa8\x96\x38\x52\x9d\x62\xe4\x93\xfa\x0a\x00\x00\xff\xff\x98\x29\x53\x99\xe9\x01\x00\x00")
-
-func resDefaultActionsGoed_helperAnkBytes() ([]byte, error) {
-	return bindataRead(
-		_resDefaultActionsGoed_helperAnk,
-		"res/default/actions/goed_helper.ank",
-	)
-}
-
-func resDefaultActionsGoed_helperAnk() (*asset, error) {
-	bytes, err := resDefaultActionsGoed_helperAnkBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "res/default/actions/goed_helper.ank", size: 489, mode: os.FileMode(420), modTime: time.Unix(1464737891, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resDefaultActionsGoimportsSh = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x8f\x41\x4b\x33\x31\x10\x86\xef\xf9\x15\xef\x47\xf7\xf8\xad\x0b\xde\x2b\x88\x16\xe9\xa5\x82\x8a\x1e\xdb\x6c\x76\xd2\x0d\x26\x99\x92\x64\x5b\x84\xfe\x78\x27\xb6\xc8\xe2\x71\x26\x4f\xde\xf7\x99\xc5\xbf\xae\x77\xb1\xeb\x75\x1e\x55\xa6\x82\x96\xb0\xb0\xda\x79\x90\x4e\xfe\x4b\xa9\x05\x5e\xa6\x88\x3d\xbb\x70\xe0\x54\x32\x38\x42\xcb\x88\xcc\x53\x32\x04\xeb\x3c\xfd\x17\xc8\x6a\xef\x33\x7a\x6d\x3e\x51\x58\xde\x6d\x28\x70\x76\xf6\x2f\x72\x81\x3e\x4a\xb0\xee\x3d\xdd\x28\x75\x74\x74\x5a\x36\x4f\xcf\xab\xc7\xed\xfb\x7a\xf5\xa1\x5c\xcc\xe5\x3a\xaf\x37\xaf\x6f\xf7\x9b\x87\x55\x2d\xdf\x8b\x52\xed\x80\x67\xa3\x8b\xe3\xa8\x72\x32\xcb\xdd\x9e\x69\x40\xdb\xea\x83\x43\x0d\xda\xca\x72\x2b\x04\x9a\x1a\x83\xa6\xee\x76\xca\x84\x61\xf9\x2b\xa0\x4e\xa3\x33\xe3\x4c\xe8\xf6\x0e\xdd\x40\xc7\x2e\x4e\xde\xe3\x7c\xc6\x85\x16\x6f\x69\x4d\x72\x72\x19\x09\x86\x43\xd0\x71\xa8\x47\xd7\xb1\x8a\xa8\x46\x40\xb4\x27\x34\xd2\x59\x51\xf2\xac\x87\x1f\x0b\xf4\x93\xb5\x94\x60\x13\x87\x0b\xfb\x57\xf3\x0a\xcf\x2c\xa1\xbe\x03\x00\x00\xff\xff\x23\x8f\x45\x09\x81\x01\x00\x00")
-
-func resDefaultActionsGoimportsShBytes() ([]byte, error) {
-	return bindataRead(
-		_resDefaultActionsGoimportsSh,
-		"res/default/actions/goimports.sh",
-	)
-}
-
-func resDefaultActionsGoimportsSh() (*asset, error) {
-	bytes, err := resDefaultActionsGoimportsShBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "res/default/actions/goimports.sh", size: 385, mode: os.FileMode(493), modTime: time.Unix(1472885273, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resDefaultActionsSearchAnk = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x5c\x90\xcf\x4a\x03\x31\x10\x87\xcf\xce\x53\x8c\xe9\x25\xc1\xed\x9f\x17\x58\x41\x45\xbc\x8a\x3d\x96\x22\x61\x3b\xdd\x06\xd3\x64\x99\x24\x52\x11\xdf\xdd\x49\x44\xad\x5e\x16\x66\xe6\xfb\x7d\x33\x9b\xd9\xa5\x0d\x2f\x11\x60\x86\xf7\x27\x7b\x9c\x3c\x61\xad\x31\x0d\xec\xa6\xdc\xe1\x50\x98\x29\x64\xff\x86\x25\x94\x44\x3b\x00\x1f\xed\x4e\xab\x31\xd2\xee\xf9\x40\x7e\x22\x5e\x48\x40\x19\x80\x57\xcb\x18\x53\x87\x7b\x6f\xc7\x0e\xe9\x44\x03\xf6\xe8\x8e\x53\xe4\xac\x55\x4c\xca\x74\x3f\x55\x45\xce\xeb\x98\x96\x95\xaf\x96\x19\x3e\x5a\x4e\x72\x05\x8f\x09\x97\x4d\x96\xc0\x8d\x21\x32\xdd\x59\xe9\xf7\xad\xb5\xb8\x8d\xd1\x6b\xe5\x94\xac\xb3\x3e\x51\x87\xea\x97\x11\x4d\x63\x9a\x48\x1b\xb9\x78\xf8\x8e\xdd\x88\x55\x9b\xcd\x6a\x0b\xcd\xdf\xe3\x46\x8d\x4c\x93\x68\xd4\x3c\xb4\xaf\x57\x5b\x70\x7b\x3c\xdf\xd8\x63\xe6\x42\xf8\x0e\x17\x2d\x74\x55\x53\x73\x27\xdc\x47\x25\x3d\x05\x2d\x1b\x0c\x5e\xe3\xea\x1f\xc3\x62\x94\x91\x80\x48\x72\xe4\x9f\xe9\x57\xbf\xfe\xef\x53\x09\x98\xc8\xf2\x70\x80\x07\x79\xd5\x05\x97\xa0\x73\x5c\x67\x76\x61\x5c\x7b\x37\x90\xae\x21\x63\xe0\x33\x00\x00\xff\xff\x7e\x24\xb8\x7b\xab\x01\x00\x00")
+var _resDefaultActionsSearchAnk = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x3c\x8e\xc1\x6a\xf3\x40\x0c\x84\xef\xfb\x14\x43\x7c\xb1\xc1\x84\xff\xfc\x43\x8f\xa5\xf7\xe6\x58\x8a\xd9\xae\xc6\xb1\x9a\x78\x65\xb4\x9b\x3a\x7d\xfb\x62\x87\xf6\x26\x69\x3e\x7d\x4c\x83\xe7\x7b\x9c\x97\x2b\x11\xf3\xc5\x50\x92\xeb\x52\x7b\x78\xcc\x58\xb5\x4e\x9a\x71\x36\x0a\xda\x42\xe2\x70\xda\xd3\x72\x80\x66\xd4\x89\x8f\xe8\x95\x51\x66\x76\xa1\xc1\x89\xd1\xd3\xc4\x82\xca\x7b\xfd\x65\x46\xbd\xb2\xe0\x96\x85\xbe\xff\xa4\x9b\x3b\x73\xc5\x97\x72\x85\xa8\x33\x55\xf3\xef\x1e\x4a\x8c\x6e\xf3\xc6\x84\x06\xc9\xe6\x39\x66\xc1\x47\x74\xfc\xdf\xaa\xa1\xec\xf2\xe3\x36\x8e\x66\x21\x88\x3a\x9e\xf0\x62\x94\xe3\xa6\x1a\x56\xf3\xcb\x20\xea\xed\xdf\xa9\xed\xba\xb0\x2f\x94\x61\x71\xfb\x64\xaa\xc3\xc3\xd2\x8a\x7a\x8f\xe8\xe7\xf2\xf6\xef\xbd\x0b\x3f\x03\x00\x54\xe2\x2b\x17\x04\x01\x00\x00")
 
 func resDefaultActionsSearchAnkBytes() ([]byte, error) {
 	return bindataRead(
@@ -281,7 +238,7 @@ func resDefaultActionsSearchAnk() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/actions/search.ank", size: 427, mode: os.FileMode(493), modTime: time.Unix(1464737891, 0)}
+	info := bindataFileInfo{name: "res/default/actions/search.ank", size: 260, mode: os.FileMode(509), modTime: time.Unix(1792321971, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -326,27 +283,7 @@ func resDefaultActionsStatsSh() (*asset, error) {
 	return a, nil
 }
 
-var _resDefaultActionsVt100_sizeSh = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x64\x90\xb1\x0e\x82\x30\x10\x86\xf7\x7b\x8a\x13\x3a\x68\x22\xe1\x09\x18\x8c\x12\xc3\x82\x03\x04\x47\x28\x58\xa5\x09\x52\x43\x1b\x08\x3e\xbd\x5c\x25\x24\xca\xd2\xb4\x5f\xbf\xfb\x73\x77\xee\xc6\x2f\x65\xeb\x97\x5c\xd7\xa0\x85\x41\x4f\xa0\x7b\xe7\xb2\x41\xc1\xbb\x66\x04\x70\x31\x99\xa8\x31\x23\x6a\xf9\x16\x68\x14\x3e\xb9\xa9\x6a\x7c\x28\x71\xc3\x5e\x8a\xe1\xcb\xb7\x9a\x94\x4e\x0d\x7a\x8f\xf6\x5a\xa9\x46\xef\x00\xc8\x08\xd8\xf9\x12\x9e\xf2\x2c\x0a\xaf\x20\x5b\x6d\xe6\x77\x14\x27\xe9\x21\x3e\x86\x00\x54\x16\x14\x36\xd1\xf3\xf8\x4b\xda\xdc\x9c\x28\x32\x2a\x40\x46\xa0\x00\xca\x5c\x7b\x44\x7f\x3d\xf8\x57\xa6\xc1\xf2\xde\xac\x4c\x64\x44\x60\x69\x1d\x19\x9d\xb0\xf4\x3f\xff\x83\xa8\x6a\x85\x0e\xed\x21\x4b\x97\x35\x58\x77\x9e\xd8\x7a\xb6\xc4\xf9\x04\x00\x00\xff\xff\xcf\x77\x15\x3b\x50\x01\x00\x00")
-
-func resDefaultActionsVt100_sizeShBytes() ([]byte, error) {
-	return bindataRead(
-		_resDefaultActionsVt100_sizeSh,
-		"res/default/actions/vt100_size.sh",
-	)
-}
-
-func resDefaultActionsVt100_sizeSh() (*asset, error) {
-	bytes, err := resDefaultActionsVt100_sizeShBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "res/default/actions/vt100_size.sh", size: 336, mode: os.FileMode(493), modTime: time.Unix(1472885273, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resDefaultBindingsToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x94\x3f\x6f\xdb\x3c\x10\xc6\x77\x7f\x0a\xc2\x19\x93\xd7\x42\x5e\x04\x45\x51\xa0\x53\xd2\xa1\x43\xa6\xa2\xb3\x40\x51\x67\x89\x35\xc5\x53\xc8\xa3\x1d\x77\xe8\x67\xaf\x74\x24\xf5\xaf\x49\x16\xd9\xfa\x3d\xa7\xe3\x3d\x3c\x1e\x6f\xc4\xb3\xec\xbd\x38\xc1\xb5\x42\xe9\xea\xa2\xc3\xe0\x41\xa8\x16\x5d\xed\x05\xa1\xf8\xf9\x5d\xc0\x19\x2c\xf9\xdd\x8d\xf8\x01\x20\x5a\xa2\xde\x7f\x29\x8a\x46\x53\x1b\xaa\x83\xc2\xae\x20\x85\x46\xba\xa2\x41\xa8\x8b\xca\x60\x55\x74\xd2\x13\xb8\x82\xbf\x8b\xcf\x92\xae\x3d\x1c\x1a\xdc\xdd\x0c\x69\xbe\xd5\x9a\x84\xb6\xe2\x4f\x71\x88\xdf\x68\x5b\x6b\xdb\xf8\x03\x61\x67\x06\xfd\x3f\xf1\xfc\x78\x2f\x3c\x49\x3b\x94\x70\x44\x27\x9e\xb9\xa6\x47\xa3\xd5\x49\x54\x81\x08\xad\xb8\x17\x45\x31\x3c\xb4\x17\x06\x8e\x74\x27\xfe\x17\x9d\xae\x6b\x03\x77\xe2\x41\x38\xdd\xb4\x14\xf3\x3c\xbd\x91\xe7\xc9\xc9\x66\x4a\x93\xc2\xf2\x7a\xcb\x30\x0c\x95\xd9\xae\xba\xdb\x0f\xa5\xed\xc5\x57\xb1\xf7\x40\xa5\x0a\xce\xa3\xdb\x8f\xf0\x81\x21\xf6\x60\x4b\x6d\x4b\x0b\x97\xf2\xac\xe1\xc2\xd2\xe7\x18\xaf\x1c\x1a\x53\x86\x9e\xd9\xfd\xa7\x25\xac\xf1\x62\x47\xfc\x94\x53\x1b\x50\x54\x72\x27\x18\x3f\xae\xf8\x65\x68\xcd\x80\xa5\xa1\xdb\xf1\xc3\x52\x3a\x87\x17\x0e\xb0\xf2\x9c\x73\x8d\xea\xb8\x33\x1b\x75\x44\x49\xe5\x5d\xda\xc8\xcc\x92\x1e\xfa\x8d\xc8\xa5\x57\x52\x9d\x7c\x2f\x15\x30\x9e\xdf\x76\x7b\x45\xce\xdc\x4a\xc6\x2d\x76\x13\xa9\x96\xa5\x4b\x63\x32\x57\xcc\x15\xf6\xd7\x4c\x62\x4a\xb0\x75\x06\x2d\x83\x0e\xcf\x90\xeb\x66\xfc\x6b\xc6\xb9\x5e\xe6\xa7\x99\x73\xa9\x0c\xcd\x0c\xd3\xce\x30\xb6\xef\xb5\x8b\x55\x5c\xa9\x5e\x76\xb0\x92\x5f\x58\x7e\x09\x7a\x5a\xda\x31\x71\x60\x50\x4e\xd5\xfb\x68\x5c\x9e\xa7\xad\xa0\x39\xed\x30\x1e\x5d\xc6\x81\x71\x3d\xec\x10\x41\xb9\xdc\xba\x33\x0b\xfd\x38\x4c\x19\xc5\x6e\x28\x83\x1e\xca\xcb\x30\x36\x38\x15\xf5\x1a\x95\x30\xd5\x74\x4d\x35\xd5\x98\xc9\x6f\x26\xc1\x32\x89\xeb\x2d\x96\x1e\xd9\xfa\x38\x2d\x77\x6d\x6c\xcb\xdc\x9e\x61\xa2\xc1\xa5\xf7\xf1\xdf\x40\xbc\x92\x7d\x4c\x47\xd8\x34\x06\x4a\xd5\xd5\x65\x25\x47\x8d\x3d\x2d\xce\xc5\xe6\x5c\x2e\x1b\x6c\xe1\x95\x92\xeb\x66\x5a\xbb\x77\x1a\xdd\x4c\xb9\xb9\x0e\x28\x38\xbb\x2a\x61\x7b\xa0\x57\x27\xc4\xb7\xfa\xf8\xcf\xbc\xa4\x53\x99\x96\x89\x21\xd9\x68\xd2\xa2\xdf\x28\x4d\x3e\x92\x96\xec\x44\x71\x63\x2a\x85\x24\x5b\x31\x64\x32\x97\xc4\xa5\xc7\x18\x31\x3b\x5d\x86\xb0\xe1\x18\xb0\xf5\x98\xc2\xd6\x2e\x57\x93\x9b\x22\x62\x8e\xd0\x83\xfb\xe0\xd2\x88\xfa\xfb\xd7\x46\xd4\x3f\xba\x38\x62\xc4\xdb\x57\x07\xc9\x78\x17\x8c\xbf\xbb\xfd\x2a\x66\x1a\xda\xdd\xdf\x00\x00\x00\xff\xff\x02\xe9\x71\xf0\x8f\x06\x00\x00")
+var _resDefaultBindingsToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x94\x3b\x73\xe3\x36\x10\xc7\x7b\x7e\x8a\x1d\xaa\x3c\x47\x1c\x39\xce\x25\xf1\x4c\x8a\x8c\x9d\x22\x85\xab\x4c\x6a\x0c\x04\x2e\x29\x44\x20\x16\x87\x87\x74\x4e\x91\xcf\x9e\xc1\x02\x7c\x48\xf7\x68\x6c\xf1\xf7\x5f\x2c\xf6\x0f\x2c\x76\x07\x6f\xd2\x05\x38\xe3\xfb\x91\xa4\xef\xbb\x89\x52\x40\x50\x27\xf2\x7d\x80\x48\xf0\xf7\x9f\x80\x17\xb4\x31\x34\x3b\xf8\x0b\x11\x4e\x31\xba\xf0\xdc\x75\xa3\x8e\xa7\x74\xdc\x2b\x9a\xba\xa8\xc8\x48\xdf\x8d\x84\x7d\x77\x34\x74\xec\x26\x19\x22\xfa\x8e\xd7\x95\xbf\x22\xbe\x3b\xdc\x8f\xd4\xec\x9a\x1d\xfc\xd1\xeb\x08\xda\xc2\x7f\xdd\xbe\xac\xd1\xb6\xd7\x76\x0c\xfb\x48\x93\x69\x76\xf0\x03\xbc\xbd\x1c\x20\x44\x69\xfb\x00\x03\x79\x78\xe3\x9a\x5e\x8c\x56\x67\x38\xa6\x18\xc9\xc2\x01\xba\x0e\x0e\xa0\x03\x18\x1c\xe2\x03\x3c\xc2\xa4\xfb\xde\xe0\x03\x3c\x81\xd7\xe3\x29\x96\x3c\xaf\x5f\xc9\xf3\xea\xe5\xb8\xa4\xa9\x61\xf3\x7e\xdb\x30\x4a\x47\x73\xbf\x6b\xb3\x83\xdf\xcb\xe1\x80\x92\x16\xa4\x09\x04\x3e\x59\x90\x10\x94\xd7\x2e\xc2\xe0\x69\x5a\x8c\x49\x15\x35\xd9\xd0\x3d\x80\xc6\x67\x68\x55\xf4\xe6\x83\x34\xf1\x43\x68\xe1\x37\x68\xcb\x8a\xe7\x80\xd2\xab\xd3\x5e\xda\x73\xdb\xb4\x6f\x2f\x87\xa2\x61\x14\x2a\xf9\x40\x9e\xe1\x13\x43\x72\x68\x85\xb6\xc2\xe2\x55\x5c\x34\x5e\x59\xfa\x65\xce\x45\xc6\x88\xe4\x98\x1d\x3e\x6e\x61\x4f\x57\x9b\xf1\xeb\x9c\xda\xa0\x8a\x82\xef\x99\xf1\xb2\x25\xf3\x2b\xf9\xbe\x6d\xda\x5c\x66\x5e\x28\xa4\xf7\x74\xe5\x00\x2b\x2f\x73\xae\xac\x1e\x19\x86\xab\x8e\xea\x34\x97\x93\xf9\xc0\x7c\xd0\xb6\x17\xce\xe3\xa5\xd2\x7c\x4b\x77\xb9\x32\xaa\x2a\xdf\xd8\x9d\xcc\xac\xea\xc9\xdd\x89\x6c\xf4\x28\xd5\x39\x38\xa9\x90\xf1\xfa\xd5\xd4\x93\x66\x7c\xa2\x69\x21\xb5\xe4\x62\x54\x1a\x33\x73\xc5\x5c\x91\x7b\x9f\x49\x49\x89\xb6\x9f\xc1\xc6\x95\xc5\xcf\x71\xc6\x23\x63\xd9\x17\x2a\x48\xa9\xe4\x3d\xda\xb5\x88\x13\x07\x4c\x74\xc1\xd9\x2f\xa7\xfb\x67\xc5\xb3\x4f\xe6\xe7\x95\xb3\x45\x86\x66\x85\xf5\xfc\x19\xdb\x6f\x35\x05\xab\x74\xa3\x06\x39\xe1\x8d\xec\x56\x3f\x83\x36\x4b\xb9\x9f\x18\x7f\x4a\x7a\xa9\xc8\x33\xf1\x68\x48\x2e\x87\x51\xfb\x57\x5e\x96\x75\x71\xdd\x2d\xa2\x9f\x66\x9c\x18\xf7\x68\x30\xa2\xd8\xde\xc4\x85\x05\x97\xe7\xc4\x8c\x4a\x93\x29\x43\x01\xc5\x55\xdb\x9e\x96\x5a\x3f\x17\x25\x2d\x35\xbd\xd7\x9a\x7a\x9a\xc9\xbf\x4c\x92\x65\x52\xf6\xdb\x6c\x9d\xd9\x6d\x2f\x6f\x0f\x33\xdf\xf2\x7a\xdb\x68\x23\xfa\xfa\x9d\x7f\x35\x2d\x06\x25\x5d\x69\x88\x48\xe3\x68\x50\xa8\xa9\x17\x47\x99\xb5\xe1\xf0\xc8\xc2\x48\x91\x44\x8f\x83\xb6\x3a\x3f\xf9\xac\x3c\xd6\xf6\xbb\x70\x92\xe1\xc7\xda\x63\x93\xab\x15\x0d\x4f\xd5\x85\x4f\x56\x44\x19\xf2\xf3\x1f\x7e\x62\x36\x49\xe5\x49\x78\x54\xe5\x2d\x0e\x1f\x37\xd4\x19\x99\xbb\x74\xf8\x99\x59\x7e\x61\x02\xbd\xe7\x39\x31\x94\x59\xc0\x9d\xb8\xb0\x5f\xb7\x85\x3b\x9d\x0d\xf3\x45\x6c\xde\xc6\xdd\xdb\xdc\x36\x6b\xce\x55\xaf\x6a\x5c\x0e\xcc\x79\x4d\x7e\xa5\xdc\xa8\x1e\x63\xf2\xf6\xe6\xdc\xee\x1f\xf5\x4d\xb7\x87\x93\x1e\xbe\x98\x30\x75\x04\xd5\x6d\x4a\xc8\x7c\x3b\x55\xcb\x9f\xb3\xb4\xf8\xa8\x5a\xb5\x53\xc4\x3b\x53\x35\xa4\xda\x2a\x21\x8b\xb9\x2a\x6e\x3d\x96\x88\xd5\xe9\x36\x84\x0d\x97\x80\x7b\x8f\x35\xec\xd6\x65\x72\x5f\x46\x94\x1c\xc9\xa1\xff\xce\x98\x2d\xfa\xb7\x47\x67\xd1\xbf\x37\x3c\x4b\xc4\xd7\xc7\x67\x94\x65\x1e\xe6\xff\x4d\x7b\x13\x33\xd1\x05\x45\x72\x6d\xd3\xfc\x3f\x00\xaa\x15\xaf\x0e\x1f\x08\x00\x00")
 
 func resDefaultBindingsTomlBytes() ([]byte, error) {
 	return bindataRead(
@@ -361,12 +298,12 @@ func resDefaultBindingsToml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/bindings.toml", size: 1679, mode: os.FileMode(420), modTime: time.Unix(1474664438, 0)}
+	info := bindataFileInfo{name: "res/default/bindings.toml", size: 2079, mode: os.FileMode(420), modTime: time.Unix(1792326027, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultConfigToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x34\x4e\x4d\x6b\xc3\x30\x0c\xbd\xfb\x57\x88\xf4\x3e\x32\x06\x63\x3b\xf8\xb2\x95\x6e\x83\x15\x06\x09\xdb\xd9\xc4\x72\x2c\x88\xe5\x90\xc8\x6b\xb7\x5f\x5f\xb9\x25\x07\x21\xbd\x0f\xf4\xde\x0e\xf6\x18\x5c\x99\x04\x86\xcc\x81\x46\xd3\xfd\xb1\xb8\xf3\x3b\x8d\x71\xd2\x11\xe2\xd1\xca\x52\xd0\xf4\x11\x13\xda\xc6\xdf\xdc\x77\x92\xd3\xd4\x98\xa3\x3b\xbf\x26\xff\x52\x42\xc0\xe5\x93\x18\x57\xfb\xd0\xb6\xad\xd9\x41\x87\x02\x12\x11\x66\x27\x11\x24\x83\x83\x94\x39\xaf\xb3\x1b\x10\xfa\xfe\x00\x21\xb3\x54\xbe\xac\xa8\xda\x50\x56\xfd\x77\x25\xcd\x5b\xa1\x83\x6e\xdb\x34\xdb\xd9\xd1\x3f\xda\xfb\x76\x83\xfb\x99\xec\xf3\xa3\x66\x7c\x2d\x58\x73\xd1\x43\x22\xa6\x54\x12\xfc\x12\x9e\xe0\x44\x5e\xa2\x39\x12\x7f\x2b\xfa\xa9\xc0\x3e\xd5\x4a\x98\x27\x50\xdf\xe0\x24\x2f\xa6\x96\xbd\x6a\x1f\xec\x6f\x94\x9a\x2e\x01\x00\x00\xff\xff\x23\x64\x52\x8d\x0c\x01\x00\x00")
+var _resDefaultConfigToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x54\x54\xd1\x6e\xdc\x36\x10\x7c\xd7\x57\x0c\xa4\x00\xb9\x4b\xe5\x8b\xd3\xa2\x45\xeb\x54\x2e\x1a\x27\x6e\x02\xd8\xa8\x01\x1b\xed\xc3\xd9\x29\x78\xe2\x4a\x22\x4c\x91\x02\xb9\xba\xf3\xe5\xc1\xdf\x5e\x2c\x75\x72\x6c\x3f\x18\x47\x8a\x3b\x3b\x3b\x3b\xbb\x05\x3e\x52\xa3\x46\xcb\xa8\xbd\x6b\x4c\x9b\x5d\xef\x1d\xab\x87\xcf\xa6\xed\xac\x69\x3b\x36\xae\xad\x38\x8c\x94\xdd\x74\xd4\x53\x95\xeb\xe9\xf5\x8a\x7d\x6f\xf3\xec\x52\x3d\x9c\xf5\xfa\xc3\xd8\x34\x14\x2e\x8c\xa3\x58\xfd\x74\x7c\x7c\x9c\x15\xb8\x54\x0f\x88\xe6\x1b\x61\xb1\xd9\x33\xc5\x25\x7c\x03\xee\x08\xde\x41\x9b\x78\x0f\xeb\xdb\xf9\xaa\xf6\x7d\xaf\x9c\x86\x1f\x79\x18\x19\x56\x60\xa0\x83\x1f\x06\xd2\x68\x82\xef\xb3\x22\x85\x6e\x52\x16\x2c\x1c\x91\x26\x0d\xf6\x88\x6a\x4b\xe9\x53\x33\x5a\x7b\x88\x5f\x96\x08\x9e\x15\x93\x86\x77\x35\x95\x38\x7a\x87\xc6\x07\x38\xef\x28\x3b\xeb\xf5\xdf\xe9\xd5\x85\x6f\xaf\xcd\x37\xaa\xde\x1d\x4f\x7f\x59\x81\x6b\xe2\x84\x35\x28\xee\x04\x5c\xa1\xf7\xce\xc7\x41\xd5\x84\x9b\x9b\x73\x34\xde\xb1\xdc\x8f\x91\xa0\x50\x8f\x91\x7d\x9f\x2e\xb3\xbf\x46\x73\xee\x1d\x57\x79\x3e\xff\x3c\x60\xcf\xc7\x8f\x83\xa9\x7e\xfb\x25\x2b\x70\x15\x48\x6a\x20\x8d\xde\x38\xd3\x8f\x3d\xb6\x86\x76\xd8\x19\xcd\x5d\x76\x69\xdc\x3f\x86\x76\xff\xca\xa1\xfa\x55\x44\x24\x6f\x61\x9c\xa9\x15\xfb\x90\x89\xbc\xe9\xdb\x17\xa7\xa7\xab\xe9\xd1\xb9\xb1\x14\x45\x4b\xc5\xb0\xa4\xa2\x54\xa1\xf8\xa5\xf8\x2a\x10\x48\x1b\x11\x65\x67\xb8\x83\xc2\x60\xa8\x26\xb0\xda\x58\xca\x0a\x2c\x7c\x30\xad\x71\xca\xa2\x31\x96\x30\x3a\xf6\x63\xdd\x91\x96\x5f\xc6\x26\x9d\xf5\x32\x49\xc9\x1e\x8e\xb6\x14\x92\x0c\x86\xb3\x2b\xc1\xb9\x11\x98\x4b\xe3\x52\xd9\x3f\x3f\x29\xfa\x41\xd5\xf7\xe3\x30\xb7\x79\x08\xb4\x35\x7e\x8c\xd8\x52\x88\xc6\x3b\xec\x3a\x72\x02\x6d\x5c\x8b\x93\xac\x40\x9e\x63\x21\x5d\x5a\x96\xc8\xa3\xe9\x07\x4b\x39\x16\xc2\xe7\x71\x09\x1f\x90\xbb\xb1\xdf\x88\x76\x87\xdb\xd5\xe3\xbb\xc7\x32\xf1\x5d\x3d\xfe\xf8\x88\xd5\x6a\xb5\xcc\xa6\x8c\x51\x3a\x31\x59\x50\x8f\x41\xb1\x24\x5b\x44\xaa\xbd\xd3\x93\x11\x95\xa4\x25\x74\xde\xdf\xcf\xee\x2b\x71\x6f\xac\x25\x8d\x85\x58\x31\xd0\xe0\x83\xa8\xa5\x62\x56\xa0\x51\xc6\x92\x5e\x62\x10\x71\x0d\x67\xd7\x6a\x4b\x9f\xbd\xbf\xbf\x31\x3d\xf9\x91\xa5\xd1\x05\xce\x0e\x2e\xde\xa8\x00\x65\x8d\x8a\x14\xcb\xe4\xa7\x74\x80\x0a\xed\xd8\x93\xe3\x98\x7a\xa1\x86\x81\xdc\xc1\xc4\xcf\x46\x20\x2b\xd6\x67\xbd\xfe\x53\x02\x28\xde\x65\x45\x1b\xab\xbc\x35\x8c\xc8\x8a\xc7\x28\x35\x5d\x28\xd7\x8e\xaa\x25\x44\x0a\xa2\x23\x16\x17\xd7\x57\xcb\x52\x5e\x1c\xf8\x62\x1a\x8f\x12\xde\x11\x06\x0a\xb0\x87\x90\xac\x58\x5f\xc4\xe1\x7a\x8a\x5b\xb5\xfe\x2e\x2b\xce\x7a\x5d\xad\xf3\xd6\x0f\x36\xe6\x77\x59\xf1\xe9\x81\xc9\x49\x6b\x62\xb5\xce\x57\xad\x97\xbb\x39\xe1\x17\x5d\xe5\xad\x17\x0a\x57\xc1\x6f\x2c\xf5\xe8\x15\xd7\x9d\x50\x38\x01\x85\xe0\x43\xfc\xae\xda\x66\x3f\x57\x14\xb1\x88\x44\x70\xf4\xc0\xff\xa5\x57\xcb\x12\x9b\x3d\x9c\xea\xa9\xcc\x0a\x28\x7d\x10\xc1\x07\xf8\x2d\x85\x60\xb4\xb8\x41\x24\xd9\x8c\xc6\xb2\x71\x52\x46\xc4\xa2\xf5\x25\xda\xba\x2e\x41\xd1\x1a\xc7\xcb\x15\xce\x7a\x3d\x69\xc9\x9d\x38\xf8\x29\x1f\x77\xb4\x87\x1a\x06\xbb\x07\xfb\x12\x57\x8a\x99\x82\x4b\x19\x35\xda\xe0\xc7\x41\x18\x8b\x6d\xca\xb4\x6a\x4a\xd4\xde\x96\x88\x62\x6a\xc3\xfb\x12\x3d\xc5\x38\xc9\x75\xa8\xf4\xf2\x50\xe8\x2a\x8c\x91\x27\xd5\x44\xa0\x5a\x85\xd6\xe7\x25\x72\xb9\xae\x45\xab\x43\xae\xea\xf5\xd7\xdb\xf8\xe6\xe8\xe8\x14\x8b\x3f\xae\x7e\x97\x4c\xa7\xeb\xaf\x27\x77\x3f\x2c\x4f\xe4\x2c\x39\x4f\x6f\xf5\xe1\x54\x7b\x9b\x0e\xaf\x5e\x67\xdf\x3d\x14\x11\xc6\x97\xe3\x21\x20\x71\x52\x5c\x8e\x89\x11\x16\xad\xf5\x1b\x99\x0c\x9a\xfb\xb6\x2c\x21\x8a\x05\x4d\x61\x25\x1b\x8d\xc5\x29\xf9\x10\x28\x97\x92\x7d\xe8\x13\xc1\x12\x2d\x71\x7c\xbe\x52\xbd\x43\x64\x6d\x1c\xc4\xfb\x86\xe3\xbc\x8a\x03\x0d\x56\xd5\x14\x61\xf8\x39\x9e\x8f\x2c\x80\x42\x52\x35\x4c\x21\x41\x09\x45\xec\x54\xc4\x2e\x18\x66\x72\x25\x0c\x9d\x40\x89\xc6\x3c\xd1\x79\x75\xfe\xe5\xe2\x13\x4c\x9c\x61\x93\x4f\x9e\x42\x65\xed\x96\x69\xd4\xc6\x40\x53\x67\x9f\xfc\x94\x98\xcd\x2e\x93\x8d\xb9\xca\xd6\xeb\x79\x0a\xe3\xdd\x5d\x96\x04\xa9\xf2\x37\x62\xda\x2c\xd1\xac\x52\xd9\xb2\xf0\xab\x75\x1e\x3b\xe9\xd3\x51\x2d\xff\x4d\x33\x7b\x05\x47\x5b\xb4\xde\xf4\x92\x24\xe2\x14\x6f\x35\x6d\xdf\xba\xd1\xda\xf7\x52\x90\x03\x3d\x50\xfd\xec\xc1\x51\x0c\xb5\x36\x01\xb7\xf9\xab\xe3\xdb\xfc\x3d\xc8\x46\x9a\xdf\x34\x3d\xbf\x47\x63\x04\x3f\x55\x29\x6e\x78\xc9\xb0\x38\x50\x4c\x0c\x8b\x99\xa2\x28\xf9\x7d\x0c\x25\x7c\x4b\xfc\x1c\xe5\xff\x01\x00\x61\xa0\x6c\x82\x9e\x07\x00\x00")
 
 func resDefaultConfigTomlBytes() ([]byte, error) {
 	return bindataRead(
@@ -381,12 +318,12 @@ func resDefaultConfigToml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/config.toml", size: 268, mode: os.FileMode(420), modTime: time.Unix(1472885273, 0)}
+	info := bindataFileInfo{name: "res/default/config.toml", size: 1950, mode: os.FileMode(420), modTime: time.Unix(1792327212, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultThemesAcmeToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x92\xbd\x6e\xea\x30\x14\xc7\xf7\xfb\x14\x28\x73\x06\x07\x92\x40\x86\x2c\x38\xc9\x72\x75\x75\x87\x70\xef\x6e\xc0\xa2\x91\x1c\x8c\x8c\x11\x45\xea\xd0\x0d\xa9\x5b\xbf\x17\x3a\x54\x95\xaa\x3e\x17\x4f\xd2\x13\x7f\x84\x04\xa2\xc2\x72\xf2\xf7\xef\xfc\x7c\x6c\x79\xbc\x88\x9d\x34\x44\x03\x04\x3f\xe7\x57\x56\x7d\x8d\xa0\xcc\xaa\xaf\xf1\x22\xa7\x8c\xce\x64\xec\x8c\x23\x20\x32\x4d\xd8\x4c\x71\x08\x79\x15\x87\x37\x62\xcd\x45\x9b\xb3\xd9\x89\xc3\xbc\x2c\xe9\x12\x5a\xb3\x21\x1a\x6a\x2c\x97\xa2\x58\xc2\xa6\x7e\x84\x42\x9d\xfc\xa6\xbb\x2d\x17\x73\x2f\x76\xfa\x9e\x92\x79\x75\xd6\x8f\x9d\x30\x3b\xcb\x06\x90\x45\x36\xcb\xe9\x8a\x08\x22\xb9\x80\x6e\x1f\xa1\xc0\xec\x61\x53\xe8\x8f\x82\x8b\x14\x0c\x69\xbf\x4e\x77\xe5\x94\x33\x68\xc7\x18\xf9\x46\xaa\x22\xe8\x1d\x25\xed\x08\x1a\x13\xaf\x8e\x24\x91\x9b\xf5\x94\x88\x5e\xdc\x73\x8e\x6f\x77\x2e\x1e\xe8\x4b\xad\x8b\x06\x33\xa1\xd7\xb2\xe2\x4e\x57\xdd\x5a\x4a\x85\xb2\x20\x0f\xfe\x4a\x8e\xcb\x79\xc3\x1c\xa6\xc6\xdc\xb3\x95\x25\x2e\xbd\xa7\xfc\xef\xb2\xb1\x02\xce\xff\x05\xdd\x5a\xe9\xed\xe3\xd9\xb8\xaa\xd7\x10\x2d\xa9\x7e\x25\x05\xa3\x98\x51\xa2\x8c\xc7\xc3\x93\x6b\xf7\x6b\x9c\xb5\x62\x92\x42\xc8\x9d\x66\x5e\x5d\xec\xeb\x9d\x9b\xf7\x31\x13\x9c\x31\x3b\xc4\xcb\xbd\x9b\x06\xc6\xa3\x8a\x06\x33\x21\x53\xcd\x3c\xb8\xa1\x6f\x18\x55\x28\xe6\x0f\x17\xb4\x9a\x32\x2f\xe6\x54\x9f\xe7\xd3\xcd\x02\xfd\xc4\x5c\x78\xdc\xc3\x36\xf6\x6f\xa5\xa0\xfd\xfb\x4f\x50\xc2\xb7\xfa\x78\xfb\x8f\x2e\x0c\x06\xc2\x57\x66\xf0\xfd\x57\xa7\x88\x88\x45\xa1\x14\x37\x5d\xcb\x98\xf1\xb5\x1e\xf6\xf0\xdc\x75\x7f\xdf\x01\x00\x00\xff\xff\xd1\x1d\x4b\x92\x98\x03\x00\x00")
+var _resDefaultThemesAcmeToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x93\xbd\x6e\xdb\x30\x10\xc7\xf7\x3c\x85\x71\xb3\x86\xa3\x24\xcb\xf1\xc0\xc5\x94\xb4\x14\x41\x07\xb9\xed\x4c\xdb\x84\x42\x40\x16\x03\x9a\x81\x6b\xa0\x43\x36\x03\x5d\x8a\x7e\x25\x4b\x3a\x14\x05\x8a\x3e\x57\x9e\xa4\x10\x29\xea\x23\x11\xb2\x1d\xff\xfc\xe1\xc7\xe3\x51\x5a\x95\x14\xb2\x04\x23\x44\x44\xb8\xc8\x9b\xd5\x25\x22\xe6\xcd\x6a\x55\x16\xa2\x12\x5b\x43\x61\xb5\xc4\xc8\x65\x79\x97\x59\x0e\x91\x34\xdc\x15\x37\xdb\x6b\x0a\x69\xda\x63\x6d\x34\xa4\xd8\xad\x3e\x28\x3d\xb6\xf9\xac\xe7\x98\xda\xef\x45\x6d\x28\xe4\x0b\x5c\x38\xac\x30\x5a\xd6\x25\x85\x78\x89\x89\x4b\xde\x88\xd3\x51\xe9\x1d\xa1\x10\x12\x2b\x23\x5d\x16\x52\x48\xf2\x67\x59\x44\x21\x59\xfa\xac\x10\x37\x5c\x73\xa3\x34\xa1\x10\x23\xce\xdb\x33\x7c\x1a\x52\x58\xce\x5f\xa4\x11\x85\x2c\xec\xd2\xd3\x7e\xa3\x2a\x42\x81\x31\x8c\x5b\xa9\x8d\x42\x0a\x97\xe9\x38\x8a\x28\xa4\xa4\x8b\x0c\x37\xb7\x87\x0d\xd7\x33\x3a\x83\xa7\x5f\x9f\x03\x16\xb9\xd1\x77\xc5\x80\x59\x8b\x8f\xa6\xe1\xfa\x07\x19\x6d\x65\xda\x5a\x90\x20\x71\x72\xb6\xdf\x0d\xcc\x49\xd6\x9a\x67\xbe\xf2\xc4\x4b\x6f\x9f\xbf\xad\x07\x3b\x04\x2e\xde\x4b\x71\xf4\xd2\xbb\xef\xcf\xda\xb5\x73\x6b\x89\x91\xd4\xde\x23\x97\x95\x60\x95\xe0\xd6\xf8\xf4\xf8\x23\xf0\xe7\x0d\xee\xda\x30\xa9\xd4\xe6\xe4\x98\x87\x80\xc5\x96\x21\x03\xa6\xd8\x6a\x55\x55\xbe\x89\xfb\xaf\x41\x36\x6f\x3d\xb6\x18\x30\x6b\xbe\xb1\x9e\xfb\x6f\x41\x12\xb7\x8c\x2d\x2c\x73\xa5\xb4\x68\xba\x2c\xe4\x4e\x58\xec\xee\x6f\x90\xcf\xdd\x27\x16\x64\x09\x2e\xc6\xd8\xbb\x1b\x0b\x9d\x7f\xbf\x06\xa5\xea\xe8\xae\x77\xfe\x33\x85\xad\xf9\x86\x5d\xb7\x8d\x9f\xff\x4d\x8a\xb8\x2e\xa5\x55\x7c\x9a\xda\x4e\x25\x2f\x6b\x75\x30\x72\x9b\x69\xad\x9c\xe8\xe1\x4b\x33\x25\xfb\xe6\x41\xff\xe7\xf6\xe4\x07\xae\x6b\x59\x97\x9e\x4d\x1b\x64\xcc\xb2\x4a\x1d\xdc\x08\x1e\x7f\x4e\xbd\xca\xff\x01\x00\x12\x34\x91\xc1\x14\x04\x00\x00")
 
 func resDefaultThemesAcmeTomlBytes() ([]byte, error) {
 	return bindataRead(
@@ -401,12 +338,12 @@ func resDefaultThemesAcmeToml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/themes/acme.toml", size: 920, mode: os.FileMode(420), modTime: time.Unix(1475266229, 0)}
+	info := bindataFileInfo{name: "res/default/themes/acme.toml", size: 1044, mode: os.FileMode(436), modTime: time.Unix(1792318767, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resDefaultThemesDefaultToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x92\xbb\xae\xda\x40\x10\x86\xfb\xf3\x14\x47\xae\xb7\xd8\xb5\xc1\xd8\x85\x8b\x78\x2f\x4d\x74\x94\xc2\x24\xbd\x81\x15\xb1\x64\xb3\x68\x31\x22\x48\x29\xd2\x21\xa5\xcb\xbd\x21\x45\x14\x29\xca\x73\xf1\x24\x99\xbd\x19\x27\x41\x50\x20\xfb\x9f\xef\xff\x67\x76\x3d\xe5\xba\x88\xf8\x33\x6c\x7f\xd1\x83\x80\x37\x26\x70\x82\x85\x79\x2b\xd7\x95\x6c\xe5\xb2\x1f\x6b\x62\xd0\xbc\x8b\x18\x8e\xee\xf5\x4e\xe9\x22\x8a\x09\x4e\x03\x17\xb4\x2b\x47\x55\xd7\xc9\x0d\x58\xc5\x0c\xcf\x1c\x56\xf5\xba\xd9\x40\xd3\x49\x1e\x8c\xcf\xe5\xf1\xa0\xf4\x8a\xd8\x30\xd3\x94\x0c\x5a\x5c\x44\xa9\xf8\x47\x4b\x40\xcb\x83\x56\xc9\x6d\xad\xeb\x5e\x69\x70\x4f\x30\x9e\xfa\x1e\x41\x05\x7f\x3e\xfd\x4f\x85\x04\x1e\x0f\xea\xb1\x5b\xa8\x16\xec\x94\xe2\x89\x0f\xb5\x12\x78\x33\xf6\xb7\x04\x46\x46\x06\xa9\xaf\xfb\xfd\x6e\x51\xeb\xc7\xe2\x31\xba\x7c\x7f\x8f\x78\x09\x67\x84\x9f\x79\xf0\xb7\x3b\x30\x73\xf9\xa6\x37\x5c\xc9\xd2\x34\xdc\xc3\xa8\xc4\xb5\x4d\xc1\x84\x4e\x5d\x38\xed\x56\xf7\x93\x1d\x70\x8d\x0d\x5f\xeb\xaa\xbf\xd8\xd8\x8a\xb0\x0d\x21\xf2\x55\x23\x0f\x21\xf3\xdd\x27\xc4\x59\xc8\x64\xf0\x6f\xbd\x9e\x08\xa1\x7c\x86\x63\xbf\x24\x4d\x2b\x69\x2b\x6b\x9b\x78\x39\x7f\x46\x84\x42\x49\x18\x73\x3e\x2c\x12\x30\xac\xd1\xfd\xd1\x31\xdf\x10\x2c\x01\x31\x9d\x47\x4c\xb5\xd4\xaa\x6d\xc3\x10\x5f\x3f\x98\xde\x99\xcb\x31\x0f\x23\x66\x5e\x2f\x1c\xf3\x11\x65\x6e\x73\x10\xce\xdc\xc0\xd1\xc3\x93\xd2\xd2\x0c\x59\x35\x2b\xe9\x8e\xf3\x0b\x11\x61\xbf\x0b\x24\x0d\xab\x1d\xb0\x97\x5b\x0b\x9d\x7e\xdc\x83\x98\x3a\xb8\xd3\x9d\x7e\xde\xc2\x60\x1e\xfa\xda\xcf\x7d\xfa\x8d\xb8\x70\x77\x66\x08\x3f\xf7\x53\xad\xd7\x8d\x8d\x78\x7b\xab\x4c\x5b\xb5\x73\xc3\x9e\xbf\xa0\x24\x71\xbb\x8f\x38\x0d\xf5\x3f\x01\x00\x00\xff\xff\xec\x79\x6d\x1e\x96\x03\x00\x00")
+var _resDefaultThemesDefaultToml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x93\xb9\x6e\x1b\x31\x10\x86\x7b\x3d\x85\xc1\x9a\xc5\x70\x57\xd6\x51\xb0\xb0\x78\x34\x81\x90\x42\x4a\x52\x53\x12\xb1\x26\xb0\x5a\x1a\x14\x0d\x45\x40\x0a\x77\x02\xd2\x04\xb9\xec\xc6\x29\x82\x00\x41\x9e\xcb\x4f\x12\xf0\x92\xe4\x03\xde\x8a\xf8\xf9\xcd\xff\xcf\x90\xdc\x49\x43\x91\xb8\x80\xf8\xa1\x9e\x6c\x28\xe2\x12\x6a\x90\x00\xa8\x37\x69\x66\xba\xd5\x4b\x7f\xaa\xc9\x83\x96\xab\x48\xe0\xa6\xca\x2f\x2f\x29\x12\xd5\x11\x2b\xd2\x09\xc5\xae\xdd\xc6\x3a\x8a\x2a\x02\x83\x82\x15\xed\xc8\x31\xbb\x5e\xeb\xce\x53\x24\x87\x30\x4c\xd8\xcc\x3b\xd3\x35\x14\xf5\xc7\xa5\xf0\x8d\xde\x6d\xad\x5b\x91\x68\x16\x32\xc9\x41\xab\x28\x1a\xc8\x27\x5a\x4d\xd1\x60\x5c\xb4\x99\xbe\x52\x4e\x79\xeb\x08\x45\x7d\x80\xf3\x9c\x51\xd4\x8a\xa2\xf1\xf9\x33\xb5\x8e\xd3\x15\x75\xb7\x5e\xd8\x96\x50\xc4\x18\xf4\xb3\x69\x94\x2a\x8a\x46\xfc\xb1\x54\x53\xc4\xc9\x41\xf2\xca\x5f\x6f\x16\xca\x9d\xd1\x33\xf4\xf0\xeb\x33\x16\x13\x18\x86\xc1\xc3\x22\xdf\xc1\x81\x99\xeb\x8f\x3e\x70\x13\x3e\xc8\x53\x3f\xda\x12\x2e\xba\x00\x61\xa1\x2b\x82\x7a\x6c\xbd\x7a\xdd\x39\x01\x47\xdb\x72\x59\x47\xfd\x6d\x17\x77\x64\x0c\x24\xa8\xf7\xde\xe8\x6d\xf1\xbc\xf9\x8e\x05\x2f\x9e\x1c\x20\xd5\x66\xa2\x98\x8a\x21\x54\xf9\x29\x99\x56\xb3\x56\xab\xe8\xf8\x70\xff\x03\x13\x06\x55\xa8\xc1\x62\x5c\x1a\x92\xa6\xd5\xdc\x38\xbf\x4b\xcc\x1d\xbe\x00\x20\x21\xf9\x84\x99\x2d\x9d\x6d\xdb\xd2\xc4\xed\xd7\x90\x3d\x4a\x3e\x61\x71\xc2\xcc\xd5\x22\xfa\xdc\x7e\xc3\xa3\xf4\x72\x30\x84\x45\x44\xa6\xd6\xe9\xd0\xe4\xcc\xac\x74\xa4\x6e\xfe\x62\x22\xe3\xbd\x00\xce\x8f\xef\x04\x7b\x77\x15\xa1\xfd\xef\xd7\x20\x6e\xb7\x69\xba\xfd\x9f\x97\xb0\xb9\x5a\xb0\xcb\xdc\xf7\xfe\x1f\x16\x32\x9d\x59\x48\xcb\x7d\x4f\x95\x6b\x4c\xb4\xf8\xf4\xd2\x36\x37\xaa\xe9\xec\xc6\x9b\xa5\x70\xce\x26\xa3\xbb\x2f\x98\xf5\xcb\x21\x1d\xa2\x8e\xe4\x07\xe5\x3a\xd3\x35\x85\xe5\x03\xa8\x9f\xb0\xac\xb5\x9b\x74\x04\xf7\x3f\x71\x5d\xa7\x3f\x0a\x0b\x06\x23\x00\x00\xd4\xfb\x3f\x00\x3a\x6d\x56\x2e\x12\x04\x00\x00")
 
 func resDefaultThemesDefaultTomlBytes() ([]byte, error) {
 	return bindataRead(
@@ -421,12 +358,12 @@ func resDefaultThemesDefaultToml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/default/themes/default.toml", size: 918, mode: os.FileMode(420), modTime: time.Unix(1475253000, 0)}
+	info := bindataFileInfo{name: "res/default/themes/default.toml", size: 1042, mode: os.FileMode(436), modTime: time.Unix(1792318767, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _resResources_versionTxt = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x32\x34\xb1\x30\x36\x30\xb5\x34\xb4\x34\xe0\x02\x04\x00\x00\xff\xff\xab\x46\x8b\x71\x0b\x00\x00\x00")
+var _resResources_versionTxt = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x00\x0b\x00\xf4\xff\x31\x37\x39\x32\x33\x32\x37\x32\x31\x35\x0a\x03\x00\x22\x77\x61\xb3\x0b\x00\x00\x00")
 
 func resResources_versionTxtBytes() ([]byte, error) {
 	return bindataRead(
@@ -441,7 +378,7 @@ func resResources_versionTxt() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "res/resources_version.txt", size: 11, mode: os.FileMode(420), modTime: time.Unix(1483059190, 0)}
+	info := bindataFileInfo{name: "res/resources_version.txt", size: 11, mode: os.FileMode(420), modTime: time.Unix(1792327215, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -525,12 +462,9 @@ var _bindata = map[string]func() (*asset, error){
 	"res/default/actions/goed.fish": resDefaultActionsGoedFish,
 	"res/default/actions/goed.rc": resDefaultActionsGoedRc,
 	"res/default/actions/goed.sh": resDefaultActionsGoedSh,
-	"res/default/actions/goed_helper.ank": resDefaultActionsGoed_helperAnk,
-	"res/default/actions/goimports.sh": resDefaultActionsGoimportsSh,
 	"res/default/actions/search.ank": resDefaultActionsSearchAnk,
 	"res/default/actions/search_text.sh": resDefaultActionsSearch_textSh,
 	"res/default/actions/stats.sh": resDefaultActionsStatsSh,
-	"res/default/actions/vt100_size.sh": resDefaultActionsVt100_sizeSh,
 	"res/default/bindings.toml": resDefaultBindingsToml,
 	"res/default/config.toml": resDefaultConfigToml,
 	"res/default/themes/acme.toml": resDefaultThemesAcmeToml,
@@ -591,12 +525,9 @@ var _bintree = &bintree{nil, map[string]*bintree{
 				"goed.fish": &bintree{resDefaultActionsGoedFish, map[string]*bintree{}},
 				"goed.rc": &bintree{resDefaultActionsGoedRc, map[string]*bintree{}},
 				"goed.sh": &bintree{resDefaultActionsGoedSh, map[string]*bintree{}},
-				"goed_helper.ank": &bintree{resDefaultActionsGoed_helperAnk, map[string]*bintree{}},
-				"goimports.sh": &bintree{resDefaultActionsGoimportsSh, map[string]*bintree{}},
 				"search.ank": &bintree{resDefaultActionsSearchAnk, map[string]*bintree{}},
 				"search_text.sh": &bintree{resDefaultActionsSearch_textSh, map[string]*bintree{}},
 				"stats.sh": &bintree{resDefaultActionsStatsSh, map[string]*bintree{}},
-				"vt100_size.sh": &bintree{resDefaultActionsVt100_sizeSh, map[string]*bintree{}},
 			}},
 			"bindings.toml": &bintree{resDefaultBindingsToml, map[string]*bintree{}},
 			"config.toml": &bintree{resDefaultConfigToml, map[string]*bintree{}},
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backup modes (Config.Backups)
+const (
+	BackupNone     = ""
+	BackupSimple   = "simple"   // file~
+	BackupNumbered = "numbered" // file.~1~, file.~2~ ...
+)
+
+// ErrChangedOnDisk is returned by Save when the file was modified by another
+// program since it was loaded.
+type ErrChangedOnDisk struct {
+	Loc string
+}
+
+func (e ErrChangedOnDisk) Error() string {
+	return fmt.Sprintf("%s was changed on disk", e.Loc)
+}
+
+// IsChangedOnDisk returns whether err is an ErrChangedOnDisk.
+func IsChangedOnDisk(err error) bool {
+	_, ok := err.(ErrChangedOnDisk)
+	return ok
+}
+
+// FileStamp records the state of a file, to detect later external changes.
+type FileStamp struct {
+	Exists  bool
+	ModTime time.Time
+	Size    int64
+	hash    *stampHash
+}
+
+// stampHash is the content hash of a stamped file, computed in the
+// background as it is only needed once the mtime changed.
+type stampHash struct {
+	done chan struct{}
+	sum  []byte // nil if the file changed while hashing
+}
+
+// StampFile returns the current stamp of the file at loc.
+func StampFile(loc string) FileStamp {
+	stamp := FileStamp{}
+	info, err := os.Stat(loc)
+	if err != nil {
+		return stamp
+	}
+	stamp.Exists = true
+	stamp.ModTime = info.ModTime()
+	stamp.Size = info.Size()
+	stamp.hash = &stampHash{done: make(chan struct{})}
+	go func(h *stampHash) {
+		defer close(h.done)
+		sum := hashFile(loc)
+		if info, err := os.Stat(loc); err == nil &&
+			info.ModTime().Equal(stamp.ModTime) && info.Size() == stamp.Size {
+			h.sum = sum
+		}
+	}(stamp.hash)
+	return stamp
+}
+
+// Changed returns whether the file at loc was modified since the stamp was
+// taken. The mtime and size are checked first, the content is only hashed
+// when they differ so that a mere "touch" does not count as a change.
+// A file that was deleted is not considered changed (saving just recreates
+// it).
+func (s FileStamp) Changed(loc string) bool {
+	info, err := os.Stat(loc)
+	if err != nil {
+		return false
+	}
+	if !s.Exists {
+		return true // created by somebody else
+	}
+	if info.ModTime().Equal(s.ModTime) && info.Size() == s.Size {
+		return false
+	}
+	if info.Size() != s.Size || s.hash == nil {
+		return true
+	}
+	<-s.hash.done
+	return s.hash.sum == nil || !bytes.Equal(hashFile(loc), s.hash.sum)
+}
+
+func hashFile(loc string) []byte {
+	f, err := os.Open(loc)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil
+	}
+	return h.Sum(nil)
+}
+
+// WriteFileAtomic writes a file "atomically", ie: a crash can't leave a
+// partially written file behind.
+// The content is written by write() to a temporary file in the same directory,
+// synced to disk, and then renamed into place, keeping the original file mode
+// and owner (best effort). If the file is a symlink, its target is written.
+// An existing file is first backed up according to the backup mode.
+func WriteFileAtomic(loc, backup string, write func(w io.Writer) error) error {
+	if target, err := filepath.EvalSymlinks(loc); err == nil {
+		loc = target
+	}
+	dir, base := filepath.Split(loc)
+	if len(dir) == 0 {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	info, err := os.Stat(loc)
+	exists := err == nil
+	if exists {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	done := false
+	defer func() {
+		if !done {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if exists {
+		chown(tmp, info) // best effort
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if exists {
+		if err = backupFile(loc, backup); err != nil {
+			return fmt.Errorf("Backup failed : %s", err.Error())
+		}
+	}
+	if err = os.Rename(tmp.Name(), loc); err != nil {
+		return err
+	}
+	done = true
+	syncDir(dir)
+	return nil
+}
+
+// BackupLoc returns where the backup of loc goes, for the given backup mode,
+// or "" if none.
+func BackupLoc(loc, backup string) string {
+	switch backup {
+	case BackupSimple:
+		return loc + "~"
+	case BackupNumbered:
+		for i := 1; ; i++ {
+			b := fmt.Sprintf("%s.~%d~", loc, i)
+			if _, err := os.Lstat(b); os.IsNotExist(err) {
+				return b
+			}
+		}
+	}
+	return ""
+}
+
+func backupFile(loc, backup string) error {
+	b := BackupLoc(loc, backup)
+	if len(b) == 0 {
+		return nil
+	}
+	os.Remove(b)
+	// the original is about to be replaced by a rename, so a hard link is
+	// enough, copy otherwise
+	if err := os.Link(loc, b); err == nil {
+		return nil
+	}
+	return CopyFile(loc, b)
+}
+
+// syncDir flushes a directory entry changes (rename) to disk, best effort.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,8 @@
+package core
+
+import "os"
+
+// chown is a no-op on windows.
+func chown(f *os.File, info os.FileInfo) error {
+	return nil
+}
@@ -21,6 +21,8 @@ type Theme struct {
 	Fg       Style // default to term fg
 	BgSelect Style // default to term bg
 	FgSelect Style // default to term fg
+	BgMatch  Style // search matches
+	FgMatch  Style
 	BgCursor Style
 	FgCursor Style
 
@@ -49,6 +51,9 @@ type Theme struct {
 	TabChar          StyledRune
 	Margin           StyledRune
 	Close            StyledRune
+	// language server diagnostics markers
+	DiagnosticError   StyledRune
+	DiagnosticWarning StyledRune
 }
 
 func ReadDefaultTheme() (*Theme, error) {
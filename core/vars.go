@@ -24,6 +24,10 @@ var Testing bool
 // ConfigFile holds the path to the config file currently in use.
 var ConfFile string
 
+// Session is the name of the session the editor layout is saved to
+// (and was restored from).
+var Session string
+
 // LogFile holds the path of the log file currently in use.
 var LogFile *os.File
 
@@ -32,10 +36,16 @@ var Terminal string
 
 var Bus ActionDispatcher
 
-var ApiPort int
+var ApiPort int // API TCP port, no TCP listener if 0
+
+var ApiHost string // API TCP listener host, ie: 127.0.0.1
 
 var Socket string // instance RPC socket
 
+var JsonSocket string // instance JSON-RPC socket
+
+var TokenLoc string // instance API access token file
+
 var InstanceId int64 // instance ID
 
 type CursorMvmt byte
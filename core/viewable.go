@@ -3,9 +3,19 @@ package core
 // Viewable is the interface to a View
 type Viewable interface {
 	Widget
+	// AddCursor adds an extra cursor (multi cursor editing)
+	AddCursor(ln, col int)
+	// AddNextOccurrence selects the next occurrence of the last selection
+	AddNextOccurrence()
 	Backspace()
 	Backend() Backend
+	// BannerClick handles a click on the view banner (if any), x being
+	// relative to the view. Returns whether the click was consumed.
+	BannerClick(x int) bool
 	ClearSelections()
+	// Complete completes the word at the cursor (language server), done is
+	// called (on the action bus) with the candidates once the server answered.
+	Complete(done func(candidates []string, err error))
 	Copy()
 	CurCol() int
 	CurLine() int
@@ -14,6 +24,17 @@ type Viewable interface {
 	Delete(row1, col1, row, col2 int, undoable bool)
 	DeleteCur()
 	Dirty() bool
+	// Find sets the search query and selects the next match.
+	Find(query string, regex, ignoreCase bool) error
+	FindClear()
+	// FindNext selects the next (or previous) match of the search query.
+	FindNext(backward bool) bool
+	// GotoDefinition opens the definition of the symbol at the cursor
+	// (language server), done is called (on the action bus) once opened.
+	GotoDefinition(done func(err error))
+	// Hover shows informations about the symbol at the cursor (language server),
+	// done is called (on the action bus) with the text shown.
+	Hover(done func(text string, err error))
 	Id() int64
 	Insert(row, col int, text string, undoable bool)
 	InsertCur(text string)
@@ -30,6 +51,12 @@ type Viewable interface {
 	Paste()
 	// Reload reloads the view data from it's source (backend)
 	Reload()
+	// Replace replaces the selected (or all) match(es) of the search query.
+	Replace(with string, all bool) int
+	// ResolveConflict resolves a change of the view file on disk, choice being
+	// "reload", "keep" (keep mine) or "diff" (show the differences).
+	// Fails if there is no such conflict.
+	ResolveConflict(choice string) error
 	// Reset reinitializes the view to it's startup state.
 	Reset()
 	Save() // Save from buffer to src
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -68,12 +69,36 @@ func handleEvent(e *Event, es *eventState) bool {
 	//log.Printf("Parsed evt: %#v", e)
 	et := e.Type
 
+	switch et {
+	case EvtMacroRecord:
+		toggleMacroRecord()
+		return false
+	case EvtMacroPlay:
+		if MacroRecording() {
+			actions.Ar.EdSetStatusErr("Can't play a macro while recording one.")
+			return false
+		}
+		playMacro(LastMacro(), es)
+		return false
+	}
+	macro.record(e)
+
+	if actions.Ar.EdOverlayEvent(string(et), e.Glyph, e.hasMouse(), e.MouseY, e.MouseX) {
+		actions.Ar.EdRender()
+		return false
+	}
+
 	es.cmdbarOn = actions.Ar.CmdbarEnabled()
 	if es.cmdbarOn || (et == EvtSetCursor && e.MouseY < 1) {
 		handleCmdbarEvent(e, es)
 		return false
 	}
 
+	if strings.HasPrefix(string(et), EvtScriptPrefix) {
+		go actions.ExecScript(strings.TrimPrefix(string(et), EvtScriptPrefix))
+		return false
+	}
+
 	curView := actions.Ar.EdCurView()
 	actions.Ar.ViewAutoScroll(curView, 0, 0)
 
@@ -101,7 +126,12 @@ func handleEvent(e *Event, es *eventState) bool {
 	}
 
 	vt := actions.Ar.ViewType(curView)
-	if !e.hasMouse() && vt == core.ViewTypeShell {
+	if (vt == core.ViewTypeShell || es.termMouse != 0) && handleTermMouse(curView, e, es, ln, col) {
+		return false
+	}
+	// a pinned terminal is browsed (search, selection ...) as other views
+	if !e.hasMouse() && vt == core.ViewTypeShell && et != EvtTogglePin &&
+		!actions.Ar.ViewPinned(curView) {
 		handleTermEvent(curView, e)
 		return false
 	}
@@ -114,13 +144,22 @@ func handleEvent(e *Event, es *eventState) bool {
 	cs := true // clear selections
 
 	switch et {
+	case EvtAddNextOccurrence:
+		actions.Ar.ViewAddNextOccurrence(curView)
+		cs = false
 	case EvtBackspace:
 		actions.Ar.ViewBackspace(curView)
 		dirty = true
+		cs = false // multi cursor edits manage selections themselves
 	case EvtBottom:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtBottom)
 	case EvtCloseWindow:
 		actions.Ar.EdDelView(curView, true)
+	case EvtComplete:
+		inBackground(func() error {
+			_, err := actions.Ar.ViewComplete(curView)
+			return err
+		})
 	case EvtCut:
 		actions.Ar.ViewCut(curView)
 		dirty = true
@@ -129,6 +168,7 @@ func handleEvent(e *Event, es *eventState) bool {
 	case EvtDelete:
 		actions.Ar.ViewDeleteCur(curView)
 		dirty = true
+		cs = false
 	case EvtDeleteHome:
 		if col > 1 {
 			actions.Ar.ViewDelete(curView, ln, 0, ln, col-1, true)
@@ -139,8 +179,22 @@ func handleEvent(e *Event, es *eventState) bool {
 	case EvtEnter:
 		actions.Ar.ViewInsertNewLine(curView)
 		dirty = true
+		cs = false
+	case EvtFindNext:
+		actions.Ar.ViewFindNext(curView, false)
+		cs = false
+	case EvtFindPrev:
+		actions.Ar.ViewFindNext(curView, true)
+		cs = false
+	case EvtGotoDefinition:
+		inBackground(func() error { return actions.Ar.ViewGotoDefinition(curView) })
 	case EvtHome:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtHome)
+	case EvtHover:
+		inBackground(func() error {
+			_, err := actions.Ar.ViewHover(curView)
+			return err
+		})
 	case EvtMoveDown:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtDown)
 	case EvtMoveLeft:
@@ -157,22 +211,39 @@ func handleEvent(e *Event, es *eventState) bool {
 		actions.Ar.EdViewNavigate(core.CursorMvmtRight)
 	case EvtNavUp:
 		actions.Ar.EdViewNavigate(core.CursorMvmtUp)
+	case EvtNextError:
+		if err := actions.Ar.EdNextError(); err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		}
 	case EvtOpenInNewView:
 		actions.Ar.ViewSetCursorPos(curView, ln, col)
 		actions.Ar.ViewOpenSelection(curView, true)
 	case EvtOpenInSameView:
 		actions.Ar.ViewSetCursorPos(curView, ln, col)
 		actions.Ar.ViewOpenSelection(curView, false)
+	case EvtFindFile, EvtSwitchView:
+		mode := core.FinderFiles
+		if et == EvtSwitchView {
+			mode = core.FinderViews
+		}
+		if err := actions.Ar.FinderOpen(mode); err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		}
 	case EvtOpenTerm:
 		v := actions.Ar.EdOpenTerm([]string{core.Terminal})
 		actions.Ar.EdActivateView(v)
 	case EvtPaste:
 		actions.Ar.ViewPaste(curView)
 		dirty = true
+		cs = false
 	case EvtPageDown:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtPgDown)
 	case EvtPageUp:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtPgUp)
+	case EvtPrevError:
+		if err := actions.Ar.EdPrevError(); err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		}
 	case EvtQuit:
 		if actions.Ar.EdQuitCheck() {
 			actions.Ar.EdQuit()
@@ -182,7 +253,11 @@ func handleEvent(e *Event, es *eventState) bool {
 		actions.Ar.ViewRedo(curView)
 		dirty = true
 	case EvtReload:
-		actions.Ar.ViewReload(curView)
+		actions.Ar.ViewReload(curView, true)
+	case EvtRerunTask:
+		if _, err := actions.Ar.EdRerunTask(); err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		}
 	case EvtScrollDown:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtScrollDown)
 	case EvtScrollUp:
@@ -247,9 +322,13 @@ func handleEvent(e *Event, es *eventState) bool {
 	case EvtTab:
 		actions.Ar.ViewInsertCur(curView, "\t")
 		dirty = true
+		cs = false
 	case EvtToggleCmdbar:
 		es.cmdbarOn = !es.cmdbarOn
 		actions.Ar.CmdbarToggle()
+	case EvtTogglePin:
+		togglePin(curView, vt)
+		cs = false
 	case EvtTop:
 		actions.Ar.ViewCursorMvmt(curView, core.CursorMvmtTop)
 	case EvtUndo:
@@ -261,6 +340,7 @@ func handleEvent(e *Event, es *eventState) bool {
 		if len(e.Glyph) > 0 {
 			actions.Ar.ViewInsertCur(curView, e.Glyph)
 			dirty = true
+			cs = false
 		} else {
 			log.Println("Unhandled action : " + string(et))
 			cs = false
@@ -280,6 +360,33 @@ func handleEvent(e *Event, es *eventState) bool {
 	return false
 }
 
+// inBackground runs a language server action, possibly slow, without holding
+// the event loop, then renders.
+func inBackground(action func() error) {
+	go func() {
+		if err := action(); err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		}
+		actions.Ar.EdRender()
+	}()
+}
+
+// togglePin pins (or unpins) a command output view : while pinned it does not
+// follow the output.
+func togglePin(vid int64, vt int) {
+	if vt != core.ViewTypeShell && vt != core.ViewTypeCmdOutput {
+		actions.Ar.EdSetStatusErr("Not a command output view")
+		return
+	}
+	pinned := !actions.Ar.ViewPinned(vid)
+	actions.Ar.ViewSetPinned(vid, pinned)
+	if pinned {
+		actions.Ar.EdSetStatus("Pinned, the view no longer follows the output")
+	} else {
+		actions.Ar.EdSetStatus("Unpinned")
+	}
+}
+
 // Events for terminal/command views
 func handleTermEvent(vid int64, e *Event) {
 	cs := true
@@ -314,7 +421,11 @@ func handleTermEvent(vid int64, e *Event) {
 		actions.Ar.ViewCopy(vid)
 		break
 	case (e.Combo.LCtrl || e.Combo.RCtrl) && e.hasKey(KeyC): // CTRL+C
-		actions.Ar.TermSendBytes(vid, []byte{byte(0x03)})
+		termSignal(vid, "INT")
+	case (e.Combo.LCtrl || e.Combo.RCtrl) && e.hasKey(KeyZ): // CTRL+Z
+		termSignal(vid, "TSTP")
+	case (e.Combo.LCtrl || e.Combo.RCtrl) && e.hasKey(KeyBackslash): // CTRL+\
+		termSignal(vid, "QUIT")
 	case e.Type == EvtPaste:
 		actions.Ar.ViewPaste(vid)
 	// "special"/navigation keys
@@ -392,6 +503,50 @@ func handleTermEvent(vid int64, e *Event) {
 	actions.Ar.EdRender()
 }
 
+func termSignal(vid int64, sig string) {
+	if err := actions.Ar.TermSignal(vid, sig); err != nil {
+		actions.Ar.EdSetStatusErr(err.Error())
+	}
+}
+
+// termMouseButtons maps the mouse buttons to the xterm ones.
+var termMouseButtons = map[MouseButton]int{
+	MouseLeft:      0,
+	MouseMiddle:    1,
+	MouseRight:     2,
+	MouseWheelUp:   64,
+	MouseWheelDown: 65,
+}
+
+// Reports mouse events to a terminal program that asked for them (ie: vim),
+// returns whether the event was reported.
+func handleTermMouse(vid int64, e *Event, es *eventState, ln, col int) bool {
+	if !e.hasMouse() {
+		// button release (no key either)
+		if es.termMouse == 0 || len(e.Keys) > 0 || len(e.Glyph) > 0 {
+			return false
+		}
+		btn := termMouseButtons[es.termMouse]
+		es.termMouse = 0
+		return actions.Ar.TermMouse(es.termMouseVid, es.termMouseLn, es.termMouseCol, btn, false, true)
+	}
+	for b, on := range e.MouseBtns {
+		btn, found := termMouseButtons[b]
+		if !on || !found {
+			continue
+		}
+		if !actions.Ar.TermMouse(vid, ln, col, btn, e.inDrag, false) {
+			return false
+		}
+		if b != MouseWheelUp && b != MouseWheelDown {
+			es.termMouse, es.termMouseVid = b, vid
+			es.termMouseLn, es.termMouseCol = ln, col
+		}
+		return true
+	}
+	return false
+}
+
 func handleCmdbarEvent(e *Event, es *eventState) {
 	switch e.Type {
 	case EvtToggleCmdbar:
@@ -417,6 +572,10 @@ func handleCmdbarEvent(e *Event, es *eventState) {
 		actions.Ar.CmdbarClear()
 	case EvtEnter:
 		actions.Ar.CmdbarNewLine()
+	case EvtTab:
+		actions.Ar.CmdbarComplete()
+	case EvtReload:
+		actions.Ar.CmdbarHistorySearch()
 	case EvtMoveDown:
 		actions.Ar.CmdbarCursorMvmt(core.CursorMvmtDown)
 	case EvtMoveUp:
@@ -500,6 +659,12 @@ func builtinEvents(e *Event, es *eventState, y, x int, curView int64) bool {
 		return true
 	}
 
+	// view banner choices (ie: file changed on disk)
+	if e.MouseBtns[1] && y == 1 && actions.Ar.ViewBannerClick(curView, x) {
+		actions.Ar.EdRender()
+		return true
+	}
+
 	// close button (left click on 'x')
 	if e.MouseBtns[1] && e.MouseX+1 == x2-1 && e.MouseY+1 == y1 {
 		actions.Ar.EdDelView(curView, true)
@@ -25,6 +25,10 @@ type eventState struct {
 	lastClickBtn           MouseButton
 	lastClick              int64 // timestamp
 	cmdbarOn               bool
+	termMouse              MouseButton // button press reported to a terminal program
+	termMouseVid           int64
+	termMouseLn            int
+	termMouseCol           int
 }
 
 func NewEvent() *Event {
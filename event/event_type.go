@@ -3,56 +3,74 @@ package event
 type EventType string
 
 const (
-	Evt_None          EventType = "_"
-	EvtBackspace                = "backspace"
-	EvtBottom                   = "bottom"
-	EvtCloseWindow              = "close_window"
-	EvtCut                      = "cut"
-	EvtCopy                     = "copy"
-	EvtDelete                   = "delete"
-	EvtDeleteHome               = "delete_home"
-	EvtEnd                      = "end"
-	EvtHome                     = "home"
-	EvtEnter                    = "enter"
-	EvtMoveDown                 = "move_down"
-	EvtMoveLeft                 = "move_left"
-	EvtMoveRight                = "move_right"
-	EvtMoveUp                   = "move_up"
-	EvtNavDown                  = "nav_down"
-	EvtNavLeft                  = "nav_left"
-	EvtNavRight                 = "nav_right"
-	EvtNavUp                    = "nav_up"
-	EvtOpenInNewView            = "open_in_new_view"
-	EvtOpenInSameView           = "open_in_same_view"
-	EvtOpenTerm                 = "open_term"
-	EvtPaste                    = "paste"
-	EvtPageDown                 = "page_down"
-	EvtPageUp                   = "page_up"
-	EvtQuit                     = "quit"
-	EvtRedo                     = "redo"
-	EvtReload                   = "reload"
-	EvtSave                     = "save"
-	EvtScrollDown               = "scroll_down"
-	EvtScrollUp                 = "scroll_up"
-	EvtSelectMouse              = "select_mouse"
-	EvtSelectAll                = "select_all"
-	EvtSelectDown               = "select_down"
-	EvtSelectEnd                = "select_end"
-	EvtSelectHome               = "select_home"
-	EvtSelectLeft               = "select_left"
-	EvtSelectPageDown           = "select_page_down"
-	EvtSelectPageUp             = "select_page_up"
-	EvtSelectRight              = "select_right"
-	EvtSelectUp                 = "select_up"
-	EvtSelectWord               = "select_word"
-	EvtSetCursor                = "set_cursor"
-	EvtTab                      = "tab"
-	EvtToggleCmdbar             = "toggle_cmd_bar"
-	EvtTop                      = "top"
-	EvtUndo                     = "undo"
-	EvtWinResize                = "win_resize"
+	Evt_None             EventType = "_"
+	EvtAddNextOccurrence           = "add_next_occurrence"
+	EvtBackspace                   = "backspace"
+	EvtBottom                      = "bottom"
+	EvtCloseWindow                 = "close_window"
+	EvtComplete                    = "complete"
+	EvtCut                         = "cut"
+	EvtCopy                        = "copy"
+	EvtDelete                      = "delete"
+	EvtDeleteHome                  = "delete_home"
+	EvtEnd                         = "end"
+	EvtHome                        = "home"
+	EvtEnter                       = "enter"
+	EvtFindNext                    = "find_next"
+	EvtFindPrev                    = "find_prev"
+	EvtFindFile                    = "find_file"
+	EvtGotoDefinition              = "goto_definition"
+	EvtHover                       = "hover"
+	EvtMacroPlay                   = "macro_play"
+	EvtMacroRecord                 = "macro_record"
+	EvtMoveDown                    = "move_down"
+	EvtMoveLeft                    = "move_left"
+	EvtMoveRight                   = "move_right"
+	EvtMoveUp                      = "move_up"
+	EvtNavDown                     = "nav_down"
+	EvtNavLeft                     = "nav_left"
+	EvtNavRight                    = "nav_right"
+	EvtNavUp                       = "nav_up"
+	EvtNextError                   = "next_error"
+	EvtOpenInNewView               = "open_in_new_view"
+	EvtOpenInSameView              = "open_in_same_view"
+	EvtOpenTerm                    = "open_term"
+	EvtPaste                       = "paste"
+	EvtPageDown                    = "page_down"
+	EvtPageUp                      = "page_up"
+	EvtPrevError                   = "prev_error"
+	EvtQuit                        = "quit"
+	EvtRedo                        = "redo"
+	EvtReload                      = "reload"
+	EvtRerunTask                   = "rerun_task"
+	EvtSave                        = "save"
+	EvtScrollDown                  = "scroll_down"
+	EvtScrollUp                    = "scroll_up"
+	EvtSelectMouse                 = "select_mouse"
+	EvtSelectAll                   = "select_all"
+	EvtSelectDown                  = "select_down"
+	EvtSelectEnd                   = "select_end"
+	EvtSelectHome                  = "select_home"
+	EvtSelectLeft                  = "select_left"
+	EvtSelectPageDown              = "select_page_down"
+	EvtSelectPageUp                = "select_page_up"
+	EvtSelectRight                 = "select_right"
+	EvtSelectUp                    = "select_up"
+	EvtSelectWord                  = "select_word"
+	EvtSetCursor                   = "set_cursor"
+	EvtSwitchView                  = "switch_view"
+	EvtTab                         = "tab"
+	EvtToggleCmdbar                = "toggle_cmd_bar"
+	EvtTogglePin                   = "toggle_pin"
+	EvtTop                         = "top"
+	EvtUndo                        = "undo"
+	EvtWinResize                   = "win_resize"
 )
 
+// EvtScriptPrefix prefixes the event types that run a script, ie: bound to
+// "script:search.ank" runs ~/.goed/actions/search.ank (see actions.ExecScript)
+const EvtScriptPrefix = "script:"
+
 // Default bindings, if bindings.toml not found
 // mirrors res/default/bindings.toml
 var defaultBindings = map[string]EventType{
@@ -72,17 +90,39 @@ var defaultBindings = map[string]EventType{
 	"tab":       "tab",
 	"delete":    "delete",
 
+	// language server
+	"f2":  "hover",
+	"f3":  "complete",
+	"f12": "goto_definition",
+
+	// keystroke macros
+	"f5": "macro_record", // start / stop recording
+	"f6": "macro_play",   // replay the last recorded macro
+
+	// command output : stop / resume following the output
+	"f9": "toggle_pin",
+
+	// problems (compiler errors ...) reported by commands
+	"f7": "prev_error",
+	"f8": "next_error",
+
+	// project tasks (.goed/tasks.toml) : run the latest run task again
+	"f4": "rerun_task",
+
 	// control sequences
 	"ctrl+a": "home",       // as in Acme
 	"ctrl+b": "select_all", // made up since ctrl+a is used
 	"ctrl+c": "copy",
 	//"ctrl+d": "TODO", // Delete word before cursor (ctrl+w in Acme)
 	"ctrl+e": "end",
-	"ctrl+h": "move_left",  // vi like mvmt
-	"ctrl+j": "move_right", // vi like mvmt
-	"ctrl+k": "move_up",    // vi like mvmt
+	"ctrl+f": "find_next",
+	"ctrl+g": "add_next_occurrence", // multi cursor
+	"ctrl+h": "move_left",           // vi like mvmt
+	"ctrl+j": "move_right",          // vi like mvmt
+	"ctrl+k": "move_up",             // vi like mvmt
 	"ctrl+l": "move_down",
 	"ctrl+o": "open_in_same_view",
+	"ctrl+p": "find_file",
 	"ctrl+n": "open_in_new_view", // or right click
 	"ctrl+q": "quit",
 	"ctrl+r": "reload",
@@ -114,6 +154,8 @@ var defaultBindings = map[string]EventType{
 	"shift+end":         "select_end",
 
 	// navigation
+	"alt+f":             "find_prev",
+	"alt+b":             "switch_view",
 	"alt+right_arrow":   "nav_right",
 	"alt+left_arrow":    "nav_left",
 	"alt+down_arrow":    "nav_down",
@@ -1,18 +1,33 @@
 package event
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
+// RenameDelay is how long a watched file rename or removal is given to
+// settle (ie: replaced by a new version) before being acted upon.
+var RenameDelay = 200 * time.Millisecond
+
+// FileWatcher watches the files and directories opened in views.
+// Files are watched through their parent directory so that they are still
+// watched after being replaced (ie: atomic saves), renamed files are followed
+// to their new location.
 type FileWatcher struct {
 	sync.Mutex
 	watcher  *fsnotify.Watcher
-	watchMap map[string][]int64 // map of watched "path" to view(s)
+	watchMap map[string][]int64               // map of watched "path" to view(s)
+	dirs     map[string]int                   // watched directories reference counts
+	infos    map[string]os.FileInfo           // watched files state, to follow renames
+	OnEvent  func(op core.FileOp, loc string) // default: actions.Ar.EdFileEvent
+	OnMove   func(from, to string)            // default: actions.Ar.EdFileMoved
 	done     chan struct{}
 }
 
@@ -25,6 +40,14 @@ func NewFileWatcher() *FileWatcher {
 		watcher:  w,
 		done:     make(chan struct{}),
 		watchMap: map[string][]int64{},
+		dirs:     map[string]int{},
+		infos:    map[string]os.FileInfo{},
+		OnEvent: func(op core.FileOp, loc string) {
+			actions.Ar.EdFileEvent(op, loc)
+		},
+		OnMove: func(from, to string) {
+			actions.Ar.EdFileMoved(from, to)
+		},
 	}
 }
 
@@ -34,7 +57,7 @@ func (w *FileWatcher) Start() {
 		case <-w.done:
 			return
 		case event := <-w.watcher.Events:
-			actions.Ar.EdFileEvent(core.FileOp(event.Op), event.Name)
+			w.event(core.FileOp(event.Op), event.Name)
 		}
 	}
 }
@@ -42,12 +65,85 @@ func (w *FileWatcher) Start() {
 func (w *FileWatcher) Stop() {
 	w.Lock()
 	defer w.Unlock()
-	for loc, _ := range w.watchMap {
-		w.watcher.Remove(loc)
+	for dir, _ := range w.dirs {
+		w.watcher.Remove(dir)
 	}
 	close(w.done)
 }
 
+// event filters and dispatches a raw file event.
+func (w *FileWatcher) event(op core.FileOp, loc string) {
+	w.Lock()
+	_, isDir := w.dirs[loc]
+	_, watched := w.watchMap[loc]
+	_, parentWatched := w.watchMap[filepath.Dir(loc)]
+	isFile := watched && !isDir
+	if isFile && op&(core.OpCreate|core.OpWrite) != 0 {
+		if info, err := os.Stat(loc); err == nil {
+			w.infos[loc] = info
+		}
+	}
+	w.Unlock()
+	if isFile && op&(core.OpRemove|core.OpRename) != 0 {
+		// might be replaced, or moved, find out once settled
+		time.AfterFunc(RenameDelay, func() { w.settle(loc) })
+		return
+	}
+	if watched || parentWatched {
+		w.OnEvent(op, loc)
+	}
+}
+
+// settle handles a watched file that was removed or renamed :
+// if it was replaced it's reported as written, if it was moved to a watched
+// directory it's followed, otherwise it's reported as removed.
+func (w *FileWatcher) settle(loc string) {
+	w.Lock()
+	if _, found := w.watchMap[loc]; !found {
+		w.Unlock()
+		return
+	}
+	if info, err := os.Stat(loc); err == nil {
+		w.infos[loc] = info
+		w.Unlock()
+		w.OnEvent(core.OpWrite, loc)
+		return
+	}
+	to := w.findMoved(loc)
+	if len(to) == 0 {
+		w.Unlock()
+		w.OnEvent(core.OpRemove, loc)
+		return
+	}
+	vids := append([]int64{}, w.watchMap[loc]...)
+	for _, vid := range vids {
+		w.unwatch(vid, loc)
+		w.watch(vid, to)
+	}
+	w.Unlock()
+	w.OnMove(loc, to)
+}
+
+// findMoved looks for the file that was at loc in the watched directories.
+func (w *FileWatcher) findMoved(loc string) string {
+	info, found := w.infos[loc]
+	if !found {
+		return ""
+	}
+	for dir, _ := range w.dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if os.SameFile(info, f) {
+				return filepath.Join(dir, f.Name())
+			}
+		}
+	}
+	return ""
+}
+
 func (w *FileWatcher) Watch(vid int64, loc string) {
 	if w == nil {
 		return
@@ -58,6 +154,10 @@ func (w *FileWatcher) Watch(vid int64, loc string) {
 	}
 	w.Lock()
 	defer w.Unlock()
+	w.watch(vid, loc)
+}
+
+func (w *FileWatcher) watch(vid int64, loc string) {
 	if m, found := w.watchMap[loc]; found {
 		// already watching this loc
 		idx := -1
@@ -74,7 +174,15 @@ func (w *FileWatcher) Watch(vid int64, loc string) {
 		return
 	}
 	w.watchMap[loc] = []int64{vid}
-	w.watcher.Add(loc)
+	info, err := os.Stat(loc)
+	if err == nil && info.IsDir() {
+		w.addDir(loc)
+		return
+	}
+	if err == nil {
+		w.infos[loc] = info
+	}
+	w.addDir(filepath.Dir(loc))
 }
 
 func (w *FileWatcher) Unwatch(vid int64, loc string) {
@@ -87,6 +195,10 @@ func (w *FileWatcher) Unwatch(vid int64, loc string) {
 	}
 	w.Lock()
 	defer w.Unlock()
+	w.unwatch(vid, loc)
+}
+
+func (w *FileWatcher) unwatch(vid int64, loc string) {
 	if m, found := w.watchMap[loc]; found {
 		idx := -1
 		for i, id := range m {
@@ -102,7 +214,27 @@ func (w *FileWatcher) Unwatch(vid int64, loc string) {
 		// if no views left for this loc, no longer need to watch it.
 		if len(w.watchMap[loc]) == 0 {
 			delete(w.watchMap, loc)
-			w.watcher.Remove(loc)
+			if _, isDir := w.dirs[loc]; isDir {
+				w.removeDir(loc)
+			} else {
+				delete(w.infos, loc)
+				w.removeDir(filepath.Dir(loc))
+			}
 		}
 	}
 }
+
+func (w *FileWatcher) addDir(dir string) {
+	if w.dirs[dir] == 0 {
+		w.watcher.Add(dir)
+	}
+	w.dirs[dir]++
+}
+
+func (w *FileWatcher) removeDir(dir string) {
+	w.dirs[dir]--
+	if w.dirs[dir] <= 0 {
+		delete(w.dirs, dir)
+		w.watcher.Remove(dir)
+	}
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tcolar/goed/assert"
+	"github.com/tcolar/goed/core"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type EventSuite struct{}
+
+var _ = Suite(&EventSuite{})
+
+type fileEvent struct {
+	op      core.FileOp
+	loc, to string
+}
+
+func (s *EventSuite) TestFileWatcher(t *C) {
+	RenameDelay = 50 * time.Millisecond
+	dir, _ := filepath.EvalSymlinks(t.MkDir())
+	loc := filepath.Join(dir, "a.txt")
+	ioutil.WriteFile(loc, []byte("a"), 0644)
+	events := make(chan fileEvent, 100)
+	w := NewFileWatcher()
+	w.OnEvent = func(op core.FileOp, loc string) { events <- fileEvent{op: op, loc: loc} }
+	w.OnMove = func(from, to string) { events <- fileEvent{op: core.OpRename, loc: from, to: to} }
+	go w.Start()
+	defer w.Stop()
+	w.Watch(1, loc)
+	// waits for an event about loc matching op
+	expect := func(op core.FileOp, loc string) fileEvent {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case e := <-events:
+				if e.loc == loc && e.op&op != 0 {
+					return e
+				}
+			case <-timeout:
+				t.Fatalf("No event %v for %s", op, loc)
+			}
+		}
+	}
+
+	// siblings are ignored
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644)
+	ioutil.WriteFile(loc, []byte("aa"), 0644)
+	e := expect(core.OpWrite, loc)
+	assert.Eq(t, e.loc, loc)
+
+	// replaced (atomic save) : still watched
+	core.WriteFileAtomic(loc, core.BackupNone, func(w io.Writer) error { return nil })
+	expect(core.OpCreate|core.OpWrite, loc)
+	ioutil.WriteFile(loc, []byte("aaa"), 0644)
+	expect(core.OpWrite, loc)
+
+	// renamed : followed
+	loc2 := filepath.Join(dir, "c.txt")
+	assert.Nil(t, os.Rename(loc, loc2))
+	e = expect(core.OpRename, loc)
+	assert.Eq(t, e.to, loc2)
+	ioutil.WriteFile(loc2, []byte("c"), 0644)
+	expect(core.OpWrite, loc2)
+
+	// removed
+	os.Remove(loc2)
+	expect(core.OpRemove, loc2)
+
+	w.Unwatch(1, loc2)
+	assert.Eq(t, len(w.dirs), 0)
+	assert.Eq(t, len(w.watchMap), 0)
+}
@@ -0,0 +1,135 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// Keystroke macros : the events handled while recording (EvtMacroRecord
+// toggles it) are kept and can be replayed (EvtMacroPlay replays the last one).
+// Macros can be saved by name to ~/.goed/macros/<name>.json.
+
+var macro = macroState{}
+
+type macroState struct {
+	sync.Mutex
+	recording bool
+	events    []*Event // being recorded
+	last      []*Event // last recorded macro
+}
+
+// record adds an event to the macro being recorded, if any.
+func (m *macroState) record(e *Event) {
+	m.Lock()
+	defer m.Unlock()
+	if m.recording {
+		m.events = append(m.events, e.Clone())
+	}
+}
+
+// toggleMacroRecord starts or stops recording a macro.
+func toggleMacroRecord() {
+	macro.Lock()
+	defer macro.Unlock()
+	macro.recording = !macro.recording
+	if macro.recording {
+		macro.events = []*Event{}
+		actions.Ar.EdSetStatus("Recording macro ...")
+		return
+	}
+	macro.last = macro.events
+	actions.Ar.EdSetStatus(fmt.Sprintf("Recorded macro (%d events)", len(macro.last)))
+}
+
+// playMacro replays events, from the event loop.
+func playMacro(events []*Event, es *eventState) {
+	for _, e := range events {
+		handleEvent(e.Clone(), es)
+	}
+}
+
+// MacroRecording returns whether a macro is being recorded.
+func MacroRecording() bool {
+	macro.Lock()
+	defer macro.Unlock()
+	return macro.recording
+}
+
+// LastMacro returns the last recorded macro.
+func LastMacro() []*Event {
+	macro.Lock()
+	defer macro.Unlock()
+	return macro.last
+}
+
+// PlayMacro replays macro events by queuing them, it should not be called
+// from the event loop.
+func PlayMacro(events []*Event) {
+	for _, e := range events {
+		Queue(e.Clone())
+	}
+}
+
+// MacroDir is where the named macros are saved.
+func MacroDir() string {
+	return path.Join(core.Home, "macros")
+}
+
+// SaveMacro saves macro events by name.
+func SaveMacro(name string, events []*Event) error {
+	if len(events) == 0 {
+		return fmt.Errorf("No macro recorded")
+	}
+	if len(name) == 0 || strings.ContainsAny(name, "/\\ ") {
+		return fmt.Errorf("Invalid macro name : '%s'", name)
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(MacroDir(), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(MacroDir(), name+".json"), data, 0640)
+}
+
+// LoadMacro loads a named macro.
+func LoadMacro(name string) ([]*Event, error) {
+	data, err := ioutil.ReadFile(path.Join(MacroDir(), name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("No such macro : %s", name)
+	}
+	events := []*Event{}
+	err = json.Unmarshal(data, &events)
+	return events, err
+}
+
+// DeleteMacro deletes a named macro.
+func DeleteMacro(name string) error {
+	if err := os.Remove(path.Join(MacroDir(), name+".json")); err != nil {
+		return fmt.Errorf("No such macro : %s", name)
+	}
+	return nil
+}
+
+// MacroNames returns the names of the saved macros, sorted.
+func MacroNames() []string {
+	names := []string{}
+	files, _ := ioutil.ReadDir(MacroDir())
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(f.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,246 @@
+// Package lsp provides a language server protocol client.
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Client is a client to a language server.
+type Client struct {
+	conn        *Conn
+	root        string
+	syncKind    int
+	lock        sync.Mutex
+	versions    map[string]int          // open documents version, by uri
+	diagnostics map[string][]Diagnostic // by uri
+	cmd         *exec.Cmd
+	// OnDiagnostics is called when diagnostics are published for a file.
+	OnDiagnostics func(loc string, diags []Diagnostic)
+}
+
+// NewClient creates a client talking to a server through r / w and
+// initializes it for the given root directory.
+func NewClient(r io.Reader, w io.Writer, root string) (*Client, error) {
+	c := &Client{
+		conn:        NewConn(r, w),
+		root:        root,
+		versions:    map[string]int{},
+		diagnostics: map[string][]Diagnostic{},
+	}
+	c.conn.OnNotify = c.onNotify
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   LocToUri(root),
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"hover": map[string]interface{}{
+					"contentFormat": []string{"plaintext"},
+				},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+		},
+	}
+	var result initializeResult
+	if err := c.conn.Call("initialize", params, &result); err != nil {
+		return nil, err
+	}
+	c.syncKind = result.syncKind()
+	if err := c.conn.Notify("initialized", struct{}{}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// StartClient starts a language server process (stdio) and returns a client
+// connected to it.
+func StartClient(args []string, root string) (*Client, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("No language server command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = root
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c, err := NewClient(out, in, root)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	c.cmd = cmd
+	return c, nil
+}
+
+// Root returns the root directory the client was initialized with.
+func (c *Client) Root() string {
+	return c.root
+}
+
+// DidOpen notifies the server that a document was opened.
+func (c *Client) DidOpen(loc, languageId, text string) error {
+	uri := LocToUri(loc)
+	c.lock.Lock()
+	c.versions[uri] = 1
+	c.lock.Unlock()
+	return c.conn.Notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": textDocumentItem{Uri: uri, LanguageId: languageId, Version: 1, Text: text},
+	})
+}
+
+// DidChange notifies the server that the range r of a document was replaced
+// by text. fullText returns the whole document, it's used instead if the
+// server does not support incremental changes.
+func (c *Client) DidChange(loc string, r Range, text string, fullText func() string) error {
+	uri := LocToUri(loc)
+	c.lock.Lock()
+	version, found := c.versions[uri]
+	if !found {
+		c.lock.Unlock()
+		return nil
+	}
+	version++
+	c.versions[uri] = version
+	c.lock.Unlock()
+	change := contentChange{Range: &r, Text: text}
+	switch c.syncKind {
+	case SyncNone:
+		return nil
+	case SyncFull:
+		change = contentChange{Text: fullText()}
+	}
+	return c.conn.Notify("textDocument/didChange", didChangeParams{
+		TextDocument: textDocumentIdentifier{Uri: uri, Version: version},
+		Changes:      []contentChange{change},
+	})
+}
+
+// DidSave notifies the server that a document was saved.
+func (c *Client) DidSave(loc string) error {
+	return c.conn.Notify("textDocument/didSave", map[string]interface{}{
+		"textDocument": textDocumentIdentifier{Uri: LocToUri(loc)},
+	})
+}
+
+// DidClose notifies the server that a document was closed.
+func (c *Client) DidClose(loc string) error {
+	uri := LocToUri(loc)
+	c.lock.Lock()
+	delete(c.versions, uri)
+	delete(c.diagnostics, uri)
+	c.lock.Unlock()
+	return c.conn.Notify("textDocument/didClose", map[string]interface{}{
+		"textDocument": textDocumentIdentifier{Uri: uri},
+	})
+}
+
+// IsOpen returns whether the document was opened with DidOpen (and not closed).
+func (c *Client) IsOpen(loc string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, found := c.versions[LocToUri(loc)]
+	return found
+}
+
+// Definition returns the location(s) where the symbol at pos is defined.
+func (c *Client) Definition(loc string, pos Position) ([]Location, error) {
+	var result json.RawMessage
+	if err := c.conn.Call("textDocument/definition", c.posParams(loc, pos), &result); err != nil {
+		return nil, err
+	}
+	return decodeLocations(result), nil
+}
+
+// Hover returns the hover information (as text) for the symbol at pos.
+func (c *Client) Hover(loc string, pos Position) (string, error) {
+	var result json.RawMessage
+	if err := c.conn.Call("textDocument/hover", c.posParams(loc, pos), &result); err != nil {
+		return "", err
+	}
+	return decodeHover(result), nil
+}
+
+// Completion returns the completion candidates at pos.
+func (c *Client) Completion(loc string, pos Position) ([]CompletionItem, error) {
+	var result json.RawMessage
+	if err := c.conn.Call("textDocument/completion", c.posParams(loc, pos), &result); err != nil {
+		return nil, err
+	}
+	return decodeCompletion(result), nil
+}
+
+// Diagnostics returns the latest diagnostics published for a document.
+func (c *Client) Diagnostics(loc string) []Diagnostic {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.diagnostics[LocToUri(loc)]
+}
+
+// ExitTimeout is how long Shutdown waits for the server process to exit
+// before killing it.
+var ExitTimeout = 2 * time.Second
+
+// Shutdown asks the server to shutdown and exit, the server process being
+// killed if it did not exit within ExitTimeout.
+func (c *Client) Shutdown() error {
+	err := c.conn.Call("shutdown", nil, nil)
+	c.conn.Notify("exit", nil)
+	if c.cmd != nil {
+		exited := make(chan struct{})
+		go func() {
+			c.cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(ExitTimeout):
+			c.Kill()
+			<-exited
+		}
+	}
+	return err
+}
+
+// Kill kills the server process, if started by the client (StartClient).
+func (c *Client) Kill() {
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+}
+
+func (c *Client) posParams(loc string, pos Position) textDocumentPositionParams {
+	return textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{Uri: LocToUri(loc)},
+		Position:     pos,
+	}
+}
+
+func (c *Client) onNotify(method string, params json.RawMessage) {
+	if method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var p publishDiagnosticsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+	c.lock.Lock()
+	c.diagnostics[p.Uri] = p.Diagnostics
+	c.lock.Unlock()
+	if c.OnDiagnostics != nil {
+		c.OnDiagnostics(UriToLoc(p.Uri), p.Diagnostics)
+	}
+}
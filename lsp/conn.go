@@ -0,0 +1,194 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a call waits for the server response.
+var DefaultTimeout = 10 * time.Second
+
+// Message is a JSON-RPC 2.0 message (request, notification or response).
+type Message struct {
+	JsonRpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// ResponseError is a JSON-RPC error.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("LSP error %d : %s", e.Code, e.Message)
+}
+
+// ReadMessage reads a message using the LSP base protocol, ie: a
+// Content-Length header followed by the JSON content.
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Content-Length : %s", err.Error())
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	msg := &Message{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// WriteMessage writes a message using the LSP base protocol.
+func WriteMessage(w io.Writer, msg *Message) error {
+	msg.JsonRpc = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+// Conn is a JSON-RPC 2.0 connection to a language server.
+type Conn struct {
+	w       io.Writer
+	r       *bufio.Reader
+	wlock   sync.Mutex
+	lock    sync.Mutex
+	nextId  int64
+	pending map[int64]chan *Message
+	err     error // set once the connection is broken
+	// Timeout is how long calls wait for a response
+	Timeout time.Duration
+	// OnNotify is called (from the reader routine) for server notifications.
+	OnNotify func(method string, params json.RawMessage)
+}
+
+// NewConn creates a connection reading responses from r and writing
+// requests to w.
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	c := &Conn{
+		w:       w,
+		r:       bufio.NewReader(r),
+		pending: map[int64]chan *Message{},
+		Timeout: DefaultTimeout,
+	}
+	go c.readLoop()
+	return c
+}
+
+// Call sends a request and decodes the response result into result
+// (unless nil).
+func (c *Conn) Call(method string, params, result interface{}) error {
+	c.lock.Lock()
+	if c.err != nil {
+		c.lock.Unlock()
+		return c.err
+	}
+	c.nextId++
+	id := c.nextId
+	answer := make(chan *Message, 1)
+	c.pending[id] = answer
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	raw := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.send(&Message{Id: &raw, Method: method}, params); err != nil {
+		return err
+	}
+	select {
+	case msg := <-answer:
+		if msg == nil {
+			return c.err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-time.After(c.Timeout):
+		return fmt.Errorf("LSP %s timed out", method)
+	}
+}
+
+// Notify sends a notification (no response).
+func (c *Conn) Notify(method string, params interface{}) error {
+	c.lock.Lock()
+	err := c.err
+	c.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.send(&Message{Method: method}, params)
+}
+
+func (c *Conn) send(msg *Message, params interface{}) error {
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	return WriteMessage(c.w, msg)
+}
+
+func (c *Conn) readLoop() {
+	for {
+		msg, err := ReadMessage(c.r)
+		if err != nil {
+			c.lock.Lock()
+			c.err = fmt.Errorf("LSP connection closed : %s", err.Error())
+			for _, answer := range c.pending {
+				answer <- nil
+			}
+			c.lock.Unlock()
+			return
+		}
+		switch {
+		case len(msg.Method) == 0 && msg.Id != nil: // response
+			id, _ := strconv.ParseInt(string(*msg.Id), 10, 64)
+			c.lock.Lock()
+			answer, found := c.pending[id]
+			c.lock.Unlock()
+			if found {
+				answer <- msg
+			}
+		case msg.Id != nil: // server request, we don't support any
+			c.wlock.Lock()
+			WriteMessage(c.w, &Message{Id: msg.Id, Result: json.RawMessage("null")})
+			c.wlock.Unlock()
+		default: // notification
+			if c.OnNotify != nil {
+				c.OnNotify(msg.Method, msg.Params)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package lsp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tcolar/goed/assert"
+	"github.com/tcolar/goed/lsp"
+	"github.com/tcolar/goed/lsp/lsptest"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type LspSuite struct{}
+
+var _ = Suite(&LspSuite{})
+
+func rng(l1, c1, l2, c2 int) lsp.Range {
+	return lsp.Range{Start: lsp.Position{l1, c1}, End: lsp.Position{l2, c2}}
+}
+
+func (s *LspSuite) TestClient(t *C) {
+	server := lsptest.NewServer()
+	c, err := server.Dial(lsp.Server{}, "/tmp")
+	assert.Nil(t, err)
+	assert.True(t, server.Received("initialize"))
+	loc := "/tmp/lsp_test.go"
+	assert.Nil(t, c.DidOpen(loc, "go", "package foo\n\nfunc a() {}\n"))
+	assert.True(t, c.IsOpen(loc))
+	full := func() string { return "" }
+	c.DidChange(loc, rng(2, 5, 2, 6), "bar", full)
+	c.DidChange(loc, rng(1, 0, 2, 0), "", full)
+	c.DidChange(loc, rng(0, 0, 0, 0), "// é\n", full)
+
+	server.Definition = []lsp.Location{{Uri: lsp.LocToUri("/tmp/b.go"), Range: rng(3, 5, 3, 8)}}
+	locs, err := c.Definition(loc, lsp.Position{1, 6})
+	assert.Nil(t, err)
+	assert.Eq(t, server.Doc(loc), "// é\npackage foo\nfunc bar() {}\n")
+	assert.Eq(t, len(locs), 1)
+	assert.Eq(t, lsp.UriToLoc(locs[0].Uri), "/tmp/b.go")
+	assert.Eq(t, locs[0].Range.Start.Line, 3)
+
+	// LocationLink variant
+	server.Definition = []map[string]interface{}{{
+		"targetUri":            lsp.LocToUri("/tmp/c.go"),
+		"targetRange":          rng(1, 0, 5, 0),
+		"targetSelectionRange": rng(1, 5, 1, 6),
+	}}
+	locs, err = c.Definition(loc, lsp.Position{1, 6})
+	assert.Nil(t, err)
+	assert.Eq(t, len(locs), 1)
+	assert.Eq(t, lsp.UriToLoc(locs[0].Uri), "/tmp/c.go")
+	assert.Eq(t, locs[0].Range.Start.Character, 5)
+
+	server.Definition = nil
+	locs, err = c.Definition(loc, lsp.Position{1, 6})
+	assert.Nil(t, err)
+	assert.Eq(t, len(locs), 0)
+
+	server.Hover = "func bar()"
+	hover, err := c.Hover(loc, lsp.Position{2, 6})
+	assert.Nil(t, err)
+	assert.Eq(t, hover, "func bar()")
+
+	server.Completion = []lsp.CompletionItem{{Label: "Println"}, {Label: "Printf", InsertText: "Printf("}}
+	items, err := c.Completion(loc, lsp.Position{2, 6})
+	assert.Nil(t, err)
+	assert.Eq(t, len(items), 2)
+	assert.Eq(t, items[0].Text(), "Println")
+	assert.Eq(t, items[1].Text(), "Printf(")
+
+	got := make(chan int, 1)
+	c.OnDiagnostics = func(l string, diags []lsp.Diagnostic) {
+		if l == loc {
+			got <- len(diags)
+		}
+	}
+	server.Publish(loc, []lsp.Diagnostic{{Range: rng(2, 0, 2, 4), Severity: lsp.SeverityError, Message: "oops"}})
+	select {
+	case n := <-got:
+		assert.Eq(t, n, 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Diagnostics not received")
+	}
+	diags := c.Diagnostics(loc)
+	assert.Eq(t, len(diags), 1)
+	assert.Eq(t, diags[0].Message, "oops")
+
+	assert.Nil(t, c.DidClose(loc))
+	assert.False(t, c.IsOpen(loc))
+	assert.Eq(t, len(c.Diagnostics(loc)), 0)
+	assert.Nil(t, c.Shutdown())
+	assert.True(t, server.Received("exit"))
+}
+
+func (s *LspSuite) TestFullSync(t *C) {
+	server := lsptest.NewServer()
+	server.SyncKind = lsp.SyncFull
+	c, err := server.Dial(lsp.Server{}, "/tmp")
+	assert.Nil(t, err)
+	loc := "/tmp/full.txt"
+	c.DidOpen(loc, "text", "abc")
+	c.DidChange(loc, rng(0, 1, 0, 2), "", func() string { return "ac" })
+	c.Hover(loc, lsp.Position{}) // round trip
+	assert.Eq(t, server.Doc(loc), "ac")
+	// changes to documents that are not open are ignored
+	c.DidChange("/tmp/other.txt", rng(0, 0, 0, 0), "x", func() string { return "x" })
+	c.Hover(loc, lsp.Position{})
+	assert.Eq(t, server.Doc("/tmp/other.txt"), "")
+}
+
+func (s *LspSuite) TestManager(t *C) {
+	server := lsptest.NewServer()
+	m := lsp.NewManager([]lsp.Server{
+		{Cmd: []string{"fakels"}, Extensions: []string{".go"}, LanguageId: "go"},
+		{Cmd: []string{"goed_no_such_server"}, Extensions: []string{".py"}, LanguageId: "python"},
+	}, "/tmp")
+	dial := m.Dial
+	m.Dial = func(srv lsp.Server, root string) (*lsp.Client, error) {
+		if srv.LanguageId == "go" {
+			return server.Dial(srv, root)
+		}
+		return dial(srv, root)
+	}
+	c, srv, err := m.Client("/tmp/a.txt")
+	assert.Nil(t, err)
+	assert.True(t, c == nil)
+	c, srv, err = m.Client("/tmp/a.go")
+	assert.Nil(t, err)
+	assert.NotNil(t, c)
+	assert.Eq(t, srv.LanguageId, "go")
+	c2, _, _ := m.Client("/tmp/B.GO")
+	assert.True(t, c == c2)
+	c, _, err = m.Client("/tmp/a.py")
+	assert.NotNil(t, err)
+	assert.True(t, c == nil)
+	_, _, err2 := m.Client("/tmp/b.py")
+	assert.Eq(t, err2, err)
+	m.Shutdown()
+	assert.True(t, server.Received("shutdown"))
+}
+
+// stuckServer answers initialize, then ignores everything (shutdown, exit).
+var stuckServer = []string{"sh", "-c", `r='{"jsonrpc":"2.0","id":1,"result":{"capabilities":{}}}'
+printf 'Content-Length: %d\r\n\r\n%s' ${#r} "$r"; exec sleep 60`}
+
+func (s *LspSuite) TestShutdownStuck(t *C) {
+	timeout, exit, shutdown := lsp.DefaultTimeout, lsp.ExitTimeout, lsp.ShutdownTimeout
+	defer func() { lsp.DefaultTimeout, lsp.ExitTimeout, lsp.ShutdownTimeout = timeout, exit, shutdown }()
+	lsp.DefaultTimeout, lsp.ExitTimeout = 200*time.Millisecond, 200*time.Millisecond
+	c, err := lsp.StartClient(stuckServer, "/tmp")
+	assert.Nil(t, err)
+	start := time.Now()
+	assert.NotNil(t, c.Shutdown()) // timed out, then killed
+	assert.True(t, time.Since(start) < 2*time.Second)
+
+	// servers are shut down in parallel, within ShutdownTimeout
+	lsp.DefaultTimeout, lsp.ShutdownTimeout = 10*time.Second, 300*time.Millisecond
+	m := lsp.NewManager([]lsp.Server{
+		{Cmd: stuckServer, Extensions: []string{".go"}, LanguageId: "go"},
+		{Cmd: stuckServer, Extensions: []string{".py"}, LanguageId: "python"},
+	}, "/tmp")
+	for _, loc := range []string{"/tmp/a.go", "/tmp/a.py"} {
+		c, _, err := m.Client(loc)
+		assert.Nil(t, err)
+		assert.NotNil(t, c)
+	}
+	start = time.Now()
+	m.Shutdown()
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func (s *LspSuite) TestUTF16(t *C) {
+	line := []rune("a😀b")
+	assert.Eq(t, lsp.UTF16Col(line, 2), 3)
+	assert.Eq(t, lsp.UTF16Col(line, 10), 4)
+	assert.Eq(t, lsp.RuneCol(line, 3), 2)
+	assert.Eq(t, lsp.RuneCol(line, 10), 3)
+	assert.Eq(t, lsp.UriToLoc(lsp.LocToUri("/tmp/a b.go")), "/tmp/a b.go")
+}
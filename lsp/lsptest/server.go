@@ -0,0 +1,195 @@
+// Package lsptest provides an in process fake language server for tests.
+package lsptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tcolar/goed/lsp"
+)
+
+// ReceiveTimeout is how long Received waits for a method.
+var ReceiveTimeout = 5 * time.Second
+
+// Server is a fake language server keeping track of the documents content
+// and answering requests with canned results.
+type Server struct {
+	lock sync.Mutex
+	w    io.Writer
+	// SyncKind is the text document sync kind announced to the client.
+	SyncKind int
+	// Docs is the current content of the open documents, by uri.
+	Docs map[string]string
+	// Methods is the list of methods (requests and notifications) received.
+	Methods []string
+	// received is closed (and replaced) whenever a method is received.
+	received chan struct{}
+	// Canned results, Definition being the raw definition result
+	// (ie: []lsp.Location)
+	Definition interface{}
+	Hover      string
+	Completion []lsp.CompletionItem
+}
+
+// NewServer creates a fake server using incremental sync.
+func NewServer() *Server {
+	return &Server{
+		SyncKind: lsp.SyncIncremental,
+		Docs:     map[string]string{},
+		received: make(chan struct{}),
+	}
+}
+
+// Dial starts serving and returns a client connected to the server.
+// It's compatible with lsp.Manager.Dial.
+func (s *Server) Dial(server lsp.Server, root string) (*lsp.Client, error) {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	s.lock.Lock()
+	s.w = sw
+	s.lock.Unlock()
+	go s.serve(sr)
+	return lsp.NewClient(cr, cw, root)
+}
+
+// Doc returns the content of an open document.
+func (s *Server) Doc(loc string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.Docs[lsp.LocToUri(loc)]
+}
+
+// Received returns whether the given method was received, waiting for it
+// up to ReceiveTimeout since notifications are not acknowledged.
+func (s *Server) Received(method string) bool {
+	timeout := time.After(ReceiveTimeout)
+	for {
+		s.lock.Lock()
+		for _, m := range s.Methods {
+			if m == method {
+				s.lock.Unlock()
+				return true
+			}
+		}
+		received := s.received
+		s.lock.Unlock()
+		select {
+		case <-received:
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// Publish sends diagnostics for a document to the client.
+func (s *Server) Publish(loc string, diags []lsp.Diagnostic) error {
+	params, _ := json.Marshal(map[string]interface{}{
+		"uri":         lsp.LocToUri(loc),
+		"diagnostics": diags,
+	})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return lsp.WriteMessage(s.w, &lsp.Message{
+		Method: "textDocument/publishDiagnostics",
+		Params: params,
+	})
+}
+
+func (s *Server) serve(r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		msg, err := lsp.ReadMessage(br)
+		if err != nil {
+			return
+		}
+		s.lock.Lock()
+		s.Methods = append(s.Methods, msg.Method)
+		close(s.received)
+		s.received = make(chan struct{})
+		result := s.handle(msg)
+		if msg.Id != nil {
+			data, _ := json.Marshal(result)
+			lsp.WriteMessage(s.w, &lsp.Message{Id: msg.Id, Result: data})
+		}
+		s.lock.Unlock()
+	}
+}
+
+// handle processes a message (locked) and returns the response result.
+func (s *Server) handle(msg *lsp.Message) interface{} {
+	var params struct {
+		TextDocument struct {
+			Uri  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Range *lsp.Range `json:"range"`
+			Text  string     `json:"text"`
+		} `json:"contentChanges"`
+	}
+	json.Unmarshal(msg.Params, &params)
+	uri := params.TextDocument.Uri
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    s.SyncKind,
+				},
+			},
+		}
+	case "textDocument/didOpen":
+		s.Docs[uri] = params.TextDocument.Text
+	case "textDocument/didChange":
+		for _, c := range params.ContentChanges {
+			if c.Range == nil {
+				s.Docs[uri] = c.Text
+				continue
+			}
+			s.Docs[uri] = applyChange(s.Docs[uri], *c.Range, c.Text)
+		}
+	case "textDocument/didClose":
+		delete(s.Docs, uri)
+	case "textDocument/definition":
+		return s.Definition
+	case "textDocument/hover":
+		if len(s.Hover) == 0 {
+			return nil
+		}
+		return map[string]interface{}{
+			"contents": map[string]string{"kind": "plaintext", "value": s.Hover},
+		}
+	case "textDocument/completion":
+		return map[string]interface{}{"isIncomplete": false, "items": s.Completion}
+	}
+	return nil
+}
+
+// applyChange replaces the range r of text (UTF-16 columns) with change.
+func applyChange(text string, r lsp.Range, change string) string {
+	lines := strings.Split(text, "\n")
+	offset := func(p lsp.Position) int {
+		o := 0
+		for i := 0; i < p.Line && i < len(lines); i++ {
+			o += len([]rune(lines[i])) + 1
+		}
+		if p.Line < len(lines) {
+			o += lsp.RuneCol([]rune(lines[p.Line]), p.Character)
+		}
+		return o
+	}
+	runes := []rune(text)
+	start, end := offset(r.Start), offset(r.End)
+	if end > len(runes) {
+		end = len(runes)
+	}
+	if start > end {
+		start = end
+	}
+	return string(runes[:start]) + change + string(runes[end:])
+}
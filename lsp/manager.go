@@ -0,0 +1,117 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a language server configuration.
+type Server struct {
+	Cmd        []string // command starting the server (stdio)
+	Extensions []string // file extensions handled by the server, ie: ".go"
+	LanguageId string   // LSP language id, ie: "go"
+}
+
+// Manager starts and keeps track of the language servers, one per language.
+type Manager struct {
+	servers []Server
+	root    string
+	lock    sync.Mutex
+	clients map[string]*Client // by language id
+	failed  map[string]error   // servers that failed to start
+	// Dial starts a server, StartClient by default (replaceable for tests).
+	Dial func(server Server, root string) (*Client, error)
+	// OnDiagnostics is set as the OnDiagnostics callback of started clients.
+	OnDiagnostics func(loc string, diags []Diagnostic)
+}
+
+// NewManager creates a manager for the given servers, root being the
+// workspace root directory.
+func NewManager(servers []Server, root string) *Manager {
+	return &Manager{
+		servers: servers,
+		root:    root,
+		clients: map[string]*Client{},
+		failed:  map[string]error{},
+		Dial: func(server Server, root string) (*Client, error) {
+			return StartClient(server.Cmd, root)
+		},
+	}
+}
+
+// ServerFor returns the server configuration for a file, if any.
+func (m *Manager) ServerFor(loc string) (Server, bool) {
+	ext := strings.ToLower(filepath.Ext(loc))
+	for _, s := range m.servers {
+		for _, e := range s.Extensions {
+			if strings.ToLower(e) == ext {
+				return s, true
+			}
+		}
+	}
+	return Server{}, false
+}
+
+// Client returns the client for a file, starting the server if needed.
+// Returns nil if no server is configured for the file, or the server failed
+// to start (in which case it won't be retried).
+func (m *Manager) Client(loc string) (*Client, Server, error) {
+	server, found := m.ServerFor(loc)
+	if !found {
+		return nil, server, nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if c, found := m.clients[server.LanguageId]; found {
+		return c, server, nil
+	}
+	if err, found := m.failed[server.LanguageId]; found {
+		return nil, server, err
+	}
+	c, err := m.Dial(server, m.root)
+	if err != nil {
+		m.failed[server.LanguageId] = err
+		return nil, server, err
+	}
+	c.OnDiagnostics = m.OnDiagnostics
+	m.clients[server.LanguageId] = c
+	return c, server, nil
+}
+
+// ShutdownTimeout is how long Shutdown waits for all the servers to stop,
+// the remaining ones being killed.
+var ShutdownTimeout = 5 * time.Second
+
+// Shutdown stops all the started servers, in parallel.
+func (m *Manager) Shutdown() {
+	m.lock.Lock()
+	clients := m.clients
+	m.clients = map[string]*Client{}
+	m.lock.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			c.Shutdown()
+		}(c)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(ShutdownTimeout):
+		for _, c := range clients {
+			c.Kill()
+		}
+		select { // killed servers close the connection, ending the calls
+		case <-done:
+		case <-time.After(ExitTimeout):
+		}
+	}
+}
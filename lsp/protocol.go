@@ -0,0 +1,233 @@
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// Subset of the LSP protocol types, as used by goed.
+// See https://microsoft.github.io/language-server-protocol/specification
+
+// Position is a 0 indexed text position, Character being in UTF-16 units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a text range, End being exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	Uri   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type locationLink struct {
+	TargetUri            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// Diagnostic severities
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+type CompletionItem struct {
+	Label      string `json:"label"`
+	Detail     string `json:"detail,omitempty"`
+	InsertText string `json:"insertText,omitempty"`
+}
+
+// Text returns the text to insert for the completion.
+func (c CompletionItem) Text() string {
+	if len(c.InsertText) > 0 {
+		return c.InsertText
+	}
+	return c.Label
+}
+
+type textDocumentIdentifier struct {
+	Uri     string `json:"uri"`
+	Version int    `json:"version,omitempty"`
+}
+
+type textDocumentItem struct {
+	Uri        string `json:"uri"`
+	LanguageId string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type contentChange struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+type didChangeParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Changes      []contentChange        `json:"contentChanges"`
+}
+
+type publishDiagnosticsParams struct {
+	Uri         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Text document sync kinds
+const (
+	SyncNone        = 0
+	SyncFull        = 1
+	SyncIncremental = 2
+)
+
+type initializeResult struct {
+	Capabilities struct {
+		// either a sync kind or TextDocumentSyncOptions
+		TextDocumentSync json.RawMessage `json:"textDocumentSync"`
+	} `json:"capabilities"`
+}
+
+// syncKind returns the text document sync kind the server wants.
+func (r initializeResult) syncKind() int {
+	var kind int
+	if err := json.Unmarshal(r.Capabilities.TextDocumentSync, &kind); err == nil {
+		return kind
+	}
+	var opts struct {
+		Change *int `json:"change"`
+	}
+	if err := json.Unmarshal(r.Capabilities.TextDocumentSync, &opts); err == nil && opts.Change != nil {
+		return *opts.Change
+	}
+	return SyncFull
+}
+
+// LocToUri returns the file URI of a path.
+func LocToUri(loc string) string {
+	loc, _ = filepath.Abs(loc)
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(loc)}
+	return u.String()
+}
+
+// UriToLoc returns the path of a file URI.
+func UriToLoc(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// UTF16Col returns the UTF-16 column of the rune column col in line.
+func UTF16Col(line []rune, col int) int {
+	if col > len(line) {
+		col = len(line)
+	}
+	return len(utf16.Encode(line[:col]))
+}
+
+// RuneCol returns the rune column of the UTF-16 column col in line.
+func RuneCol(line []rune, col int) int {
+	n := 0
+	for i, r := range line {
+		if n >= col {
+			return i
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
+}
+
+// decodeLocations decodes a definition result :
+// Location | Location[] | LocationLink[] | null
+func decodeLocations(data json.RawMessage) []Location {
+	locs := []Location{}
+	if len(data) == 0 || string(data) == "null" {
+		return locs
+	}
+	var loc Location
+	if err := json.Unmarshal(data, &loc); err == nil && len(loc.Uri) > 0 {
+		return append(locs, loc)
+	}
+	var links []locationLink
+	if err := json.Unmarshal(data, &links); err == nil {
+		for _, l := range links {
+			if len(l.TargetUri) > 0 {
+				locs = append(locs, Location{Uri: l.TargetUri, Range: l.TargetSelectionRange})
+			}
+		}
+		if len(locs) > 0 {
+			return locs
+		}
+	}
+	json.Unmarshal(data, &locs)
+	return locs
+}
+
+// decodeHover decodes the contents of a hover result as text :
+// MarkedString | MarkedString[] | MarkupContent
+func decodeHover(data json.RawMessage) string {
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := json.Unmarshal(data, &hover); err != nil || len(hover.Contents) == 0 {
+		return ""
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(hover.Contents, &parts); err != nil {
+		parts = []json.RawMessage{hover.Contents}
+	}
+	texts := []string{}
+	for _, p := range parts {
+		var s string
+		if err := json.Unmarshal(p, &s); err == nil {
+			texts = append(texts, s)
+			continue
+		}
+		var content struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(p, &content); err == nil {
+			texts = append(texts, content.Value)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n"))
+}
+
+// decodeCompletion decodes a completion result :
+// CompletionItem[] | CompletionList | null
+func decodeCompletion(data json.RawMessage) []CompletionItem {
+	items := []CompletionItem{}
+	if err := json.Unmarshal(data, &items); err == nil {
+		return items
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	json.Unmarshal(data, &list)
+	if list.Items != nil {
+		items = list.Items
+	}
+	return items
+}
@@ -30,6 +30,9 @@ var (
 	config     = kingpin.Flag("config", "Config file.").Default("config.toml").String()
 	cpuprof    = kingpin.Flag("cpuprof", "Cpu profile").Default("false").Bool()
 	memprof    = kingpin.Flag("memprof", "Mem profile").Default("false").Bool()
+	session    = kingpin.Flag("session", "Session name, restores its layout (saved automatically).").String()
+	apiPort    = kingpin.Flag("api-port", "API TCP port (token authenticated), none if 0.").Default("0").Int()
+	apiHost    = kingpin.Flag("api-host", "API TCP listener host.").Default("127.0.0.1").String()
 
 	locs = kingpin.Arg("location", "location to open").Strings()
 )
@@ -86,6 +89,15 @@ func Initialize() *core.Config {
 	core.Bus = actions.NewActionBus()
 	core.InitHome(id)
 	core.ConfFile = *config
+	core.Session = *session
+	if len(core.Session) > 0 {
+		if err := ui.CheckSessionName(core.Session); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	core.ApiPort = *apiPort
+	core.ApiHost = *apiHost
 
 	startupChecks()
 
@@ -0,0 +1,185 @@
+// Package problems extracts the problems (errors, warnings) reported by
+// compilers, linters and test runners from their output, using regular
+// expressions per tool (problem matchers).
+package problems
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Matcher finds the problems reported in the output of a tool.
+type Matcher struct {
+	Name string
+	// Cmds are the commands whose output the matcher applies to, ie: "go".
+	Cmds []string
+	// Pattern matches a problem line, using the named groups : file, line
+	// and optionally col, severity and message.
+	Pattern string
+	// FilePattern optionally matches a line giving the file (group file) of
+	// the problems on the following lines, for the tools grouping them by file.
+	FilePattern string
+	re, fileRe  *regexp.Regexp
+}
+
+// Compile compiles the matcher patterns, it must be called before use.
+func (m *Matcher) Compile() (err error) {
+	if m.re, err = regexp.Compile(m.Pattern); err != nil {
+		return fmt.Errorf("Invalid problem matcher %s : %s", m.Name, err.Error())
+	}
+	if len(m.FilePattern) == 0 {
+		return nil
+	}
+	if m.fileRe, err = regexp.Compile(m.FilePattern); err != nil {
+		return fmt.Errorf("Invalid problem matcher %s : %s", m.Name, err.Error())
+	}
+	return nil
+}
+
+// Problem is a problem reported by a tool.
+type Problem struct {
+	Loc       string // file, as reported unless resolved
+	Line, Col int    // 1 indexed, Col is 0 if not reported
+	Severity  string // SeverityError, SeverityWarning or SeverityInfo
+	Message   string
+	Row       int // row of the output it was reported on (0 indexed)
+}
+
+// String returns the problem as loc:line:col: severity: message
+func (p Problem) String() string {
+	col := p.Col
+	if col == 0 {
+		col = 1
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.Loc, p.Line, col, p.Severity, p.Message)
+}
+
+// Defaults returns the builtin matchers, by name.
+func Defaults() map[string]*Matcher {
+	matchers := map[string]*Matcher{
+		"go": {
+			Cmds:    []string{"go", "gofmt", "golint", "staticcheck", "golangci-lint"},
+			Pattern: `^\s*(?P<file>[^\s:]+\.go):(?P<line>\d+)(?::(?P<col>\d+))?: (?P<message>.+)$`,
+		},
+		"gcc": {
+			Cmds: []string{"gcc", "g++", "cc", "c++", "clang", "clang++"},
+			Pattern: `^(?P<file>[^\s:][^:]*):(?P<line>\d+):(?:(?P<col>\d+):)? ` +
+				`(?P<severity>fatal error|error|warning|note): (?P<message>.+)$`,
+		},
+		"eslint": { // default (stylish) format
+			Cmds:        []string{"eslint"},
+			Pattern:     `^\s+(?P<line>\d+):(?P<col>\d+)\s+(?P<severity>error|warning)\s+(?P<message>.+)$`,
+			FilePattern: `^(?P<file>[^\s].*\.\w+)$`,
+		},
+	}
+	for name, m := range matchers {
+		m.Name = name
+	}
+	return matchers
+}
+
+// For returns the matchers applying to the output of a command, ie: "go test",
+// all of them if none is specific to it. They are sorted by name.
+func For(matchers map[string]*Matcher, cmd string) []*Matcher {
+	all, found := []*Matcher{}, []*Matcher{}
+	name := ""
+	if fields := strings.Fields(cmd); len(fields) > 0 {
+		name = filepath.Base(fields[0])
+	}
+	for _, m := range matchers {
+		all = append(all, m)
+		for _, c := range m.Cmds {
+			if c == name {
+				found = append(found, m)
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		found = all
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
+
+// Parse returns the problems reported in the lines of a tool output, the
+// first matcher matching a line wins.
+func Parse(lines []string, matchers []*Matcher) []Problem {
+	files := make([]string, len(matchers)) // current file, per FilePattern
+	problems := []Problem{}
+	for row, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		for i, m := range matchers {
+			if p, ok := m.match(line, files[i]); ok {
+				p.Row = row
+				problems = append(problems, p)
+				break
+			}
+			if m.fileRe != nil {
+				if groups := submatches(m.fileRe, line); groups != nil {
+					files[i] = groups["file"]
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// match returns the problem reported on a line, if any, file being the one
+// given by a previous line (FilePattern).
+func (m *Matcher) match(line, file string) (p Problem, ok bool) {
+	groups := submatches(m.re, line)
+	if groups == nil {
+		return p, false
+	}
+	p.Loc = groups["file"]
+	if len(p.Loc) == 0 {
+		p.Loc = file
+	}
+	p.Line, _ = strconv.Atoi(groups["line"])
+	p.Col, _ = strconv.Atoi(groups["col"])
+	if len(p.Loc) == 0 || p.Line <= 0 {
+		return p, false
+	}
+	p.Severity = severity(groups["severity"])
+	p.Message = strings.TrimSpace(groups["message"])
+	return p, true
+}
+
+// submatches returns the named groups matched by re in s, nil if no match.
+func submatches(re *regexp.Regexp, s string) map[string]string {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	groups := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if len(name) > 0 {
+			groups[name] = m[i]
+		}
+	}
+	return groups
+}
+
+// severity normalizes a reported severity.
+func severity(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case len(s) == 0, strings.Contains(s, "err"), strings.Contains(s, "fatal"):
+		return SeverityError
+	case strings.HasPrefix(s, "warn"):
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
@@ -0,0 +1,102 @@
+package problems
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tcolar/goed/assert"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ProblemsSuite struct {
+	matchers map[string]*Matcher
+}
+
+var _ = Suite(&ProblemsSuite{})
+
+func (s *ProblemsSuite) SetUpSuite(t *C) {
+	s.matchers = Defaults()
+	for _, m := range s.matchers {
+		assert.Nil(t, m.Compile())
+	}
+}
+
+func (s *ProblemsSuite) parse(cmd, output string) []string {
+	results := []string{}
+	for _, p := range Parse(strings.Split(output, "\n"), For(s.matchers, cmd)) {
+		results = append(results, p.String())
+	}
+	return results
+}
+
+func (s *ProblemsSuite) TestGo(t *C) {
+	output := `# github.com/tcolar/goed/ui
+ui/view.go:12:2: undefined: foo
+ui/view.go:30:9: cannot use x (type int) as type string in return argument
+--- FAIL: TestFind (0.00s)
+    view_test.go:381: obtained "a" expected "b"
+panic: oops
+	/usr/lib/go/src/runtime/panic.go:1038 +0x215
+FAIL	github.com/tcolar/goed/ui	0.160s`
+	assert.DeepEq(t, s.parse("go test ./...", output), []string{
+		"ui/view.go:12:2: error: undefined: foo",
+		"ui/view.go:30:9: error: cannot use x (type int) as type string in return argument",
+		"view_test.go:381:1: error: obtained \"a\" expected \"b\"",
+	})
+	problems := Parse(strings.Split(output, "\n"), For(s.matchers, "go"))
+	assert.Eq(t, problems[1].Row, 2)
+	assert.Eq(t, problems[2].Col, 0)
+}
+
+func (s *ProblemsSuite) TestGcc(t *C) {
+	output := `main.c: In function 'main':
+main.c:5:3: warning: implicit declaration of function 'foo' [-Wimplicit-function-declaration]
+    5 |   foo();
+      |   ^~~
+main.c:7:10: error: expected ';' before '}' token
+lib/x.h:2: note: declared here
+cc1: all warnings being treated as errors`
+	assert.DeepEq(t, s.parse("/usr/bin/gcc -Wall main.c", output), []string{
+		"main.c:5:3: warning: implicit declaration of function 'foo' [-Wimplicit-function-declaration]",
+		"main.c:7:10: error: expected ';' before '}' token",
+		"lib/x.h:2:1: info: declared here",
+	})
+}
+
+func (s *ProblemsSuite) TestEslint(t *C) {
+	output := `
+/home/me/app/src/a.js
+  1:10  error    'x' is defined but never used  no-unused-vars
+  3:1   warning  Unexpected console statement   no-console
+
+/home/me/app/src/b.js
+  12:5  error  Missing semicolon  semi
+
+✖ 3 problems (2 errors, 1 warning)`
+	assert.DeepEq(t, s.parse("eslint src", output), []string{
+		"/home/me/app/src/a.js:1:10: error: 'x' is defined but never used  no-unused-vars",
+		"/home/me/app/src/a.js:3:1: warning: Unexpected console statement   no-console",
+		"/home/me/app/src/b.js:12:5: error: Missing semicolon  semi",
+	})
+}
+
+func (s *ProblemsSuite) TestMatchers(t *C) {
+	// unknown commands (ie: a terminal) use all the matchers
+	names := []string{}
+	for _, m := range For(s.matchers, "make all") {
+		names = append(names, m.Name)
+	}
+	assert.DeepEq(t, names, []string{"eslint", "gcc", "go"})
+	assert.Eq(t, len(For(s.matchers, "go build")), 1)
+	assert.Eq(t, len(s.parse("make", "main.c:7:10: error: foo\nx.go:1:2: bar")), 2)
+	// custom
+	m := &Matcher{Name: "custom", Pattern: `^ERR (?P<file>\S+) line (?P<line>\d+) : (?P<message>.*)$`}
+	assert.Nil(t, m.Compile())
+	problems := Parse([]string{"ERR a.txt line 3 : bad", "ERR a.txt line x : bad"}, []*Matcher{m})
+	assert.Eq(t, len(problems), 1)
+	assert.Eq(t, problems[0].String(), "a.txt:3:1: error: bad")
+	m.Pattern = "(?P<file"
+	assert.NotNil(t, m.Compile())
+}
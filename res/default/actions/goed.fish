@@ -22,6 +22,3 @@ alias cd="goed_cd"
 
 alias s="search_text.sh"
 alias f="find_files.sh"
-alias sz="vt100_size.sh"
-
-./vt100_size.sh
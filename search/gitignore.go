@@ -0,0 +1,129 @@
+package search
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a .gitignore pattern.
+type ignoreRule struct {
+	base     string // directory of the .gitignore, relative to the root
+	pattern  string
+	negate   bool // "!" pattern
+	dirOnly  bool // "foo/" pattern
+	anchored bool // pattern containing a "/", relative to base
+}
+
+// Ignorer tells whether files should be ignored according to the .gitignore
+// files found while walking a directory tree.
+type Ignorer struct {
+	root  string
+	rules []ignoreRule
+}
+
+// NewIgnorer creates an ignorer for the tree rooted at root.
+func NewIgnorer(root string) *Ignorer {
+	return &Ignorer{root: root}
+}
+
+// Load reads the .gitignore of the given directory (relative to the root)
+// if there is one.
+func (ig *Ignorer) Load(dir string) error {
+	f, err := os.Open(filepath.Join(ig.root, dir, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.AddPattern(dir, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// AddPattern adds a gitignore pattern relative to the given directory.
+func (ig *Ignorer) AddPattern(dir, pattern string) {
+	p := strings.TrimRight(pattern, " \t\r")
+	if len(p) == 0 || strings.HasPrefix(p, "#") {
+		return
+	}
+	rule := ignoreRule{base: filepath.ToSlash(dir)}
+	if rule.base == "." {
+		rule.base = ""
+	}
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	}
+	p = strings.TrimPrefix(p, "\\")
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if strings.Contains(p, "/") {
+		rule.anchored = true
+		p = strings.TrimPrefix(p, "/")
+	}
+	if len(p) == 0 {
+		return
+	}
+	rule.pattern = p
+	ig.rules = append(ig.rules, rule)
+}
+
+// Ignored returns whether the given path (relative to the root) is ignored.
+func (ig *Ignorer) Ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, r := range ig.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		p := rel
+		if len(r.base) > 0 {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			p = rel[len(r.base)+1:]
+		}
+		var matched bool
+		if r.anchored {
+			matched = matchGlob(strings.Split(r.pattern, "/"), strings.Split(p, "/"))
+		} else {
+			matched, _ = path.Match(r.pattern, path.Base(p))
+		}
+		if matched {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlob matches path segments against pattern segments, where a "**"
+// pattern segment matches any number of path segments.
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
@@ -0,0 +1,169 @@
+// Package search provides project wide (directory tree) search and replace.
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryCheckSize is how many bytes are checked to detect binary files.
+const binaryCheckSize = 8000
+
+// Query is a text search query.
+type Query struct {
+	Pattern    string
+	Regex      bool // whether Pattern is a regular expression or literal text
+	IgnoreCase bool
+}
+
+// Compile returns the regular expression matching the query.
+func (q Query) Compile() (*regexp.Regexp, error) {
+	expr := q.Pattern
+	if !q.Regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if q.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid search expression : %s", err.Error())
+	}
+	return re, nil
+}
+
+// Match is a search match in a file.
+type Match struct {
+	Path      string // relative to the search directory
+	Line, Col int    // 1 indexed, Col in runes
+	Text      string // the matching line
+	Repl      string // the line after replacement (replace only)
+}
+
+// String returns the match as path:line:col: text
+func (m Match) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", m.Path, m.Line, m.Col, m.Text)
+}
+
+// Reader returns the lines of a file, or nil lines to skip the file.
+type Reader func(loc string) (lines []string, err error)
+
+// ReadLines is a Reader reading a file from disk, skipping binary files.
+func ReadLines(loc string) ([]string, error) {
+	data, err := ioutil.ReadFile(loc)
+	if err != nil {
+		return nil, err
+	}
+	head := data
+	if len(head) > binaryCheckSize {
+		head = head[:binaryCheckSize]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n"), nil
+}
+
+// Walk calls fn with the path (relative to dir) of every file under dir,
+// skipping .git directories and what is ignored by .gitignore files.
+func Walk(dir string, fn func(rel string) error) error {
+	ig := NewIgnorer(dir)
+	return filepath.Walk(dir, func(loc string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // unreadable, skip it
+		}
+		rel, err := filepath.Rel(dir, loc)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || (rel != "." && ig.Ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			return ig.Load(rel)
+		}
+		if !info.Mode().IsRegular() || ig.Ignored(rel, false) {
+			return nil
+		}
+		return fn(rel)
+	})
+}
+
+// Search returns all the matches of the query in the files under dir.
+// Files are read with read, or ReadLines if nil.
+func Search(dir string, q Query, read Reader) ([]Match, error) {
+	return walkMatches(dir, q, read, func(m *Match, line string, re *regexp.Regexp, idx [][]int) []Match {
+		matches := []Match{}
+		for _, i := range idx {
+			mm := *m
+			mm.Col = utf8.RuneCountInString(line[:i[0]]) + 1
+			matches = append(matches, mm)
+		}
+		return matches
+	})
+}
+
+// Replace returns the lines of the files under dir that would be changed by
+// replacing the query matches with repl, Repl being the replaced line.
+// If the query is a regular expression, repl may reference groups ($1 etc...)
+func Replace(dir string, q Query, repl string, read Reader) ([]Match, error) {
+	return walkMatches(dir, q, read, func(m *Match, line string, re *regexp.Regexp, idx [][]int) []Match {
+		m.Col = utf8.RuneCountInString(line[:idx[0][0]]) + 1
+		if q.Regex {
+			m.Repl = re.ReplaceAllString(line, repl)
+		} else {
+			m.Repl = re.ReplaceAllLiteralString(line, repl)
+		}
+		if m.Repl == line {
+			return nil
+		}
+		return []Match{*m}
+	})
+}
+
+// walkMatches calls fn for each line of the files under dir matching the query
+// and collects the matches it returns.
+func walkMatches(dir string, q Query, read Reader,
+	fn func(m *Match, line string, re *regexp.Regexp, idx [][]int) []Match) ([]Match, error) {
+	re, err := q.Compile()
+	if err != nil {
+		return nil, err
+	}
+	if read == nil {
+		read = ReadLines
+	}
+	matches := []Match{}
+	err = Walk(dir, func(rel string) error {
+		lines, err := read(filepath.Join(dir, rel))
+		if err != nil {
+			return nil // unreadable, skip it
+		}
+		for i, line := range lines {
+			idx := nonEmpty(re.FindAllStringIndex(line, -1))
+			if len(idx) == 0 {
+				continue
+			}
+			m := Match{Path: rel, Line: i + 1, Text: line}
+			matches = append(matches, fn(&m, line, re, idx)...)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+func nonEmpty(idx [][]int) [][]int {
+	result := [][]int{}
+	for _, i := range idx {
+		if i[1] > i[0] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
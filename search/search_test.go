@@ -0,0 +1,103 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tcolar/goed/assert"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type SearchSuite struct {
+	dir string
+}
+
+var _ = Suite(&SearchSuite{})
+
+func (s *SearchSuite) SetUpSuite(t *C) {
+	s.dir = t.MkDir()
+	files := map[string]string{
+		".gitignore":       "*.log\n/build/\n!keep.log\n# comment\n",
+		"a.txt":            "foo bar\nno match\nFoo foo\n",
+		"keep.log":         "foo\n",
+		"skip.log":         "foo\n",
+		"build/b.txt":      "foo\n",
+		"sub/.gitignore":   "gen/**/*.txt\n",
+		"sub/c.txt":        "a foo\n",
+		"sub/build/d.txt":  "foo\n",
+		"sub/gen/x/e.txt":  "foo\n",
+		".git/config":      "foo\n",
+		"sub/bin.dat":      "foo\x00\n",
+		"sub/gen/x/f.json": "{\"foo\": 1}\n",
+	}
+	for f, content := range files {
+		loc := filepath.Join(s.dir, f)
+		os.MkdirAll(filepath.Dir(loc), 0750)
+		err := ioutil.WriteFile(loc, []byte(content), 0640)
+		assert.Nil(t, err)
+	}
+}
+
+func (s *SearchSuite) TestIgnorer(t *C) {
+	ig := NewIgnorer(s.dir)
+	ig.AddPattern("", "*.o")
+	ig.AddPattern("", "/out/")
+	ig.AddPattern("", "!main.o")
+	ig.AddPattern("a", "docs/**/*.md")
+	assert.True(t, ig.Ignored("x/y.o", false))
+	assert.False(t, ig.Ignored("x/main.o", false))
+	assert.True(t, ig.Ignored("out", true))
+	assert.False(t, ig.Ignored("out", false))
+	assert.False(t, ig.Ignored("x/out", true))
+	assert.True(t, ig.Ignored("a/docs/b.md", false))
+	assert.True(t, ig.Ignored("a/docs/b/c/d.md", false))
+	assert.False(t, ig.Ignored("docs/b.md", false))
+}
+
+func (s *SearchSuite) TestSearch(t *C) {
+	matches, err := Search(s.dir, Query{Pattern: "foo"}, nil)
+	assert.Nil(t, err)
+	strs := []string{}
+	for _, m := range matches {
+		strs = append(strs, m.String())
+	}
+	assert.DeepEq(t, strs, []string{
+		"a.txt:1:1: foo bar",
+		"a.txt:3:5: Foo foo",
+		"keep.log:1:1: foo",
+		"sub/build/d.txt:1:1: foo",
+		"sub/c.txt:1:3: a foo",
+		"sub/gen/x/f.json:1:3: {\"foo\": 1}",
+	})
+	matches, err = Search(s.dir, Query{Pattern: "^fo+", Regex: true, IgnoreCase: true},
+		func(loc string) ([]string, error) {
+			if filepath.Base(loc) == "a.txt" {
+				return ReadLines(loc)
+			}
+			return nil, nil
+		})
+	assert.Nil(t, err)
+	assert.Eq(t, len(matches), 2)
+	assert.Eq(t, matches[1].String(), "a.txt:3:1: Foo foo")
+	_, err = Search(s.dir, Query{Pattern: "(", Regex: true}, nil)
+	assert.NotNil(t, err)
+}
+
+func (s *SearchSuite) TestReplace(t *C) {
+	matches, err := Replace(s.dir, Query{Pattern: "(f)oo", Regex: true}, "${1}u", nil)
+	assert.Nil(t, err)
+	assert.Eq(t, len(matches), 6)
+	assert.Eq(t, matches[1].Text, "Foo foo")
+	assert.Eq(t, matches[1].Repl, "Foo fu")
+	assert.Eq(t, matches[1].Col, 5)
+	matches, err = Replace(s.dir, Query{Pattern: "foo", IgnoreCase: true}, "$1", nil)
+	assert.Nil(t, err)
+	assert.Eq(t, matches[1].Repl, "$1 $1")
+	// files are left alone
+	data, _ := ioutil.ReadFile(filepath.Join(s.dir, "a.txt"))
+	assert.Eq(t, string(data), "foo bar\nno match\nFoo foo\n")
+}
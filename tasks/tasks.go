@@ -0,0 +1,162 @@
+// Package tasks reads the project tasks (build, test, run ...) : named
+// commands defined in a .goed/tasks.toml file at the project root.
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+// File is the location of the tasks file, relative to the project root.
+var File = filepath.Join(".goed", "tasks.toml")
+
+// Task is a named project command, ie:
+//   [test]
+//   Cmd=["go", "test", "./..."]
+//   Env=["CGO_ENABLED=0"]
+//   Matcher="go"
+//   OnSave=["*.go"]
+type Task struct {
+	Name string `toml:"-"`
+	// Cmd is the command and its arguments.
+	Cmd []string
+	// Env are extra environment variables (KEY=value).
+	Env []string
+	// Cwd is the directory the command runs in, relative to the project root.
+	Cwd string
+	// Matcher is the problem matcher used on the output, if empty the ones
+	// applying to the command.
+	Matcher string
+	// OnSave triggers the task when saving a project file matching one of
+	// them (glob or extension).
+	OnSave []string
+}
+
+// Tasks are the tasks of a project.
+type Tasks struct {
+	Loc   string // tasks file
+	Dir   string // project root
+	Hash  string // of the tasks file content, see Trusted
+	Tasks map[string]*Task
+}
+
+// Find returns the tasks file of the project dir is in, walking up the
+// directory tree, or "" if none.
+func Find(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		loc := filepath.Join(dir, File)
+		if info, err := os.Stat(loc); err == nil && !info.IsDir() {
+			return loc
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the tasks of the project dir is in, see Find.
+func Load(dir string) (*Tasks, error) {
+	loc := Find(dir)
+	if len(loc) == 0 {
+		return nil, fmt.Errorf("No %s found from %s", File, dir)
+	}
+	data, err := ioutil.ReadFile(loc)
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string]*Task{}
+	if _, err := toml.Decode(string(data), &tasks); err != nil {
+		return nil, fmt.Errorf("Invalid tasks file %s : %s", loc, err.Error())
+	}
+	for name, t := range tasks {
+		if len(t.Cmd) == 0 {
+			return nil, fmt.Errorf("Invalid tasks file %s : task %s has no Cmd", loc, name)
+		}
+		t.Name = name
+	}
+	return &Tasks{
+		Loc:   loc,
+		Dir:   filepath.Dir(filepath.Dir(loc)),
+		Hash:  fmt.Sprintf("%x", sha256.Sum256(data)),
+		Tasks: tasks,
+	}, nil
+}
+
+// Names returns the task names, sorted.
+func (t *Tasks) Names() []string {
+	names := []string{}
+	for name := range t.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WorkDir returns the directory a task runs in.
+func (t *Tasks) WorkDir(task *Task) string {
+	if filepath.IsAbs(task.Cwd) {
+		return task.Cwd
+	}
+	return filepath.Join(t.Dir, task.Cwd)
+}
+
+// OnSave returns the tasks triggered by saving loc, sorted by name.
+func (t *Tasks) OnSave(loc string) []*Task {
+	found := []*Task{}
+	for _, name := range t.Names() {
+		task := t.Tasks[name]
+		for _, m := range task.OnSave {
+			match := m == filepath.Ext(loc)
+			if !match {
+				match, _ = filepath.Match(m, filepath.Base(loc))
+			}
+			if match {
+				found = append(found, task)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Trusted returns whether the tasks file, as is, was trusted (see Trust) to
+// run its tasks on save. trustLoc is the file recording the trusted ones.
+func (t *Tasks) Trusted(trustLoc string) bool {
+	return readTrusted(trustLoc)[t.Loc] == t.Hash
+}
+
+// Trust records the tasks file as trusted, until it changes.
+func (t *Tasks) Trust(trustLoc string) error {
+	trusted := readTrusted(trustLoc)
+	trusted[t.Loc] = t.Hash
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(trustLoc), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(trustLoc, data, 0600)
+}
+
+// readTrusted reads the trusted tasks files : location -> hash.
+func readTrusted(trustLoc string) map[string]string {
+	trusted := map[string]string{}
+	if data, err := ioutil.ReadFile(trustLoc); err == nil {
+		json.Unmarshal(data, &trusted)
+	}
+	return trusted
+}
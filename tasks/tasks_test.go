@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tcolar/goed/assert"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type TasksSuite struct {
+	dir string
+}
+
+var _ = Suite(&TasksSuite{})
+
+func (s *TasksSuite) SetUpTest(t *C) {
+	s.dir = t.MkDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(s.dir, ".goed"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(s.dir, "a", "b"), 0755))
+}
+
+func (s *TasksSuite) write(t *C, content string) {
+	err := ioutil.WriteFile(filepath.Join(s.dir, File), []byte(content), 0644)
+	assert.Nil(t, err)
+}
+
+func (s *TasksSuite) TestLoad(t *C) {
+	s.write(t, `
+[test]
+Cmd=["go", "test", "./..."]
+Env=["CGO_ENABLED=0"]
+Matcher="go"
+OnSave=["*_test.go", ".mod"]
+
+[build]
+Cmd=["go", "build"]
+Cwd="a"
+OnSave=[".go"]
+`)
+	assert.Eq(t, Find(filepath.Join(s.dir, "a", "b")), filepath.Join(s.dir, File))
+	tasks, err := Load(filepath.Join(s.dir, "a", "b"))
+	assert.Nil(t, err)
+	assert.Eq(t, tasks.Dir, s.dir)
+	assert.DeepEq(t, tasks.Names(), []string{"build", "test"})
+	test := tasks.Tasks["test"]
+	assert.Eq(t, test.Name, "test")
+	assert.DeepEq(t, test.Cmd, []string{"go", "test", "./..."})
+	assert.DeepEq(t, test.Env, []string{"CGO_ENABLED=0"})
+	assert.Eq(t, test.Matcher, "go")
+	assert.Eq(t, tasks.WorkDir(test), s.dir)
+	assert.Eq(t, tasks.WorkDir(tasks.Tasks["build"]), filepath.Join(s.dir, "a"))
+
+	names := func(loc string) []string {
+		found := []string{}
+		for _, t := range tasks.OnSave(loc) {
+			found = append(found, t.Name)
+		}
+		return found
+	}
+	assert.DeepEq(t, names("/x/a_test.go"), []string{"build", "test"})
+	assert.DeepEq(t, names("/x/a.go"), []string{"build"})
+	assert.DeepEq(t, names("/x/go.mod"), []string{"test"})
+	assert.DeepEq(t, names("/x/a.txt"), []string{})
+}
+
+func (s *TasksSuite) TestInvalid(t *C) {
+	s.write(t, "[test]\nEnv=[\"A=1\"]")
+	_, err := Load(s.dir)
+	assert.NotNil(t, err)
+	s.write(t, "[test\n")
+	_, err = Load(s.dir)
+	assert.NotNil(t, err)
+	os.Remove(filepath.Join(s.dir, File))
+	assert.Eq(t, Find(filepath.Join(s.dir, "a")), "")
+	_, err = Load(s.dir)
+	assert.NotNil(t, err)
+}
+
+func (s *TasksSuite) TestTrust(t *C) {
+	s.write(t, "[test]\nCmd=[\"go\", \"test\"]\nOnSave=[\".go\"]\n")
+	trustLoc := filepath.Join(t.MkDir(), "trusted.json")
+	tasks, err := Load(s.dir)
+	assert.Nil(t, err)
+	assert.False(t, tasks.Trusted(trustLoc))
+	assert.Nil(t, tasks.Trust(trustLoc))
+	assert.True(t, tasks.Trusted(trustLoc))
+	tasks, err = Load(filepath.Join(s.dir, "a"))
+	assert.Nil(t, err)
+	assert.True(t, tasks.Trusted(trustLoc))
+	// changed : no longer trusted
+	s.write(t, "[test]\nCmd=[\"rm\", \"-rf\", \"/\"]\nOnSave=[\".go\"]\n")
+	tasks, err = Load(s.dir)
+	assert.Nil(t, err)
+	assert.False(t, tasks.Trusted(trustLoc))
+}
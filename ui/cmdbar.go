@@ -20,6 +20,11 @@ type Cmdbar struct {
 	history    [][]rune
 	cursorX    int
 	historyPos int
+	aliasDepth int // aliases being expanded
+	// fuzzy history search (see cmdbar_history.go)
+	histSearch bool
+	histQuery  []rune
+	histMatch  int // index of the selected match
 }
 
 func (c *Cmdbar) Render() {
@@ -34,12 +39,19 @@ func (c *Cmdbar) Render() {
 		fg = t.CmdbarTextOn
 	}
 	ed.TermFB(fg, bg)
-	for i, r := range "> " + string(c.cmd) + " " {
-		if ed.CmdOn() && i == c.cursorX+2 {
+	prompt, cmd, cursorX := "> ", c.cmd, c.cursorX
+	if c.histSearch {
+		prompt = fmt.Sprintf("(history '%s') : ", string(c.histQuery))
+		cmd = []rune(c.historyMatch())
+		cursorX = len(cmd)
+	}
+	cursorX += len([]rune(prompt))
+	for i, r := range []rune(prompt + string(cmd) + " ") {
+		if ed.CmdOn() && i == cursorX {
 			ed.TermFB(t.FgCursor, t.BgCursor)
 		}
 		ed.TermChar(y1, x1+i, r)
-		if ed.CmdOn() && i == c.cursorX+2 {
+		if ed.CmdOn() && i == cursorX {
 			ed.TermFB(fg, bg)
 		}
 	}
@@ -52,39 +64,59 @@ func (e *Editor) CmdbarToggle() {
 	if e.cmdOn {
 		e.Cmdbar.historyPos = 0
 	}
+	e.Cmdbar.histSearch = false
 }
 
 func (c *Cmdbar) Backspace() {
+	if c.histSearch {
+		if len(c.histQuery) > 0 {
+			c.histQuery = c.histQuery[:len(c.histQuery)-1]
+			c.histMatch = 0
+		}
+		return
+	}
 	if c.cursorX <= 0 {
 		return
 	}
 	c.cmd = append(c.cmd[:c.cursorX-1], c.cmd[c.cursorX:]...)
 	c.cursorX--
+	c.incrementalSearch()
 }
 
 func (c *Cmdbar) Clear() {
 	c.cmd = []rune{}
 	c.cursorX = 0
+	c.histSearch = false
 }
 
+// Delete deletes the character at the cursor.
 func (c *Cmdbar) Delete() {
+	c.endHistorySearch()
 	if c.cursorX >= len(c.cmd) {
 		return
 	}
-	if c.cursorX == len(c.cmd)-1 {
-		c.cmd = c.cmd[:c.cursorX+1]
-	} else {
-		c.cmd = append(c.cmd[:c.cursorX+1], c.cmd[c.cursorX+2:]...)
-	}
-	c.cursorX--
+	c.cmd = append(c.cmd[:c.cursorX], c.cmd[c.cursorX+1:]...)
+	c.incrementalSearch()
 }
 
 func (c *Cmdbar) Insert(s string) {
-	c.cmd = append(c.cmd[:c.cursorX], append([]rune(s), c.cmd[c.cursorX:]...)...)
-	c.cursorX += len(s)
+	if c.histSearch {
+		c.histQuery = append(c.histQuery, []rune(s)...)
+		c.histMatch = 0
+		return
+	}
+	runes := []rune(s)
+	cmd := append([]rune{}, c.cmd[:c.cursorX]...)
+	c.cmd = append(append(cmd, runes...), c.cmd[c.cursorX:]...)
+	c.cursorX += len(runes)
+	c.incrementalSearch()
 }
 
 func (c *Cmdbar) CursorMvmt(m core.CursorMvmt) {
+	if c.histSearch {
+		c.endHistorySearch()
+		return
+	}
 	switch m {
 	case core.CursorMvmtLeft:
 		if c.cursorX > 0 {
@@ -95,45 +127,33 @@ func (c *Cmdbar) CursorMvmt(m core.CursorMvmt) {
 			c.cursorX++
 		}
 	case core.CursorMvmtUp:
-		if c.historyPos >= len(c.history)-1 {
+		if c.historyPos >= len(c.history) {
 			return
 		}
 		c.historyPos++
-		c.cmd = c.history[len(c.history)-c.historyPos]
+		c.cmd = append([]rune{}, c.history[len(c.history)-c.historyPos]...)
+		c.cursorX = len(c.cmd)
 	case core.CursorMvmtDown:
-		if c.historyPos <= 1 {
+		if c.historyPos <= 0 {
 			return
 		}
 		c.historyPos--
-		c.cmd = c.history[len(c.history)-c.historyPos]
+		c.cmd = []rune{}
+		if c.historyPos > 0 {
+			c.cmd = append(c.cmd, c.history[len(c.history)-c.historyPos]...)
+		}
+		c.cursorX = len(c.cmd)
 	}
 }
 
 func (c *Cmdbar) NewLine() { // run the command
-	// TODO: Temporary hard coded commands until I create real fs based events & actions
-	s := string(c.cmd)
-	parts := strings.Fields(s)
-	if len(parts) < 1 {
+	c.endHistorySearch()
+	if len(strings.TrimSpace(string(c.cmd))) == 0 {
 		return
 	}
-	args := parts[1:]
-	var err error
-	switch parts[0] {
-	case "o", "open":
-		err = c.open(args)
-	case ":", "line":
-		c.line(args)
-	case "/", "search":
-		if len(c.cmd) < 2 {
-			break
-		}
-		query := string(c.cmd[2:])
-		c.Search(query)
-	default:
-		exec(parts, false)
-	}
-
-	c.history = append(c.history, c.cmd)
+	c.addHistory(c.cmd)
+	c.historyPos = 0
+	err := c.run(string(c.cmd))
 
 	if err != nil {
 		actions.Ar.EdSetStatusErr(err.Error())
@@ -144,6 +164,32 @@ func (c *Cmdbar) NewLine() { // run the command
 	actions.Ar.EdRender()
 }
 
+// run runs a command line : a named command (see commands.go), a search or
+// replace expression, or else a shell command.
+func (c *Cmdbar) run(s string) error {
+	parts := strings.Fields(s)
+	if len(parts) < 1 {
+		return nil
+	}
+	if cmd := command(parts[0]); cmd != nil {
+		return cmd.run(c, parts[1:])
+	}
+	if query, regex, ignoreCase, ok := searchCmd(s, "/"); ok {
+		return c.Search(query, regex, ignoreCase)
+	}
+	if query, regex, ignoreCase, ok := searchCmd(s, "//"); ok {
+		return c.projectSearch(query, regex, ignoreCase)
+	}
+	if strings.HasPrefix(s, "s/") {
+		return c.replace(s[1:])
+	}
+	if strings.HasPrefix(s, "//s/") {
+		return c.projectReplace(s[3:])
+	}
+	exec(parts, false)
+	return nil
+}
+
 func (c *Cmdbar) open(args []string) error {
 	if len(args) < 1 {
 		// try to expand a location from the current view
@@ -161,23 +207,176 @@ func (c *Cmdbar) open(args []string) error {
 	return nil
 }
 
-func (c *Cmdbar) line(args []string) {
+// resolveConflict resolves a change on disk of the current view file.
+func (c *Cmdbar) resolveConflict(choice string) error {
 	ed := core.Ed.(*Editor)
-	if len(args) < 0 {
-		ed.SetStatusErr("Expected a line number argument.")
-		return
+	v := ed.ViewById(ed.CurViewId())
+	if v == nil {
+		return fmt.Errorf("No current view")
+	}
+	return v.ResolveConflict(choice)
+}
+
+func (c *Cmdbar) line(args []string) error {
+	ed := core.Ed.(*Editor)
+	if len(args) < 1 {
+		return fmt.Errorf("Expected a line number argument.")
 	}
 	l, err := strconv.Atoi(args[0])
 	if err != nil {
-		ed.SetStatusErr("Expected a line number argument.")
-		return
+		return fmt.Errorf("Expected a line number argument.")
 	}
 	v := ed.ViewById(ed.CurViewId())
 	if v != nil {
 		actions.Ar.ViewMoveCursor(ed.CurViewId(), l-v.CurLine()-1, 0, false)
 	}
+	return nil
+}
+
+// Search searches the current view for query.
+func (c *Cmdbar) Search(query string, regex, ignoreCase bool) error {
+	ed := core.Ed.(*Editor)
+	v := ed.ViewById(ed.CurViewId())
+	if v == nil {
+		return fmt.Errorf("No current view")
+	}
+	return v.Find(query, regex, ignoreCase)
+}
+
+// incrementalSearch updates the current view search as a search command is
+// being typed.
+func (c *Cmdbar) incrementalSearch() {
+	query, regex, ignoreCase, ok := searchCmd(string(c.cmd), "/")
+	if !ok {
+		return
+	}
+	c.Search(query, regex, ignoreCase)
 }
 
-func (c *Cmdbar) Search(query string) {
-	exec([]string{"grep", "-rni", "--color", query, "."}, false)
+// searchCmd parses a search command starting with prefix, such as :
+// "/ foo" : search for foo
+// "/i foo" : search for foo, ignoring case
+// "/r fo+" : search for regular expression fo+
+// "/ri fo+" : search for regular expression fo+, ignoring case
+func searchCmd(s, prefix string) (query string, regex, ignoreCase, ok bool) {
+	i := strings.Index(s, " ")
+	if i < 0 {
+		return "", false, false, false
+	}
+	cmd := s[:i]
+	if cmd == "search" && prefix == "/" {
+		return s[i+1:], false, false, true
+	}
+	if !strings.HasPrefix(cmd, prefix) || strings.Trim(cmd[len(prefix):], "ri") != "" {
+		return "", false, false, false
+	}
+	return s[i+1:], strings.Contains(cmd, "r"), strings.Contains(cmd, "i"), true
+}
+
+// replace runs a sed like replacement in the current view, ie:
+// "s/foo/bar/" replaces the next foo with bar.
+// Expressions are regular expressions, flags are :
+// g : replace all, i : ignore case.
+func (c *Cmdbar) replace(expr string) error {
+	parts, err := replaceCmd(expr)
+	if err != nil {
+		return err
+	}
+	flags := parts[3]
+	ed := core.Ed.(*Editor)
+	v := ed.ViewById(ed.CurViewId())
+	if v == nil {
+		return fmt.Errorf("No current view")
+	}
+	err = v.Find(parts[1], true, strings.Contains(flags, "i"))
+	if err != nil {
+		return err
+	}
+	count := v.Replace(parts[2], strings.Contains(flags, "g"))
+	ed.SetStatus(fmt.Sprintf("Replaced %d occurrence(s)", count))
+	return nil
+}
+
+// replaceCmd splits a replacement expression such as /foo/bar/g
+func replaceCmd(expr string) ([]string, error) {
+	parts := splitUnescaped(expr, '/')
+	if len(parts) != 4 || parts[0] != "" || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("Expected a replacement such as /foo/bar/g")
+	}
+	return parts, nil
+}
+
+// projectSearch searches the files under the current view work directory.
+func (c *Cmdbar) projectSearch(query string, regex, ignoreCase bool) error {
+	ed := core.Ed.(*Editor)
+	vid, err := ed.ProjectSearch(c.workDir(), query, regex, ignoreCase)
+	if err != nil {
+		return err
+	}
+	ed.ViewActivate(vid)
+	return nil
+}
+
+// projectReplace previews a replacement such as /foo/bar/i in the files
+// under the current view work directory. See applyReplace.
+func (c *Cmdbar) projectReplace(expr string) error {
+	parts, err := replaceCmd(expr)
+	if err != nil {
+		return err
+	}
+	ed := core.Ed.(*Editor)
+	vid, err := ed.ProjectReplace(c.workDir(), parts[1], parts[2], true,
+		strings.Contains(parts[3], "i"))
+	if err != nil {
+		return err
+	}
+	ed.ViewActivate(vid)
+	return nil
+}
+
+// applyReplace applies the replacement previewed in the current view.
+func (c *Cmdbar) applyReplace() error {
+	ed := core.Ed.(*Editor)
+	count, err := ed.ProjectReplaceApply(ed.CurViewId())
+	if err != nil {
+		return err
+	}
+	ed.SetStatus(fmt.Sprintf("Replaced %d line(s)", count))
+	return nil
+}
+
+func (c *Cmdbar) workDir() string {
+	ed := core.Ed.(*Editor)
+	if ed.CurView() != nil {
+		return ed.CurView().WorkDir()
+	}
+	return "."
+}
+
+// splitUnescaped splits s around the separator unless escaped with a backslash.
+func splitUnescaped(s string, sep rune) []string {
+	parts := []string{}
+	part := []rune{}
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != sep {
+				part = append(part, '\\')
+			}
+			part = append(part, r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == sep:
+			parts = append(parts, string(part))
+			part = []rune{}
+		default:
+			part = append(part, r)
+		}
+	}
+	if escaped {
+		part = append(part, '\\')
+	}
+	return append(parts, string(part))
 }
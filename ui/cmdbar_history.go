@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tcolar/goed/core"
+)
+
+// Command bar history, persisted to ~/.goed/history (one command per line)
+// and searched fuzzily (Ctrl+R).
+
+// maxHistory is the number of commands kept in the history.
+const maxHistory = 1000
+
+func historyLoc() string {
+	return path.Join(core.Home, "history")
+}
+
+// loadHistory loads the persisted history, trimming it to maxHistory.
+func (c *Cmdbar) loadHistory() {
+	c.history = [][]rune{}
+	data, err := ioutil.ReadFile(historyLoc())
+	if err != nil {
+		return
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+		ioutil.WriteFile(historyLoc(), []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+	for _, line := range lines {
+		if len(line) > 0 {
+			c.history = append(c.history, []rune(line))
+		}
+	}
+}
+
+// addHistory adds a command to the history, unless it's the same as the
+// previous one.
+func (c *Cmdbar) addHistory(cmd []rune) {
+	s := string(cmd)
+	if len(c.history) > 0 && string(c.history[len(c.history)-1]) == s {
+		return
+	}
+	c.history = append(c.history, []rune(s))
+	if len(c.history) > maxHistory {
+		c.history = c.history[len(c.history)-maxHistory:]
+	}
+	f, err := os.OpenFile(historyLoc(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(s + "\n")
+}
+
+// historyMatches returns the history entries fuzzily matching the query,
+// best first, the most recent first if equal.
+func (c *Cmdbar) historyMatches(query string) []string {
+	type match struct {
+		cmd   string
+		score int
+	}
+	matches := []match{}
+	seen := map[string]bool{}
+	for i := len(c.history) - 1; i >= 0; i-- {
+		cmd := string(c.history[i])
+		if seen[cmd] {
+			continue
+		}
+		seen[cmd] = true
+		if ok, score, _ := fuzzyMatch(query, cmd); ok {
+			matches = append(matches, match{cmd, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	cmds := []string{}
+	for _, m := range matches {
+		cmds = append(cmds, m.cmd)
+	}
+	return cmds
+}
+
+// HistorySearch starts a fuzzy search of the history, the typed text being
+// the query, or if already searching, selects the next match.
+func (c *Cmdbar) HistorySearch() {
+	if !c.histSearch {
+		c.histSearch = true
+		c.histQuery = []rune{}
+		c.histMatch = 0
+		return
+	}
+	if c.histMatch < len(c.historyMatches(string(c.histQuery)))-1 {
+		c.histMatch++
+	}
+}
+
+// historyMatch returns the selected history match, "" if none.
+func (c *Cmdbar) historyMatch() string {
+	matches := c.historyMatches(string(c.histQuery))
+	if c.histMatch >= len(matches) {
+		return ""
+	}
+	return matches[c.histMatch]
+}
+
+// endHistorySearch ends the history search, the selected match becoming the
+// command.
+func (c *Cmdbar) endHistorySearch() {
+	if !c.histSearch {
+		return
+	}
+	c.histSearch = false
+	c.cmd = []rune(c.historyMatch())
+	c.cursorX = len(c.cmd)
+}
@@ -0,0 +1,620 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+)
+
+// Command bar commands : the builtins, the user aliases (CmdAliases in
+// config.toml), the saved keystroke macros and the scripts of ~/.goed/actions
+// (.ank, or executable .sh) are all named commands, looked up in that order.
+// Search and replace expressions ("/ foo", "s/foo/bar/" ...) are not named
+// commands, and anything else is executed (shell) in a new view.
+
+// cmdbarCmd is a named command bar command.
+type cmdbarCmd struct {
+	name    string
+	aliases []string // other names, ie: "o" for "open"
+	usage   string   // ie: "open <path>"
+	help    string
+	run     func(c *Cmdbar, args []string) error
+	// complete returns the candidates for the last (partial) argument, may be nil.
+	complete func(c *Cmdbar, args []string) []string
+}
+
+// maxAliasDepth limits the expansion of aliases of aliases.
+const maxAliasDepth = 10
+
+// builtin commands, by name and alias
+var builtinCmds = map[string]*cmdbarCmd{}
+
+func init() {
+	cmds := []*cmdbarCmd{
+		{name: "open", aliases: []string{"o"}, usage: "open <path>",
+			help:     "Open a file or directory.",
+			run:      func(c *Cmdbar, args []string) error { return c.open(args) },
+			complete: completePath},
+		{name: "line", aliases: []string{":"}, usage: "line <number>",
+			help: "Go to the given line of the current view.",
+			run:  func(c *Cmdbar, args []string) error { return c.line(args) }},
+		{name: "search", usage: "search <text>",
+			help: "Search text in the current view, same as '/ <text>'.",
+			run: func(c *Cmdbar, args []string) error {
+				return c.Search(strings.Join(args, " "), false, false)
+			}},
+		{name: "apply", usage: "apply",
+			help: "Apply the replacement previewed in the current view.",
+			run:  func(c *Cmdbar, args []string) error { return c.applyReplace() }},
+		{name: conflictReload, usage: conflictReload,
+			help: "Reload the current view file, changed on disk.",
+			run:  func(c *Cmdbar, args []string) error { return c.resolveConflict(conflictReload) }},
+		{name: conflictKeep, usage: conflictKeep,
+			help: "Keep the current view content, the file having changed on disk.",
+			run:  func(c *Cmdbar, args []string) error { return c.resolveConflict(conflictKeep) }},
+		{name: conflictDiff, usage: conflictDiff,
+			help: "Show the differences with the current view file, changed on disk.",
+			run: func(c *Cmdbar, args []string) error {
+				if len(args) > 0 { // ie: diff a b
+					exec(append([]string{conflictDiff}, args...), false)
+					return nil
+				}
+				return c.resolveConflict(conflictDiff)
+			}},
+		{name: "ank", usage: "ank <script> [args]",
+			help:     "Run an anko script (see Readme).",
+			run:      func(c *Cmdbar, args []string) error { return c.script(args) },
+			complete: completeScripts},
+		{name: "help", usage: "help [command]",
+			help:     "List the commands, or show the help of a command.",
+			run:      func(c *Cmdbar, args []string) error { return c.help(args) },
+			complete: completeCommands},
+		{name: "macro", usage: "macro play|save|delete <name>",
+			help:     "Play, save (the last recorded one) or delete a keystroke macro.",
+			run:      func(c *Cmdbar, args []string) error { return c.macro(args) },
+			complete: completeMacro},
+		{name: "action", usage: "action <name> [args]",
+			help:     "Run an editor action (see 'goed api'), ie: action ed_set_status hello.",
+			run:      func(c *Cmdbar, args []string) error { return c.action(args) },
+			complete: completeActions},
+		{name: "view", usage: "view <title>",
+			help:     "Activate the view with that title.",
+			run:      func(c *Cmdbar, args []string) error { return c.view(args) },
+			complete: completeViews},
+		{name: "problems", usage: "problems [matcher]",
+			help:     "Collect the problems (compiler errors ...) reported in the current view and list them.",
+			run:      func(c *Cmdbar, args []string) error { return c.problems(args) },
+			complete: completeMatchers},
+		{name: "pin", usage: "pin",
+			help: "Pin (or unpin) the current command output view : stop following the output.",
+			run:  func(c *Cmdbar, args []string) error { return c.pin() }},
+		{name: "saveoutput", usage: "saveoutput <path>",
+			help:     "Save the full output of the current command output view to a file.",
+			run:      func(c *Cmdbar, args []string) error { return c.saveOutput(args) },
+			complete: completePath},
+		{name: "task", usage: "task [name]",
+			help:     "Run a task of the current project (.goed/tasks.toml), or list them.",
+			run:      func(c *Cmdbar, args []string) error { return c.task(args) },
+			complete: completeTasks},
+		{name: "rerun", usage: "rerun",
+			help: "Run the latest run task again.",
+			run: func(c *Cmdbar, args []string) error {
+				_, err := core.Ed.RerunTask()
+				return err
+			}},
+		{name: "trust", usage: "trust",
+			help: "Trust the current project tasks file to run tasks on save, until it changes.",
+			run:  func(c *Cmdbar, args []string) error { return core.Ed.(*Editor).TrustTasks() }},
+	}
+	for _, cmd := range cmds {
+		builtinCmds[cmd.name] = cmd
+		for _, alias := range cmd.aliases {
+			builtinCmds[alias] = cmd
+		}
+	}
+}
+
+// commands returns all the commands by name.
+func commands() map[string]*cmdbarCmd {
+	cmds := map[string]*cmdbarCmd{}
+	for name, loc := range scripts() {
+		cmds[name] = scriptCmd(name, loc)
+	}
+	for _, name := range event.MacroNames() {
+		cmds[name] = macroCmd(name)
+	}
+	if conf := core.Ed.Config(); conf.CmdAliases != nil {
+		for name, expansion := range conf.CmdAliases {
+			cmds[name] = aliasCmd(name, expansion)
+		}
+	}
+	for name, cmd := range builtinCmds {
+		cmds[name] = cmd
+	}
+	return cmds
+}
+
+// command returns the command of that name, nil if none.
+func command(name string) *cmdbarCmd {
+	return commands()[name]
+}
+
+func aliasCmd(name, expansion string) *cmdbarCmd {
+	return &cmdbarCmd{
+		name:  name,
+		usage: name + " [args]",
+		help:  "Alias for '" + expansion + "'.",
+		run: func(c *Cmdbar, args []string) error {
+			c.aliasDepth++
+			defer func() { c.aliasDepth-- }()
+			if c.aliasDepth > maxAliasDepth {
+				return fmt.Errorf("Alias loop : %s", name)
+			}
+			return c.run(strings.Join(append([]string{expansion}, args...), " "))
+		},
+	}
+}
+
+func macroCmd(name string) *cmdbarCmd {
+	return &cmdbarCmd{
+		name:  name,
+		usage: name,
+		help:  "Play the keystroke macro " + name + ".",
+		run: func(c *Cmdbar, args []string) error {
+			return c.macro([]string{"play", name})
+		},
+	}
+}
+
+func scriptCmd(name, loc string) *cmdbarCmd {
+	return &cmdbarCmd{
+		name:  name,
+		usage: name + " [args]",
+		help:  scriptHelp(loc),
+		run: func(c *Cmdbar, args []string) error {
+			if strings.HasSuffix(loc, ".ank") {
+				go actions.ExecScript(loc, args...)
+				return nil
+			}
+			exec(append([]string{loc}, args...), false)
+			return nil
+		},
+	}
+}
+
+// scripts returns the command scripts of the actions directories, by name,
+// the user ones (~/.goed/actions) first.
+func scripts() map[string]string {
+	locs := map[string]string{}
+	for _, dir := range []string{path.Join(core.Home, "default", "actions"), path.Join(core.Home, "actions")} {
+		files, _ := ioutil.ReadDir(dir)
+		for _, f := range files {
+			ext := path.Ext(f.Name())
+			if f.IsDir() || (ext != ".ank" && (ext != ".sh" || f.Mode()&0111 == 0)) {
+				continue
+			}
+			locs[strings.TrimSuffix(f.Name(), ext)] = path.Join(dir, f.Name())
+		}
+	}
+	return locs
+}
+
+// scriptHelp returns the first comment line of a script as its help.
+func scriptHelp(loc string) string {
+	data, _ := ioutil.ReadFile(loc)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#!") {
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") && len(strings.Trim(line, "# ")) > 0 {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return "Run " + path.Base(loc) + "."
+}
+
+// help lists the commands in a new view, or shows the help of a command in
+// the status bar.
+func (c *Cmdbar) help(args []string) error {
+	ed := core.Ed.(*Editor)
+	if len(args) > 0 {
+		cmd := command(args[0])
+		if cmd == nil {
+			return fmt.Errorf("No such command : %s", args[0])
+		}
+		ed.SetStatus(cmd.usage + " : " + cmd.help)
+		return nil
+	}
+	v := ed.newResultsView(c.workDir(), "help", helpLines())
+	ed.ViewActivate(v.Id())
+	return nil
+}
+
+// helpLines returns the commands usage and help, one per line.
+func helpLines() []string {
+	lines := []string{}
+	seen := map[*cmdbarCmd]bool{}
+	cmds := commands()
+	for _, name := range commandNames() {
+		cmd := cmds[name]
+		if seen[cmd] {
+			continue
+		}
+		seen[cmd] = true
+		usage := cmd.usage
+		if len(cmd.aliases) > 0 {
+			usage += " (" + strings.Join(cmd.aliases, ", ") + ")"
+		}
+		lines = append(lines, fmt.Sprintf("%-32s %s", usage, cmd.help))
+	}
+	return append(lines, "",
+		"/[ri] <text>                     Search text in the current view, r: regexp, i: ignore case.",
+		"//[ri] <text>                    Search text in the files under the current view directory.",
+		"s/<regexp>/<replacement>/[gi]    Replace in the current view, g: all, i: ignore case.",
+		"//s/<regexp>/<replacement>/[i]   Preview replacing in the files under the current view directory.",
+		"<anything else>                  Execute it (shell) in a new view.")
+}
+
+// commandNames returns all the command names, sorted.
+func commandNames() []string {
+	names := []string{}
+	for name := range commands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completeCommands(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	return commandNames()
+}
+
+func completeScripts(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	names := []string{}
+	for _, loc := range scripts() {
+		if strings.HasSuffix(loc, ".ank") {
+			names = append(names, path.Base(loc))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completeMacro(c *Cmdbar, args []string) []string {
+	switch len(args) {
+	case 1:
+		return []string{"delete", "play", "save"}
+	case 2:
+		if args[0] != "save" {
+			return event.MacroNames()
+		}
+	}
+	return nil
+}
+
+// macro plays, saves or deletes a keystroke macro, the last recorded one if
+// no name is given to play.
+func (c *Cmdbar) macro(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Usage : macro play|save|delete <name>")
+	}
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	}
+	switch args[0] {
+	case "play":
+		if event.MacroRecording() {
+			return fmt.Errorf("Can't play a macro while recording one.")
+		}
+		events := event.LastMacro()
+		if len(name) > 0 {
+			var err error
+			if events, err = event.LoadMacro(name); err != nil {
+				return err
+			}
+		}
+		// the events are handled once the command bar is closed
+		go event.PlayMacro(events)
+		return nil
+	case "save":
+		if err := event.SaveMacro(name, event.LastMacro()); err != nil {
+			return err
+		}
+		core.Ed.SetStatus("Saved macro " + name)
+		return nil
+	case "delete":
+		return event.DeleteMacro(name)
+	}
+	return fmt.Errorf("Usage : macro play|save|delete <name>")
+}
+
+// completePath returns the files and directories (with a trailing "/")
+// matching the last argument, relative to the current view directory.
+// Hidden files are only candidates once the "." is typed.
+func completePath(c *Cmdbar, args []string) []string {
+	partial := args[len(args)-1]
+	dir, base := path.Split(partial)
+	abs := dir
+	if !path.IsAbs(dir) {
+		abs = path.Join(c.workDir(), dir)
+	}
+	files, _ := ioutil.ReadDir(abs)
+	paths := []string{}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		p := dir + f.Name()
+		if f.IsDir() {
+			p += "/"
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func completeActions(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	names := []string{}
+	for _, info := range actions.Infos() {
+		names = append(names, info.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completeViews(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	ed := core.Ed.(*Editor)
+	titles := []string{}
+	for _, vid := range ed.Views() {
+		if v := ed.ViewById(vid); v != nil {
+			titles = append(titles, v.Title())
+		}
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// action runs an action (in the background, actions wait on the action bus),
+// its results or error are shown in the status bar.
+func (c *Cmdbar) action(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Usage : action <name> [args]")
+	}
+	go func() {
+		res, err := actions.Exec(args[0], args[1:])
+		if err != nil {
+			actions.Ar.EdSetStatusErr(err.Error())
+		} else if len(res) > 0 {
+			actions.Ar.EdSetStatus(strings.Join(res, " "))
+		}
+		actions.Ar.EdRender()
+	}()
+	return nil
+}
+
+// view activates the first view with the given title.
+func (c *Cmdbar) view(args []string) error {
+	title := strings.Join(args, " ")
+	ed := core.Ed.(*Editor)
+	for _, vid := range ed.Views() {
+		if v := ed.ViewById(vid); v != nil && v.Title() == title {
+			ed.ViewActivate(vid)
+			return nil
+		}
+	}
+	return fmt.Errorf("No such view : %s", title)
+}
+
+func completeMatchers(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	names := []string{}
+	for name := range core.Ed.(*Editor).matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// problems collects the problems reported in the current view, using the
+// given problem matcher if any, and lists them in a problems view (replacing
+// the previous one).
+func (c *Cmdbar) problems(args []string) error {
+	ed := core.Ed.(*Editor)
+	matcher := ""
+	if len(args) > 0 {
+		matcher = args[0]
+	}
+	vid := ed.CurViewId()
+	count, err := ed.CollectProblems(vid, matcher)
+	if err != nil {
+		return err
+	}
+	if count == 0 || vid == ed.problemsVid {
+		ed.SetStatus(fmt.Sprintf("%d problem(s)", count))
+		return nil
+	}
+	if _, found := ed.views[ed.problemsVid]; found {
+		ed.DelView(ed.problemsVid, true)
+	}
+	v := ed.newResultsView(c.workDir(), "problems", ed.Problems())
+	ed.problemsVid = v.Id()
+	ed.SetStatus(fmt.Sprintf("%d problem(s)", count))
+	return nil
+}
+
+func completeTasks(c *Cmdbar, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+	names, _ := core.Ed.Tasks("")
+	return names
+}
+
+// task runs the named task of the current project, or without a name lists
+// the project tasks in the status bar.
+func (c *Cmdbar) task(args []string) error {
+	ed := core.Ed.(*Editor)
+	if len(args) > 0 {
+		_, err := ed.RunTask(args[0])
+		return err
+	}
+	names, err := ed.Tasks("")
+	if err != nil {
+		return err
+	}
+	ed.SetStatus("Tasks : " + strings.Join(names, ", "))
+	return nil
+}
+
+// pin toggles the pinning of the current command output view.
+func (c *Cmdbar) pin() error {
+	ed := core.Ed.(*Editor)
+	out, err := curOutput()
+	if err != nil {
+		return err
+	}
+	pinned := !out.Pinned()
+	out.SetPinned(pinned)
+	if pinned {
+		ed.SetStatus("Pinned, the view no longer follows the output")
+	} else {
+		ed.SetStatus("Unpinned")
+	}
+	return nil
+}
+
+// saveOutput saves the full output of the current command output view,
+// relative paths being relative to the view directory.
+func (c *Cmdbar) saveOutput(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("No path provided")
+	}
+	out, err := curOutput()
+	if err != nil {
+		return err
+	}
+	loc := args[0]
+	if !path.IsAbs(loc) {
+		loc = path.Join(c.workDir(), loc)
+	}
+	if err := out.SaveOutput(loc); err != nil {
+		return err
+	}
+	core.Ed.SetStatus("Saved the output to " + loc)
+	return nil
+}
+
+// curOutput returns the backend of the current view, if a command output one.
+func curOutput() (core.CmdOutputBackend, error) {
+	ed := core.Ed.(*Editor)
+	v := ed.ViewById(ed.CurViewId())
+	if v == nil {
+		return nil, fmt.Errorf("No current view")
+	}
+	out, ok := v.Backend().(core.CmdOutputBackend)
+	if !ok {
+		return nil, fmt.Errorf("Not a command output view")
+	}
+	return out, nil
+}
+
+// script runs an anko script (in the background), with its arguments.
+func (c *Cmdbar) script(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("No script provided")
+	}
+	go actions.ExecScript(args[0], args[1:]...)
+	return nil
+}
+
+// Complete completes the command name, or the command argument, before the
+// cursor. A unique candidate is inserted, otherwise their common prefix is
+// and the candidates are listed in the status bar.
+// Paths are completed for the arguments of shell commands, and for a command
+// name containing a "/".
+func (c *Cmdbar) Complete() {
+	c.endHistorySearch()
+	text := string(c.cmd[:c.cursorX])
+	fields := strings.Fields(text)
+	if len(fields) == 0 || strings.HasSuffix(text, " ") {
+		fields = append(fields, "")
+	}
+	var candidates []string
+	cmd := command(fields[0])
+	switch {
+	case len(fields) == 1 && strings.Contains(fields[0], "/"):
+		if !isSearchOrReplace(text) {
+			candidates = completePath(c, fields)
+		}
+	case len(fields) == 1:
+		candidates = commandNames()
+	case cmd != nil && cmd.complete != nil:
+		candidates = cmd.complete(c, fields[1:])
+	case cmd == nil && !isSearchOrReplace(text):
+		candidates = completePath(c, fields[1:])
+	}
+	partial := fields[len(fields)-1]
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, partial) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		completion := strings.TrimPrefix(matches[0], partial)
+		if !strings.HasSuffix(completion, "/") {
+			completion += " "
+		}
+		c.Insert(completion)
+	default:
+		c.Insert(strings.TrimPrefix(commonPrefix(matches), partial))
+		core.Ed.SetStatus(strings.Join(matches, " "))
+	}
+}
+
+// isSearchOrReplace returns whether s is a search or replace expression.
+func isSearchOrReplace(s string) bool {
+	if _, _, _, ok := searchCmd(s, "/"); ok {
+		return true
+	}
+	if _, _, _, ok := searchCmd(s, "//"); ok {
+		return true
+	}
+	return strings.HasPrefix(s, "s/") || strings.HasPrefix(s, "//s/")
+}
+
+// commonPrefix returns the longest prefix common to all the strings.
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -3,6 +3,7 @@ package ui
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path"
 	"path/filepath"
@@ -13,6 +14,9 @@ import (
 	"github.com/tcolar/goed/backend"
 	"github.com/tcolar/goed/core"
 	"github.com/tcolar/goed/event"
+	"github.com/tcolar/goed/lsp"
+	"github.com/tcolar/goed/problems"
+	"github.com/tcolar/goed/ui/widgets"
 )
 
 var _ core.Editable = (*Editor)(nil)
@@ -31,23 +35,43 @@ type Editor struct {
 	term        core.Term
 	views       map[int64]*View
 	fileWatcher *event.FileWatcher
+	lsp         *lsp.Manager                 // language servers
+	hookErrors  int64                        // save hooks errors view
+	scriptErrs  int64                        // scripts errors view
+	problems    *problemList                 // problems reported by a command (compiler errors ...)
+	problemsVid int64                        // problems view
+	matchers    map[string]*problems.Matcher // problem matchers, by name
+	finder      *Finder                      // fuzzy finder, nil if not open
+	overlays    *widgets.TermWidget
+	taskRuns    map[string]*taskRun // latest run per task, by tasks file and name
+	lastTask    *taskRun            // latest task run, see RerunTask
 }
 
 func NewEditor(term core.Term, config *core.Config) *Editor {
 	return &Editor{
 		term:        term,
+		overlays:    widgets.NewTermWidget(term),
 		config:      config,
 		views:       map[int64]*View{},
 		fileWatcher: event.NewFileWatcher(),
+		lsp:         newLspManager(config),
+		matchers:    newProblemMatchers(config),
+		taskRuns:    map[string]*taskRun{},
 	}
 }
 
 // Editor with Mock terminal for testing
 func NewMockEditor() *Editor {
+	config := core.LoadConfig("config.toml")
+	term := core.NewMockTerm()
 	return &Editor{
-		term:   core.NewMockTerm(),
-		config: core.LoadConfig("config.toml"),
-		views:  map[int64]*View{},
+		term:     term,
+		overlays: widgets.NewTermWidget(term),
+		config:   config,
+		views:    map[int64]*View{},
+		lsp:      newLspManager(config),
+		matchers: newProblemMatchers(config),
+		taskRuns: map[string]*taskRun{},
 	}
 }
 
@@ -60,9 +84,17 @@ func (e *Editor) Commandbar() core.Commander {
 }
 
 func (e *Editor) Quit() {
+	if len(core.Session) > 0 {
+		if err := e.SaveSession(); err != nil {
+			log.Printf("Failed to save the session : %s", err.Error())
+		}
+	}
 	if e.fileWatcher != nil {
 		e.fileWatcher.Stop()
 	}
+	if e.lsp != nil {
+		e.lsp.Shutdown()
+	}
 	event.Shutdown()
 	e.term.Close()
 	os.Exit(0)
@@ -85,9 +117,44 @@ func (e *Editor) Start(locs []string) {
 
 	h, w := e.term.Size()
 	e.Cmdbar = &Cmdbar{}
+	e.Cmdbar.loadHistory()
 	e.Cmdbar.SetBounds(0, 0, 0, w)
 	e.Statusbar = &Statusbar{}
 	e.Statusbar.SetBounds(h-1, 0, h-1, w)
+	if len(core.Session) > 0 && e.restore() {
+		for _, loc := range locs {
+			e.Open(loc, -1, "", true)
+		}
+	} else {
+		e.layout(locs)
+	}
+
+	actions.Ar.EdResize(e.term.Size())
+
+	actions.Ar.EdRender()
+
+	if e.fileWatcher != nil {
+		go e.fileWatcher.Start()
+	}
+
+	go core.Bus.Start()
+
+	go e.autoScroller()
+
+	if len(core.Session) > 0 {
+		go e.sessionSaver()
+	}
+
+	go e.problemsCollector()
+
+	go event.Listen()
+
+	e.term.Listen()
+}
+
+// layout lays out the given locations : directories in the first column,
+// files in the second one.
+func (e *Editor) layout(locs []string) {
 	dirs := []string{}
 	files := []string{}
 	for _, loc := range locs {
@@ -128,22 +195,6 @@ func (e *Editor) Start(locs []string) {
 		e.CurCol = c
 		e.curViewId = c.Views[0]
 	}
-
-	actions.Ar.EdResize(e.term.Size())
-
-	actions.Ar.EdRender()
-
-	if e.fileWatcher != nil {
-		go e.fileWatcher.Start()
-	}
-
-	go core.Bus.Start()
-
-	go e.autoScroller()
-
-	go event.Listen()
-
-	e.term.Listen()
 }
 
 // Open opens a given location in the editor (in the given view)
@@ -182,6 +233,9 @@ func (e *Editor) Open(loc string, viewId int64, rel string, create bool) (int64,
 	}
 	view.Reset()
 	view.SetTitle(title)
+	if v := viewCast(view); v != nil {
+		v.lspClose()
+	}
 	if newFile || !stat.IsDir() {
 		err = e.openFile(loc, view)
 	} else {
@@ -199,6 +253,7 @@ func (e *Editor) Open(loc string, viewId int64, rel string, create bool) (int64,
 	}
 	view.Reset()
 	view.SetWorkDir(filepath.Dir(loc))
+	core.Events.Publish(core.Event{Type: core.EvtViewOpened, ViewId: view.Id(), Loc: loc})
 	return view.Id(), nil
 }
 
@@ -223,13 +278,25 @@ func (e *Editor) openDir(loc string, view core.Viewable) error {
 
 // OpenFile opens a file in the editor
 func (e *Editor) openFile(loc string, view core.Viewable) error {
-	b, err := backend.NewFileBackend(loc, view.Id())
+	var b core.Backend
+	var err error
+	min := e.Config().PieceTableMinSize
+	if stat, serr := os.Stat(loc); serr == nil && min >= 0 && stat.Size() >= min {
+		b, err = backend.NewPieceBackend(loc, view.Id())
+	} else {
+		b, err = backend.NewFileBackend(loc, view.Id())
+	}
 	if err != nil {
 		return err
 	}
 	view.SetBackend(b)
+	actions.UndoClear(view.Id())
+	actions.UndoLoad(view.Id(), loc)
 	e.SetStatus(fmt.Sprintf("%v  [%d]", view.WorkDir(), view.Id()))
 	view.SetDirty(false)
+	if v := viewCast(view); v != nil {
+		v.lspOpen()
+	}
 	e.ViewActivate(view.Id())
 	return nil
 }
@@ -246,7 +313,6 @@ func (e *Editor) Render() {
 
 	// cursor
 	v := viewCast(e.CurView())
-	cc, cl := v.CurCol(), v.CurLine()
 	c, _, _ := v.CurChar()
 	// With some terminals & color schemes the cursor might be "invisible" if we are at a
 	// location with no text (ie: end of line)
@@ -258,12 +324,13 @@ func (e *Editor) Render() {
 	// Note the terminal inverts the colors where the cursor is
 	// this is why this statement might appear "backward"
 	e.TermFB(e.theme.BgCursor, e.theme.FgCursor)
-	y1, x1, _, _ := v.Bounds()
-	e.TermChar(cl+y1-v.offy+2, cc+x1-v.offx+2, car)
+	y, x := v.cursorTermPos()
+	e.TermChar(y, x, car)
 	e.TermFB(e.theme.Fg, e.theme.Bg)
 
 	e.Cmdbar.Render()
 	e.Statusbar.Render()
+	e.overlays.Render()
 
 	e.TermFlush()
 }
@@ -317,39 +384,60 @@ func (e *Editor) CmdOn() bool {
 
 func (e *Editor) SetCmdOn(v bool) {
 	e.cmdOn = v
+	if !v {
+		e.Cmdbar.histSearch = false
+	}
 }
 
 func (e *Editor) TermFlush() {
 	e.term.Flush()
 }
 
-// true if ok to quit
+// true if ok to quit, otherwise the user is prompted to save or discard the
+// unsaved changes, then quit.
 func (e *Editor) QuitCheck() bool {
+	vids := []int64{}
 	for _, c := range e.Cols {
-		for _, vi := range c.Views {
-			v, found := e.views[vi]
-			if found && !v.canClose() {
-				return false
-			}
-		}
+		vids = append(vids, c.Views...)
 	}
-	return true
+	dirty := e.dirtyViews(vids)
+	if len(dirty) == 0 {
+		return true
+	}
+	// dispatched, rather than exiting from within the prompt event
+	e.saveDiscardPrompt(dirty, func() { actions.Ar.EdQuit() })
+	return false
 }
 
 func (e *Editor) FileEvent(op core.FileOp, loc string) {
+	core.Events.Publish(core.Event{Type: core.EvtFileEvent, Loc: loc, Op: op.String()})
 	for _, v := range e.views {
 		v.fileEvent(op, loc)
 	}
 }
 
+func (e *Editor) FileMoved(from, to string) {
+	for _, v := range e.views {
+		v.fileMoved(from, to)
+	}
+}
+
 func (e *Editor) StartTermView(args []string) int64 {
 	vid := exec(args, true)
 	v := viewCast(core.Ed.ViewById(vid))
 	if v == nil || v.backend == nil {
 		return -1
 	}
-	// source the goed shell script onc eterminal has launched
-	b := v.backend.(*backend.BackendCmd)
+	e.termInit(v)
+	return vid
+}
+
+// termInit sources the goed shell script once the terminal has launched.
+func (e *Editor) termInit(v *View) {
+	b, ok := v.backend.(*backend.BackendCmd)
+	if !ok {
+		return
+	}
 	ext := ".sh"
 	if os.Getenv("SHELL") == "rc" {
 		ext = ".rc"
@@ -373,7 +461,6 @@ func (e *Editor) StartTermView(args []string) int64 {
 			time.Sleep(50 * time.Millisecond)
 		}
 	}(cmd)
-	return vid
 }
 
 // Handle selection auto scrolling of views
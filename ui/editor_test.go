@@ -1,9 +1,17 @@
 package ui
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/assert"
 	"github.com/tcolar/goed/backend"
 	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
 	. "gopkg.in/check.v1"
 )
 
@@ -84,3 +92,518 @@ func (us *UiSuite) TestRingBuffer(t *C) {
 	s = core.RunesToString(*s1.Text())
 	assert.Eq(t, s, "4444\n55555\n666666\n77\n888")
 }
+
+func (us *UiSuite) TestScriptErrors(t *C) {
+	Ed := core.Ed.(*Editor)
+	Ed.ScriptErrors("/tmp/foo.ank", []string{"/tmp/foo.ank:3:1: oops\nmore"})
+	v, found := Ed.views[Ed.scriptErrs]
+	assert.True(t, found)
+	assert.Eq(t, v.Title(), "errors: foo.ank")
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "/tmp/foo.ank:3:1: oops\nmore")
+	assert.True(t, Ed.Statusbar.isErr)
+	Ed.ScriptErrors("/tmp/foo.ank", nil)
+	_, found = Ed.views[v.Id()]
+	assert.False(t, found)
+}
+
+func (us *UiSuite) TestCmdbarCommands(t *C) {
+	Ed := core.Ed.(*Editor)
+	c := Ed.Cmdbar
+	assert.NotNil(t, command("o"))
+	assert.Eq(t, command("o"), command("open"))
+	assert.Nil(t, command("foo"))
+	assert.True(t, c.run("line") != nil)
+
+	// aliases
+	aliases := Ed.config.CmdAliases
+	defer func() { Ed.config.CmdAliases = aliases }()
+	Ed.config.CmdAliases = map[string]string{"l": "line", "a": "b", "b": "a"}
+	assert.Eq(t, command("l").help, "Alias for 'line'.")
+	assert.Eq(t, c.run("l x").Error(), "Expected a line number argument.")
+	assert.Eq(t, c.run("a").Error(), "Alias loop : a")
+	assert.Eq(t, c.aliasDepth, 0)
+
+	// scripts
+	os.MkdirAll(path.Join(core.Home, "actions"), 0750)
+	loc := path.Join(core.Home, "actions", "foo.ank")
+	ioutil.WriteFile(loc, []byte("# Foo script\nGoed.ed_render()\n"), 0640)
+	defer os.Remove(loc)
+	assert.Eq(t, command("foo").help, "Foo script")
+	assert.True(t, strings.Contains(strings.Join(helpLines(), "\n"), "Foo script"))
+
+	// macros
+	e := event.NewEvent()
+	e.Glyph = "a"
+	assert.NotNil(t, event.SaveMacro("m1", nil))
+	assert.Nil(t, event.SaveMacro("m1", []*event.Event{e}))
+	defer event.DeleteMacro("m1")
+	events, err := event.LoadMacro("m1")
+	assert.Nil(t, err)
+	assert.Eq(t, events[0].Glyph, "a")
+	assert.Eq(t, command("m1").help, "Play the keystroke macro m1.")
+	assert.DeepEq(t, completeMacro(c, []string{"play", "m"}), []string{"m1"})
+
+	// help
+	lines := helpLines()
+	assert.True(t, strings.Contains(strings.Join(lines, "\n"), "open <path> (o)"))
+
+	// completion
+	complete := func(s string) string {
+		c.cmd = []rune(s)
+		c.cursorX = len(c.cmd)
+		c.Complete()
+		return string(c.cmd)
+	}
+	defer c.Clear()
+	assert.Eq(t, complete("hel"), "help ")
+	assert.Eq(t, complete("help ope"), "help open ")
+	assert.Eq(t, complete("macro p"), "macro play ")
+	assert.Eq(t, complete("macro play "), "macro play m1 ")
+	assert.Eq(t, complete("ank fo"), "ank foo.ank ")
+	assert.Eq(t, complete("zzz"), "zzz")
+}
+
+func (us *UiSuite) TestCmdbarHistory(t *C) {
+	ok, s1, pos := fuzzyMatch("fb", "foo_bar")
+	assert.True(t, ok)
+	assert.DeepEq(t, pos, []int{0, 4})
+	_, s2, _ := fuzzyMatch("fb", "xfxxxb")
+	assert.True(t, s1 > s2)
+	ok, _, _ = fuzzyMatch("bf", "foo_bar")
+	assert.False(t, ok)
+	ok, _, _ = fuzzyMatch("FB", "foo_bar")
+	assert.False(t, ok)
+
+	Ed := core.Ed.(*Editor)
+	c := Ed.Cmdbar
+	history := c.history
+	defer func() { c.history = history }()
+	os.Remove(historyLoc())
+	defer os.Remove(historyLoc())
+	c.loadHistory()
+	c.addHistory([]rune("make test"))
+	c.addHistory([]rune("ls -la"))
+	c.addHistory([]rune("ls -la"))
+	c.addHistory([]rune("git status"))
+	c.loadHistory() // persisted
+	assert.Eq(t, len(c.history), 3)
+	assert.DeepEq(t, c.historyMatches("t"), []string{"make test", "git status"}) // word start first
+
+	// up / down
+	defer c.Clear()
+	c.Clear()
+	c.historyPos = 0
+	c.CursorMvmt(core.CursorMvmtUp)
+	assert.Eq(t, string(c.cmd), "git status")
+	c.CursorMvmt(core.CursorMvmtUp)
+	c.CursorMvmt(core.CursorMvmtUp)
+	c.CursorMvmt(core.CursorMvmtUp)
+	assert.Eq(t, string(c.cmd), "make test")
+	c.Backspace()
+	assert.Eq(t, string(c.history[0]), "make test")
+	c.CursorMvmt(core.CursorMvmtDown)
+	c.CursorMvmt(core.CursorMvmtDown)
+	c.CursorMvmt(core.CursorMvmtDown)
+	assert.Eq(t, string(c.cmd), "")
+
+	// fuzzy search
+	c.HistorySearch()
+	c.Insert("s")
+	assert.Eq(t, c.historyMatch(), "git status")
+	c.HistorySearch()
+	assert.Eq(t, c.historyMatch(), "ls -la")
+	c.Backspace()
+	c.Insert("mt")
+	assert.Eq(t, c.historyMatch(), "make test")
+	c.CursorMvmt(core.CursorMvmtLeft)
+	assert.False(t, c.histSearch)
+	assert.Eq(t, string(c.cmd), "make test")
+	assert.Eq(t, c.cursorX, 9)
+
+	// delete
+	c.cursorX = 4
+	c.Delete()
+	assert.Eq(t, string(c.cmd), "maketest")
+	assert.Eq(t, c.cursorX, 4)
+	c.cursorX = 7
+	c.Delete()
+	assert.Eq(t, string(c.cmd), "maketes")
+	c.Delete()
+	assert.Eq(t, string(c.cmd), "maketes")
+
+	// completion
+	dir := t.MkDir()
+	os.Mkdir(path.Join(dir, "subdir"), 0750)
+	ioutil.WriteFile(path.Join(dir, "file.txt"), []byte{}, 0640)
+	ioutil.WriteFile(path.Join(dir, ".hidden"), []byte{}, 0640)
+	complete := func(s string) string {
+		c.cmd = []rune(s)
+		c.cursorX = len(c.cmd)
+		c.Complete()
+		return string(c.cmd)
+	}
+	assert.Eq(t, complete("open "+dir+"/s"), "open "+dir+"/subdir/")
+	assert.Eq(t, complete("o "+dir+"/f"), "o "+dir+"/file.txt ")
+	assert.Eq(t, complete("cat "+dir+"/."), "cat "+dir+"/.hidden ")
+	assert.Eq(t, complete(dir+"/fi"), dir+"/file.txt ")
+	assert.DeepEq(t, completePath(c, []string{dir + "/"}), []string{dir + "/file.txt", dir + "/subdir/"})
+	assert.Eq(t, complete("/ "+dir+"/f"), "/ "+dir+"/f")
+	assert.Eq(t, complete("action ed_set_stat"), "action ed_set_status")
+	vid, err := Ed.Open(path.Join(dir, "file.txt"), -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	assert.Eq(t, complete("view file.t"), "view file.txt ")
+	assert.Nil(t, c.view([]string{"file.txt"}))
+	assert.Eq(t, Ed.CurViewId(), vid)
+	assert.NotNil(t, c.view([]string{"nope"}))
+}
+
+func (us *UiSuite) TestFinder(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	os.Mkdir(path.Join(dir, "ui"), 0750)
+	ioutil.WriteFile(path.Join(dir, "ui", "finder.go"), []byte("package ui\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, "ui", "fuzzy.go"), []byte("package ui\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, "find.txt"), []byte("1\n2\n3\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, "ignored.log"), []byte{}, 0640)
+	ioutil.WriteFile(path.Join(dir, ".gitignore"), []byte("*.log\n"), 0640)
+	vid, err := Ed.Open(path.Join(dir, "find.txt"), -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+
+	assert.NotNil(t, Ed.FinderOpen("foo"))
+	assert.Nil(t, Ed.Finder())
+	onBus(func() { err = Ed.FinderOpen(core.FinderFiles) })
+	assert.Nil(t, err)
+	f := Ed.finder
+	assert.Eq(t, f.dir, dir)
+	// files are indexed in the background
+	indexing := true
+	for i := 0; i != 100 && indexing; i++ {
+		time.Sleep(10 * time.Millisecond)
+		onBus(func() { indexing = f.indexing })
+	}
+	assert.False(t, indexing)
+	assert.Eq(t, len(f.items), 4) // not ignored.log
+	f.Insert("fi")
+	assert.Eq(t, len(f.matches), 2)
+	assert.Eq(t, f.matches[0].item.text, "find.txt") // word start, shorter
+	assert.DeepEq(t, f.previewLines(2), []string{"1", "2"})
+	f.Insert("g")
+	assert.Eq(t, f.matches[0].item.text, "ui/finder.go")
+	f.Backspace()
+	f.Move(5)
+	assert.Eq(t, f.sel, 1)
+	assert.Eq(t, f.matches[f.sel].item.text, "ui/finder.go")
+	// files arriving meanwhile are filtered, the selection is kept
+	finderIndexed{finder: f, files: []string{"fi", "x"}}.Run()
+	assert.Eq(t, len(f.items), 6)
+	assert.Eq(t, len(f.matches), 3)
+	assert.Eq(t, f.matches[0].item.text, "fi")
+	assert.Eq(t, f.matches[f.sel].item.text, "ui/finder.go")
+	f.items, f.matches, f.sel = f.items[:4], f.matches[1:], 1
+	Ed.Render()
+	f.Select()
+	assert.Nil(t, Ed.Finder())
+	vid2 := Ed.CurViewId()
+	assert.True(t, vid2 != vid)
+	defer Ed.DelView(vid2, true)
+	assert.Eq(t, Ed.CurView().Title(), "finder.go")
+	// closed : later files are dropped
+	finderIndexed{finder: f, files: []string{"late"}, done: true}.Run()
+	assert.Eq(t, len(f.items), 4)
+	assert.Eq(t, f.closed, int32(1))
+
+	// switching views
+	assert.Nil(t, Ed.FinderOpen(core.FinderViews))
+	f = Ed.finder
+	assert.Eq(t, f.items[len(f.items)-1].vid, vid2) // current view last
+	f.Insert("find.t")
+	assert.Eq(t, len(f.matches), 1)
+	assert.DeepEq(t, f.previewLines(5), []string{"1", "2", "3"})
+	f.Select()
+	assert.Eq(t, Ed.CurViewId(), vid)
+}
+
+func (us *UiSuite) TestProjectSearch(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	ioutil.WriteFile(path.Join(dir, "a.txt"), []byte("foo\nbar\nfoo foo\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, "b.txt"), []byte("xfoo\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, ".gitignore"), []byte("c.txt\n"), 0640)
+	ioutil.WriteFile(path.Join(dir, "c.txt"), []byte("foo\n"), 0640)
+
+	// a.txt is open, with unsaved changes
+	vid, err := Ed.Open(path.Join(dir, "a.txt"), -1, "", false)
+	assert.Nil(t, err)
+	v := Ed.views[vid]
+	defer Ed.DelView(vid, true)
+	v.Insert(1, 0, "foo ", true)
+
+	// waits for a search to complete, in the background
+	wait := func(rv *View) {
+		for i := 0; i != 100 && strings.HasPrefix(rv.Title(), "[RUNNING]"); i++ {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	rid, err := Ed.ProjectSearch(dir, "foo", false, false)
+	assert.Nil(t, err)
+	rv := Ed.views[rid]
+	wait(rv)
+	assert.Eq(t, rv.Title(), "search: foo (5)")
+	assert.Eq(t, rv.Type(), core.ViewType(core.ViewTypeCmdOutput))
+	assert.Eq(t, rv.WorkDir(), dir)
+	assert.Eq(t, core.RunesToString(*rv.backend.Slice(0, 0, -1, -1).Text()),
+		"a.txt:1:1: foo\na.txt:2:1: foo bar\na.txt:3:1: foo foo\na.txt:3:5: foo foo\nb.txt:1:2: xfoo")
+	s := core.NewSelection(4, 0, 4, 9)
+	loc, ln, col := rv.SelectionToLoc(s)
+	assert.Eq(t, loc, "b.txt")
+	assert.Eq(t, ln, 1)
+	assert.Eq(t, col, 2)
+
+	// preview, then apply
+	Ed.DelView(rid, true)
+	rid, err = Ed.ProjectReplace(dir, "f(o+)", "b$1", true, false)
+	assert.Nil(t, err)
+	defer Ed.DelView(rid, true)
+	rv = Ed.views[rid]
+	wait(rv)
+	lines := *rv.backend.Slice(0, 0, -1, -1).Text()
+	assert.Eq(t, len(lines), 8)
+	assert.Eq(t, string(lines[4]), "a.txt:3:1:- foo foo")
+	assert.Eq(t, string(lines[5]), "a.txt:3:1:+ boo boo")
+	data, _ := ioutil.ReadFile(path.Join(dir, "b.txt"))
+	assert.Eq(t, string(data), "xfoo\n")
+	v.Insert(0, 0, "a", true) // no longer matches the preview
+	count, err := Ed.ProjectReplaceApply(rid)
+	assert.Nil(t, err)
+	assert.Eq(t, count, 3)
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "afoo\nboo bar\nboo boo")
+	assert.True(t, v.Dirty())
+	data, _ = ioutil.ReadFile(path.Join(dir, "b.txt"))
+	assert.Eq(t, string(data), "xboo\n")
+	data, _ = ioutil.ReadFile(path.Join(dir, "c.txt"))
+	assert.Eq(t, string(data), "foo\n")
+	// the view changes are a single undo step
+	actions.Undo(vid)
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "afoo\nfoo bar\nfoo foo")
+	_, err = Ed.ProjectReplaceApply(rid)
+	assert.NotNil(t, err)
+	_, err = Ed.ProjectSearch(dir, "(", true, false)
+	assert.NotNil(t, err)
+}
+
+func (us *UiSuite) TestProblems(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	a := path.Join(dir, "a.go")
+	ioutil.WriteFile(a, []byte("package a\n\nfunc a() {\n\t_ = \"é\" + x\n}\n"), 0640)
+	rv := Ed.newResultsView(dir, "go build", []string{"# a", "./a.go:4:13: undefined: x",
+		"b.go:1:1: expected 'package'", "a.go:3: missing return"})
+	defer Ed.DelView(rv.Id(), true)
+	count, err := Ed.CollectProblems(rv.Id(), "")
+	assert.Nil(t, err)
+	assert.Eq(t, count, 3)
+	assert.DeepEq(t, Ed.Problems(), []string{a + ":4:13: error: undefined: x",
+		"b.go:1:1: error: expected 'package'", a + ":3:1: error: missing return"})
+	_, err = Ed.CollectProblems(rv.Id(), "foo")
+	assert.NotNil(t, err)
+
+	// next / previous error, a.go being open
+	vid, err := Ed.Open(a, -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	v := Ed.views[vid]
+	v.SetBounds(0, 0, 20, 100)
+	assert.Nil(t, Ed.NextError(false))
+	assert.Eq(t, Ed.CurViewId(), vid)
+	assert.Eq(t, v.CurLine(), 3)
+	assert.Eq(t, v.LineRunesTo(v.backend.Slice(0, 0, -1, -1), 3, v.CurCol()), 11) // byte column 13
+	assert.Eq(t, Ed.Statusbar.msg, "[1/3] error: undefined: x")
+	assert.NotNil(t, Ed.NextError(false)) // b.go does not exist
+	assert.Nil(t, Ed.NextError(false))
+	assert.Eq(t, Ed.CurViewId(), vid)
+	assert.Eq(t, v.CurLine(), 2)
+	assert.Eq(t, v.CurCol(), 0)
+	assert.Nil(t, Ed.NextError(false)) // wraps around
+	assert.Eq(t, v.CurLine(), 3)
+	assert.Nil(t, Ed.NextError(true))
+	assert.Eq(t, v.CurLine(), 2)
+
+	// completed commands : no problems only replace the list from the same view
+	ov := Ed.newResultsView(dir, "ls", []string{"a.go"})
+	defer Ed.DelView(ov.Id(), true)
+	Ed.cmdDone(ov.Id(), 0)
+	assert.Eq(t, len(Ed.Problems()), 3)
+	rv.backend.Wipe()
+	Ed.cmdDone(rv.Id(), 0)
+	assert.Eq(t, len(Ed.Problems()), 0)
+	assert.NotNil(t, Ed.NextError(false))
+}
+
+func (us *UiSuite) TestTasks(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	os.MkdirAll(path.Join(dir, ".goed"), 0750)
+	os.MkdirAll(path.Join(dir, "sub"), 0750)
+	ioutil.WriteFile(path.Join(dir, ".goed", "tasks.toml"), []byte(`
+[build]
+Cmd=["sh", "-c", "echo $GOED_TASK_TEST; pwd; echo 'a.go:1:2: oops'; exit 2"]
+Env=["GOED_TASK_TEST=hello"]
+Cwd="sub"
+Matcher="go"
+OnSave=[".txt"]
+
+[other]
+Cmd=["true"]
+Matcher="nope"
+`), 0640)
+	a, txt := path.Join(dir, "sub", "a.go"), path.Join(dir, "sub", "a.txt")
+	ioutil.WriteFile(a, []byte("package a\n"), 0640)
+	ioutil.WriteFile(txt, []byte("a\n"), 0640)
+	vid, err := Ed.Open(txt, -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	Ed.ViewActivate(vid)
+	// waits for the latest task run to complete
+	done := func() *taskRun {
+		for i := 0; i != 100 && Ed.lastTask.status == taskRunning; i++ {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return Ed.lastTask
+	}
+
+	names, err := Ed.Tasks("")
+	assert.Nil(t, err)
+	assert.DeepEq(t, names, []string{"build", "other"})
+	_, err = Ed.Tasks(t.MkDir())
+	assert.NotNil(t, err)
+	_, err = Ed.RunTask("nope")
+	assert.NotNil(t, err)
+	_, err = Ed.RunTask("other") // invalid matcher
+	assert.NotNil(t, err)
+	_, err = Ed.RerunTask()
+	assert.NotNil(t, err)
+
+	tv, err := Ed.RunTask("build")
+	assert.Nil(t, err)
+	defer func() { Ed.DelView(Ed.lastTask.viewId, true) }()
+	run := done()
+	assert.Eq(t, run.viewId, tv)
+	assert.Eq(t, run.status, taskFailed)
+	assert.Eq(t, run.exitCode, 2)
+	assert.Eq(t, run.String(), "build: failed, 1 problem(s)")
+	assert.Eq(t, Ed.Statusbar.msg, "Task build failed (exit code 2), 1 problem(s) reported")
+	assert.True(t, Ed.Statusbar.isErr)
+	assert.DeepEq(t, Ed.Problems(), []string{a + ":1:2: error: oops"})
+	v := Ed.views[tv]
+	assert.True(t, strings.HasSuffix(v.Title(), "build: sh -c echo $GOED_TASK_TEST; pwd; echo 'a.go:1:2: oops'; exit 2"))
+	lines := *v.backend.Slice(0, 0, 1, -1).Text()
+	assert.Eq(t, string(lines[0]), "hello")
+	assert.Eq(t, string(lines[1]), path.Join(dir, "sub"))
+
+	// rerun, replacing the previous run view
+	row, col := Ed.ViewIndex(tv)
+	tv2, err := Ed.RerunTask()
+	assert.Nil(t, err)
+	assert.True(t, tv2 != tv)
+	_, found := Ed.views[tv]
+	assert.False(t, found)
+	row2, col2 := Ed.ViewIndex(tv2)
+	assert.Eq(t, row2, row)
+	assert.Eq(t, col2, col)
+	assert.Eq(t, done().viewId, tv2)
+
+	// run on save, once trusted
+	trusted := path.Join(core.Home, "trusted_tasks.json")
+	data, _ := ioutil.ReadFile(trusted)
+	defer ioutil.WriteFile(trusted, data, 0600)
+	os.Remove(trusted)
+	Ed.views[vid].Save()
+	assert.Eq(t, Ed.lastTask.viewId, tv2)
+	assert.True(t, strings.HasPrefix(Ed.Statusbar.msg, "Not running tasks on save"))
+	assert.Nil(t, Ed.TrustTasks())
+	Ed.views[vid].Save()
+	assert.True(t, Ed.lastTask.viewId != tv2)
+	assert.Eq(t, done().status, taskFailed)
+	Ed.cmdDone(Ed.lastTask.viewId, 0) // as if fixed
+	assert.Eq(t, Ed.Statusbar.msg, "Task build ok")
+}
+
+func (us *UiSuite) TestSession(t *C) {
+	Ed := core.Ed.(*Editor)
+	cols, curCol, curView := Ed.Cols, Ed.CurCol, Ed.curViewId
+	defer func() {
+		Ed.Cols, Ed.CurCol, Ed.curViewId = cols, curCol, curView
+		Ed.Resize(Ed.term.Size())
+	}()
+	dir := t.MkDir()
+	loc := path.Join(dir, "a.txt")
+	ioutil.WriteFile(loc, []byte("abc\ndef\nghi\n"), 0644)
+	sel := core.Selection{LineFrom: 0, ColFrom: 1, LineTo: 1, ColTo: 1}
+	s := &Session{Cols: []SessionCol{
+		{WidthRatio: 1, Views: []SessionView{
+			{Type: core.ViewTypeDirListing, HeightRatio: 2, Loc: dir},
+		}},
+		{WidthRatio: 3, Views: []SessionView{
+			{Type: core.ViewTypeStandard, HeightRatio: 1, Loc: path.Join(dir, "gone.txt")},
+			{Type: core.ViewTypeStandard, HeightRatio: 1, Loc: loc, CursorLine: 2, CursorCol: 1,
+				Selections: []core.Selection{sel}, Current: true},
+			{Type: core.ViewTypeShell, HeightRatio: 1, Cmd: []string{"cat"}, WorkDir: dir},
+		}},
+	}}
+	assert.True(t, Ed.restoreSession(s))
+	for _, c := range Ed.Cols {
+		for _, vid := range c.Views {
+			defer Ed.TerminateView(vid)
+		}
+	}
+	assert.Eq(t, len(Ed.Cols), 2)
+	assert.Eq(t, Ed.Cols[0].WidthRatio, 0.25)
+	assert.Eq(t, Ed.Cols[1].WidthRatio, 0.75)
+	assert.Eq(t, len(Ed.Cols[1].Views), 2) // gone.txt skipped
+	v := Ed.views[Ed.curViewId]
+	assert.Eq(t, v.backend.SrcLoc(), loc)
+	assert.Eq(t, v.HeightRatio, 0.5)
+	ln, col := v.CurTextPos()
+	assert.Eq(t, ln, 2)
+	assert.Eq(t, col, 1)
+	assert.DeepEq(t, v.selections, []core.Selection{sel})
+	assert.True(t, Ed.CurCol == Ed.Cols[1])
+
+	// saved and loaded back
+	session := core.Session
+	defer func() { core.Session = session }()
+	core.Session = ""
+	assert.NotNil(t, Ed.SaveSession()) // only with --session
+	// names escaping the sessions directory are refused
+	for _, name := range []string{"../x", "a/b", `a\b`, "..", "../../x"} {
+		core.Session = name
+		assert.NotNil(t, CheckSessionName(name))
+		assert.NotNil(t, Ed.SaveSession())
+		_, err := sessionLoc()
+		assert.NotNil(t, err)
+	}
+	assert.Nil(t, CheckSessionName("work.2"))
+	core.Session = "session_test"
+	assert.Nil(t, Ed.SaveSession())
+	sessionFile, err := sessionLoc()
+	assert.Nil(t, err)
+	s, err = LoadSession(sessionFile)
+	assert.Nil(t, err)
+	assert.Eq(t, len(s.Cols), 2)
+	assert.Eq(t, s.Cols[0].Views[0].Type, core.ViewType(core.ViewTypeDirListing))
+	assert.Eq(t, s.Cols[0].Views[0].Loc, dir)
+	sv := s.Cols[1].Views[0]
+	assert.Eq(t, sv.Loc, loc)
+	assert.Eq(t, sv.CursorLine, 2)
+	assert.True(t, sv.Current)
+	assert.DeepEq(t, sv.Selections, []core.Selection{sel})
+	sv = s.Cols[1].Views[1]
+	assert.Eq(t, sv.Type, core.ViewType(core.ViewTypeShell))
+	assert.DeepEq(t, sv.Cmd, []string{"cat"})
+	assert.Eq(t, sv.WorkDir, dir)
+
+	_, err = LoadSession(path.Join(dir, "a.txt"))
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/backend"
 	"github.com/tcolar/goed/core"
@@ -13,15 +16,50 @@ func exec(args []string, interactive bool) int64 {
 		workDir = ed.CurView().WorkDir()
 	}
 	v := ed.AddViewSmart(nil)
+	execIn(v, args, workDir, interactive)
+	return v.Id()
+}
+
+// execIn runs a command, its output shown in the given view.
+func execIn(v *View, args []string, workDir string, interactive bool) {
 	v.highlighter = &TermHighlighter{}
 	b, err := backend.NewMemBackendCmd(args, workDir, v.Id(), nil, false)
 	if interactive {
 		actions.Ar.ViewSetType(v.Id(), core.ViewTypeShell)
 	}
-	b.MaxRows = core.Ed.Config().MaxCmdBufferLines
 	if err != nil {
-		ed.SetStatusErr(err.Error())
+		core.Ed.SetStatusErr(err.Error())
+		return
 	}
+	b.MaxRows = core.Ed.Config().MaxCmdBufferLines
+	b.MaxLogSize = core.Ed.Config().CmdOutputLogSize
 	v.backend = b
-	return v.Id()
+	core.Events.Publish(core.Event{Type: core.EvtViewOpened, ViewId: v.Id(), Loc: workDir})
+}
+
+// ScriptErrors shows the errors of a script in an errors view (replacing the
+// previous one) and in the status bar. None closes the errors view.
+func (e *Editor) ScriptErrors(script string, errs []string) {
+	e.showErrors(&e.scriptErrs, filepath.Dir(script), "errors: "+filepath.Base(script),
+		"Script failed", errs)
+}
+
+// showErrors shows errors in an errors view, replacing the previous one
+// (*viewId), the first error line going to the status bar after msg.
+// None closes the errors view.
+func (e *Editor) showErrors(viewId *int64, dir, title, msg string, errs []string) {
+	if _, found := e.views[*viewId]; found {
+		e.DelView(*viewId, true)
+	}
+	*viewId = -1
+	if len(errs) == 0 {
+		return
+	}
+	lines := []string{}
+	for _, err := range errs {
+		lines = append(lines, strings.Split(err, "\n")...)
+	}
+	v := e.newResultsView(dir, title, lines)
+	*viewId = v.Id()
+	e.SetStatusErr(msg + " : " + lines[0])
 }
@@ -0,0 +1,450 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+	"github.com/tcolar/goed/search"
+	"github.com/tcolar/goed/ui/widgets"
+)
+
+var _ core.Finder = (*Finder)(nil)
+var _ widgets.Overlay = (*Finder)(nil)
+
+// maxFinderFiles limits how many files the finder indexes.
+const maxFinderFiles = 50000
+
+// finderBatch is how many files are indexed before being listed.
+const finderBatch = 500
+
+var errTooManyFiles = errors.New("Too many files")
+var errFinderClosed = errors.New("Finder closed")
+
+// Finder is the fuzzy finder (modal) overlay, it lists the files under a directory
+// (ignoring what .gitignore files exclude), or the open views, ranked
+// against the query, with a preview of the selected one.
+type Finder struct {
+	widgets.BaseWidget
+	mode    string
+	dir     string // root of the files
+	items   []finderItem
+	query   []rune
+	matches []finderMatch
+	sel     int      // selected match
+	offset  int      // first match shown
+	preview struct { // of the selected item
+		item  finderItem
+		n     int
+		lines []string
+	}
+	indexing bool  // files still being indexed, in the background
+	closed   int32 // stops the indexing
+}
+
+// finderItem is a file path (relative to the finder dir) or a view.
+type finderItem struct {
+	text string
+	vid  int64
+}
+
+type finderMatch struct {
+	item      finderItem
+	score     int
+	positions []int
+}
+
+// newFinder creates a finder, indexing the files under dir (in the
+// background) or the views.
+func newFinder(mode, dir string) (*Finder, error) {
+	f := &Finder{mode: mode, dir: dir}
+	switch mode {
+	case core.FinderFiles:
+		f.indexing = true
+		go f.index()
+	case core.FinderViews:
+		ed := core.Ed.(*Editor)
+		cur := []finderItem{}
+		for _, vid := range ed.Views() {
+			item := finderItem{text: ed.ViewById(vid).Title(), vid: vid}
+			if vid == ed.CurViewId() {
+				cur = append(cur, item) // listed last
+				continue
+			}
+			f.items = append(f.items, item)
+		}
+		f.items = append(f.items, cur...)
+	default:
+		return nil, fmt.Errorf("Unknown finder mode : %s", mode)
+	}
+	f.filter()
+	return f, nil
+}
+
+// index walks the files under the finder dir, relative to it, handing
+// them over to the finder (on the action bus) in batches.
+func (f *Finder) index() {
+	batch := []string{}
+	count := 0
+	err := search.Walk(f.dir, func(rel string) error {
+		if atomic.LoadInt32(&f.closed) > 0 {
+			return errFinderClosed
+		}
+		if count >= maxFinderFiles {
+			return errTooManyFiles
+		}
+		count++
+		batch = append(batch, rel)
+		if len(batch) >= finderBatch {
+			core.Bus.Dispatch(finderIndexed{finder: f, files: batch})
+			batch = []string{}
+		}
+		return nil
+	})
+	core.Bus.Dispatch(finderIndexed{finder: f, files: batch, done: true, err: err})
+}
+
+// finderIndexed adds a batch of indexed files to a finder, unless closed
+// meanwhile.
+type finderIndexed struct {
+	finder *Finder
+	files  []string
+	done   bool
+	err    error
+}
+
+func (a finderIndexed) Run() {
+	e := core.Ed.(*Editor)
+	f := a.finder
+	if e.finder != f {
+		return
+	}
+	items := []finderItem{}
+	for _, file := range a.files {
+		items = append(items, finderItem{text: file})
+	}
+	f.items = append(f.items, items...)
+	// the selection stays on the same item
+	hasSel := f.sel < len(f.matches)
+	var sel finderItem
+	if hasSel {
+		sel = f.matches[f.sel].item
+	}
+	f.addMatches(items)
+	if hasSel {
+		for i, m := range f.matches {
+			if m.item == sel {
+				f.sel = i
+				break
+			}
+		}
+	}
+	if a.done {
+		f.indexing = false
+		if a.err != nil && a.err != errTooManyFiles && a.err != errFinderClosed {
+			e.SetStatusErr(a.err.Error())
+		}
+	}
+}
+
+// filter ranks the items matching the query.
+func (f *Finder) filter() {
+	f.matches = []finderMatch{}
+	f.addMatches(f.items)
+	f.sel, f.offset = 0, 0
+}
+
+// addMatches ranks the given items matching the query among the matches,
+// best first, shorter first if equal.
+func (f *Finder) addMatches(items []finderItem) {
+	for _, item := range items {
+		if ok, score, positions := fuzzyMatch(string(f.query), item.text); ok {
+			f.matches = append(f.matches, finderMatch{item, score, positions})
+		}
+	}
+	if len(f.query) > 0 {
+		sort.SliceStable(f.matches, func(i, j int) bool {
+			a, b := f.matches[i], f.matches[j]
+			if a.score != b.score {
+				return a.score > b.score
+			}
+			return len(a.item.text) < len(b.item.text)
+		})
+	}
+}
+
+func (f *Finder) Backspace() {
+	if len(f.query) == 0 {
+		return
+	}
+	f.query = f.query[:len(f.query)-1]
+	f.filter()
+}
+
+func (f *Finder) Close() {
+	atomic.StoreInt32(&f.closed, 1)
+	ed := core.Ed.(*Editor)
+	ed.overlays.RemoveWidget(f)
+	ed.finder = nil
+}
+
+func (f *Finder) Insert(text string) {
+	f.query = append(f.query, []rune(text)...)
+	f.filter()
+}
+
+func (f *Finder) Move(delta int) {
+	f.sel += delta
+	if f.sel >= len(f.matches) {
+		f.sel = len(f.matches) - 1
+	}
+	if f.sel < 0 {
+		f.sel = 0
+	}
+}
+
+// Select opens the selected file (activating the view it's already open in
+// if any), or activates the selected view.
+func (f *Finder) Select() {
+	f.Close()
+	if f.sel >= len(f.matches) {
+		return
+	}
+	ed := core.Ed.(*Editor)
+	item := f.matches[f.sel].item
+	if f.mode == core.FinderViews {
+		ed.ViewActivate(item.vid)
+		return
+	}
+	loc := path.Join(f.dir, item.text)
+	if vids := ed.ViewsByLoc(loc); len(vids) > 0 {
+		ed.ViewActivate(vids[0])
+		return
+	}
+	if _, err := ed.Open(loc, -1, "", false); err != nil {
+		ed.SetStatusErr(err.Error())
+	}
+}
+
+func (f *Finder) Focusable() bool {
+	return true
+}
+
+// Event handles the finder keys and clicks, a click elsewhere closes it.
+// The finder being modal, all events are consumed.
+func (f *Finder) Event(e widgets.OverlayEvent) bool {
+	if e.Mouse {
+		_, x1, _, _ := f.Bounds()
+		switch {
+		case !widgets.Contains(f, e.Y, e.X):
+			f.Close()
+		case e.Type == event.EvtScrollDown:
+			f.Move(1)
+		case e.Type == event.EvtScrollUp:
+			f.Move(-1)
+		case e.Y >= 3 && e.X < f.mid()-x1: // a match
+			f.Move(f.offset + e.Y - 3 - f.sel)
+			if e.Type == event.EvtSetCursor && f.offset+e.Y-3 == f.sel {
+				f.Select()
+			}
+		}
+		return true
+	}
+	switch e.Type {
+	case event.EvtToggleCmdbar: // escape
+		f.Close()
+	case event.EvtBackspace:
+		f.Backspace()
+	case event.EvtEnter:
+		f.Select()
+	case event.EvtMoveDown:
+		f.Move(1)
+	case event.EvtMoveUp:
+		f.Move(-1)
+	case event.EvtPageDown:
+		f.Move(10)
+	case event.EvtPageUp:
+		f.Move(-10)
+	default:
+		if len(e.Glyph) > 0 {
+			f.Insert(e.Glyph)
+		}
+	}
+	return true
+}
+
+// previewLines returns up to n lines of the selected file or view.
+func (f *Finder) previewLines(n int) []string {
+	if f.sel >= len(f.matches) {
+		return nil
+	}
+	item := f.matches[f.sel].item
+	if f.preview.lines != nil && f.preview.item == item && f.preview.n == n {
+		return f.preview.lines
+	}
+	lines := []string{}
+	if f.mode == core.FinderViews {
+		v := viewCast(core.Ed.ViewById(item.vid))
+		if v != nil && v.backend != nil {
+			from := v.CurLine() - n/2
+			if from < 0 {
+				from = 0
+			}
+			for _, l := range *v.backend.Slice(from, 0, from+n-1, -1).Text() {
+				lines = append(lines, string(l))
+			}
+		}
+	} else {
+		all, err := search.ReadLines(path.Join(f.dir, item.text))
+		switch {
+		case err != nil:
+			lines = []string{err.Error()}
+		case all == nil:
+			lines = []string{"(binary file)"}
+		case len(all) > n:
+			lines = all[:n]
+		default:
+			lines = all
+		}
+	}
+	f.preview.item, f.preview.n, f.preview.lines = item, n, lines
+	return lines
+}
+
+func (f *Finder) SetParent(parent core.Widget) {
+	f.BaseWidget.SetParent(parent)
+	f.place()
+}
+
+// place centers the finder on the terminal.
+func (f *Finder) place() {
+	h, w := core.Ed.(*Editor).term.Size()
+	fh, fw := h*3/4, w*4/5
+	y1, x1 := (h-fh)/2, (w-fw)/2
+	f.SetBounds(y1, x1, y1+fh-1, x1+fw-1)
+}
+
+// mid is the column separating the matches from the preview.
+func (f *Finder) mid() int {
+	_, x1, _, x2 := f.Bounds()
+	return x1 + (x2-x1)/2
+}
+
+// Render draws the finder centered on the editor : the query, the matches
+// on the left and the preview on the right.
+func (f *Finder) Render() {
+	ed := core.Ed.(*Editor)
+	t := ed.Theme()
+	f.place()
+	y1, x1, y2, x2 := f.Bounds()
+	if y2-y1 < 4 || x2-x1 < 10 {
+		return
+	}
+	mid := f.mid()
+
+	ed.TermFB(t.Fg, t.Bg)
+	ed.TermFill(' ', y1, x1, y2, x2)
+	ed.TermFB(t.Viewbar.Fg, t.Viewbar.Bg)
+	ed.TermFill('─', y1, x1, y1, x2)
+	ed.TermFill('─', y1+2, x1, y1+2, x2)
+	ed.TermFill('─', y2, x1, y2, x2)
+	ed.TermFill('│', y1+1, x1, y2-1, x1)
+	ed.TermFill('│', y1+1, x2, y2-1, x2)
+	ed.TermFill('│', y1+3, mid, y2-1, mid)
+	title := " Views "
+	if f.mode == core.FinderFiles {
+		title = " Files : " + f.dir + " "
+	}
+	title += fmt.Sprintf("%d/%d ", len(f.matches), len(f.items))
+	if f.indexing {
+		title += "(indexing) "
+	}
+	f.str(y1, x1+2, x2-1, title)
+
+	ed.TermFB(t.CmdbarTextOn, t.Bg)
+	query := "> " + string(f.query)
+	f.str(y1+1, x1+2, x2-1, query)
+	ed.TermFB(t.FgCursor, t.BgCursor)
+	ed.TermChar(y1+1, x1+2+len([]rune(query)), ' ')
+
+	// matches
+	rows := y2 - y1 - 3
+	if f.sel < f.offset {
+		f.offset = f.sel
+	}
+	if f.sel >= f.offset+rows {
+		f.offset = f.sel - rows + 1
+	}
+	for i := 0; i < rows && f.offset+i < len(f.matches); i++ {
+		m := f.matches[f.offset+i]
+		fg, bg := t.Fg, t.Bg
+		if f.offset+i == f.sel {
+			fg, bg = t.FgSelect, t.BgSelect
+			ed.TermFB(fg, bg)
+			ed.TermFill(' ', y1+3+i, x1+1, y1+3+i, mid-1)
+		}
+		matched := map[int]bool{}
+		for _, p := range m.positions {
+			matched[p] = true
+		}
+		for j, r := range []rune(m.item.text) {
+			if x1+2+j >= mid-1 {
+				break
+			}
+			if matched[j] {
+				ed.TermFB(t.FgMatch, bg)
+			} else {
+				ed.TermFB(fg, bg)
+			}
+			ed.TermChar(y1+3+i, x1+2+j, r)
+		}
+	}
+
+	// preview
+	ed.TermFB(t.Fg, t.Bg)
+	for i, line := range f.previewLines(rows) {
+		f.str(y1+3+i, mid+2, x2-1, strings.Replace(line, "\t", "    ", -1))
+	}
+}
+
+// str draws s at y, x, truncated at maxX.
+func (f *Finder) str(y, x, maxX int, s string) {
+	for i, r := range []rune(s) {
+		if x+i > maxX {
+			return
+		}
+		core.Ed.TermChar(y, x+i, r)
+	}
+}
+
+func (e *Editor) Finder() core.Finder {
+	if e.finder == nil {
+		return nil
+	}
+	return e.finder
+}
+
+// FinderOpen opens the fuzzy finder, on the current view directory files in
+// FinderFiles mode.
+func (e *Editor) FinderOpen(mode string) error {
+	dir := "."
+	if v := e.CurView(); v != nil {
+		dir = v.WorkDir()
+	}
+	f, err := newFinder(mode, dir)
+	if err != nil {
+		return err
+	}
+	if e.finder != nil {
+		e.finder.Close()
+	}
+	e.SetCmdOn(false)
+	e.finder = f
+	e.overlays.AddWidgetZ(f, zFinder)
+	return nil
+}
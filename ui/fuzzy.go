@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"unicode"
+)
+
+// Fuzzy matching, scored the way fzf (v1 algorithm) does : the pattern runes
+// must appear in order in the text, the shortest such match is scored,
+// favoring consecutive runes and runes at the start of words.
+// Matching ignores case unless the pattern contains upper case runes.
+
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyScoreGapStart     = -3
+	fuzzyScoreGapExtension = -1
+	fuzzyBonusBoundary     = fuzzyScoreMatch / 2
+	fuzzyBonusNonWord      = fuzzyScoreMatch / 2
+	fuzzyBonusCamel123     = fuzzyBonusBoundary - 1
+	fuzzyBonusConsecutive  = -(fuzzyScoreGapStart + fuzzyScoreGapExtension)
+	fuzzyBonusFirstChar    = 2 // multiplier of the first pattern rune bonus
+)
+
+type charClass int
+
+const (
+	charNonWord charClass = iota
+	charLower
+	charUpper
+	charLetter
+	charNumber
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case unicode.IsLower(r):
+		return charLower
+	case unicode.IsUpper(r):
+		return charUpper
+	case unicode.IsLetter(r):
+		return charLetter
+	case unicode.IsNumber(r):
+		return charNumber
+	}
+	return charNonWord
+}
+
+// fuzzyBonus is the bonus of a rune of class c following a rune of class prev.
+func fuzzyBonus(prev, c charClass) int {
+	switch {
+	case prev == charNonWord && c != charNonWord:
+		return fuzzyBonusBoundary // start of a word
+	case prev == charLower && c == charUpper, prev != charNumber && c == charNumber:
+		return fuzzyBonusCamel123 // camelCase, or letter123
+	case c == charNonWord:
+		return fuzzyBonusNonWord
+	}
+	return 0
+}
+
+// fuzzyMatch matches pattern against text, returns whether it matches, its
+// score (higher is better) and the positions (rune index) of the matched
+// runes in text.
+func fuzzyMatch(pattern, text string) (ok bool, score int, positions []int) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return true, 0, nil
+	}
+	caseSensitive := false
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			caseSensitive = true
+		}
+	}
+	eq := func(a, b rune) bool {
+		if caseSensitive {
+			return a == b
+		}
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+	// forward scan, for the first complete match
+	pi, end := 0, -1
+	for i, r := range t {
+		if eq(r, p[pi]) {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return false, 0, nil
+	}
+	// backward scan, for the shortest match ending there
+	pi, start := len(p)-1, 0
+	for i := end; i >= 0; i-- {
+		if eq(t[i], p[pi]) {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+	// score
+	prevClass := charNonWord
+	if start > 0 {
+		prevClass = classOf(t[start-1])
+	}
+	pi = 0
+	consecutive, firstBonus := 0, 0
+	inGap := false
+	for i := start; i <= end; i++ {
+		class := classOf(t[i])
+		if pi < len(p) && eq(t[i], p[pi]) {
+			score += fuzzyScoreMatch
+			bonus := fuzzyBonus(prevClass, class)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				if bonus == fuzzyBonusBoundary {
+					firstBonus = bonus
+				}
+				bonus = maxInt(bonus, firstBonus, fuzzyBonusConsecutive)
+			}
+			if pi == 0 {
+				bonus *= fuzzyBonusFirstChar
+			}
+			score += bonus
+			positions = append(positions, i)
+			consecutive++
+			inGap = false
+			pi++
+		} else {
+			if inGap {
+				score += fuzzyScoreGapExtension
+			} else {
+				score += fuzzyScoreGapStart
+			}
+			inGap = true
+			consecutive, firstBonus = 0, 0
+		}
+		prevClass = class
+	}
+	return true, score, positions
+}
+
+func maxInt(vals ...int) int {
+	m := vals[0]
+	for _, v := range vals[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/tcolar/goed/ui/widgets"
+)
+
+// Overlays z order, higher ones are drawn over lower ones.
+const (
+	zFinder  = 10
+	zMenu    = 20
+	zTooltip = 30
+	zPrompt  = 40
+)
+
+// OverlayEvent offers an event to the overlays, see widgets.TermWidget.Dispatch.
+func (e *Editor) OverlayEvent(evtType, glyph string, mouse bool, y, x int) bool {
+	return e.overlays.Dispatch(widgets.OverlayEvent{
+		Type: evtType, Glyph: glyph, Mouse: mouse, Y: y, X: x})
+}
+
+// showTooltip shows text in a tooltip near the terminal location y, x,
+// replacing the current tooltip if any.
+func (e *Editor) showTooltip(y, x int, text string) {
+	for _, w := range e.overlays.Widgets() {
+		if _, ok := w.(*widgets.Tooltip); ok {
+			e.overlays.RemoveWidget(w)
+		}
+	}
+	e.overlays.AddWidgetZ(widgets.NewTooltip(y, x, text), zTooltip)
+}
+
+// showMenu shows a menu near the terminal location y, x, onSelect is called
+// with the index of the picked item.
+func (e *Editor) showMenu(y, x int, items []string, onSelect func(index int)) *widgets.Menu {
+	m := widgets.NewMenu(y, x, items, onSelect)
+	e.overlays.AddWidgetZ(m, zMenu)
+	return m
+}
+
+// Prompt choices.
+const (
+	choiceYes     = "yes"
+	choiceNo      = "no"
+	choiceSave    = "save"
+	choiceDiscard = "discard"
+	choiceCancel  = "cancel"
+)
+
+// Prompt asks the user a question in a modal dialog, answer is called with
+// the picked choice ("" if cancelled). Choices default to yes / no.
+// The same question is not asked again while pending, answer is called
+// with "" instead.
+func (e *Editor) Prompt(question string, choices []string, defaultChoice int, answer func(choice string)) {
+	if e.prompt(question) != nil {
+		answer("")
+		return
+	}
+	if len(choices) == 0 {
+		choices = []string{choiceYes, choiceNo}
+	}
+	e.SetCmdOn(false)
+	e.overlays.AddWidgetZ(widgets.NewPrompt(question, choices, defaultChoice, answer), zPrompt)
+}
+
+// prompt returns the pending prompt asking question, nil if none.
+func (e *Editor) prompt(question string) *widgets.Prompt {
+	for _, w := range e.overlays.Widgets() {
+		if p, ok := w.(*widgets.Prompt); ok && p.Question() == question {
+			return p
+		}
+	}
+	return nil
+}
+
+// closePrompt closes the pending prompt asking question, its answer callback
+// being called with "".
+func (e *Editor) closePrompt(question string) {
+	if p := e.prompt(question); p != nil {
+		p.Cancel()
+	}
+}
+
+// saveDiscardPrompt asks whether to save or discard the changes of dirty
+// views before doing something (ie: closing them), then does it unless
+// cancelled, or saving failed.
+func (e *Editor) saveDiscardPrompt(dirty []*View, then func()) {
+	question := fmt.Sprintf("%d views have unsaved changes.", len(dirty))
+	if len(dirty) == 1 {
+		question = dirty[0].Title() + " has unsaved changes."
+	}
+	choices := []string{choiceSave, choiceDiscard, choiceCancel}
+	e.Prompt(question, choices, 0, func(choice string) {
+		switch choice {
+		case choiceSave:
+			saveViews(dirty, then)
+		case choiceDiscard:
+			then()
+		}
+	})
+}
+
+// saveViews saves the views one after the other, then calls then, unless
+// one was not saved (failed, or changed on disk).
+func saveViews(views []*View, then func()) {
+	if len(views) == 0 {
+		then()
+		return
+	}
+	views[0].save(func(saved bool) {
+		if saved {
+			saveViews(views[1:], then)
+		}
+	})
+}
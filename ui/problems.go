@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/problems"
+)
+
+// problemList is the list of problems (compiler errors ...) collected from
+// a command output, gone through with next_error / prev_error.
+type problemList struct {
+	viewId   int64 // view they were collected from
+	problems []problems.Problem
+	cur      int // current problem, -1 before the first one
+}
+
+// newProblemMatchers returns the problem matchers by name : the builtin ones
+// and the configured ones (ProblemMatchers), compiled, the invalid ones
+// being logged and skipped.
+func newProblemMatchers(config *core.Config) map[string]*problems.Matcher {
+	matchers := problems.Defaults()
+	for name, c := range config.ProblemMatchers {
+		matchers[name] = &problems.Matcher{Name: name, Cmds: c.Cmds,
+			Pattern: c.Pattern, FilePattern: c.FilePattern}
+	}
+	for name, m := range matchers {
+		if err := m.Compile(); err != nil {
+			log.Println(err.Error())
+			delete(matchers, name)
+		}
+	}
+	return matchers
+}
+
+// parseProblems returns the problems reported in a view text, using the
+// named matcher, or if empty the ones applying to the view command.
+// Their locations are resolved relative to the view directory.
+func (e *Editor) parseProblems(v *View, matcher string) ([]problems.Problem, error) {
+	all := e.matchers
+	cmd := ""
+	if out, ok := v.backend.(core.CmdOutputBackend); ok {
+		cmd = strings.Join(out.Cmd(), " ")
+	}
+	matchers := problems.For(all, cmd)
+	if len(matcher) > 0 {
+		m, found := all[matcher]
+		if !found {
+			return nil, fmt.Errorf("No such problem matcher : %s", matcher)
+		}
+		matchers = []*problems.Matcher{m}
+	}
+	lines := []string{}
+	for _, l := range *v.backend.Slice(0, 0, -1, -1).Text() {
+		lines = append(lines, string(l))
+	}
+	found := problems.Parse(lines, matchers)
+	for i, p := range found {
+		if !filepath.IsAbs(p.Loc) {
+			found[i].Loc, _ = core.LookupLocation(v.WorkDir(), p.Loc)
+		}
+	}
+	return found, nil
+}
+
+// CollectProblems collects the problems reported in a view (typically a
+// command output) as the problem list, see parseProblems.
+// Returns how many were found.
+func (e *Editor) CollectProblems(viewId int64, matcher string) (int, error) {
+	v := viewCast(e.ViewById(viewId))
+	if v == nil {
+		return 0, fmt.Errorf("No such view : %d", viewId)
+	}
+	found, err := e.parseProblems(v, matcher)
+	if err != nil {
+		return 0, err
+	}
+	e.problems = &problemList{viewId: viewId, problems: found, cur: -1}
+	return len(found), nil
+}
+
+// Problems returns the problem list, as loc:line:col: severity: message
+func (e *Editor) Problems() []string {
+	list := []string{}
+	if e.problems != nil {
+		for _, p := range e.problems.problems {
+			list = append(list, p.String())
+		}
+	}
+	return list
+}
+
+// NextError opens the location of the next (or previous if backward)
+// problem of the problem list, wrapping around.
+func (e *Editor) NextError(backward bool) error {
+	l := e.problems
+	if l == nil || len(l.problems) == 0 {
+		return fmt.Errorf("No problems")
+	}
+	count := len(l.problems)
+	if backward {
+		if l.cur <= 0 {
+			l.cur = count
+		}
+		l.cur--
+	} else {
+		l.cur = (l.cur + 1) % count
+	}
+	p := l.problems[l.cur]
+	vid := int64(-1)
+	if vids := e.ViewsByLoc(p.Loc); len(vids) > 0 {
+		vid = vids[0]
+	} else {
+		var err error
+		if vid, err = e.Open(p.Loc, -1, "", false); err != nil {
+			return err
+		}
+	}
+	v := viewCast(e.ViewById(vid))
+	if v == nil {
+		return fmt.Errorf("No such view")
+	}
+	ln := p.Line - 1
+	text := *v.backend.Slice(ln, 0, ln, -1).Text()
+	line := []rune{}
+	if len(text) > 0 {
+		line = text[0]
+	}
+	v.SetCursorPos(ln, runeCol(line, p.Col))
+	e.ViewActivate(v.Id())
+	e.SetStatus(fmt.Sprintf("[%d/%d] %s: %s", l.cur+1, count, p.Severity, p.Message))
+	return nil
+}
+
+// runeCol returns the rune index in line of a (1 based) byte column, as
+// reported by most tools.
+func runeCol(line []rune, col int) int {
+	offset := 0
+	for i, r := range line {
+		if offset >= col-1 {
+			return i
+		}
+		offset += utf8.RuneLen(r)
+	}
+	return len(line)
+}
+
+// cmdDone collects the problems of a completed command. They replace the
+// problem list if any, or if it came from the same view (ie: a clean build).
+// Task runs use the task problem matcher and get their status updated.
+func (e *Editor) cmdDone(viewId int64, exitCode int) {
+	run := e.taskRunOf(viewId)
+	v := viewCast(e.ViewById(viewId))
+	if v == nil || v.Type() != core.ViewTypeCmdOutput {
+		if run != nil && v == nil {
+			run.status = taskStopped
+		}
+		return
+	}
+	matcher := ""
+	if run != nil {
+		matcher = run.task.Matcher
+	}
+	found, err := e.parseProblems(v, matcher)
+	if err == nil && (len(found) > 0 || (e.problems != nil && e.problems.viewId == viewId)) {
+		e.problems = &problemList{viewId: viewId, problems: found, cur: -1}
+		if len(found) > 0 {
+			e.SetStatusErr(fmt.Sprintf("%d problem(s) reported by %s", len(found), v.Title()))
+		}
+	}
+	if run != nil {
+		e.taskDone(run, exitCode, len(found))
+	}
+}
+
+// problemsCollector collects the problems of the commands as they complete.
+func (e *Editor) problemsCollector() {
+	s := core.Events.Subscribe(core.EvtCmdDone)
+	for evt := range s.C {
+		core.Bus.Dispatch(cmdDoneAction{viewId: evt.ViewId, exitCode: evt.ExitCode})
+	}
+}
+
+type cmdDoneAction struct {
+	viewId   int64
+	exitCode int
+}
+
+func (a cmdDoneAction) Run() {
+	core.Ed.(*Editor).cmdDone(a.viewId, a.exitCode)
+}
+
+// renderProblems marks the lines with a problem in the view gutter.
+func (v *View) renderProblems() {
+	e, ok := core.Ed.(*Editor)
+	if !ok || e.problems == nil || len(v.backend.SrcLoc()) == 0 {
+		return
+	}
+	t := e.Theme()
+	y1, x1, _, _ := v.Bounds()
+	last := v.offy + v.LastViewLine()
+	errors := map[int]bool{}
+	for _, p := range e.problems.problems {
+		ln := p.Line - 1
+		if p.Loc != v.backend.SrcLoc() || ln < v.offy || ln > last {
+			continue
+		}
+		errors[ln] = errors[ln] || p.Severity == problems.SeverityError
+	}
+	for ln, isErr := range errors {
+		style := t.DiagnosticWarning
+		if isErr {
+			style = t.DiagnosticError
+		}
+		e.TermFB(style.Fg, style.Bg)
+		e.TermChar(y1+2+ln-v.offy, x1+1, style.Rune)
+	}
+	e.TermFB(t.Fg, t.Bg)
+}
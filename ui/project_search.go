@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/backend"
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/search"
+)
+
+// projectReplace is a pending project replacement, as previewed in a view.
+type projectReplace struct {
+	dir     string
+	matches []search.Match
+}
+
+// ProjectSearch searches the files under dir in the background and shows the
+// matches in a new command output view, one "path:line:col: text" per line.
+func (e *Editor) ProjectSearch(dir, pattern string, regex, ignoreCase bool) (int64, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return -1, err
+	}
+	q := search.Query{Pattern: pattern, Regex: regex, IgnoreCase: ignoreCase}
+	if _, err := q.Compile(); err != nil {
+		return -1, err
+	}
+	title := "search: " + pattern
+	v := e.newResultsView(dir, fmt.Sprintf("[RUNNING] %s", title), nil)
+	read := e.searchReader()
+	go func() {
+		matches, err := search.Search(dir, q, read)
+		lines := []string{}
+		for _, m := range matches {
+			lines = append(lines, m.String())
+		}
+		title := fmt.Sprintf("%s (%d)", title, len(matches))
+		core.Bus.Dispatch(projectSearchDone{viewId: v.Id(), title: title, lines: lines, err: err})
+	}()
+	return v.Id(), nil
+}
+
+// ProjectReplace previews replacing the query matches in the files under dir
+// in a new command output view, see ProjectReplaceApply. The files are
+// searched in the background.
+func (e *Editor) ProjectReplace(dir, pattern, repl string, regex, ignoreCase bool) (int64, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return -1, err
+	}
+	q := search.Query{Pattern: pattern, Regex: regex, IgnoreCase: ignoreCase}
+	if _, err := q.Compile(); err != nil {
+		return -1, err
+	}
+	title := fmt.Sprintf("replace: %s -> %s", pattern, repl)
+	v := e.newResultsView(dir, fmt.Sprintf("[RUNNING] %s", title), nil)
+	read := e.searchReader()
+	go func() {
+		matches, err := search.Replace(dir, q, repl, read)
+		lines := []string{}
+		for _, m := range matches {
+			loc := fmt.Sprintf("%s:%d:%d:", m.Path, m.Line, m.Col)
+			lines = append(lines, loc+"- "+m.Text, loc+"+ "+m.Repl)
+		}
+		title := fmt.Sprintf("%s (%d) [apply]", title, len(matches))
+		core.Bus.Dispatch(projectSearchDone{viewId: v.Id(), title: title, lines: lines, err: err,
+			replace: &projectReplace{dir: dir, matches: matches}})
+	}()
+	return v.Id(), nil
+}
+
+// projectSearchDone fills a results view once its project search or
+// replacement preview completed.
+type projectSearchDone struct {
+	viewId  int64
+	title   string
+	lines   []string
+	replace *projectReplace // replacement preview, if any
+	err     error
+}
+
+func (a projectSearchDone) Run() {
+	e := core.Ed.(*Editor)
+	v, found := e.views[a.viewId]
+	if !found {
+		return // closed meanwhile
+	}
+	if a.err != nil {
+		v.SetTitle("[FAILED] " + strings.TrimPrefix(v.Title(), "[RUNNING] "))
+		e.SetStatusErr(a.err.Error())
+		return
+	}
+	if len(a.lines) > 0 {
+		v.backend.Insert(0, 0, strings.Join(a.lines, "\n"))
+	}
+	v.replace = a.replace
+	v.SetTitle(a.title)
+}
+
+// ProjectReplaceApply applies the replacements previewed in the given view.
+// Files opened in views are edited in the views (undoable, unsaved), other
+// files are saved. Lines changed since the preview are left alone.
+// Returns the number of lines replaced.
+func (e *Editor) ProjectReplaceApply(viewId int64) (int, error) {
+	v := viewCast(e.ViewById(viewId))
+	if v == nil || v.replace == nil {
+		if v != nil && strings.HasPrefix(v.Title(), "[RUNNING] ") {
+			return 0, fmt.Errorf("The replacement preview is not complete yet")
+		}
+		return 0, fmt.Errorf("Not a replacement preview view")
+	}
+	files := []string{}
+	byFile := map[string][]search.Match{}
+	for _, m := range v.replace.matches {
+		if _, found := byFile[m.Path]; !found {
+			files = append(files, m.Path)
+		}
+		byFile[m.Path] = append(byFile[m.Path], m)
+	}
+	count := 0
+	for _, f := range files {
+		loc := filepath.Join(v.replace.dir, f)
+		vids := e.ViewsByLoc(loc)
+		if len(vids) == 0 {
+			n, err := e.replaceInFile(loc, byFile[f])
+			count += n
+			if err != nil {
+				return count, err
+			}
+			continue
+		}
+		n := 0
+		for _, vid := range vids {
+			if fv := viewCast(e.ViewById(vid)); fv != nil {
+				n = fv.replaceLines(byFile[f])
+			}
+		}
+		count += n
+	}
+	v.replace = nil
+	v.SetTitle(strings.TrimSuffix(v.Title(), "[apply]") + "[applied]")
+	return count, nil
+}
+
+// searchReader returns a reader of the files for search, those open in a
+// view being read from a snapshot of the view, so that unsaved changes are
+// accounted for. The snapshot is taken now, on the bus, so that the reader
+// can be used in the background.
+func (e *Editor) searchReader() search.Reader {
+	open := map[string][]string{}
+	for _, v := range e.views {
+		if v == nil || v.backend == nil || v.Type() != core.ViewTypeStandard {
+			continue
+		}
+		loc := v.backend.SrcLoc()
+		if _, found := open[loc]; found || len(loc) == 0 {
+			continue
+		}
+		lines := []string{}
+		for _, l := range *v.backend.Slice(0, 0, -1, -1).Text() {
+			lines = append(lines, string(l))
+		}
+		open[loc] = lines
+	}
+	return func(loc string) ([]string, error) {
+		loc, _ = filepath.Abs(loc)
+		if lines, found := open[loc]; found {
+			return lines, nil
+		}
+		return search.ReadLines(loc)
+	}
+}
+
+// newResultsView creates a command output view showing the given lines.
+func (e *Editor) newResultsView(dir, title string, lines []string) *View {
+	v := e.AddViewSmart(nil)
+	v.highlighter = &TermHighlighter{}
+	v.viewType = core.ViewTypeCmdOutput
+	v.workDir = dir
+	b, _ := backend.NewMemBackend("", v.Id())
+	if len(lines) > 0 {
+		b.Insert(0, 0, strings.Join(lines, "\n"))
+	}
+	v.SetBackend(b)
+	v.SetTitle(title)
+	return v
+}
+
+// replaceInFile replaces the lines of a file (not opened in a view) through
+// a file backend and saves it.
+func (e *Editor) replaceInFile(loc string, matches []search.Match) (int, error) {
+	id := e.genViewId()
+	b, err := backend.NewFileBackend(loc, id)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(backend.BufferFile(id))
+	defer b.Close()
+	count := replaceLines(b, matches, func(ln int, old []rune, repl string) {
+		if len(old) > 0 {
+			b.Remove(ln, 0, ln, len(old)-1)
+		}
+		if len(repl) > 0 {
+			b.Insert(ln, 0, repl)
+		}
+	})
+	if count == 0 {
+		return 0, nil
+	}
+	return count, b.Save(loc)
+}
+
+// replaceLines replaces the view lines, as a single undo step.
+func (v *View) replaceLines(matches []search.Match) int {
+	actions.UndoBegin(v.Id())
+	defer actions.UndoEnd(v.Id())
+	count := replaceLines(v.backend, matches, func(ln int, old []rune, repl string) {
+		if len(old) > 0 {
+			v.Delete(ln, 0, ln, len(old)-1, true)
+		}
+		if len(repl) > 0 {
+			v.Insert(ln, 0, repl, true)
+		}
+	})
+	if count > 0 {
+		v.SetDirty(true)
+	}
+	return count
+}
+
+// replaceLines calls replace for each match whose line is unchanged since the
+// match was found. Returns the number of lines replaced.
+func replaceLines(b core.Backend, matches []search.Match,
+	replace func(ln int, old []rune, repl string)) int {
+	count := 0
+	for _, m := range matches {
+		ln := m.Line - 1
+		if ln >= b.LineCount() {
+			continue
+		}
+		text := *b.Slice(ln, 0, ln, -1).Text()
+		if len(text) == 0 || string(text[0]) != m.Text {
+			continue
+		}
+		replace(ln, text[0], m.Repl)
+		count++
+	}
+	return count
+}
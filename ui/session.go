@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tcolar/goed/backend"
+	"github.com/tcolar/goed/core"
+)
+
+// SessionSaveInterval is how often the session is saved automatically.
+var SessionSaveInterval = 30 * time.Second
+
+// Session is the saved editor layout : columns and their views.
+type Session struct {
+	Cols []SessionCol
+}
+
+// SessionCol is a saved editor column.
+type SessionCol struct {
+	WidthRatio float64
+	Views      []SessionView
+}
+
+// SessionView is a saved view.
+type SessionView struct {
+	Type        core.ViewType
+	HeightRatio float64
+	Loc         string   // file or directory
+	WorkDir     string   // terminal work dir
+	Cmd         []string // terminal command
+	CursorLine  int
+	CursorCol   int
+	ScrollLine  int
+	ScrollCol   int
+	Selections  []core.Selection
+	Current     bool // whether this is the active view
+}
+
+// CheckSessionName returns an error if name is not a valid session name : a
+// plain file name, so the session file stays in the sessions directory.
+func CheckSessionName(name string) error {
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("Invalid session name %q : no path separator or '..' allowed", name)
+	}
+	return nil
+}
+
+// sessionLoc returns the location of the session (core.Session) file.
+func sessionLoc() (string, error) {
+	if err := CheckSessionName(core.Session); err != nil {
+		return "", err
+	}
+	return path.Join(core.Home, "sessions", core.Session+".json"), nil
+}
+
+// session captures the current editor layout.
+// Only files, directories and terminals are kept, other views (ie: command
+// output) are transient.
+func (e *Editor) session() *Session {
+	s := &Session{}
+	for _, c := range e.Cols {
+		sc := SessionCol{WidthRatio: c.WidthRatio}
+		for _, vid := range c.Views {
+			v, found := e.views[vid]
+			if !found || v.backend == nil {
+				continue
+			}
+			sv := SessionView{
+				Type:        v.Type(),
+				HeightRatio: v.HeightRatio,
+				Current:     vid == e.curViewId,
+			}
+			switch v.Type() {
+			case core.ViewTypeStandard:
+				sv.Loc = v.backend.SrcLoc()
+				if len(sv.Loc) == 0 {
+					continue
+				}
+				sv.CursorLine, sv.CursorCol = v.CurTextPos()
+				sv.ScrollLine, sv.ScrollCol = v.ScrollPos()
+				sv.Selections = append(sv.Selections, v.selections...)
+			case core.ViewTypeDirListing:
+				sv.Loc = v.WorkDir()
+			case core.ViewTypeShell:
+				b, ok := v.backend.(*backend.BackendCmd)
+				if !ok {
+					continue
+				}
+				sv.Cmd, sv.WorkDir = b.Args(), b.Dir()
+			default:
+				continue
+			}
+			sc.Views = append(sc.Views, sv)
+		}
+		if len(sc.Views) > 0 {
+			s.Cols = append(s.Cols, sc)
+		}
+	}
+	return s
+}
+
+// SaveSession saves the editor layout to the session file, if there is a
+// session (--session).
+func (e *Editor) SaveSession() error {
+	if len(core.Session) == 0 {
+		return fmt.Errorf("No session to save to (see --session)")
+	}
+	loc, err := sessionLoc()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e.session(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return core.WriteFileAtomic(loc, core.BackupNone, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// LoadSession reads a session file.
+func LoadSession(loc string) (*Session, error) {
+	data, err := ioutil.ReadFile(loc)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{}
+	if err = json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("Invalid session file %s : %s", loc, err.Error())
+	}
+	return s, nil
+}
+
+// restore restores the session from its file, if any.
+func (e *Editor) restore() bool {
+	loc, err := sessionLoc()
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	if _, err := os.Stat(loc); os.IsNotExist(err) {
+		return false
+	}
+	s, err := LoadSession(loc)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	return e.restoreSession(s)
+}
+
+// restoreSession recreates the session layout, replacing the editor columns.
+// Files that no longer exist are skipped.
+// Returns false if nothing could be restored.
+func (e *Editor) restoreSession(s *Session) bool {
+	cols := []*Col{}
+	restored := map[*View]SessionView{}
+	var cur *View
+	for _, sc := range s.Cols {
+		c := &Col{WidthRatio: sc.WidthRatio}
+		for _, sv := range sc.Views {
+			v := e.restoreView(sv)
+			if v == nil {
+				continue
+			}
+			v.HeightRatio = sv.HeightRatio
+			c.Views = append(c.Views, v.Id())
+			restored[v] = sv
+			if sv.Current || cur == nil {
+				cur = v
+			}
+		}
+		if len(c.Views) > 0 {
+			e.normalizeHeights(c)
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return false
+	}
+	total := 0.0
+	for _, c := range cols {
+		total += c.WidthRatio
+	}
+	for _, c := range cols {
+		if total > 0 {
+			c.WidthRatio /= total
+		} else {
+			c.WidthRatio = 1.0 / float64(len(cols))
+		}
+	}
+	e.Cols = cols
+	e.Resize(e.term.Size())
+	for v, sv := range restored {
+		if v.Type() != core.ViewTypeStandard {
+			continue
+		}
+		v.SetScrollPos(sv.ScrollLine, sv.ScrollCol)
+		v.SyncSlice()
+		v.SetCursorPos(sv.CursorLine, sv.CursorCol)
+		v.selections = append([]core.Selection{}, sv.Selections...)
+	}
+	e.CurCol = e.ViewColumn(cur.Id())
+	e.curViewId = cur.Id()
+	return true
+}
+
+// restoreView creates a view from its saved state, or nil if it can't be.
+func (e *Editor) restoreView(sv SessionView) *View {
+	switch sv.Type {
+	case core.ViewTypeStandard, core.ViewTypeDirListing:
+		if _, err := os.Stat(sv.Loc); err != nil {
+			return nil
+		}
+		v := e.NewView(sv.Loc)
+		if _, err := e.Open(sv.Loc, v.Id(), "", false); err != nil {
+			log.Printf("Failed to restore %s : %s", sv.Loc, err.Error())
+			e.TerminateView(v.Id())
+			return nil
+		}
+		v.viewType = sv.Type
+		return v
+	case core.ViewTypeShell:
+		if len(sv.Cmd) == 0 {
+			return nil
+		}
+		if _, err := os.Stat(sv.WorkDir); err != nil {
+			sv.WorkDir = "."
+		}
+		v := e.NewView("")
+		execIn(v, sv.Cmd, sv.WorkDir, true)
+		if v.backend == nil {
+			e.TerminateView(v.Id())
+			return nil
+		}
+		v.viewType = core.ViewTypeShell
+		v.SetWorkDir(sv.WorkDir)
+		e.termInit(v)
+		return v
+	}
+	return nil
+}
+
+// normalizeHeights makes the heights of the column views add up to 1.
+func (e *Editor) normalizeHeights(c *Col) {
+	total := 0.0
+	for _, vid := range c.Views {
+		total += e.views[vid].HeightRatio
+	}
+	for _, vid := range c.Views {
+		v := e.views[vid]
+		if total > 0 {
+			v.HeightRatio /= total
+		} else {
+			v.HeightRatio = 1.0 / float64(len(c.Views))
+		}
+	}
+}
+
+// sessionSaver saves the session periodically.
+func (e *Editor) sessionSaver() {
+	for {
+		time.Sleep(SessionSaveInterval)
+		core.Bus.Dispatch(sessionSaveAction{})
+	}
+}
+
+type sessionSaveAction struct{}
+
+func (a sessionSaveAction) Run() {
+	if err := core.Ed.(*Editor).SaveSession(); err != nil {
+		log.Printf("Failed to save the session : %s", err.Error())
+	}
+}
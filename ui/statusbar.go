@@ -26,23 +26,43 @@ func (s *Statusbar) Render() {
 		e.TermFB(t.StatusbarText, t.Statusbar.Bg)
 	}
 	e.TermStr(y1, x1, s.msg)
-	s.RenderPos()
+	x2 = s.RenderPos()
+	s.renderTask(x2)
 }
 
-func (s *Statusbar) RenderPos() {
+// RenderPos renders the cursor position, right aligned, returns the column
+// it starts at.
+func (s *Statusbar) RenderPos() int {
 	e := core.Ed
 	t := e.Theme()
 	y1, _, _, x2 := s.Bounds()
 	e.TermFB(t.StatusbarText, t.Statusbar.Bg)
 	vid := e.CurViewId()
 	if vid < 0 {
-		return
+		return x2
 	}
 	v := e.ViewById(vid)
 	if v == nil || v.Backend() == nil {
-		return
+		return x2
 	}
 	ln, col := v.CurLine(), v.LineRunesTo(v.Slice(), v.CurLine(), v.CurCol())
 	pos := fmt.Sprintf(" %d:%d [%d]", ln+1, col+1, v.LineCount())
 	e.TermStr(y1, x2-len(pos), pos)
+	return x2 - len(pos)
+}
+
+// renderTask renders the status of the latest task run, ending at column x2.
+func (s *Statusbar) renderTask(x2 int) {
+	e, ok := core.Ed.(*Editor)
+	if !ok || e.lastTask == nil {
+		return
+	}
+	t := e.Theme()
+	if e.lastTask.status == taskFailed {
+		e.TermFB(t.StatusbarTextErr, t.Statusbar.Bg)
+	} else {
+		e.TermFB(t.StatusbarText, t.Statusbar.Bg)
+	}
+	status := fmt.Sprintf(" [%s]", e.lastTask)
+	e.TermStr(s.Y1(), x2-len(status), status)
 }
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcolar/goed/backend"
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/tasks"
+)
+
+// Task run status
+const (
+	taskRunning = "running"
+	taskOk      = "ok"
+	taskFailed  = "failed"
+	taskStopped = "stopped" // its view was closed
+)
+
+// taskRun is the latest run of a project task (.goed/tasks.toml).
+type taskRun struct {
+	tasks    *tasks.Tasks
+	task     *tasks.Task
+	viewId   int64 // output view
+	status   string
+	exitCode int
+	problems int
+}
+
+// String returns the run status as shown in the status bar.
+func (r *taskRun) String() string {
+	s := fmt.Sprintf("%s: %s", r.task.Name, r.status)
+	if r.status == taskFailed && r.problems > 0 {
+		s += fmt.Sprintf(", %d problem(s)", r.problems)
+	}
+	return s
+}
+
+// loadTasks loads the tasks of the project dir is in, or if empty the one of
+// the current view.
+func (e *Editor) loadTasks(dir string) (*tasks.Tasks, error) {
+	if len(dir) == 0 {
+		dir = "."
+		if v := e.CurView(); v != nil {
+			dir = v.WorkDir()
+		}
+	}
+	return tasks.Load(dir)
+}
+
+// Tasks returns the task names of the project dir is in, or if empty the one
+// of the current view.
+func (e *Editor) Tasks(dir string) ([]string, error) {
+	ts, err := e.loadTasks(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ts.Names(), nil
+}
+
+// RunTask runs the named task of the current view project, returns the id of
+// the view showing its output.
+func (e *Editor) RunTask(name string) (int64, error) {
+	ts, err := e.loadTasks("")
+	if err != nil {
+		return -1, err
+	}
+	return e.runTask(ts, name)
+}
+
+// RerunTask runs the last run task again, the tasks file being read again.
+func (e *Editor) RerunTask() (int64, error) {
+	if e.lastTask == nil {
+		return -1, fmt.Errorf("No task run yet")
+	}
+	ts, err := e.loadTasks(e.lastTask.tasks.Dir)
+	if err != nil {
+		return -1, err
+	}
+	return e.runTask(ts, e.lastTask.task.Name)
+}
+
+// runTask runs a task in a new view, replacing the view of its previous run
+// (stopped if still running) if still open.
+func (e *Editor) runTask(ts *tasks.Tasks, name string) (int64, error) {
+	t, found := ts.Tasks[name]
+	if !found {
+		return -1, fmt.Errorf("No such task : %s", name)
+	}
+	if len(t.Matcher) > 0 {
+		if _, found := e.matchers[t.Matcher]; !found {
+			return -1, fmt.Errorf("No such problem matcher : %s", t.Matcher)
+		}
+	}
+	key := ts.Loc + ":" + name
+	var v *View
+	if prev, found := e.taskRuns[key]; found {
+		if old, found := e.views[prev.viewId]; found && e.ViewColumn(old.Id()) != nil {
+			v = e.NewView("")
+			e.ReplaceView(old, v)
+			if e.problems != nil && e.problems.viewId == old.Id() {
+				e.problems.viewId = v.Id()
+			}
+		}
+	}
+	if v == nil {
+		v = e.AddViewSmart(nil)
+	}
+	run := &taskRun{tasks: ts, task: t, viewId: v.Id(), status: taskRunning}
+	e.taskRuns[key] = run
+	e.lastTask = run
+	title := fmt.Sprintf("%s: %s", name, strings.Join(t.Cmd, " "))
+	v.highlighter = &TermHighlighter{}
+	b, err := backend.NewMemBackendCmdEnv(t.Cmd, t.Env, ts.WorkDir(t), v.Id(), &title, false)
+	if err != nil {
+		run.status = taskFailed
+		return v.Id(), err
+	}
+	b.MaxRows = e.Config().MaxCmdBufferLines
+	b.MaxLogSize = e.Config().CmdOutputLogSize
+	v.backend = b
+	core.Events.Publish(core.Event{Type: core.EvtViewOpened, ViewId: v.Id(), Loc: ts.WorkDir(t)})
+	e.SetStatus("Running task " + name)
+	return v.Id(), nil
+}
+
+// taskRunOf returns the latest task run whose output is in the view, if any.
+func (e *Editor) taskRunOf(viewId int64) *taskRun {
+	for _, run := range e.taskRuns {
+		if run.viewId == viewId {
+			return run
+		}
+	}
+	return nil
+}
+
+// taskDone records the outcome of a task run, once its command completed.
+func (e *Editor) taskDone(run *taskRun, exitCode, problems int) {
+	run.exitCode, run.problems = exitCode, problems
+	if exitCode != 0 {
+		run.status = taskFailed
+		msg := fmt.Sprintf("Task %s failed (exit code %d)", run.task.Name, exitCode)
+		if problems > 0 {
+			msg += fmt.Sprintf(", %d problem(s) reported", problems)
+		}
+		e.SetStatusErr(msg)
+		return
+	}
+	run.status = taskOk
+	e.SetStatus(fmt.Sprintf("Task %s ok", run.task.Name))
+}
+
+// trustedTasksLoc is the file recording the tasks files trusted to run tasks
+// on save.
+func trustedTasksLoc() string {
+	return path.Join(core.Home, "trusted_tasks.json")
+}
+
+// TrustTasks trusts the tasks file of the current view project to run its
+// tasks on save (OnSave), until it changes.
+func (e *Editor) TrustTasks() error {
+	ts, err := e.loadTasks("")
+	if err != nil {
+		return err
+	}
+	if err := ts.Trust(trustedTasksLoc()); err != nil {
+		return err
+	}
+	e.SetStatus("Trusted " + ts.Loc)
+	return nil
+}
+
+// saveTasks runs the tasks of the project of a saved file triggered by it
+// (OnSave), provided the tasks file was trusted (see TrustTasks).
+func (e *Editor) saveTasks(loc string) {
+	dir := filepath.Dir(loc)
+	ts, err := tasks.Load(dir)
+	if err != nil {
+		if len(tasks.Find(dir)) > 0 {
+			e.SetStatusErr(err.Error()) // invalid tasks file
+		}
+		return
+	}
+	triggered := ts.OnSave(loc)
+	if len(triggered) == 0 {
+		return
+	}
+	if !ts.Trusted(trustedTasksLoc()) {
+		e.SetStatusErr(fmt.Sprintf("Not running tasks on save, untrusted %s (see 'trust')", ts.Loc))
+		return
+	}
+	for _, t := range triggered {
+		if _, err := e.runTask(ts, t.Name); err != nil {
+			e.SetStatusErr(err.Error())
+		}
+	}
+}
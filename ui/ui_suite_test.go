@@ -24,6 +24,7 @@ func (s *UiSuite) SetUpSuite(c *C) {
 	core.InitHome(time.Now().Unix())
 	core.Ed = NewMockEditor()
 	core.Bus = actions.NewActionBus()
+	actions.RegisterActions()
 	// Note: not starting the action bus in UI tests so not to have to worry
 	// about potential races internally.
 	//go core.Bus.Start()
@@ -8,6 +8,7 @@ import (
 
 	"github.com/tcolar/goed/backend"
 	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/lsp"
 	"github.com/tcolar/goed/ui/widgets"
 )
 
@@ -28,8 +29,13 @@ type View struct {
 	offx, offy       int
 	HeightRatio      float64
 	selections       []core.Selection
+	carets           []textPos       // extra carets (multi cursor), besides the cursor
+	find             *viewFind       // current search, if any
+	replace          *projectReplace // pending project replacement (preview)
+	lsp              *lsp.Client     // language server client, if any
 	title            string
-	lastCloseTs      time.Time   // Timestamp of previous view close request
+	conflict         bool        // file changed on disk while dirty, see ResolveConflict
+	dropped          int         // command output rows dropped, see followDrops
 	slice            *core.Slice // curSlice
 	autoScrollX      int
 	autoScrollY      int
@@ -73,6 +79,7 @@ func (v *View) Reset() {
 }
 
 func (v *View) Render() {
+	v.followDrops()
 	e := core.Ed
 	t := e.Theme()
 	e.TermFB(t.Viewbar.Fg, t.Viewbar.Bg)
@@ -84,16 +91,23 @@ func (v *View) Render() {
 	}
 	e.TermFB(fg, t.Viewbar.Bg)
 	ti := v.Title()
+	if v.pinned() {
+		ti = "[PINNED] " + ti
+	}
 	if x2-x1 > 4 && x1+len(ti) > x2-4 {
 		ti = ti[:x2-x1-4]
 	}
 	e.TermStr(y1, x1+2, ti)
+	v.renderBanner()
 	v.renderClose()
 	v.renderScroll()
 	v.renderIsDirty()
 	v.renderMargin()
 	if v.backend != nil {
 		v.renderText()
+		v.renderDiagnostics()
+		v.renderProblems()
+		v.renderCarets()
 	}
 }
 
@@ -165,7 +179,7 @@ func (v *View) renderText() {
 	fg := t.Fg
 	bg := t.Bg
 	e.TermFB(fg, bg)
-	inSelection := false
+	inSelection, inMatch := false, false
 	tab := string(t.TabChar.Rune)
 	for j := 1; j < tabSize; j++ {
 		tab += " "
@@ -187,6 +201,7 @@ func (v *View) renderText() {
 			y++
 			continue
 		}
+		matches := v.lineMatches(l, false)
 		start := 0
 		if v.offx > 0 {
 			// More text to our left
@@ -211,10 +226,13 @@ func (v *View) renderText() {
 			sx := v.offx + x - 2 - x1
 			sx = v.LineRunesTo(v.slice, sy, sx)
 			selected, _ := v.Selected(sx, sy)
-			if selected != inSelection {
-				inSelection = selected
+			matched := !selected && isMatch(matches, sx)
+			if selected != inSelection || matched != inMatch {
+				inSelection, inMatch = selected, matched
 				if selected {
 					fg, bg = t.FgSelect, t.BgSelect
+				} else if matched {
+					fg, bg = t.FgMatch, t.BgMatch
 				} else {
 					fg, bg = t.Fg, t.Bg
 				}
@@ -229,7 +247,7 @@ func (v *View) renderText() {
 				e.TermChar(y, x, 0x1A) // ASCII substitute char (invisible)
 				e.TermFB(fg, bg)
 			} else { // normal char
-				if e.Config().SyntaxHighlighting && !inSelection {
+				if e.Config().SyntaxHighlighting && !inSelection && !inMatch {
 					v.highlighter.ApplyHighlight(v, v.offy, lnc, start+colc)
 				}
 				e.TermChar(y, x, c)
@@ -309,6 +327,7 @@ func (v *View) MoveCursor(y, x int) {
 // This makes all the checks to make sure it's in a valid location,
 // as well as scrolling the view as needed.
 func (v *View) SetCursorPos(y, x int) {
+	prevY, prevX := v.CursorY+v.offy, v.CursorX+v.offx
 	lastLine := v.LineCount()
 	ln := y
 	if ln < 0 {
@@ -357,6 +376,10 @@ func (v *View) SetCursorPos(y, x int) {
 	v.CursorY = ln - v.offy
 	v.CursorX = col - v.offx
 	v.updateCursor(slice)
+	if ln != prevY || col != prevX {
+		tl, tc := v.CurTextPos()
+		core.Events.Publish(core.Event{Type: core.EvtCursorMoved, ViewId: v.id, Line: tl + 1, Col: tc + 1})
+	}
 }
 
 // Update the editor cursor to be this view current cursor
@@ -415,26 +438,17 @@ func (v *View) CurCol() int {
 	return v.CursorX + v.offx
 }
 
+// cursorTermPos returns the terminal location of the cursor.
+func (v *View) cursorTermPos() (y, x int) {
+	y1, x1, _, _ := v.Bounds()
+	return v.CurLine() + y1 - v.offy + 2, v.CurCol() + x1 - v.offx + 2
+}
+
 // Return the postion of the cursor in the view's text.
 func (v *View) CurTextPos() (ln int, col int) {
 	return v.CurLine(), v.LineRunesTo(v.Slice(), v.CurLine(), v.CurCol())
 }
 
-// canClose checks if the view can be closed
-// that is true if the view is not dirty
-// otherwise, if dirty, returns true if we get 2 lose request in a short timespan
-func (v *View) canClose() bool {
-	if !v.Dirty() {
-		return true
-	}
-	if v.lastCloseTs.IsZero() || time.Now().Sub(v.lastCloseTs) > 10*time.Second {
-		v.lastCloseTs = time.Now()
-		return false
-	}
-	// 2 "quick" close request in a row
-	return true
-}
-
 func (v *View) Backend() core.Backend {
 	return v.backend
 }
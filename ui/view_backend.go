@@ -2,25 +2,129 @@ package ui
 
 import (
 	"bytes"
+	"fmt"
+	"log"
 	"unicode/utf8"
 
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
+// Save saves the view to its source, see save.
 func (v *View) Save() {
+	v.save(nil)
+}
+
+// save runs the pre save hooks, saves the view to its source then runs the
+// post save hooks and the project tasks triggered by saving it.
+// The pre save hooks (formatters) run in the background, the view being
+// saved once they are done. If the file was changed on disk by another
+// program the save is refused, and the user prompted to overwrite it.
+// done, if not nil, is called with whether the view was saved.
+func (v *View) save(done func(saved bool)) {
+	if done == nil {
+		done = func(bool) {}
+	}
+	loc := v.backend.SrcLoc()
+	hooks := saveHooks(core.Ed.Config().SaveHooks, core.SaveHookPre, loc)
+	if len(hooks) == 0 {
+		v.write(loc, nil, done)
+		return
+	}
+	text := v.text()
+	go func() {
+		formatted, failures := runPreSaveHooks(hooks, loc, text)
+		core.Bus.Dispatch(preSaveDone{v: v, loc: loc, text: text, formatted: formatted,
+			failures: failures, done: done})
+	}()
+}
+
+// preSaveDone applies the outcome of the pre save hooks, then saves the view.
+type preSaveDone struct {
+	v               *View
+	loc             string
+	text, formatted string // view text, and once formatted
+	failures        []error
+	done            func(saved bool)
+}
+
+func (a preSaveDone) Run() {
+	v := a.v
+	if v.backend == nil || v.backend.SrcLoc() != a.loc {
+		a.done(false) // closed or reused meanwhile
+		return
+	}
+	if v.text() != a.text {
+		a.failures = append(a.failures, fmt.Errorf("%s changed while formatting, not formatted", v.Title()))
+	} else {
+		actions.UndoBegin(v.Id())
+		v.setText(a.formatted)
+		actions.UndoEnd(v.Id())
+	}
+	v.write(a.loc, a.failures, a.done)
+}
+
+// write writes the view to loc, then runs the post save hooks and tasks.
+func (v *View) write(loc string, failures []error, done func(saved bool)) {
 	e := core.Ed
-	err := v.backend.Save(v.backend.SrcLoc())
+	err := v.backend.Save(loc)
+	if core.IsChangedOnDisk(err) {
+		e.SetStatusErr(err.Error())
+		v.overwritePrompt(loc, failures, done)
+		return
+	}
 	if err != nil {
 		e.SetStatusErr("Saving Failed " + err.Error())
+		done(false)
 		return
 	}
 	v.SetDirty(false)
-	e.SetStatus("Saved " + v.backend.SrcLoc())
+	v.conflict = false
+	v.lspSave()
+	e.SetStatus("Saved " + loc)
+	core.Events.Publish(core.Event{Type: core.EvtSaved, ViewId: v.Id(), Loc: loc})
+	if err = actions.UndoSave(v.Id(), loc); err != nil {
+		log.Printf("Failed to save undo history : %s", err.Error())
+	}
+	v.postSave(loc, failures)
+	core.Ed.(*Editor).saveTasks(loc)
+	done(true)
+}
+
+// Choices offered when saving a file changed on disk.
+const (
+	overwriteYes    = "overwrite"
+	overwriteDiff   = "diff"
+	overwriteCancel = "cancel"
+)
+
+// overwritePrompt asks whether to overwrite the view file, changed on disk.
+// Overwriting writes the view as is, the pre save hooks having run already.
+func (v *View) overwritePrompt(loc string, failures []error, done func(saved bool)) {
+	e := core.Ed.(*Editor)
+	question := v.Title() + " changed on disk, overwrite it?"
+	choices := []string{overwriteYes, overwriteDiff, overwriteCancel}
+	e.Prompt(question, choices, 2, func(choice string) {
+		switch choice {
+		case overwriteYes:
+			v.backend.Stamp()
+			v.write(loc, failures, done)
+			return
+		case overwriteDiff:
+			if err := v.diskDiff(); err != nil {
+				e.SetStatusErr(err.Error())
+			}
+		}
+		done(false)
+	})
 }
 
 // InsertCur inserts text at the current location.
 func (v *View) InsertCur(s string) {
+	if v.multiCursor() {
+		v.multiInsert(s)
+		return
+	}
 	_, y, x := v.CurChar()
 	if len(v.selections) > 0 {
 		s := v.selections[0]
@@ -43,11 +147,13 @@ func (v *View) Insert(line, col int, s string, undoable bool) {
 			s += string(v.lineIndent(line))
 		}
 	}
+	r := v.lspInsertRange(line, col)
 	err := v.backend.Insert(line, col, s)
 	if err != nil {
 		e.SetStatusErr("Insert Failed " + err.Error())
 		return
 	}
+	v.lspChange(r, s)
 
 	// move the cursor to after insertion
 	b := []byte(s)
@@ -57,6 +163,7 @@ func (v *View) Insert(line, col int, s string, undoable bool) {
 	if line == endLn {
 		endCol += col
 	}
+	v.bufferChanged(line, endLn)
 
 	if undoable {
 		actions.UndoAdd(
@@ -94,12 +201,23 @@ func (v *View) InsertNewLine(line, col int) {
 	v.Insert(line, col, "\n", true)
 }
 
+// Reload reloads the view from its source, keeping the cursor and scroll
+// position.
 func (v *View) Reload() {
+	ln, col := v.CurTextPos()
+	offy, offx := v.ScrollPos()
 	err := v.backend.Reload()
 	if err != nil {
 		core.Ed.SetStatusErr(err.Error())
 	}
+	v.conflict = false
+	v.SetScrollPos(offy, offx)
+	v.SyncSlice()
+	v.SetCursorPos(ln, col)
+	v.lspReload()
+	v.bufferChanged(0, v.LineCount()-1)
 	actions.UndoClear(v.Id())
+	actions.UndoLoad(v.Id(), v.backend.SrcLoc())
 	v.Render()
 	core.Ed.TermFlush()
 }
@@ -111,11 +229,14 @@ func (v *View) Delete(line1, col1, line2, col2 int, undoable bool) {
 	v.SetDirty(true)
 	s := core.NewSelection(line1, col1, line2, col2)
 	text := core.RunesToString(v.SelectionText(s))
+	r := v.lspDeleteRange(line1, col1, line2, col2)
 	err := v.backend.Remove(line1, col1, line2, col2)
 	if err != nil {
 		core.Ed.SetStatusErr("Delete Failed " + err.Error())
 		return
 	}
+	v.lspChange(r, "")
+	v.bufferChanged(line1, line2)
 	if undoable {
 		actions.UndoAdd(
 			v.Id(),
@@ -133,8 +254,23 @@ func (v *View) Delete(line1, col1, line2, col2 int, undoable bool) {
 	v.SetCursorPos(line1, col1)
 }
 
+// bufferChanged publishes a change of the view text, lines are 0 indexed.
+func (v *View) bufferChanged(line1, line2 int) {
+	core.Events.Publish(core.Event{
+		Type:     core.EvtBufferChanged,
+		ViewId:   v.Id(),
+		Loc:      v.backend.SrcLoc(),
+		FromLine: line1 + 1,
+		ToLine:   line2 + 1,
+	})
+}
+
 // DeleteCur removes a selection or the curent character
 func (v *View) DeleteCur() {
+	if v.multiCursor() {
+		v.multiDelete(false)
+		return
+	}
 	c, y, x := v.CurChar()
 	if len(v.selections) > 0 {
 		s := v.selections[0]
@@ -150,6 +286,10 @@ func (v *View) DeleteCur() {
 
 // Backspace removes a selection or character before the current location
 func (v *View) Backspace() {
+	if v.multiCursor() {
+		v.multiDelete(true)
+		return
+	}
 	if v.CurLine() == 0 && v.CurCol() == 0 {
 		return
 	}
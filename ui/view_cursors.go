@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// textPos is a text location (0 indexed line and rune column).
+type textPos struct {
+	ln, col int
+}
+
+func (p textPos) before(o textPos) bool {
+	return p.ln < o.ln || (p.ln == o.ln && p.col < o.col)
+}
+
+// editTarget is a location affected by a multi cursor edit, either a
+// caret (from == end) or a selection from "from" up to "end" (exclusive).
+type editTarget struct {
+	from, end textPos
+	main      bool // whether it holds the main cursor
+}
+
+// AddCursor adds an extra caret at the given text location.
+func (v *View) AddCursor(ln, col int) {
+	if ln < 0 || ln >= v.LineCount() {
+		return
+	}
+	if l := v.LineLen(v.slice, ln); col > l {
+		col = l
+	}
+	if col < 0 {
+		col = 0
+	}
+	p := textPos{ln, col}
+	cl, cc := v.CurTextPos()
+	if p == (textPos{cl, cc}) {
+		return
+	}
+	for _, c := range v.carets {
+		if c == p {
+			return
+		}
+	}
+	v.carets = append(v.carets, p)
+}
+
+// AddNextOccurrence selects the next occurrence of the last selection's text
+// (wrapping around), or the word under the cursor if there is no selection.
+func (v *View) AddNextOccurrence() {
+	if len(v.selections) == 0 {
+		ln, col := v.CurTextPos()
+		s := v.ExpandSelectionWord(ln, col)
+		if s == nil {
+			return
+		}
+		v.selections = []core.Selection{*s}
+		v.SetCursorPos(s.LineTo, s.ColTo+1)
+		return
+	}
+	last := v.selections[len(v.selections)-1]
+	needle := []rune(core.RunesToString(v.SelectionText(&last)))
+	if len(needle) == 0 {
+		return
+	}
+	lines := *v.backend.Slice(0, 0, -1, -1).Text()
+	text := []rune(core.RunesToString(lines))
+	from := v.runeOffset(lines, last.LineFrom, last.ColFrom) + len(needle)
+	// selects the first match starting in [start, end) not already selected
+	next := func(start, end int) bool {
+		for at := runesIndex(text, needle, start); at >= 0 && at < end; at = runesIndex(text, needle, at+1) {
+			ln, col := v.offsetPos(lines, at)
+			lnTo, colTo := v.offsetPos(lines, at+len(needle)-1)
+			s := core.NewSelection(ln, col, lnTo, colTo)
+			if v.isSelection(s) {
+				continue
+			}
+			v.selections = append(v.selections, *s)
+			v.SetCursorPos(lnTo, colTo+1)
+			return true
+		}
+		return false
+	}
+	if !next(from, len(text)+1) {
+		next(0, from)
+	}
+}
+
+func (v *View) isSelection(s *core.Selection) bool {
+	for _, sel := range v.selections {
+		if sel == *s {
+			return true
+		}
+	}
+	return false
+}
+
+// runeOffset returns the rune offset of a text location within lines.
+func (v *View) runeOffset(lines [][]rune, ln, col int) int {
+	offset := col
+	for i := 0; i < ln && i < len(lines); i++ {
+		offset += len(lines[i]) + 1
+	}
+	return offset
+}
+
+// offsetPos returns the text location of a rune offset within lines.
+func (v *View) offsetPos(lines [][]rune, offset int) (ln, col int) {
+	for ln = 0; ln < len(lines)-1 && offset > len(lines[ln]); ln++ {
+		offset -= len(lines[ln]) + 1
+	}
+	return ln, offset
+}
+
+// runesIndex returns the index of the first instance of needle in hay,
+// starting at from, or -1.
+func runesIndex(hay, needle []rune, from int) int {
+outer:
+	for i := from; i <= len(hay)-len(needle); i++ {
+		for j, r := range needle {
+			if hay[i+j] != r {
+				continue outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// multiCursor returns whether there are several locations to edit at once.
+func (v *View) multiCursor() bool {
+	return len(v.carets) > 0 || len(v.selections) > 1
+}
+
+// editTargets returns the selections and carets to be edited, last in the
+// text first, skipping overlapping ones.
+func (v *View) editTargets() []editTarget {
+	cl, cc := v.CurTextPos()
+	cur := textPos{cl, cc}
+	targets := []editTarget{}
+	inSelection := false
+	for _, s := range v.selections {
+		t := editTarget{from: textPos{s.LineFrom, s.ColFrom}}
+		colTo := s.ColTo
+		if colTo == -1 || colTo >= v.LineLen(v.slice, s.LineTo) {
+			t.end = textPos{s.LineTo + 1, 0}
+		} else {
+			t.end = textPos{s.LineTo, colTo + 1}
+		}
+		if !cur.before(t.from) && !t.end.before(cur) {
+			t.main = true
+			inSelection = true
+		}
+		targets = append(targets, t)
+	}
+	for _, c := range v.carets {
+		targets = append(targets, editTarget{from: c, end: c})
+	}
+	if !inSelection {
+		targets = append(targets, editTarget{from: cur, end: cur, main: true})
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[j].from.before(targets[i].from)
+	})
+	result := []editTarget{}
+	for _, t := range targets {
+		if len(result) > 0 {
+			prev := &result[len(result)-1]
+			if t.from == prev.from || prev.from.before(t.end) {
+				prev.main = prev.main || t.main
+				continue // overlapping
+			}
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// multiEdit replaces every edit target with the text returned by edit, as a
+// single undo step. Afterwards each target becomes a caret located after
+// its new text.
+func (v *View) multiEdit(edit func(i int, t editTarget) (from, end textPos, text string)) {
+	targets := v.editTargets()
+	v.carets = []textPos{}
+	done := []editTarget{}
+	actions.UndoBegin(v.Id())
+	for i, t := range targets {
+		from, end, text := edit(i, t)
+		if from.before(end) {
+			last := end
+			if last.col > 0 {
+				last.col--
+			} else {
+				last.ln--
+				last.col = v.LineLen(v.slice, last.ln)
+			}
+			v.Delete(from.ln, from.col, last.ln, last.col, true)
+		}
+		if len(text) > 0 {
+			v.Insert(from.ln, from.col, text, true)
+		}
+		newEnd := from
+		if len(text) > 0 {
+			newEnd.ln, newEnd.col = v.CurTextPos()
+		}
+		// shift the carets of the edits located after this one
+		for j := range done {
+			p := &done[j].from
+			if p.ln == end.ln {
+				p.ln, p.col = newEnd.ln, newEnd.col+p.col-end.col
+			} else {
+				p.ln += newEnd.ln - end.ln
+			}
+		}
+		done = append(done, editTarget{from: newEnd, end: newEnd, main: t.main})
+	}
+	actions.UndoEnd(v.Id())
+	v.selections = []core.Selection{}
+	var main *textPos
+	for i := range done {
+		if done[i].main && main == nil {
+			main = &done[i].from
+			continue
+		}
+		v.carets = append(v.carets, done[i].from)
+	}
+	if main == nil && len(v.carets) > 0 {
+		main = &v.carets[len(v.carets)-1]
+		v.carets = v.carets[:len(v.carets)-1]
+	}
+	if main != nil {
+		v.SetCursorPos(main.ln, main.col)
+	}
+}
+
+// multiInsert inserts the text at all the edit targets.
+func (v *View) multiInsert(s string) {
+	v.multiEdit(func(i int, t editTarget) (textPos, textPos, string) {
+		return t.from, t.end, s
+	})
+}
+
+// multiPaste pastes the text at all the edit targets, if the text has
+// as many lines as there are targets, each gets one line.
+func (v *View) multiPaste(s string) {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	count := len(v.editTargets())
+	v.multiEdit(func(i int, t editTarget) (textPos, textPos, string) {
+		if len(lines) == count {
+			// targets are last first
+			return t.from, t.end, lines[count-1-i]
+		}
+		return t.from, t.end, s
+	})
+}
+
+// multiDelete deletes the selections, and the character at (or before if
+// backspace) the carets.
+func (v *View) multiDelete(backspace bool) {
+	v.multiEdit(func(i int, t editTarget) (textPos, textPos, string) {
+		if t.from != t.end {
+			return t.from, t.end, ""
+		}
+		p := t.from
+		if backspace {
+			if p.col > 0 {
+				return textPos{p.ln, p.col - 1}, p, ""
+			}
+			if p.ln > 0 {
+				return textPos{p.ln - 1, v.LineLen(v.slice, p.ln-1)}, p, ""
+			}
+			return p, p, ""
+		}
+		if p.col < v.LineLen(v.slice, p.ln) {
+			return p, textPos{p.ln, p.col + 1}, ""
+		}
+		if p.ln < v.LineCount()-1 {
+			return p, textPos{p.ln + 1, 0}, ""
+		}
+		return p, p, ""
+	})
+}
+
+// renderCarets renders the extra carets (the main cursor is rendered by the
+// editor).
+func (v *View) renderCarets() {
+	e := core.Ed
+	t := e.Theme()
+	y1, x1, _, _ := v.Bounds()
+	for _, c := range v.carets {
+		if c.ln < v.offy || c.ln > v.offy+v.LastViewLine() {
+			continue
+		}
+		x := v.lineColsTo(v.slice, c.ln, c.col) - v.offx
+		if x < 0 || x > v.LastViewCol() {
+			continue
+		}
+		r := ' '
+		if ln := v.Line(v.slice, c.ln); c.col < len(ln) && ln[c.col] >= 32 {
+			r = ln[c.col]
+		}
+		e.TermFB(t.BgCursor, t.FgCursor)
+		e.TermChar(y1+2+c.ln-v.offy, x1+2+x, r)
+	}
+	e.TermFB(t.Fg, t.Bg)
+}
@@ -1,13 +1,34 @@
 package ui
 
 import (
+	"bytes"
+	"fmt"
+	osexec "os/exec"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/core"
 )
 
+// Choices offered when the file of a dirty view changed on disk.
+const (
+	conflictReload = "reload" // drop the view changes, reload from disk
+	conflictKeep   = "keep"   // keep the view content, saving overwrites the file
+	conflictDiff   = "diff"   // show the differences between the file and the view
+)
+
+var conflictChoices = []string{conflictReload, conflictKeep, conflictDiff}
+
+// Choices offered when the file of a dirty view was deleted on disk.
+const (
+	deletedKeep  = "keep"  // keep the view, saving recreates the file
+	deletedClose = "close" // close the view, dropping its changes
+)
+
+const conflictBanner = "Changed on disk :"
+
 // Handle filewatcher events
 func (v *View) fileEvent(op core.FileOp, loc string) {
 	if v.Backend() == nil {
@@ -19,21 +40,23 @@ func (v *View) fileEvent(op core.FileOp, loc string) {
 	src, _ := filepath.Abs(v.Backend().SrcLoc())
 	switch v.Type() {
 	case core.ViewTypeStandard:
-		if src == loc && op == core.OpChmod {
-			// reload, idf dirty, ask
-			if !v.dirty {
-				actions.Ar.ViewReload(v.id)
-			}
+		if src != loc {
+			break
+		}
+		if op&(core.OpCreate|core.OpWrite|core.OpChmod) != 0 {
+			v.diskChanged()
 		}
-		if src == loc && (op == core.OpRemove || op == core.OpRename) {
-			// close view, if dirty, ask
+		// Note: renames are resolved by the file watcher (moved or removed)
+		if op&core.OpRemove != 0 {
 			if !v.dirty {
 				actions.Ar.EdDelView(v.id, true)
+			} else {
+				v.diskDeleted()
 			}
 		}
 	case core.ViewTypeDirListing:
 		if parent == wd && (op == core.OpCreate || op == core.OpRemove || op == core.OpRename) {
-			actions.Ar.ViewReload(v.id)
+			actions.Ar.ViewReload(v.id, false)
 		}
 		if loc == wd && (op == core.OpRename || op == core.OpRemove) {
 			actions.Ar.EdDelView(v.id, true)
@@ -44,3 +67,163 @@ func (v *View) fileEvent(op core.FileOp, loc string) {
 		// nothing
 	}
 }
+
+// fileMoved handles a watched file being moved from one location to another,
+// views of that file follow it.
+func (v *View) fileMoved(from, to string) {
+	if v.Backend() == nil {
+		return
+	}
+	wd, _ := filepath.Abs(v.workDir)
+	src, _ := filepath.Abs(v.Backend().SrcLoc())
+	switch v.Type() {
+	case core.ViewTypeStandard:
+		if src != from {
+			return
+		}
+		v.lspClose()
+		v.backend.SetSrcLoc(to)
+		v.SetTitle(filepath.Base(to))
+		v.SetWorkDir(filepath.Dir(to))
+		v.lspOpen()
+		core.Ed.SetStatus(fmt.Sprintf("%s was moved to %s", filepath.Base(from), to))
+	case core.ViewTypeDirListing:
+		if path.Dir(from) == wd || path.Dir(to) == wd {
+			actions.Ar.ViewReload(v.id, false)
+		}
+	}
+}
+
+// diskChanged handles the view file being modified by another program :
+// a clean view is reloaded, a dirty one shows the conflict banner and prompts
+// the user to resolve the conflict.
+func (v *View) diskChanged() {
+	if !v.backend.IsStale() {
+		return // ie: our own save
+	}
+	if !v.dirty {
+		v.Reload()
+		return
+	}
+	v.conflict = true
+	e := core.Ed.(*Editor)
+	e.SetStatusErr(v.Title() + " was changed on disk.")
+	e.Prompt(v.conflictQuestion(), conflictChoices, 1, func(choice string) {
+		if choice == "" {
+			return // the banner is still there
+		}
+		if err := v.ResolveConflict(choice); err != nil {
+			e.SetStatusErr(err.Error())
+		}
+	})
+	e.Render()
+}
+
+func (v *View) conflictQuestion() string {
+	return v.Title() + " was changed on disk."
+}
+
+// diskDeleted handles the file of a dirty view being deleted by another
+// program, the user is prompted to keep the view, or close it.
+func (v *View) diskDeleted() {
+	e := core.Ed.(*Editor)
+	e.SetStatusErr(v.Title() + " was deleted on disk, save to recreate it.")
+	choices := []string{deletedKeep, deletedClose}
+	e.Prompt(v.Title()+" was deleted on disk.", choices, 0, func(choice string) {
+		if choice == deletedClose {
+			e.DelView(v.Id(), true)
+		}
+	})
+	e.Render()
+}
+
+// ResolveConflict resolves a change of the view file on disk, failing if
+// there is none.
+func (v *View) ResolveConflict(choice string) error {
+	if !v.conflict {
+		return fmt.Errorf("%s was not changed on disk", v.Title())
+	}
+	if choice != conflictDiff {
+		core.Ed.(*Editor).closePrompt(v.conflictQuestion())
+	}
+	switch choice {
+	case conflictReload:
+		v.Reload()
+	case conflictKeep:
+		v.backend.Stamp()
+		v.conflict = false
+		core.Ed.SetStatus("Keeping the view content, saving will overwrite " + v.Title())
+	case conflictDiff:
+		return v.diskDiff()
+	default:
+		return fmt.Errorf("Unexpected choice : %s", choice)
+	}
+	core.Ed.Render()
+	return nil
+}
+
+// diskDiff shows the differences between the file on disk and the view
+// content in a new view.
+func (v *View) diskDiff() error {
+	loc := v.backend.SrcLoc()
+	lines := *v.backend.Slice(0, 0, -1, -1).Text()
+	cmd := osexec.Command("diff", "-u", "-L", loc+" (disk)", "-L", loc+" (view)", loc, "-")
+	cmd.Stdin = strings.NewReader(core.RunesToString(lines) + "\n")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if exit, ok := err.(*osexec.ExitError); err != nil && (!ok || exit.ExitCode() != 1) {
+		return fmt.Errorf("diff failed : %s %s", err.Error(), out.String())
+	}
+	diff := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if out.Len() == 0 {
+		diff = []string{"No differences."}
+	}
+	e := core.Ed.(*Editor)
+	dv := e.newResultsView(filepath.Dir(loc), "diff: "+filepath.Base(loc), diff)
+	e.ViewActivate(dv.Id())
+	return nil
+}
+
+// renderBanner shows the conflict banner, with its choices, in the view bar.
+func (v *View) renderBanner() {
+	if !v.conflict {
+		return
+	}
+	e := core.Ed
+	t := e.Theme()
+	y1, x1, _, x2 := v.Bounds()
+	e.TermFB(t.Viewbar.Fg, t.Viewbar.Bg)
+	e.TermFill(t.Viewbar.Rune, y1, x1+1, y1, x2)
+	e.TermFB(t.StatusbarTextErr, t.Viewbar.Bg)
+	e.TermStr(y1, x1+2, conflictBanner)
+	e.TermFB(t.ViewbarText.WithAttr(core.Bold), t.Viewbar.Bg)
+	x := x1 + 2 + len(conflictBanner)
+	for _, c := range conflictChoices {
+		if x+len(c)+3 >= x2 {
+			break
+		}
+		e.TermStr(y1, x+1, "["+c+"]")
+		x += len(c) + 3
+	}
+}
+
+// BannerClick handles a click on the conflict banner choices.
+func (v *View) BannerClick(x int) bool {
+	if !v.conflict {
+		return false
+	}
+	// x is relative to the view, 1 indexed, the banner starts at x1+2
+	pos := len(conflictBanner) + 3
+	for _, c := range conflictChoices {
+		if x > pos && x <= pos+len(c)+2 {
+			if err := v.ResolveConflict(c); err != nil {
+				core.Ed.SetStatusErr(err.Error())
+			}
+			return true
+		}
+		pos += len(c) + 3
+	}
+	return false
+}
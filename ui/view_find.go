@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/search"
+)
+
+// viewFind holds a view search settings.
+type viewFind struct {
+	query string
+	regex bool
+	re    *regexp.Regexp
+	repl  string // replacement text
+}
+
+// textMatch is a match location within a line, runes from col up to end
+// (exclusive).
+type textMatch struct {
+	col, end int
+	// replacement text, only set by lineMatches when requested
+	repl string
+}
+
+// Find sets the view search query and selects the first match located
+// at or after the selection (or cursor).
+// If regex is false the query is a literal string.
+// An empty query clears the search.
+func (v *View) Find(query string, regex, ignoreCase bool) error {
+	if len(query) == 0 {
+		v.FindClear()
+		return nil
+	}
+	re, err := search.Query{Pattern: query, Regex: regex, IgnoreCase: ignoreCase}.Compile()
+	if err != nil {
+		return err
+	}
+	v.find = &viewFind{query: query, regex: regex, re: re}
+	// stay on the matches rather than following a command output
+	v.followDrops()
+	v.pin()
+	ln, col := v.CurTextPos()
+	if len(v.selections) > 0 {
+		s := v.selections[0]
+		ln, col = s.LineFrom, s.ColFrom
+	}
+	if !v.findFrom(ln, col, false) {
+		return fmt.Errorf("Not found : %s", query)
+	}
+	return nil
+}
+
+// FindClear clears the search query and match highlights.
+func (v *View) FindClear() {
+	v.find = nil
+}
+
+// FindNext selects the next (or previous if backward) match of the search
+// query, wrapping around the text if needed.
+// Returns false if there is no match.
+func (v *View) FindNext(backward bool) bool {
+	if v.find == nil {
+		return false
+	}
+	v.followDrops()
+	ln, col := v.CurTextPos()
+	if backward && len(v.selections) > 0 {
+		s := v.selections[0]
+		ln, col = s.LineFrom, s.ColFrom
+	}
+	return v.findFrom(ln, col, backward)
+}
+
+// findFrom selects the first match starting at or after ln, col, or if
+// backward, the last match starting before ln, col.
+func (v *View) findFrom(ln, col int, backward bool) bool {
+	lines := *v.backend.Slice(0, 0, -1, -1).Text()
+	count := len(lines)
+	for i := 0; i <= count; i++ {
+		l := (ln + i) % count
+		if backward {
+			l = (ln - i + count) % count
+		}
+		matches := v.lineMatches(lines[l], false)
+		if backward {
+			for j := len(matches) - 1; j >= 0; j-- {
+				if i == 0 && matches[j].col >= col {
+					continue
+				}
+				v.selectMatch(l, matches[j])
+				return true
+			}
+			continue
+		}
+		for _, m := range matches {
+			if i == 0 && m.col < col {
+				continue
+			}
+			if i == count && m.col >= col {
+				break // wrapped around
+			}
+			v.selectMatch(l, m)
+			return true
+		}
+	}
+	return false
+}
+
+func (v *View) selectMatch(ln int, m textMatch) {
+	v.selections = []core.Selection{*core.NewSelection(ln, m.col, ln, m.end-1)}
+	v.SetCursorPos(ln, m.end)
+}
+
+// lineMatches returns the (non empty) matches of the search query in a line.
+// If expand is true, the replacement text of each match is computed
+// from v.find.repl, expanding regexp groups ($1 etc..) if applicable.
+func (v *View) lineMatches(line []rune, expand bool) []textMatch {
+	matches := []textMatch{}
+	if v.find == nil {
+		return matches
+	}
+	s := string(line)
+	col, prev := 0, 0 // rune and byte offsets of the previous match
+	for _, m := range v.find.re.FindAllStringSubmatchIndex(s, -1) {
+		if m[1] == m[0] {
+			continue
+		}
+		col += utf8.RuneCountInString(s[prev:m[0]])
+		tm := textMatch{col: col, end: col + utf8.RuneCountInString(s[m[0]:m[1]])}
+		if expand {
+			tm.repl = v.find.repl
+			if v.find.regex {
+				tm.repl = string(v.find.re.ExpandString(nil, v.find.repl, s, m))
+			}
+		}
+		matches = append(matches, tm)
+		prev = m[0]
+	}
+	return matches
+}
+
+// isMatch returns whether the given text location is within a search match
+// amongst the given line matches.
+func isMatch(matches []textMatch, col int) bool {
+	for _, m := range matches {
+		if col >= m.col && col < m.end {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace replaces the selected match of the search query with the given text
+// and selects the next match, or if all is true replaces all the matches.
+// If the query is a regular expression, with can reference groups ($1 etc..)
+// Returns the number of replacements, done as a single undoable operation.
+func (v *View) Replace(with string, all bool) int {
+	if v.find == nil {
+		return 0
+	}
+	v.find.repl = with
+	count := 0
+	actions.UndoBegin(v.Id())
+	defer actions.UndoEnd(v.Id())
+	if all {
+		lines := *v.backend.Slice(0, 0, -1, -1).Text()
+		// last first, so that locations stay valid
+		for ln := len(lines) - 1; ln >= 0; ln-- {
+			matches := v.lineMatches(lines[ln], true)
+			for i := len(matches) - 1; i >= 0; i-- {
+				v.replaceMatch(ln, matches[i])
+				count++
+			}
+		}
+		v.ClearSelections()
+		return count
+	}
+	if len(v.selections) == 1 {
+		s := v.selections[0]
+		if s.LineFrom == s.LineTo {
+			for _, m := range v.lineMatches(v.Line(v.slice, s.LineFrom), true) {
+				if m.col == s.ColFrom && m.end == s.ColTo+1 {
+					v.replaceMatch(s.LineFrom, m)
+					count++
+					break
+				}
+			}
+		}
+	}
+	v.ClearSelections()
+	v.FindNext(false)
+	return count
+}
+
+func (v *View) replaceMatch(ln int, m textMatch) {
+	v.Delete(ln, m.col, ln, m.end-1, true)
+	if len(m.repl) > 0 {
+		v.Insert(ln, m.col, m.repl, true)
+	} else {
+		v.SetCursorPos(ln, m.col)
+	}
+}
@@ -0,0 +1,377 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/lsp"
+)
+
+// newLspManager creates the language servers manager from the configuration.
+func newLspManager(config *core.Config) *lsp.Manager {
+	names := []string{}
+	for name := range config.LspServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	servers := []lsp.Server{}
+	for _, name := range names {
+		s := config.LspServers[name]
+		id := s.LanguageId
+		if len(id) == 0 {
+			id = name
+		}
+		servers = append(servers, lsp.Server{Cmd: s.Cmd, Extensions: s.Extensions, LanguageId: id})
+	}
+	root, _ := filepath.Abs(".")
+	m := lsp.NewManager(servers, root)
+	m.OnDiagnostics = func(loc string, diags []lsp.Diagnostic) {
+		actions.Ar.EdRender()
+	}
+	return m
+}
+
+// lspOpen notifies the language server (if any) that the view file was
+// opened. The server is started in the background if needed, then attached
+// to the view on the action bus.
+func (v *View) lspOpen() {
+	e, ok := core.Ed.(*Editor)
+	if !ok || e.lsp == nil || v.backend == nil {
+		return
+	}
+	loc := v.backend.SrcLoc()
+	if _, found := e.lsp.ServerFor(loc); !found {
+		return
+	}
+	go func() {
+		c, server, err := e.lsp.Client(loc)
+		if err != nil {
+			log.Printf("Language server %s failed to start : %s", server.LanguageId, err.Error())
+			return
+		}
+		if c != nil {
+			lspDo(func() { v.lspAttach(c, server.LanguageId, loc) })
+		}
+	}()
+}
+
+// lspAttach attaches a (started) language server client to the view, provided
+// it still shows loc, and opens the document.
+func (v *View) lspAttach(c *lsp.Client, languageId, loc string) {
+	if v.lsp != nil || v.backend == nil || v.backend.SrcLoc() != loc {
+		return // closed or reused meanwhile
+	}
+	if c.IsOpen(loc) {
+		// already opened by another view : the document is synchronized with
+		// that view buffer only, this one takes over once it's closed.
+		return
+	}
+	v.lsp = c
+	if err := c.DidOpen(loc, languageId, v.lspText()); err != nil {
+		log.Printf("Language server didOpen failed : %s", err.Error())
+	}
+}
+
+// lspDo runs fn on the action bus, typically to apply the outcome of a
+// language server request made in the background.
+func lspDo(fn func()) {
+	core.Bus.Dispatch(lspDoAction{fn: fn})
+}
+
+type lspDoAction struct {
+	fn func()
+}
+
+func (a lspDoAction) Run() {
+	a.fn()
+}
+
+// lspClose notifies the language server that the view file was closed, then
+// hands the document over to another view of the file, if any.
+func (v *View) lspClose() {
+	if v.lsp == nil {
+		return
+	}
+	c := v.lsp
+	v.lsp = nil
+	if v.backend == nil {
+		return
+	}
+	loc := v.backend.SrcLoc()
+	c.DidClose(loc)
+	if e, ok := core.Ed.(*Editor); ok {
+		for _, vid := range e.ViewsByLoc(loc) {
+			if vv, found := e.views[vid]; found && vv != v && vv.lsp == nil {
+				vv.lspOpen()
+				return
+			}
+		}
+	}
+}
+
+// lspSave notifies the language server that the view file was saved.
+func (v *View) lspSave() {
+	if v.lsp != nil {
+		v.lsp.DidSave(v.backend.SrcLoc())
+	}
+}
+
+// lspReload resynchronizes the language server after a reload.
+func (v *View) lspReload() {
+	if v.lsp == nil {
+		return
+	}
+	v.lsp.DidClose(v.backend.SrcLoc())
+	v.lsp = nil
+	v.lspOpen()
+}
+
+// lspChange notifies the language server that the text range r was
+// replaced by text.
+func (v *View) lspChange(r lsp.Range, text string) {
+	if v.lsp == nil {
+		return
+	}
+	err := v.lsp.DidChange(v.backend.SrcLoc(), r, text, v.lspText)
+	if err != nil {
+		log.Printf("Language server didChange failed : %s", err.Error())
+	}
+}
+
+// lspPos returns the LSP position of a text position.
+func (v *View) lspPos(ln, col int) lsp.Position {
+	return lsp.Position{Line: ln, Character: lsp.UTF16Col(v.Line(v.slice, ln), col)}
+}
+
+// lspInsertRange returns the LSP range of an insertion at line, col.
+func (v *View) lspInsertRange(line, col int) lsp.Range {
+	if v.lsp == nil {
+		return lsp.Range{}
+	}
+	pos := v.lspPos(line, col)
+	return lsp.Range{Start: pos, End: pos}
+}
+
+// lspDeleteRange returns the LSP range of the text removed by Delete.
+// Must be called before the removal.
+func (v *View) lspDeleteRange(line1, col1, line2, col2 int) lsp.Range {
+	if v.lsp == nil {
+		return lsp.Range{}
+	}
+	end := v.lspPos(line2, col2+1)
+	if col2 < 0 {
+		end = lsp.Position{Line: line2}
+	} else if col2 >= v.LineLen(v.slice, line2) {
+		end = lsp.Position{Line: line2 + 1}
+		if line2+1 >= v.LineCount() { // end of text
+			end = v.lspPos(line2, col2+1)
+		}
+	}
+	return lsp.Range{Start: v.lspPos(line1, col1), End: end}
+}
+
+// lspText returns the whole view text.
+func (v *View) lspText() string {
+	lines := []string{}
+	for _, l := range *v.backend.Slice(0, 0, -1, -1).Text() {
+		lines = append(lines, string(l))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lspCursorPos returns the LSP position of the cursor.
+func (v *View) lspCursorPos() lsp.Position {
+	ln, col := v.CurTextPos()
+	return v.lspPos(ln, col)
+}
+
+func (v *View) lspClient() (*lsp.Client, error) {
+	if v.lsp == nil {
+		return nil, fmt.Errorf("No language server for this view")
+	}
+	return v.lsp, nil
+}
+
+// GotoDefinition opens the definition of the symbol under the cursor. The
+// server is queried in the background, done being called on the action bus.
+func (v *View) GotoDefinition(done func(err error)) {
+	c, err := v.lspClient()
+	if err != nil {
+		done(err)
+		return
+	}
+	loc, pos := v.backend.SrcLoc(), v.lspCursorPos()
+	go func() {
+		locs, err := c.Definition(loc, pos)
+		lspDo(func() {
+			if err == nil {
+				err = v.gotoDefinition(locs)
+			}
+			done(err)
+		})
+	}()
+}
+
+func (v *View) gotoDefinition(locs []lsp.Location) error {
+	if len(locs) == 0 {
+		return fmt.Errorf("No definition found")
+	}
+	ed := core.Ed.(*Editor)
+	loc := lsp.UriToLoc(locs[0].Uri)
+	vid := int64(-1)
+	var err error
+	if vids := ed.ViewsByLoc(loc); len(vids) > 0 {
+		vid = vids[0]
+	} else if vid, err = ed.Open(loc, -1, "", false); err != nil {
+		return err
+	}
+	vv := viewCast(ed.ViewById(vid))
+	if vv == nil {
+		return fmt.Errorf("No such view")
+	}
+	pos := locs[0].Range.Start
+	vv.SetCursorPos(pos.Line, lsp.RuneCol(vv.Line(vv.slice, pos.Line), pos.Character))
+	ed.ViewActivate(vv.Id())
+	return nil
+}
+
+// Hover shows informations about the symbol under the cursor in a tooltip
+// and the status bar, as well as the diagnostics of the cursor line.
+// The server is queried in the background, done being called on the action
+// bus with the text shown.
+func (v *View) Hover(done func(text string, err error)) {
+	c, err := v.lspClient()
+	if err != nil {
+		done("", err)
+		return
+	}
+	loc, pos := v.backend.SrcLoc(), v.lspCursorPos()
+	go func() {
+		hover, err := c.Hover(loc, pos)
+		lspDo(func() {
+			if err != nil {
+				done("", err)
+				return
+			}
+			done(v.showHover(c.Diagnostics(loc), pos, hover))
+		})
+	}()
+}
+
+func (v *View) showHover(diags []lsp.Diagnostic, pos lsp.Position, hover string) (string, error) {
+	if v.backend == nil {
+		return "", fmt.Errorf("The view was closed")
+	}
+	texts := []string{}
+	for _, d := range diags {
+		if d.Range.Start.Line <= pos.Line && d.Range.End.Line >= pos.Line {
+			texts = append(texts, d.Message)
+		}
+	}
+	if len(hover) > 0 {
+		texts = append(texts, hover)
+	}
+	text := strings.Join(strings.Fields(strings.Join(texts, " | ")), " ")
+	core.Ed.SetStatus(text)
+	if len(texts) > 0 {
+		y, x := v.cursorTermPos()
+		core.Ed.(*Editor).showTooltip(y, x, strings.Join(texts, "\n"))
+	}
+	return text, nil
+}
+
+// Complete completes the word under the cursor. If there is a single
+// candidate it's inserted, otherwise they are shown in a menu to pick one.
+// The server is queried in the background, done being called on the action
+// bus with the candidates, matching the word under the cursor by then.
+func (v *View) Complete(done func(candidates []string, err error)) {
+	c, err := v.lspClient()
+	if err != nil {
+		done(nil, err)
+		return
+	}
+	loc, pos := v.backend.SrcLoc(), v.lspCursorPos()
+	go func() {
+		items, err := c.Completion(loc, pos)
+		lspDo(func() {
+			if err != nil {
+				done(nil, err)
+				return
+			}
+			done(v.complete(items))
+		})
+	}()
+}
+
+func (v *View) complete(items []lsp.CompletionItem) ([]string, error) {
+	if v.backend == nil {
+		return nil, fmt.Errorf("The view was closed")
+	}
+	ln, col := v.CurTextPos()
+	line := v.Line(v.slice, ln)
+	if col > len(line) {
+		col = len(line)
+	}
+	start := col
+	for start > 0 && isWordRune(line[start-1]) {
+		start--
+	}
+	prefix := string(line[start:col])
+	candidates := []string{}
+	for _, item := range items {
+		if strings.HasPrefix(item.Text(), prefix) {
+			candidates = append(candidates, item.Text())
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		core.Ed.SetStatus("No completion")
+	case 1:
+		v.Insert(ln, col, strings.TrimPrefix(candidates[0], prefix), true)
+	default:
+		y, x := v.cursorTermPos()
+		core.Ed.(*Editor).showMenu(y, x, candidates, func(i int) {
+			v.Insert(ln, col, strings.TrimPrefix(candidates[i], prefix), true)
+		})
+	}
+	return candidates, nil
+}
+
+// renderDiagnostics shows the language server diagnostics in the view gutter.
+func (v *View) renderDiagnostics() {
+	if v.lsp == nil {
+		return
+	}
+	e := core.Ed
+	t := e.Theme()
+	y1, x1, _, _ := v.Bounds()
+	last := v.offy + v.LastViewLine()
+	severities := map[int]int{}
+	for _, d := range v.lsp.Diagnostics(v.backend.SrcLoc()) {
+		ln := d.Range.Start.Line
+		if ln < v.offy || ln > last {
+			continue
+		}
+		s := d.Severity
+		if s == 0 {
+			s = lsp.SeverityError
+		}
+		if cur, found := severities[ln]; !found || s < cur {
+			severities[ln] = s
+		}
+	}
+	for ln, s := range severities {
+		style := t.DiagnosticWarning
+		if s == lsp.SeverityError {
+			style = t.DiagnosticError
+		}
+		e.TermFB(style.Fg, style.Bg)
+		e.TermChar(y1+2+ln-v.offy, x1+1, style.Rune)
+	}
+	e.TermFB(t.Fg, t.Bg)
+}
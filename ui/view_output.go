@@ -0,0 +1,65 @@
+package ui
+
+import "github.com/tcolar/goed/core"
+
+// outputBackend returns the view backend if it holds a command output
+// (exec or terminal view), nil otherwise.
+func (v *View) outputBackend() core.CmdOutputBackend {
+	out, _ := v.backend.(core.CmdOutputBackend)
+	return out
+}
+
+// pinned returns whether the view is pinned : it does not follow the command
+// output.
+func (v *View) pinned() bool {
+	out := v.outputBackend()
+	return out != nil && out.Pinned()
+}
+
+// pin pins the view, if it holds a command output, so that it stays on the
+// text being read or searched.
+func (v *View) pin() {
+	if out := v.outputBackend(); out != nil {
+		out.SetPinned(true)
+	}
+}
+
+// followDrops accounts for the command output rows dropped from memory since
+// the last call (the oldest ones, past MaxCmdBufferLines) : selections are
+// shifted so they stay on the same text, and if the view is pinned so are the
+// scroll position and cursor.
+func (v *View) followDrops() {
+	out := v.outputBackend()
+	if out == nil {
+		return
+	}
+	dropped := out.Dropped()
+	d := dropped - v.dropped
+	v.dropped = dropped
+	if d <= 0 {
+		return // none, or the output was wiped
+	}
+	selections := []core.Selection{}
+	for _, s := range v.selections {
+		if s.LineTo-d < 0 {
+			continue // no longer there
+		}
+		s.LineFrom, s.LineTo = s.LineFrom-d, s.LineTo-d
+		if s.LineFrom < 0 {
+			s.LineFrom, s.ColFrom = 0, 0
+		}
+		selections = append(selections, s)
+	}
+	v.selections = selections
+	if !v.pinned() {
+		return
+	}
+	v.offy -= d
+	if v.offy < 0 {
+		v.CursorY += v.offy
+		v.offy = 0
+		if v.CursorY < 0 {
+			v.CursorY, v.CursorX = 0, 0
+		}
+	}
+}
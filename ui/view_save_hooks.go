@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tcolar/goed/actions"
+	"github.com/tcolar/goed/core"
+)
+
+// saveHooks returns the configured hooks of the given stage matching loc.
+func saveHooks(hooks []core.SaveHook, stage, loc string) []core.SaveHook {
+	matches := []core.SaveHook{}
+	for _, h := range hooks {
+		if h.Stage != stage || len(h.Cmd) == 0 || len(h.Match) == 0 {
+			continue
+		}
+		match := h.Match == filepath.Ext(loc)
+		if !match {
+			match, _ = filepath.Match(h.Match, filepath.Base(loc))
+		}
+		if match {
+			matches = append(matches, h)
+		}
+	}
+	return matches
+}
+
+// runSaveHook runs a hook command for the file loc, with input on stdin.
+// Returns the command standard output. The command is killed if it runs for
+// longer than the configured SaveHookTimeout.
+func runSaveHook(h core.SaveHook, loc, input string) (string, error) {
+	args := []string{}
+	for _, arg := range h.Cmd {
+		args = append(args, strings.Replace(arg, "$FILE", loc, -1))
+	}
+	timeout := time.Duration(core.Ed.Config().SaveHookTimeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := osexec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = time.Second // in case a child process holds the output
+	cmd.Dir = filepath.Dir(loc)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		out := strings.TrimSpace(stderr.String() + "\n" + stdout.String())
+		return "", fmt.Errorf("%s : %s\n%s", strings.Join(h.Cmd, " "), err.Error(), out)
+	}
+	return stdout.String(), nil
+}
+
+// runPreSaveHooks runs the "pre" save hooks (formatters) on text, each one
+// formatting the output of the previous one, a failed one being skipped.
+// Returns the formatted text.
+func runPreSaveHooks(hooks []core.SaveHook, loc, text string) (string, []error) {
+	failures := []error{}
+	for _, h := range hooks {
+		out, err := runSaveHook(h, loc, text+"\n")
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		text = strings.TrimSuffix(out, "\n")
+	}
+	return text, failures
+}
+
+// postSave runs the "post" save hooks (ie: linters) in the background, then
+// reports the failures, including the pre save ones.
+func (v *View) postSave(loc string, failures []error) {
+	hooks := saveHooks(core.Ed.Config().SaveHooks, core.SaveHookPost, loc)
+	if len(hooks) == 0 {
+		core.Ed.(*Editor).reportSaveHooks(loc, failures)
+		return
+	}
+	go func() {
+		for _, h := range hooks {
+			if _, err := runSaveHook(h, loc, ""); err != nil {
+				failures = append(failures, err)
+			}
+		}
+		core.Bus.Dispatch(saveHooksReport{loc: loc, failures: failures})
+	}()
+}
+
+// text returns the whole view text.
+func (v *View) text() string {
+	return core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text())
+}
+
+// setText replaces the view text, changing only the part that differs, as a
+// single undo step. Returns whether the text was changed.
+func (v *View) setText(text string) bool {
+	lines := *v.backend.Slice(0, 0, -1, -1).Text()
+	old := []rune(core.RunesToString(lines))
+	runes := []rune(text)
+	p := 0
+	for p < len(old) && p < len(runes) && old[p] == runes[p] {
+		p++
+	}
+	if p == len(old) && p == len(runes) {
+		return false
+	}
+	s := 0
+	for s < len(old)-p && s < len(runes)-p && old[len(old)-1-s] == runes[len(runes)-1-s] {
+		s++
+	}
+	if p > 0 && string(runes[p:len(runes)-s]) == "\n" {
+		p-- // Insert would auto indent a lone new line
+	}
+	ln, col := v.CurTextPos()
+	actions.UndoBegin(v.Id())
+	defer actions.UndoEnd(v.Id())
+	l1, c1 := v.offsetPos(lines, p)
+	if end := len(old) - s; end > p {
+		l2, c2 := v.offsetPos(lines, end-1)
+		v.Delete(l1, c1, l2, c2, true)
+	}
+	if insert := runes[p : len(runes)-s]; len(insert) > 0 {
+		v.Insert(l1, c1, string(insert), true)
+	}
+	v.SetCursorPos(ln, col)
+	return true
+}
+
+// saveHooksReport reports save hooks failures (from the background).
+type saveHooksReport struct {
+	loc      string
+	failures []error
+}
+
+func (a saveHooksReport) Run() {
+	core.Ed.(*Editor).reportSaveHooks(a.loc, a.failures)
+}
+
+// reportSaveHooks shows the save hooks failures in the status bar and in an
+// errors view (replacing the previous one).
+func (e *Editor) reportSaveHooks(loc string, failures []error) {
+	errs := []string{}
+	for _, f := range failures {
+		errs = append(errs, f.Error())
+	}
+	e.showErrors(&e.hookErrors, filepath.Dir(loc), "save hooks: "+filepath.Base(loc),
+		"Save hook failed", errs)
+}
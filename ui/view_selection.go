@@ -13,6 +13,7 @@ import (
 
 func (v *View) ClearSelections() {
 	v.selections = []core.Selection{}
+	v.carets = []textPos{}
 }
 
 // Text returns the text contained in the selection of the given view
@@ -105,6 +106,14 @@ func (v *View) Paste() {
 		core.Ed.SetStatusErr(err.Error())
 		return
 	}
+	if term, ok := v.backend.(core.TerminalBackend); ok && v.viewType == core.ViewTypeShell {
+		term.TermPaste(text)
+		return
+	}
+	if v.multiCursor() {
+		v.multiPaste(text)
+		return
+	}
 	if len(v.selections) > 0 {
 		v.DeleteCur()
 	}
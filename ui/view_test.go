@@ -1,12 +1,21 @@
 package ui
 
 import (
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/assert"
 	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+	"github.com/tcolar/goed/lsp"
+	"github.com/tcolar/goed/lsp/lsptest"
+	"github.com/tcolar/goed/ui/widgets"
 	. "gopkg.in/check.v1"
 )
 
@@ -309,6 +318,195 @@ func testChar(t *C, v *View, y, x int, c rune) {
 func (us *UiSuite) TestViewScrolling(t *C) {
 }
 
+func (us *UiSuite) TestMultiCursor(t *C) {
+	Ed := core.Ed.(*Editor)
+	v := Ed.NewView("")
+	Ed.InsertViewSmart(v)
+	v.SetBounds(0, 0, 100, 1000)
+	v.slice = v.backend.Slice(0, 0, 100, 1000)
+	v.InsertCur("foo bar\nfoo\nbaz foo")
+	v.SetCursorPos(0, 0)
+	v.AddCursor(1, 0)
+	v.AddCursor(2, 4)
+	v.InsertCur("ab")
+	s := core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "abfoo bar\nabfoo\nbaz abfoo")
+	ln, col := v.CurTextPos()
+	assert.Eq(t, ln, 0)
+	assert.Eq(t, col, 2)
+	assert.Eq(t, len(v.carets), 2)
+	v.Backspace()
+	s = core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "afoo bar\nafoo\nbaz afoo")
+	// the whole multi edit is a single undo step
+	actions.Undo(v.Id())
+	s = core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "abfoo bar\nabfoo\nbaz abfoo")
+	actions.Undo(v.Id())
+	s = core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "foo bar\nfoo\nbaz foo")
+
+	// add next occurrence
+	v.ClearSelections()
+	v.SetCursorPos(1, 1)
+	v.AddNextOccurrence()
+	assert.Eq(t, len(v.selections), 1)
+	assert.Eq(t, v.selections[0].String(), "1 0 1 2")
+	v.AddNextOccurrence()
+	v.AddNextOccurrence()
+	v.AddNextOccurrence() // wraps around, all selected already
+	assert.Eq(t, len(v.selections), 3)
+	assert.Eq(t, v.selections[1].String(), "2 4 2 6")
+	assert.Eq(t, v.selections[2].String(), "0 0 0 2")
+	v.InsertCur("x")
+	s = core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "x bar\nx\nbaz x")
+	assert.Eq(t, len(v.selections), 0)
+	actions.Undo(v.Id())
+	s = core.RunesToString(*v.Slice().Text())
+	assert.Eq(t, s, "foo bar\nfoo\nbaz foo")
+
+	// large buffer, with all the occurrences selected already (linear search)
+	v.ClearSelections()
+	v.SetCursorPos(2, 7)
+	v.InsertCur(" " + strings.Repeat("x", 200000))
+	v.SetCursorPos(1, 1)
+	for i := 0; i != 4; i++ {
+		v.AddNextOccurrence()
+	}
+	assert.Eq(t, len(v.selections), 3)
+}
+
+func (us *UiSuite) TestFind(t *C) {
+	Ed := core.Ed.(*Editor)
+	v := Ed.NewView("")
+	Ed.InsertViewSmart(v)
+	v.SetBounds(0, 0, 100, 1000)
+	v.slice = v.backend.Slice(0, 0, 100, 1000)
+	v.InsertCur("Foo föo\nbar foo\nfo")
+	v.SetCursorPos(0, 1)
+	err := v.Find("foo", false, false)
+	assert.Nil(t, err)
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6")
+	assert.True(t, v.FindNext(false))
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6") // wrapped around
+	assert.True(t, v.FindNext(true))
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6")
+	err = v.Find("FOO", false, true)
+	assert.Nil(t, err)
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6")
+	assert.True(t, v.FindNext(false))
+	assert.Eq(t, v.selections[0].String(), "0 0 0 2")
+	assert.True(t, v.FindNext(true))
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6")
+	err = v.Find("f.o", false, false)
+	assert.NotNil(t, err)
+	err = v.Find("f(.)o", true, false)
+	assert.Nil(t, err)
+	assert.Eq(t, v.selections[0].String(), "1 4 1 6")
+	err = v.Find("f(", true, false)
+	assert.NotNil(t, err)
+
+	// replace
+	v.ClearSelections()
+	v.SetCursorPos(0, 0)
+	err = v.Find("f(.)o", true, true)
+	assert.Eq(t, v.selections[0].String(), "0 0 0 2")
+	assert.Eq(t, v.Replace("<$1>", false), 1)
+	assert.Eq(t, core.RunesToString(*v.Slice().Text()), "<o> föo\nbar foo\nfo")
+	assert.Eq(t, v.selections[0].String(), "0 4 0 6")
+	assert.Eq(t, v.Replace("$1$1", true), 2)
+	assert.Eq(t, core.RunesToString(*v.Slice().Text()), "<o> öö\nbar oo\nfo")
+	actions.Undo(v.Id())
+	assert.Eq(t, core.RunesToString(*v.Slice().Text()), "<o> föo\nbar foo\nfo")
+	v.Find("o", false, false)
+	assert.Eq(t, v.Replace("", true), 5)
+	assert.Eq(t, core.RunesToString(*v.Slice().Text()), "<> fö\nbar f\nf")
+
+	query, regex, ignoreCase, ok := searchCmd("/ri foo bar", "/")
+	assert.True(t, ok)
+	assert.True(t, regex)
+	assert.True(t, ignoreCase)
+	assert.Eq(t, query, "foo bar")
+	_, _, _, ok = searchCmd("/usr/bin/ls -al", "/")
+	assert.False(t, ok)
+	assert.DeepEq(t, splitUnescaped(`/a\/b/c\d/g`, '/'), []string{"", "a/b", `c\d`, "g"})
+}
+
+// testOutput is a command output backend whose oldest rows are dropped on
+// demand.
+type testOutput struct {
+	core.Backend
+	dropped int
+	pinned  bool
+}
+
+func (o *testOutput) Cmd() []string               { return []string{"go", "build"} }
+func (o *testOutput) Dropped() int                { return o.dropped }
+func (o *testOutput) SaveOutput(loc string) error { return nil }
+func (o *testOutput) Pinned() bool                { return o.pinned }
+func (o *testOutput) SetPinned(pinned bool)       { o.pinned = pinned }
+
+func (o *testOutput) drop(rows int) {
+	text := core.RunesToString(*o.Slice(rows, 0, -1, -1).Text())
+	o.Wipe()
+	o.Insert(0, 0, text)
+	o.dropped += rows
+}
+
+func (us *UiSuite) TestViewOutput(t *C) {
+	Ed := core.Ed.(*Editor)
+	v := Ed.NewView("")
+	Ed.InsertViewSmart(v)
+	defer Ed.DelView(v.Id(), true)
+	v.SetBounds(0, 0, 12, 100)
+	out := &testOutput{Backend: v.backend}
+	v.backend = out
+	lines := []string{}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	out.Insert(0, 0, strings.Join(lines, "\n"))
+	v.SetCursorPos(0, 0)
+	// searching pins the view
+	assert.Nil(t, v.Find("line 50", false, false))
+	assert.True(t, v.pinned())
+	assert.Eq(t, v.selections[0].String(), "50 0 50 6")
+	offy, cursorY := v.offy, v.CursorY
+	// pinned : stays on the same text
+	out.drop(20)
+	v.followDrops()
+	assert.Eq(t, v.selections[0].String(), "30 0 30 6")
+	assert.Eq(t, v.offy, offy-20)
+	assert.Eq(t, v.CursorY, cursorY)
+	assert.Eq(t, string(v.Line(v.slice, v.CurLine())), "line 50")
+	// and past the top
+	v.SetCursorPos(5, 0)
+	out.drop(10)
+	v.followDrops()
+	assert.Eq(t, v.offy, 0)
+	assert.Eq(t, v.CursorY, 0)
+	assert.Eq(t, v.selections[0].String(), "20 0 20 6")
+	// unpinned : the selections only
+	out.SetPinned(false)
+	v.SetCursorPos(40, 0)
+	offy, cursorY = v.offy, v.CursorY
+	out.drop(5)
+	v.followDrops()
+	assert.Eq(t, v.offy, offy)
+	assert.Eq(t, v.CursorY, cursorY)
+	assert.Eq(t, v.selections[0].String(), "15 0 15 6")
+	out.drop(20)
+	v.followDrops()
+	assert.Eq(t, len(v.selections), 0)
+	// wiped
+	out.dropped = 0
+	v.followDrops()
+	assert.Eq(t, v.dropped, 0)
+	assert.False(t, v.FindNext(false)) // dropped
+	assert.False(t, v.pinned())
+}
+
 func (us *UiSuite) TestUndo(t *C) {
 	Ed := core.Ed.(*Editor)
 	v := Ed.NewView("")
@@ -370,7 +568,8 @@ func (us *UiSuite) TestUndo(t *C) {
 	}
 	states := []state{}
 	for i := 0; i != 15; i++ {
-		size := 1 + rand.Int63()%int64(15)
+		// at least 2 chars, single typed chars get coalesced in the undo tree
+		size := 2 + rand.Int63()%int64(14)
 		txt := core.RandString(int(size))
 		v.InsertCur(txt)
 		s := state{
@@ -392,3 +591,348 @@ func (us *UiSuite) TestUndo(t *C) {
 
 // TODO: test term mock
 // TODO: save etc ....
+
+func (us *UiSuite) TestLsp(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	a, b := path.Join(dir, "a.go"), path.Join(dir, "b.go")
+	ioutil.WriteFile(a, []byte("package a\n\nfunc foo() {}\n"), 0640)
+	ioutil.WriteFile(b, []byte("package a\n\nfunc bar() {}\n"), 0640)
+	server := lsptest.NewServer()
+	m := lsp.NewManager([]lsp.Server{{Extensions: []string{".go"}, LanguageId: "go"}}, dir)
+	m.Dial = server.Dial
+	prev := Ed.lsp
+	Ed.lsp = m
+	defer func() { Ed.lsp = prev }()
+
+	vid, err := Ed.Open(a, -1, "", false)
+	assert.Nil(t, err)
+	defer func() {
+		if _, found := Ed.views[vid]; found {
+			Ed.DelView(vid, true)
+		}
+	}()
+	v := Ed.views[vid]
+	// the server is started and requests are made off the action bus
+	waitLsp := func(v *View) {
+		for i := 0; i != 100 && v.lsp == nil; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.NotNil(t, v.lsp)
+	}
+	hover := func(v *View) (string, error) {
+		text, errs := make(chan string, 1), make(chan error, 1)
+		v.Hover(func(t string, err error) { text <- t; errs <- err })
+		return <-text, <-errs
+	}
+	complete := func(v *View) ([]string, error) {
+		candidates, errs := make(chan []string, 1), make(chan error, 1)
+		v.Complete(func(c []string, err error) { candidates <- c; errs <- err })
+		return <-candidates, <-errs
+	}
+	gotoDefinition := func(v *View) error {
+		errs := make(chan error, 1)
+		v.GotoDefinition(func(err error) { errs <- err })
+		return <-errs
+	}
+	waitLsp(v)
+	assert.True(t, server.Received("textDocument/didOpen"))
+	// edits are kept in sync
+	v.Insert(2, 5, "é😀", true)
+	v.Insert(2, 0, "// x\n", true)
+	v.Delete(1, 0, 2, 1, true)
+	v.Delete(0, 8, 0, 100, true) // joins lines
+	actions.Undo(v.Id())
+	v.Delete(0, 8, 0, 100, true)
+	v.Insert(0, 1, "\n", true)
+	hover(v) // round trip
+	assert.Eq(t, server.Doc(a), v.lspText())
+	assert.True(t, strings.Contains(server.Doc(a), "func é😀foo"))
+
+	// diagnostics
+	server.Publish(a, []lsp.Diagnostic{{Range: lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1, Character: 3}},
+		Severity: lsp.SeverityWarning, Message: "oops"}})
+	for i := 0; i != 100 && len(v.lsp.Diagnostics(a)) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	v.SetBounds(0, 0, 20, 80)
+	v.Render()
+	assert.Eq(t, Ed.term.(*core.MockTerm).CharAt(3, 1), Ed.theme.DiagnosticWarning.Rune)
+	assert.True(t, Ed.term.(*core.MockTerm).CharAt(2, 1) != Ed.theme.DiagnosticWarning.Rune)
+
+	// hover
+	server.Hover = "func foo()\n doc"
+	v.SetCursorPos(1, 0)
+	text, err := hover(v)
+	assert.Nil(t, err)
+	assert.Eq(t, text, "oops | func foo() doc")
+	assert.Eq(t, Ed.Statusbar.msg, text)
+	y, x := v.cursorTermPos()
+	assert.IsType(t, Ed.overlays.WidgetAt(y+1, x), &widgets.Tooltip{})
+	Ed.OverlayEvent(event.EvtMoveDown, "", false, -1, -1) // dismissed
+	assert.Eq(t, len(Ed.overlays.Widgets()), 0)
+
+	// completion
+	ln := v.LineCount() - 1
+	v.Insert(ln, v.LineLen(v.slice, ln), "\nfmt.Pri", true)
+	ln++
+	v.SetCursorPos(ln, 7)
+	server.Completion = []lsp.CompletionItem{{Label: "Println"}, {Label: "Printf"}, {Label: "Sprintf"}}
+	candidates, err := complete(v)
+	assert.Nil(t, err)
+	assert.DeepEq(t, candidates, []string{"Println", "Printf"})
+	menu := Ed.overlays.Focused().(*widgets.Menu)
+	assert.True(t, Ed.OverlayEvent(event.EvtToggleCmdbar, "", false, -1, -1))
+	assert.Eq(t, len(Ed.overlays.Widgets()), 0)
+	assert.Eq(t, menu.Selected(), 0)
+	server.Completion = []lsp.CompletionItem{{Label: "Println"}}
+	candidates, err = complete(v)
+	assert.Nil(t, err)
+	assert.Eq(t, string(v.Line(v.slice, ln)), "fmt.Println")
+	hover(v)
+	assert.Eq(t, server.Doc(a), v.lspText())
+
+	// goto definition
+	server.Definition = []lsp.Location{{Uri: lsp.LocToUri(b),
+		Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 5}}}}
+	assert.Nil(t, gotoDefinition(v))
+	bv := viewCast(Ed.CurView())
+	assert.Eq(t, bv.backend.SrcLoc(), b)
+	ln, col := bv.CurTextPos()
+	assert.Eq(t, ln, 2)
+	assert.Eq(t, col, 5)
+	waitLsp(bv)
+	hover(bv)
+	assert.Eq(t, server.Doc(b), "package a\n\nfunc bar() {}")
+	Ed.DelView(bv.Id(), true)
+	hover(v)
+	assert.Eq(t, server.Doc(b), "")
+	server.Definition = nil
+	assert.NotNil(t, gotoDefinition(v))
+
+	// a second view of the file (own buffer) takes over once the first is closed
+	vid2, err := Ed.Open(a, -1, "", false)
+	assert.Nil(t, err)
+	v2 := Ed.views[vid2]
+	assert.True(t, v2.lsp == nil)
+	v2.Insert(0, 0, "// v2\n", true)
+	hover(v)
+	assert.Eq(t, server.Doc(a), v.lspText())
+	Ed.DelView(vid, true)
+	waitLsp(v2)
+	hover(v2)
+	assert.Eq(t, server.Doc(a), v2.lspText())
+	assert.True(t, strings.HasPrefix(server.Doc(a), "// v2\n"))
+	Ed.DelView(vid2, true)
+	m.Shutdown()
+	assert.True(t, server.Received("textDocument/didClose"))
+	assert.Eq(t, server.Doc(a), "")
+}
+
+func (us *UiSuite) TestSaveHooks(t *C) {
+	Ed := core.Ed.(*Editor)
+	loc := path.Join(t.MkDir(), "a.txt")
+	ioutil.WriteFile(loc, []byte("b\na\nc\n"), 0640)
+	hooks := Ed.config.SaveHooks
+	defer func() { Ed.config.SaveHooks = hooks }()
+	Ed.config.SaveHooks = []core.SaveHook{
+		{Match: "*.txt", Stage: core.SaveHookPre, Cmd: []string{"sort"}},
+		{Match: "*.go", Stage: core.SaveHookPre, Cmd: []string{"false"}},
+		{Match: "*.txt", Stage: core.SaveHookPre, Cmd: []string{"sh", "-c", "echo oops; exit 3"}},
+		{Match: ".txt", Stage: core.SaveHookPre, Cmd: []string{"tr", "a-z", "A-Z"}},
+	}
+	assert.Eq(t, len(saveHooks(Ed.config.SaveHooks, core.SaveHookPre, loc)), 3)
+	assert.Eq(t, len(saveHooks(Ed.config.SaveHooks, core.SaveHookPost, loc)), 0)
+
+	vid, err := Ed.Open(loc, -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	v := Ed.views[vid]
+	v.SetCursorPos(2, 1)
+	assert.True(t, saveWait(v))
+	data, _ := ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "A\nB\nC\n")
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "A\nB\nC")
+	assert.False(t, v.Dirty())
+	ln, col := v.CurTextPos()
+	assert.Eq(t, ln, 2)
+	assert.Eq(t, col, 1)
+	// failures are reported
+	ev, found := Ed.views[Ed.hookErrors]
+	assert.True(t, found)
+	lines := *ev.backend.Slice(0, 0, -1, -1).Text()
+	assert.Eq(t, string(lines[0]), "sh -c echo oops; exit 3 : exit status 3")
+	assert.Eq(t, string(lines[1]), "oops")
+	assert.True(t, Ed.Statusbar.isErr)
+	// formatting is a single undo step
+	actions.Undo(vid)
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "b\na\nc")
+
+	Ed.config.SaveHooks = Ed.config.SaveHooks[:1]
+	assert.True(t, saveWait(v))
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "a\nb\nc")
+	_, found = Ed.views[ev.Id()]
+	assert.False(t, found)
+
+	// post hooks
+	out, err := runSaveHook(core.SaveHook{Cmd: []string{"echo", "$FILE"}}, loc, "")
+	assert.Nil(t, err)
+	assert.Eq(t, out, loc+"\n")
+	// timeout
+	timeout := Ed.config.SaveHookTimeout
+	defer func() { Ed.config.SaveHookTimeout = timeout }()
+	Ed.config.SaveHookTimeout = 1
+	_, err = runSaveHook(core.SaveHook{Cmd: []string{"sleep", "10"}}, loc, "")
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "sleep 10 : timed out after 1s"))
+
+	// minimal changes
+	assert.False(t, v.setText("a\nb\nc"))
+	assert.True(t, v.setText("a\nb\n\nc"))
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "a\nb\n\nc")
+	assert.True(t, v.setText("x"))
+	assert.Eq(t, core.RunesToString(*v.backend.Slice(0, 0, -1, -1).Text()), "x")
+}
+
+func (us *UiSuite) TestSaveChangedOnDisk(t *C) {
+	Ed := core.Ed.(*Editor)
+	loc := path.Join(t.MkDir(), "a.txt")
+	ioutil.WriteFile(loc, []byte("abc\n"), 0640)
+	vid, err := Ed.Open(loc, -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	v := Ed.views[vid]
+	hooks := Ed.config.SaveHooks
+	defer func() { Ed.config.SaveHooks = hooks }()
+	Ed.config.SaveHooks = []core.SaveHook{
+		{Match: "*.txt", Stage: core.SaveHookPre, Cmd: []string{"sed", "s/^/>/"}},
+	}
+	v.Insert(0, 0, "1", true)
+	later := time.Now().Add(time.Minute)
+	ioutil.WriteFile(loc, []byte("xyz\n"), 0640)
+	os.Chtimes(loc, later, later)
+	saved := make(chan bool, 1)
+	v.save(func(s bool) { saved <- s })
+	q := v.Title() + " changed on disk, overwrite it?"
+	var data []byte
+	var dirty, isErr bool
+	var selected string
+	answerPrompt(q, func(p *widgets.Prompt) {
+		data, _ = ioutil.ReadFile(loc)
+		dirty, isErr, selected = v.Dirty(), Ed.Statusbar.isErr, p.Selected()
+		Ed.OverlayEvent("", "o", false, -1, -1)
+	})
+	assert.Eq(t, string(data), "xyz\n")
+	assert.True(t, dirty)
+	assert.True(t, isErr)
+	assert.Eq(t, selected, overwriteCancel)
+	// overwritten, without formatting again
+	assert.True(t, <-saved)
+	data, _ = ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), ">1abc\n")
+	assert.False(t, v.Dirty())
+}
+
+// saveWait saves the view, waiting for the pre save hooks (in the
+// background). Returns whether it was saved.
+func saveWait(v *View) bool {
+	saved := make(chan bool, 1)
+	v.save(func(s bool) { saved <- s })
+	return <-saved
+}
+
+func (us *UiSuite) TestFileConflict(t *C) {
+	Ed := core.Ed.(*Editor)
+	dir := t.MkDir()
+	loc := path.Join(dir, "a.txt")
+	ioutil.WriteFile(loc, []byte("abc\ndef\nghi\n"), 0640)
+	vid, err := Ed.Open(loc, -1, "", false)
+	assert.Nil(t, err)
+	defer Ed.DelView(vid, true)
+	v := Ed.views[vid]
+	later := time.Now().Add(time.Minute)
+	modify := func(s string) {
+		ioutil.WriteFile(loc, []byte(s), 0640)
+		later = later.Add(time.Minute)
+		os.Chtimes(loc, later, later)
+	}
+
+	// own writes and touches are ignored
+	v.fileEvent(core.OpWrite, loc)
+	v.fileEvent(core.OpChmod, loc)
+	assert.False(t, v.conflict)
+
+	// clean view : reloaded, cursor kept
+	v.SetCursorPos(1, 2)
+	modify("abc\nDEF\nghi\n")
+	v.fileEvent(core.OpWrite, loc)
+	assert.Eq(t, core.RunesToString(v.Text(0, 0, -1, -1)), "abc\nDEF\nghi")
+	ln, col := v.CurTextPos()
+	assert.Eq(t, ln, 1)
+	assert.Eq(t, col, 2)
+	assert.False(t, v.Dirty())
+
+	// dirty view : banner
+	v.Insert(0, 0, "1", true)
+	modify("xyz\n")
+	v.fileEvent(core.OpWrite, loc)
+	assert.True(t, v.conflict)
+	assert.Eq(t, Ed.prompt(v.conflictQuestion()).Selected(), conflictKeep)
+	assert.Eq(t, core.RunesToString(v.Text(0, 0, 0, -1)), "1abc")
+	assert.False(t, v.BannerClick(3)) // on the banner text, not a choice
+	assert.NotNil(t, v.ResolveConflict("foo"))
+
+	// diff
+	x := len(conflictBanner) + 3 + len(conflictReload) + 3 + len(conflictKeep) + 3 + 2
+	assert.True(t, v.BannerClick(x))
+	dv := viewCast(Ed.CurView())
+	assert.True(t, dv != v)
+	diff := core.RunesToString(dv.Text(0, 0, -1, -1))
+	assert.True(t, strings.Contains(diff, "-xyz\n+1abc"))
+	Ed.DelView(dv.Id(), true)
+	assert.True(t, v.conflict)
+
+	// keep mine : saved without asking
+	assert.Nil(t, v.ResolveConflict(conflictKeep))
+	assert.False(t, v.conflict)
+	assert.True(t, Ed.prompt(v.conflictQuestion()) == nil)
+	// nothing to resolve anymore
+	assert.NotNil(t, v.ResolveConflict(conflictReload))
+	assert.Eq(t, core.RunesToString(v.Text(0, 0, 0, -1)), "1abc")
+	v.Save()
+	data, _ := ioutil.ReadFile(loc)
+	assert.Eq(t, string(data), "1abc\nDEF\nghi\n")
+
+	// reload
+	v.Insert(0, 0, "2", true)
+	modify("xyz\n")
+	v.fileEvent(core.OpWrite, loc)
+	assert.True(t, v.conflict)
+	Ed.OverlayEvent("", "r", false, -1, -1) // from the prompt
+	assert.False(t, v.conflict)
+	assert.False(t, v.Dirty())
+	assert.Eq(t, core.RunesToString(v.Text(0, 0, -1, -1)), "xyz")
+
+	// moved
+	loc2 := path.Join(dir, "b.txt")
+	os.Rename(loc, loc2)
+	Ed.FileMoved(loc, loc2)
+	assert.Eq(t, v.backend.SrcLoc(), loc2)
+	assert.Eq(t, v.Title(), "b.txt")
+	v.Insert(0, 0, "3", true)
+	v.Save()
+	data, _ = ioutil.ReadFile(loc2)
+	assert.Eq(t, string(data), "3xyz\n")
+
+	// deleted while dirty : kept or closed
+	v.Insert(0, 0, "4", true)
+	os.Remove(loc2)
+	v.fileEvent(core.OpRemove, loc2)
+	q := v.Title() + " was deleted on disk."
+	assert.True(t, Ed.prompt(q) != nil)
+	Ed.OverlayEvent("", "k", false, -1, -1)
+	assert.True(t, Ed.ViewById(vid) != nil)
+	v.fileEvent(core.OpRemove, loc2)
+	Ed.OverlayEvent("", "c", false, -1, -1)
+	assert.True(t, Ed.ViewById(vid) == nil)
+}
@@ -0,0 +1,66 @@
+package widgets
+
+import "github.com/tcolar/goed/core"
+
+// Overlay is a floating widget (menu, tooltip, dialog ...) drawn over the
+// editor views, as a child of the overlay layer (a TermWidget).
+// Its bounds are terminal coordinates.
+type Overlay interface {
+	core.Widget
+	// Focusable returns whether the overlay takes the keyboard focus.
+	Focusable() bool
+	// Event offers an event to the overlay, returns whether it consumed it.
+	Event(e OverlayEvent) bool
+}
+
+// OverlayEvent is an event offered to an overlay.
+type OverlayEvent struct {
+	Type  string // event type, ie: "enter" (see event/event_type.go)
+	Glyph string // typed text, if any
+	Mouse bool
+	Y, X  int // mouse location, relative to the overlay (might be outside)
+}
+
+// Focused returns the topmost focusable overlay, nil if none.
+func (w *TermWidget) Focused() Overlay {
+	for i := len(w.widgets) - 1; i >= 0; i-- {
+		if o, ok := w.widgets[i].(Overlay); ok && o.Focusable() {
+			return o
+		}
+	}
+	return nil
+}
+
+// Dispatch offers an event to the overlays, topmost first, until one
+// consumes it. Mouse locations are given in terminal coordinates.
+// Keyboard events are not offered below the focused overlay.
+// Returns whether the event was consumed.
+func (w *TermWidget) Dispatch(e OverlayEvent) bool {
+	y, x := e.Y, e.X
+	children := w.Widgets()
+	for i := len(children) - 1; i >= 0; i-- {
+		o, ok := children[i].(Overlay)
+		if !ok {
+			continue
+		}
+		oe := e
+		if e.Mouse {
+			y1, x1, _, _ := o.Bounds()
+			oe.Y, oe.X = y-y1, x-x1
+		}
+		if o.Event(oe) {
+			return true
+		}
+		if !e.Mouse && o.Focusable() {
+			return false
+		}
+	}
+	return false
+}
+
+// Contains returns whether the overlay relative location y, x is within the
+// widget.
+func Contains(w core.Widget, y, x int) bool {
+	y1, x1, y2, x2 := w.Bounds()
+	return y >= 0 && x >= 0 && y <= y2-y1 && x <= x2-x1
+}
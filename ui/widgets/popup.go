@@ -0,0 +1,261 @@
+package widgets
+
+import (
+	"strings"
+
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+)
+
+var _ Overlay = (*Tooltip)(nil)
+var _ Overlay = (*Menu)(nil)
+
+// popup is an overlay shown near an anchor location (ie: the cursor), below
+// it if there is room, above otherwise.
+type popup struct {
+	BaseWidget
+	anchorY, anchorX int
+}
+
+// place sizes the popup to h rows and w columns, within the terminal.
+func (p *popup) place(self core.Widget, h, w int) {
+	term := GetTermWidget(self)
+	if term == nil {
+		return
+	}
+	_, _, ty2, tx2 := term.Bounds()
+	if w > tx2+1 {
+		w = tx2 + 1
+	}
+	y := p.anchorY + 1
+	if y+h-1 > ty2 && p.anchorY-h >= 0 {
+		y = p.anchorY - h
+	}
+	x := p.anchorX
+	if x+w-1 > tx2 {
+		x = tx2 - w + 1
+	}
+	p.SetBounds(y, x, y+h-1, x+w-1)
+}
+
+// close removes the popup from the overlay layer.
+func (p *popup) close(self core.Widget) {
+	if term := GetTermWidget(self); term != nil {
+		term.RemoveWidget(self)
+	}
+}
+
+// str draws s at the popup relative location y, x, truncated at the popup
+// right edge.
+func (p *popup) str(self core.Widget, y, x int, s string, fg, bg core.Style) {
+	term := GetTermWidget(self)
+	y1, x1, _, x2 := p.Bounds()
+	for _, r := range s {
+		if x1+x > x2 {
+			return
+		}
+		term.Char(y1+y, x1+x, r, fg, bg)
+		x++
+	}
+}
+
+// Tooltip is a popup showing some text, dismissed by any event.
+type Tooltip struct {
+	popup
+	lines []string
+}
+
+// maxTooltipWidth and maxTooltipLines limit the size of a tooltip.
+const (
+	maxTooltipWidth = 60
+	maxTooltipLines = 10
+)
+
+// NewTooltip creates a tooltip showing text near the terminal location y, x.
+func NewTooltip(y, x int, text string) *Tooltip {
+	t := &Tooltip{lines: wrap(strings.TrimSpace(text), maxTooltipWidth-2)}
+	if len(t.lines) > maxTooltipLines {
+		t.lines = append(t.lines[:maxTooltipLines-1], "...")
+	}
+	t.anchorY, t.anchorX = y, x
+	return t
+}
+
+func (t *Tooltip) SetParent(parent core.Widget) {
+	t.popup.SetParent(parent)
+	w := 0
+	for _, l := range t.lines {
+		if len([]rune(l)) > w {
+			w = len([]rune(l))
+		}
+	}
+	t.place(t, len(t.lines), w+2)
+}
+
+func (t *Tooltip) Focusable() bool {
+	return false
+}
+
+// Event dismisses the tooltip, only a click on it is consumed.
+func (t *Tooltip) Event(e OverlayEvent) bool {
+	t.close(t)
+	return e.Mouse && Contains(t, e.Y, e.X)
+}
+
+func (t *Tooltip) Render() {
+	theme := core.Ed.Theme()
+	_, x1, _, x2 := t.Bounds()
+	for i, l := range t.lines {
+		t.str(t, i, 0, pad(" "+l, x2-x1+1), theme.StatusbarText, theme.Statusbar.Bg)
+	}
+}
+
+// Menu is a focusable popup list of items to pick one from.
+type Menu struct {
+	popup
+	items    []string
+	sel      int // selected item
+	offset   int // first item shown
+	onSelect func(index int)
+}
+
+// maxMenuItems is the number of items shown at once.
+const maxMenuItems = 10
+
+// NewMenu creates a menu near the terminal location y, x, onSelect is called
+// with the index of the picked item once the menu is closed.
+func NewMenu(y, x int, items []string, onSelect func(index int)) *Menu {
+	m := &Menu{items: items, onSelect: onSelect}
+	m.anchorY, m.anchorX = y, x
+	return m
+}
+
+func (m *Menu) SetParent(parent core.Widget) {
+	m.popup.SetParent(parent)
+	h, w := len(m.items), 0
+	if h > maxMenuItems {
+		h = maxMenuItems
+	}
+	for _, item := range m.items {
+		if len([]rune(item)) > w {
+			w = len([]rune(item))
+		}
+	}
+	m.place(m, h, w+2)
+}
+
+func (m *Menu) Focusable() bool {
+	return true
+}
+
+// Selected returns the index of the selected item.
+func (m *Menu) Selected() int {
+	return m.sel
+}
+
+func (m *Menu) move(delta int) {
+	m.sel += delta
+	if m.sel >= len(m.items) {
+		m.sel = len(m.items) - 1
+	}
+	if m.sel < 0 {
+		m.sel = 0
+	}
+	if m.sel < m.offset {
+		m.offset = m.sel
+	}
+	if m.sel >= m.offset+maxMenuItems {
+		m.offset = m.sel - maxMenuItems + 1
+	}
+}
+
+func (m *Menu) pick() {
+	m.close(m)
+	if m.onSelect != nil && m.sel < len(m.items) {
+		m.onSelect(m.sel)
+	}
+}
+
+// Event handles the menu navigation, Enter or a click picks an item.
+// Other keys and clicks elsewhere close the menu and are not consumed.
+func (m *Menu) Event(e OverlayEvent) bool {
+	if e.Mouse {
+		if !Contains(m, e.Y, e.X) {
+			m.close(m)
+			return false
+		}
+		switch e.Type {
+		case event.EvtScrollDown:
+			m.move(1)
+		case event.EvtScrollUp:
+			m.move(-1)
+		default:
+			m.move(m.offset + e.Y - m.sel)
+			m.pick()
+		}
+		return true
+	}
+	switch e.Type {
+	case event.EvtMoveDown:
+		m.move(1)
+	case event.EvtMoveUp:
+		m.move(-1)
+	case event.EvtPageDown:
+		m.move(maxMenuItems)
+	case event.EvtPageUp:
+		m.move(-maxMenuItems)
+	case event.EvtEnter, event.EvtTab:
+		m.pick()
+	case event.EvtToggleCmdbar: // escape
+		m.close(m)
+	default:
+		m.close(m)
+		return false
+	}
+	return true
+}
+
+func (m *Menu) Render() {
+	theme := core.Ed.Theme()
+	_, x1, _, x2 := m.Bounds()
+	for i := 0; i < maxMenuItems && m.offset+i < len(m.items); i++ {
+		fg, bg := theme.ViewbarText, theme.Viewbar.Bg
+		if m.offset+i == m.sel {
+			fg, bg = theme.FgSelect, theme.BgSelect
+		}
+		m.str(m, i, 0, pad(" "+m.items[m.offset+i], x2-x1+1), fg, bg)
+	}
+}
+
+// pad pads s with spaces up to width runes.
+func pad(s string, width int) string {
+	if n := width - len([]rune(s)); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// wrap splits text into lines of at most width runes, on spaces if possible.
+func wrap(text string, width int) []string {
+	lines := []string{}
+	for _, para := range strings.Split(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Fields(para) {
+			w := []rune(word)
+			if len(line) > 0 && len(line)+1+len(w) > width {
+				lines = append(lines, string(line))
+				line = []rune{}
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, w...)
+			for len(line) > width {
+				lines = append(lines, string(line[:width]))
+				line = line[width:]
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
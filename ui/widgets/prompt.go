@@ -0,0 +1,224 @@
+package widgets
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+)
+
+var _ Overlay = (*Prompt)(nil)
+
+// maxPromptWidth is the width the prompt question is wrapped at.
+const maxPromptWidth = 60
+
+// Prompt is a modal dialog asking a question, centered on the terminal.
+// It's answered by clicking a choice, or typing its key (underlined), or
+// moving the selection (Left / Right / Tab) then Enter, the default choice
+// being selected initially. Escape cancels it.
+type Prompt struct {
+	BaseWidget
+	question string
+	lines    []string // wrapped question
+	choices  []string
+	keys     []rune // choices keys
+	sel      int    // selected choice
+	onAnswer func(choice string)
+}
+
+// NewPrompt creates a prompt, onAnswer is called with the picked choice, or
+// "" if cancelled, once the prompt is closed.
+func NewPrompt(question string, choices []string, defaultChoice int, onAnswer func(choice string)) *Prompt {
+	if len(choices) == 0 {
+		choices = []string{"ok"}
+	}
+	p := &Prompt{
+		question: question,
+		lines:    wrap(question, maxPromptWidth),
+		choices:  choices,
+		onAnswer: onAnswer,
+	}
+	if defaultChoice >= 0 && defaultChoice < len(choices) {
+		p.sel = defaultChoice
+	}
+	// the first letter of each choice not used by a previous choice
+	used := map[rune]bool{}
+	for _, choice := range choices {
+		key := rune(0)
+		for _, r := range strings.ToLower(choice) {
+			if unicode.IsLetter(r) && !used[r] {
+				key = r
+				break
+			}
+		}
+		used[key] = true
+		p.keys = append(p.keys, key)
+	}
+	return p
+}
+
+// Question returns the prompt question.
+func (p *Prompt) Question() string {
+	return p.question
+}
+
+// Selected returns the selected choice.
+func (p *Prompt) Selected() string {
+	return p.choices[p.sel]
+}
+
+func (p *Prompt) SetParent(parent core.Widget) {
+	p.BaseWidget.SetParent(parent)
+	p.place()
+}
+
+// place centers the prompt on the terminal.
+func (p *Prompt) place() {
+	term := GetTermWidget(p)
+	if term == nil {
+		return
+	}
+	w := 0
+	for _, l := range p.lines {
+		if len([]rune(l)) > w {
+			w = len([]rune(l))
+		}
+	}
+	if cw := p.choicesWidth(); cw > w {
+		w = cw
+	}
+	w += 4 // borders and margins
+	h := len(p.lines) + 4
+	_, _, ty2, tx2 := term.Bounds()
+	y, x := (ty2+1-h)/2, (tx2+1-w)/2
+	if x < 0 {
+		x = 0
+	}
+	p.SetBounds(y, x, y+h-1, x+w-1)
+}
+
+// choicesWidth is the width of the choices row, each choice being shown as
+// " choice " followed by a space.
+func (p *Prompt) choicesWidth() int {
+	w := 0
+	for _, c := range p.choices {
+		w += len([]rune(c)) + 3
+	}
+	return w - 1
+}
+
+// choiceAt returns the index of the choice at the prompt relative location
+// y, x, -1 if none.
+func (p *Prompt) choiceAt(y, x int) int {
+	if y != len(p.lines)+2 {
+		return -1
+	}
+	pos := 2
+	for i, c := range p.choices {
+		w := len([]rune(c)) + 2
+		if x >= pos && x < pos+w {
+			return i
+		}
+		pos += w + 1
+	}
+	return -1
+}
+
+func (p *Prompt) Focusable() bool {
+	return true
+}
+
+// Cancel closes the prompt unanswered, onAnswer being called with "".
+func (p *Prompt) Cancel() {
+	p.answer("")
+}
+
+func (p *Prompt) answer(choice string) {
+	if term := GetTermWidget(p); term != nil {
+		term.RemoveWidget(p)
+	}
+	if p.onAnswer != nil {
+		p.onAnswer(choice)
+	}
+}
+
+// Event answers the prompt, it's modal so all events are consumed.
+func (p *Prompt) Event(e OverlayEvent) bool {
+	if e.Mouse {
+		if i := p.choiceAt(e.Y, e.X); i >= 0 && e.Type == event.EvtSetCursor {
+			p.answer(p.choices[i])
+		}
+		return true
+	}
+	switch e.Type {
+	case event.EvtEnter:
+		p.answer(p.choices[p.sel])
+	case event.EvtToggleCmdbar: // escape
+		p.answer("")
+	case event.EvtMoveLeft:
+		if p.sel > 0 {
+			p.sel--
+		}
+	case event.EvtMoveRight:
+		if p.sel < len(p.choices)-1 {
+			p.sel++
+		}
+	case event.EvtTab:
+		p.sel = (p.sel + 1) % len(p.choices)
+	default:
+		for i, key := range p.keys {
+			if len(e.Glyph) > 0 && unicode.ToLower([]rune(e.Glyph)[0]) == key {
+				p.answer(p.choices[i])
+				break
+			}
+		}
+	}
+	return true
+}
+
+func (p *Prompt) Render() {
+	term := GetTermWidget(p)
+	if term == nil {
+		return
+	}
+	p.place()
+	t := core.Ed.Theme()
+	y1, x1, y2, x2 := p.Bounds()
+	border, text := t.Viewbar.Fg, t.Fg
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			r := ' '
+			switch {
+			case y == y1 || y == y2:
+				r = '─'
+			case x == x1 || x == x2:
+				r = '│'
+			}
+			term.Char(y, x, r, border, t.Bg)
+		}
+	}
+	for i, l := range p.lines {
+		for j, r := range []rune(l) {
+			term.Char(y1+1+i, x1+2+j, r, text, t.Bg)
+		}
+	}
+	x := x1 + 2
+	y := y1 + len(p.lines) + 2
+	for i, c := range p.choices {
+		fg, bg := t.ViewbarText, t.Viewbar.Bg
+		if i == p.sel {
+			fg, bg = t.FgSelect, t.BgSelect
+		}
+		keyed := false
+		for j, r := range []rune(" " + c + " ") {
+			style := fg
+			if !keyed && unicode.ToLower(r) == p.keys[i] && j > 0 {
+				style = fg.WithAttr(core.Underlined)
+				keyed = true
+			}
+			term.Char(y, x+j, r, style, bg)
+		}
+		x += len([]rune(c)) + 3
+	}
+}
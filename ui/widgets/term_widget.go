@@ -2,14 +2,18 @@ package widgets
 
 import "github.com/tcolar/goed/core"
 
+// TermWidget is the root widget, its children are rendered by increasing z
+// order, each over the previous ones.
 type TermWidget struct {
 	term    core.Term
-	widgets []core.Widget
+	widgets []core.Widget // by increasing z order
+	z       map[core.Widget]int
 }
 
 func NewTermWidget(term core.Term) *TermWidget {
 	return &TermWidget{
 		term: term,
+		z:    map[core.Widget]int{},
 	}
 }
 
@@ -31,7 +35,7 @@ func (w *TermWidget) Flush() {
 }
 
 func (w *TermWidget) Render() {
-	for _, child := range w.widgets {
+	for _, child := range w.Widgets() {
 		child.Render()
 	}
 }
@@ -41,8 +45,49 @@ func (w *TermWidget) SetBounds(y1, x1, y2, x2 int) {
 }
 
 func (w *TermWidget) AddWidget(ww core.Widget) {
+	w.AddWidgetZ(ww, 0)
+}
+
+// AddWidgetZ adds a widget at the given z order, over the widgets of lower
+// or equal z.
+func (w *TermWidget) AddWidgetZ(ww core.Widget, z int) {
+	w.RemoveWidget(ww)
 	ww.SetParent(w)
-	w.widgets = append(w.widgets, ww)
+	w.z[ww] = z
+	i := len(w.widgets)
+	for i > 0 && w.z[w.widgets[i-1]] > z {
+		i--
+	}
+	w.widgets = append(w.widgets[:i], append([]core.Widget{ww}, w.widgets[i:]...)...)
+}
+
+// RemoveWidget removes a widget, if present.
+func (w *TermWidget) RemoveWidget(ww core.Widget) {
+	for i, child := range w.widgets {
+		if child == ww {
+			w.widgets = append(w.widgets[:i], w.widgets[i+1:]...)
+			delete(w.z, ww)
+			ww.SetParent(nil)
+			return
+		}
+	}
+}
+
+// Widgets returns the child widgets, by increasing z order.
+func (w *TermWidget) Widgets() []core.Widget {
+	return append([]core.Widget{}, w.widgets...)
+}
+
+// WidgetAt returns the topmost child widget at the given location, nil if
+// none.
+func (w *TermWidget) WidgetAt(y, x int) core.Widget {
+	for i := len(w.widgets) - 1; i >= 0; i-- {
+		y1, x1, y2, x2 := w.widgets[i].Bounds()
+		if y >= y1 && y <= y2 && x >= x1 && x <= x2 {
+			return w.widgets[i]
+		}
+	}
+	return nil
 }
 
 func (w *TermWidget) SetParent(_ core.Widget) {
@@ -39,6 +39,9 @@ func (e *Editor) ViewAt(y, x int) int64 {
 
 // WidgetAt returns the widget at a given editor location
 func (e *Editor) WidgetAt(y, x int) Renderer {
+	if w := e.overlays.WidgetAt(y, x); w != nil {
+		return w
+	}
 	h, _ := e.term.Size()
 	if y == 0 {
 		return e.Cmdbar
@@ -567,6 +570,8 @@ func (e *Editor) TerminateView(vid int64) {
 	}
 	e.fileWatcher.Unwatch(vid, v.Backend().SrcLoc())
 	delete(e.views, vid)
+	core.Events.Publish(core.Event{Type: core.EvtViewClosed, ViewId: vid, Loc: v.Backend().SrcLoc()})
+	v.lspClose()
 	// This probably way overkill, but without nugging the GC it tends to not
 	// be very agressive and leave the memory allocated quite a while.
 	if v.backend != nil {
@@ -577,34 +582,62 @@ func (e *Editor) TerminateView(vid int64) {
 	actions.UndoClear(vid)
 }
 
-// Delete (close) a view, with dirty check
+// Delete (close) a view, with dirty check : if it has unsaved changes the user
+// is prompted to save or discard them first.
 func (e *Editor) DelViewCheck(viewId int64, terminate bool) {
 	view := viewCast(e.ViewById(viewId))
 	if view == nil {
 		return
 	}
-	if !view.canClose() {
-		e.SetStatusErr("Unsaved changes. Save or request close again.")
+	if !view.Dirty() {
+		e.DelView(view.Id(), terminate)
 		return
 	}
-	e.DelView(view.Id(), terminate)
+	e.saveDiscardPrompt([]*View{view}, func() {
+		e.DelView(view.Id(), terminate)
+	})
 }
 
 // Delete (close) a col, but with dirty check
 func (e *Editor) DelColCheck(c *Col) {
-	ok := true
-	for _, vid := range c.Views {
-		v, found := e.views[vid]
-		if !found {
-			continue
-		}
-		ok = ok && v.canClose()
+	dirty := e.dirtyViews(c.Views)
+	if len(dirty) == 0 {
+		e.DelCol(c, true)
+		return
+	}
+	e.saveDiscardPrompt(dirty, func() {
+		e.DelCol(c, true)
+	})
+}
+
+// Reload a view, with dirty check : if it has unsaved changes the user is
+// asked whether to discard them first.
+func (e *Editor) ViewReloadCheck(viewId int64) {
+	view := viewCast(e.ViewById(viewId))
+	if view == nil {
+		return
 	}
-	if !ok {
-		e.SetStatusErr("Unsaved changes. Save or request close again.")
+	if !view.Dirty() {
+		view.Reload()
 		return
 	}
-	e.DelCol(c, true)
+	question := view.Title() + " has unsaved changes, discard them and reload?"
+	e.Prompt(question, []string{choiceYes, choiceNo}, 1, func(choice string) {
+		if choice == choiceYes {
+			view.Reload()
+		}
+	})
+}
+
+// dirtyViews returns the views, amongst vids, with unsaved changes.
+func (e *Editor) dirtyViews(vids []int64) []*View {
+	dirty := []*View{}
+	for _, vid := range vids {
+		if v, found := e.views[vid]; found && v.Dirty() {
+			dirty = append(dirty, v)
+		}
+	}
+	return dirty
 }
 
 func (e *Editor) ViewActivate(viewId int64) {
@@ -617,6 +650,7 @@ func (e *Editor) ViewActivate(viewId int64) {
 	v.updateCursor(v.Slice())
 	e.SetStatus(fmt.Sprintf("%s [%d]", v.WorkDir(), viewId))
 	v.Backend().OnActivate()
+	core.Events.Publish(core.Event{Type: core.EvtViewActivated, ViewId: viewId})
 }
 
 func (e *Editor) ViewById(id int64) core.Viewable {
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"time"
+
+	"github.com/tcolar/goed/actions"
 	"github.com/tcolar/goed/assert"
 	"github.com/tcolar/goed/core"
+	"github.com/tcolar/goed/event"
+	"github.com/tcolar/goed/ui/widgets"
 	. "gopkg.in/check.v1"
 )
 
@@ -77,10 +82,20 @@ func (us *UiSuite) TestWm(t *C) {
 	assert.Eq(t, Ed.WidgetAt(20, 30), v2)
 
 	v3.SetDirty(true)
+	Ed.ViewReloadCheck(v3.Id())
+	assert.True(t, Ed.prompt(v3.Title()+" has unsaved changes, discard them and reload?") != nil)
+	Ed.OverlayEvent("", "n", false, -1, -1)
+	assert.True(t, v3.Dirty())
 	Ed.DelViewCheck(v3.Id(), true)
-	assert.Eq(t, len(c2.Views), 2) // dirty disallow it
+	assert.Eq(t, len(c2.Views), 2) // dirty : prompts first
+	q := v3.Title() + " has unsaved changes."
+	assert.True(t, Ed.prompt(q) != nil)
+	Ed.OverlayEvent("", "c", false, -1, -1) // cancel
+	assert.True(t, Ed.prompt(q) == nil)
+	assert.Eq(t, len(c2.Views), 2)
 	Ed.DelViewCheck(v3.Id(), true)
-	assert.Eq(t, len(c2.Views), 1) // allowed second time
+	Ed.OverlayEvent("", "d", false, -1, -1) // discard
+	assert.Eq(t, len(c2.Views), 1)
 	assert.Eq(t, len(Ed.Cols), 2)
 
 	Ed.DelCol(c2, true)
@@ -90,7 +105,145 @@ func (us *UiSuite) TestWm(t *C) {
 	assert.Eq(t, len(Ed.Cols), 1) // can't remove last view/col
 }
 
-func assertBounds(t *C, v core.Viewable, y1, x1, y2, x2 int) {
+func (us *UiSuite) TestPrompt(t *C) {
+	Ed := core.Ed.(*Editor)
+	actions.Ar.EdActionBusFlush() // nothing pending touching the overlays
+	answer := "none"
+	ask := func(choices []string, def int) *widgets.Prompt {
+		answer = "none"
+		Ed.Prompt("Well ?", choices, def, func(c string) { answer = c })
+		p := Ed.prompt("Well ?")
+		assert.True(t, p != nil)
+		return p
+	}
+	// defaults to yes / no, keys
+	p := ask(nil, 1)
+	assert.Eq(t, p.Selected(), "no")
+	Ed.Prompt("Well ?", nil, 0, func(c string) { answer = c })
+	assert.Eq(t, answer, "") // already asked
+	assert.True(t, Ed.OverlayEvent(string(event.EvtMoveLeft), "", false, -1, -1))
+	assert.Eq(t, p.Selected(), "yes")
+	assert.True(t, Ed.OverlayEvent(string(event.EvtTab), "", false, -1, -1))
+	assert.Eq(t, p.Selected(), "no")
+	assert.True(t, Ed.OverlayEvent("", "x", false, -1, -1)) // modal
+	assert.Eq(t, answer, "")
+	assert.True(t, Ed.OverlayEvent(string(event.EvtEnter), "", false, -1, -1))
+	assert.Eq(t, answer, "no")
+	assert.True(t, Ed.prompt("Well ?") == nil)
+	// hot keys : first letter not used yet
+	ask([]string{"save", "skip", "cancel"}, 0)
+	Ed.OverlayEvent("", "K", false, -1, -1)
+	assert.Eq(t, answer, "skip")
+	// escape
+	ask([]string{"save", "skip"}, 0)
+	Ed.OverlayEvent(string(event.EvtToggleCmdbar), "", false, -1, -1)
+	assert.Eq(t, answer, "")
+	// mouse, centered, choices on the 4th row : "│  save   skip  │"
+	p = ask([]string{"save", "skip"}, 0)
+	assertBounds(t, p, 10, 16, 14, 32)
+	assert.True(t, Ed.OverlayEvent(string(event.EvtSetCursor), "", true, 0, 0))
+	assert.Eq(t, answer, "none") // outside, still modal
+	Ed.OverlayEvent(string(event.EvtSetCursor), "", true, 13, 26)
+	assert.Eq(t, answer, "skip")
+	// ed_prompt action, the prompt being shown and answered on the action bus
+	res := make(chan string, 1)
+	go func() {
+		res <- actions.Ar.EdPrompt("Sure ?", 0, nil)
+	}()
+	answerPrompt("Sure ?", func(*widgets.Prompt) { Ed.OverlayEvent("", "y", false, -1, -1) })
+	assert.Eq(t, <-res, "yes")
+	// closed unanswered
+	go func() {
+		res <- actions.Ar.EdPrompt("Sure ?", 0, nil)
+	}()
+	answerPrompt("Sure ?", func(*widgets.Prompt) { Ed.closePrompt("Sure ?") })
+	assert.Eq(t, <-res, "")
+}
+
+// answerPrompt waits for a prompt asking question, then answers it on the
+// action bus.
+func answerPrompt(question string, answer func(p *widgets.Prompt)) {
+	Ed := core.Ed.(*Editor)
+	for answered := false; !answered; time.Sleep(10 * time.Millisecond) {
+		onBus(func() {
+			if p := Ed.prompt(question); p != nil {
+				answer(p)
+				answered = true
+			}
+		})
+	}
+}
+
+// onBus runs fn on the action bus, waiting for it to complete.
+func onBus(fn func()) {
+	done := make(chan struct{})
+	core.Bus.Dispatch(funcAction(func() {
+		fn()
+		close(done)
+	}))
+	<-done
+}
+
+type funcAction func()
+
+func (f funcAction) Run() {
+	f()
+}
+
+func (us *UiSuite) TestOverlays(t *C) {
+	Ed := core.Ed.(*Editor)
+	o := Ed.overlays
+	picked := -1
+	m := Ed.showMenu(5, 10, []string{"a", "bb", "ccc"}, func(i int) { picked = i })
+	defer o.RemoveWidget(m)
+	assertBounds(t, m, 6, 10, 8, 14)
+	Ed.showTooltip(5, 45, "some\nhelp text")
+	assert.Eq(t, len(o.Widgets()), 2)
+	tip := o.Widgets()[1].(*widgets.Tooltip)
+	assertBounds(t, tip, 6, 39, 7, 49) // within the terminal
+	Ed.showTooltip(24, 12, "other")
+	assert.Eq(t, len(o.Widgets()), 2)
+	tip = o.Widgets()[1].(*widgets.Tooltip)
+	assertBounds(t, tip, 23, 12, 23, 18) // above, no room below
+	// z order
+	assert.Eq(t, o.Widgets()[0], m)
+	assert.Eq(t, Ed.WidgetAt(7, 12), m)
+	assert.Eq(t, Ed.WidgetAt(23, 12), tip)
+	o.RemoveWidget(tip)
+	o.AddWidgetZ(tip, zMenu-1) // below the menu
+	assert.Eq(t, o.Widgets()[1], m)
+	assert.Eq(t, o.Focused(), m)
+
+	// keyboard : the tooltip is dismissed, the focused menu gets the keys
+	assert.True(t, Ed.OverlayEvent(event.EvtMoveDown, "", false, -1, -1))
+	assert.Eq(t, m.Selected(), 1)
+	assert.Eq(t, len(o.Widgets()), 2)
+	assert.True(t, Ed.OverlayEvent(event.EvtEnter, "", false, -1, -1))
+	assert.Eq(t, picked, 1)
+	assert.Eq(t, len(o.Widgets()), 1) // tooltip, below the menu
+	assert.False(t, Ed.OverlayEvent(event.EvtMoveDown, "", false, -1, -1))
+	assert.Eq(t, len(o.Widgets()), 0)
+	assert.Nil(t, o.Focused())
+
+	// mouse
+	m = Ed.showMenu(5, 10, []string{"a", "bb", "ccc"}, func(i int) { picked = i })
+	assert.True(t, Ed.OverlayEvent(event.EvtSetCursor, "", true, 8, 11))
+	assert.Eq(t, picked, 2)
+	assert.Eq(t, len(o.Widgets()), 0)
+	m = Ed.showMenu(5, 10, []string{"a", "bb", "ccc"}, func(i int) { picked = i })
+	assert.False(t, Ed.OverlayEvent(event.EvtSetCursor, "", true, 1, 1)) // outside
+	assert.Eq(t, len(o.Widgets()), 0)
+
+	// the finder is modal
+	assert.Nil(t, Ed.FinderOpen(core.FinderViews))
+	assert.True(t, Ed.OverlayEvent(event.EvtSetCursor, "z", false, -1, -1))
+	assert.Eq(t, string(Ed.finder.query), "z")
+	assert.True(t, Ed.OverlayEvent(event.EvtSetCursor, "", true, 0, 0)) // outside
+	assert.Nil(t, Ed.Finder())
+	assert.Eq(t, len(o.Widgets()), 0)
+}
+
+func assertBounds(t *C, v Renderer, y1, x1, y2, x2 int) {
 	b1, b2, b3, b4 := v.Bounds()
 	assert.Eq(t, b1, y1)
 	assert.Eq(t, b2, x1)